@@ -10,14 +10,18 @@ use soroban_env_host::xdr::HashIdPreimageSourceAccountContractId;
 use soroban_env_host::xdr::{
     AccountId, Error as XdrError, Hash, HashIdPreimage, HostFunction, InvokeHostFunctionOp,
     LedgerFootprint, LedgerKey::ContractData, LedgerKeyContractData, Memo, MuxedAccount, Operation,
-    OperationBody, Preconditions, PublicKey, ScObject, ScStatic::LedgerKeyContractCode, ScVal,
-    SequenceNumber, Transaction, TransactionEnvelope, TransactionExt, Uint256, VecM, WriteXdr,
+    OperationBody, Preconditions, PublicKey, ReadXdr, ScObject, ScStatic::LedgerKeyContractCode,
+    ScVal, SequenceNumber, Transaction, TransactionEnvelope, TransactionExt, Uint256, VecM,
+    WriteXdr,
 };
 use soroban_env_host::HostError;
+use soroban_spec::read::FromWasmError;
 
+use crate::invoke::{self, Arg};
 use crate::rpc::{self, Client};
+use crate::signer::{self, Signer};
 use crate::snapshot::{self, get_default_ledger_info};
-use crate::{utils, HEADING_RPC, HEADING_SANDBOX};
+use crate::{keys, strval, utils, HEADING_RPC, HEADING_SANDBOX};
 
 #[derive(Parser, Debug)]
 pub struct Cmd {
@@ -43,7 +47,7 @@ pub struct Cmd {
     )]
     ledger_file: std::path::PathBuf,
 
-    /// Secret 'S' key used to sign the transaction sent to the rpc server
+    /// Secret 'S' key used to sign the transaction sent to the rpc server, "keychain:<name>" for a key stored in the OS keychain or "ledger[:<account index>]" for a Ledger device
     #[clap(
         long = "secret-key",
         env = "SOROBAN_SECRET_KEY",
@@ -74,6 +78,38 @@ pub struct Cmd {
         help_heading = HEADING_RPC,
     )]
     network_passphrase: Option<String>,
+    /// Fund the source account with friendbot when it doesn't exist yet (test networks only)
+    #[clap(long, requires = "rpc-url", help_heading = HEADING_RPC)]
+    fund: bool,
+    /// Friendbot endpoint used by --fund, defaults to the /friendbot endpoint of the host of --rpc-url
+    #[clap(long, env = "SOROBAN_FRIENDBOT_URL", help_heading = HEADING_RPC)]
+    friendbot_url: Option<String>,
+    /// Print the ID of the contract which would be deployed without submitting the transaction
+    #[clap(long = "dry-run", requires = "rpc-url", help_heading = HEADING_RPC)]
+    dry_run: bool,
+
+    /// Function invoked on the contract once it is deployed (e.g. to initialize it), in a
+    /// subsequent transaction since the network can't deploy and invoke a contract atomically
+    #[clap(long = "init-fn", requires = "rpc-url", help_heading = HEADING_RPC)]
+    init_fn: Option<String>,
+    /// Argument to pass to the function set with --init-fn
+    #[clap(
+        long = "init-arg",
+        value_name = "arg",
+        multiple = true,
+        requires = "init-fn",
+        help_heading = HEADING_RPC,
+    )]
+    init_args: Vec<String>,
+    /// Argument to pass to the function set with --init-fn (base64-encoded xdr)
+    #[clap(
+        long = "init-arg-xdr",
+        value_name = "arg-xdr",
+        multiple = true,
+        requires = "init-fn",
+        help_heading = HEADING_RPC,
+    )]
+    init_args_xdr: Vec<String>,
 }
 
 #[derive(thiserror::Error, Debug)]
@@ -110,21 +146,30 @@ pub enum Error {
         contract_id: String,
         error: FromHexError,
     },
-    #[error("cannot parse secret key")]
-    CannotParseSecretKey,
+    #[error(transparent)]
+    Signer(#[from] signer::Error),
     #[error(transparent)]
     Rpc(#[from] rpc::Error),
+    #[error(transparent)]
+    Fund(#[from] keys::fund::Error),
+    #[error("parsing contract spec: {0}")]
+    CannotParseContractSpec(FromWasmError),
+    #[error("invoking {function}: {error}")]
+    Init {
+        function: String,
+        error: invoke::Error,
+    },
 }
 
 impl Cmd {
-    pub async fn run(&self) -> Result<(), Error> {
+    pub async fn run(&self, matches: &clap::ArgMatches) -> Result<(), Error> {
         let contract = fs::read(&self.wasm).map_err(|e| Error::CannotReadContractFile {
             filepath: self.wasm.clone(),
             error: e,
         })?;
 
         let res_str = if self.rpc_url.is_some() {
-            self.run_against_rpc_server(contract).await?
+            self.run_against_rpc_server(contract, matches).await?
         } else {
             self.run_in_sandbox(contract)?
         };
@@ -159,7 +204,11 @@ impl Cmd {
         Ok(hex::encode(contract_id))
     }
 
-    async fn run_against_rpc_server(&self, contract: Vec<u8>) -> Result<String, Error> {
+    async fn run_against_rpc_server(
+        &self,
+        contract: Vec<u8>,
+        matches: &clap::ArgMatches,
+    ) -> Result<String, Error> {
         let salt: [u8; 32] = match &self.salt {
             // Hack: re-use contract_id_from_str to parse the 32-byte salt hex.
             Some(h) => utils::contract_id_from_str(h)
@@ -167,55 +216,154 @@ impl Cmd {
             None => rand::thread_rng().gen::<[u8; 32]>(),
         };
 
+        let key = signer::from_str(self.secret_key.as_ref().unwrap())?;
+        let contract_id = contract_id_from_salt(key.public_key(), salt)?;
+        if self.dry_run {
+            return Ok(hex::encode(contract_id.0));
+        }
+        // The contract ID only depends on the source account and the salt, so it is known
+        // before anything is submitted
+        eprintln!("contract id: {}", hex::encode(contract_id.0));
+
+        // Parse the arguments of the init function before deploying the contract, so that
+        // invalid arguments don't leave a deployed but uninitialized contract behind
+        let init = match &self.init_fn {
+            Some(function) => {
+                let spec_entries = soroban_spec::read::from_wasm(&contract)
+                    .map_err(Error::CannotParseContractSpec)?;
+                let args = Arg::from_matches(
+                    matches,
+                    "init-args",
+                    &self.init_args,
+                    "init-args-xdr",
+                    &self.init_args_xdr,
+                );
+                let parameters = invoke::build_host_function_parameters(
+                    contract_id.0,
+                    &spec_entries,
+                    function,
+                    &args,
+                )
+                .map_err(|error| Error::Init {
+                    function: function.clone(),
+                    error,
+                })?;
+                Some((function, parameters))
+            }
+            None => None,
+        };
+
         let client = Client::new(self.rpc_url.as_ref().unwrap());
-        let key = utils::parse_secret_key(self.secret_key.as_ref().unwrap())
-            .map_err(|_| Error::CannotParseSecretKey)?;
 
         // Get the account sequence number
-        let public_strkey =
-            stellar_strkey::StrkeyPublicKeyEd25519(key.public.to_bytes()).to_string();
-        let account_details = client.get_account(&public_strkey).await?;
+        let public_strkey = stellar_strkey::StrkeyPublicKeyEd25519(key.public_key()).to_string();
+        let account_details = match client.get_account(&public_strkey).await {
+            Err(_) if self.fund => {
+                let friendbot_url = match &self.friendbot_url {
+                    Some(url) => url.clone(),
+                    None => keys::fund::default_friendbot_url(self.rpc_url.as_ref().unwrap())?,
+                };
+                eprintln!("funding {public_strkey}");
+                keys::fund::fund(&friendbot_url, &public_strkey).await?;
+                client.get_account(&public_strkey).await?
+            }
+            result => result?,
+        };
         // TODO: create a cmdline parameter for the fee instead of simply using the minimum fee
         let fee: u32 = 100;
         let sequence = account_details.sequence.parse::<i64>()?;
-        let (tx, contract_id) = build_create_contract_tx(
-            contract,
-            sequence + 1,
-            fee,
-            self.network_passphrase.as_ref().unwrap(),
-            salt,
-            &key,
-        )?;
+        let network_passphrase = self.network_passphrase.as_ref().unwrap();
+        let (tx, _) =
+            build_create_contract_tx(contract, sequence + 1, fee, network_passphrase, salt, &key)?;
 
         client.send_transaction(&tx).await?;
 
+        if let Some((function, parameters)) = init {
+            let res_str = invoke_init(
+                &client,
+                parameters,
+                sequence + 2,
+                fee,
+                network_passphrase,
+                &key,
+            )
+            .await
+            .map_err(|error| Error::Init {
+                function: function.clone(),
+                error,
+            })?;
+            eprintln!("{function}: {res_str}");
+        }
+
         Ok(hex::encode(contract_id.0))
     }
 }
 
-fn build_create_contract_tx(
-    contract: Vec<u8>,
+async fn invoke_init(
+    client: &Client,
+    parameters: soroban_env_host::xdr::ScVec,
     sequence: i64,
     fee: u32,
     network_passphrase: &str,
-    salt: [u8; 32],
-    key: &ed25519_dalek::Keypair,
-) -> Result<(TransactionEnvelope, Hash), Error> {
+    key: &dyn Signer,
+) -> Result<String, invoke::Error> {
+    // Get the ledger footprint, now that the contract exists
+    let tx_without_footprint = invoke::build_invoke_contract_tx(
+        parameters.clone(),
+        None,
+        sequence,
+        fee,
+        network_passphrase,
+        key,
+    )?;
+    let simulation_response = client.simulate_transaction(&tx_without_footprint).await?;
+    let footprint = LedgerFootprint::from_xdr_base64(simulation_response.footprint)?;
+
+    let tx = invoke::build_invoke_contract_tx(
+        parameters,
+        Some(footprint),
+        sequence,
+        fee,
+        network_passphrase,
+        key,
+    )?;
+    let results = client.send_transaction(&tx).await?;
+    if results.is_empty() {
+        return Err(invoke::Error::MissingTransactionResult);
+    }
+    let res = ScVal::from_xdr_base64(&results[0].xdr)?;
+    strval::to_string(&res).map_err(|e| invoke::Error::CannotPrintResult {
+        result: res,
+        error: e,
+    })
+}
+
+/// The ID of the contract created by `source_account` with `salt`
+fn contract_id_from_salt(source_account: [u8; 32], salt: [u8; 32]) -> Result<Hash, Error> {
     let preimage =
         HashIdPreimage::ContractIdFromSourceAccount(HashIdPreimageSourceAccountContractId {
-            source_account: AccountId(PublicKey::PublicKeyTypeEd25519(
-                key.public.to_bytes().into(),
-            )),
+            source_account: AccountId(PublicKey::PublicKeyTypeEd25519(source_account.into())),
             salt: Uint256(salt),
         });
     let preimage_xdr = preimage.to_xdr()?;
-    let contract_id = Sha256::digest(preimage_xdr);
+    Ok(Hash(Sha256::digest(preimage_xdr).into()))
+}
+
+fn build_create_contract_tx(
+    contract: Vec<u8>,
+    sequence: i64,
+    fee: u32,
+    network_passphrase: &str,
+    salt: [u8; 32],
+    key: &dyn Signer,
+) -> Result<(TransactionEnvelope, Hash), Error> {
+    let contract_id = contract_id_from_salt(key.public_key(), salt)?;
 
     let contract_parameter = ScVal::Object(Some(ScObject::Bytes(contract.try_into()?)));
     let salt_parameter = ScVal::Object(Some(ScObject::Bytes(salt.try_into()?)));
 
     let lk = ContractData(LedgerKeyContractData {
-        contract_id: Hash(contract_id.into()),
+        contract_id: contract_id.clone(),
         key: ScVal::Static(LedgerKeyContractCode),
     });
 
@@ -233,7 +381,7 @@ fn build_create_contract_tx(
         }),
     };
     let tx = Transaction {
-        source_account: MuxedAccount::Ed25519(Uint256(key.public.to_bytes())),
+        source_account: MuxedAccount::Ed25519(Uint256(key.public_key())),
         fee,
         seq_num: SequenceNumber(sequence),
         cond: Preconditions::None,
@@ -244,7 +392,7 @@ fn build_create_contract_tx(
 
     let envelope = utils::sign_transaction(key, &tx, network_passphrase)?;
 
-    Ok((envelope, Hash(contract_id.into())))
+    Ok((envelope, contract_id))
 }
 
 #[cfg(test)]
@@ -265,4 +413,30 @@ mod tests {
 
         assert!(result.is_ok());
     }
+
+    #[test]
+    fn test_contract_id_from_salt() {
+        let key =
+            utils::parse_secret_key("SBFGFF27Y64ZUGFAIG5AMJGQODZZKV2YQKAVUUN4HNE24XZXD2OEUVUP")
+                .unwrap();
+        let (_, contract_id) = build_create_contract_tx(
+            b"foo".to_vec(),
+            300,
+            1,
+            "Public Global Stellar Network ; September 2015",
+            [1u8; 32],
+            &key,
+        )
+        .unwrap();
+
+        // the precomputed ID is the one the transaction creates the contract with
+        assert_eq!(
+            contract_id_from_salt(key.public_key(), [1u8; 32]).unwrap(),
+            contract_id
+        );
+        assert_ne!(
+            contract_id_from_salt(key.public_key(), [2u8; 32]).unwrap(),
+            contract_id
+        );
+    }
 }
@@ -0,0 +1,213 @@
+use std::{
+    collections::BTreeMap,
+    fs, io,
+    path::{Path, PathBuf},
+    process::Command,
+    time::{Duration, SystemTime},
+};
+
+use clap::{CommandFactory, FromArgMatches, Parser};
+
+use crate::{deploy, invoke, HEADING_SANDBOX};
+
+/// Directories which are never watched, since they are written by the loop itself
+const IGNORED_DIRS: &[&str] = &["target", ".soroban", ".git"];
+
+#[derive(Parser, Debug)]
+pub struct Cmd {
+    /// Directory of the contract crate, built with `cargo build --target wasm32-unknown-unknown --release`
+    #[clap(long, parse(from_os_str), default_value = ".")]
+    dir: PathBuf,
+    /// WASM file produced by the build, relative to the current directory
+    #[clap(long, parse(from_os_str))]
+    wasm: PathBuf,
+    /// Contract ID to deploy to
+    #[clap(long = "id", default_value = "1")]
+    contract_id: String,
+    /// File to persist ledger state
+    #[clap(
+        long,
+        parse(from_os_str),
+        default_value = ".soroban/ledger.json",
+        env = "SOROBAN_LEDGER_FILE",
+        help_heading = HEADING_SANDBOX,
+    )]
+    ledger_file: PathBuf,
+    /// Rebuild, redeploy and invoke the contract every time a file of --dir changes
+    #[clap(long)]
+    watch: bool,
+    /// How often (in milliseconds) the files are checked for changes
+    #[clap(long, default_value = "500", requires = "watch")]
+    interval: u64,
+    /// Options of `soroban invoke` (e.g. -- --fn hello --arg world), the contract isn't invoked when omitted
+    #[clap(last = true)]
+    invoke: Vec<String>,
+}
+
+#[derive(thiserror::Error, Debug)]
+pub enum Error {
+    #[error("running cargo: {0}")]
+    CannotRunCargo(io::Error),
+    #[error("soroban dev only runs in the sandbox, remove the rpc server provided by {0}")]
+    RpcNotSupported(&'static str),
+    #[error("building the contract failed")]
+    BuildFailed,
+    #[error("watching {dir}: {error}")]
+    CannotWatch { dir: PathBuf, error: io::Error },
+    #[error(transparent)]
+    Clap(#[from] clap::Error),
+    #[error(transparent)]
+    Deploy(#[from] deploy::Error),
+    #[error(transparent)]
+    Invoke(#[from] invoke::Error),
+}
+
+impl Cmd {
+    pub async fn run(&self) -> Result<(), Error> {
+        if self.invoke.iter().any(|arg| is_rpc_url_arg(arg)) {
+            return Err(Error::RpcNotSupported("--rpc-url"));
+        }
+        if std::env::var_os("SOROBAN_RPC_URL").is_some() {
+            return Err(Error::RpcNotSupported(
+                "SOROBAN_RPC_URL (or a network profile selected with --network)",
+            ));
+        }
+
+        if !self.watch {
+            return self.run_once().await;
+        }
+        let mut state = modification_times(&self.dir)?;
+        loop {
+            // failures are reported without stopping the loop, they are fixed by the next change
+            if let Err(e) = self.run_once().await {
+                eprintln!("error: {e}");
+            }
+            eprintln!("watching {} for changes", self.dir.display());
+            loop {
+                tokio::time::sleep(Duration::from_millis(self.interval)).await;
+                let current = modification_times(&self.dir)?;
+                if current != state {
+                    state = current;
+                    break;
+                }
+            }
+        }
+    }
+
+    async fn run_once(&self) -> Result<(), Error> {
+        self.build()?;
+
+        let deploy_args = [
+            "deploy".to_string(),
+            "--wasm".to_string(),
+            self.wasm.to_string_lossy().to_string(),
+            "--id".to_string(),
+            self.contract_id.clone(),
+            "--ledger-file".to_string(),
+            self.ledger_file.to_string_lossy().to_string(),
+        ];
+        let matches = deploy::Cmd::command().try_get_matches_from(&deploy_args)?;
+        deploy::Cmd::from_arg_matches(&matches)?
+            .run(&matches)
+            .await?;
+
+        if self.invoke.is_empty() {
+            return Ok(());
+        }
+        let mut invoke_args = vec![
+            "invoke".to_string(),
+            "--id".to_string(),
+            self.contract_id.clone(),
+            "--ledger-file".to_string(),
+            self.ledger_file.to_string_lossy().to_string(),
+        ];
+        invoke_args.extend(self.invoke.iter().cloned());
+        let matches = invoke::Cmd::command().try_get_matches_from(&invoke_args)?;
+        invoke::Cmd::from_arg_matches(&matches)?
+            .run(&matches)
+            .await?;
+        Ok(())
+    }
+
+    fn build(&self) -> Result<(), Error> {
+        eprintln!("building {}", self.dir.display());
+        let status = Command::new(std::env::var("CARGO").unwrap_or_else(|_| "cargo".to_string()))
+            .args(["build", "--target", "wasm32-unknown-unknown", "--release"])
+            .current_dir(&self.dir)
+            .status()
+            .map_err(Error::CannotRunCargo)?;
+        if !status.success() {
+            return Err(Error::BuildFailed);
+        }
+        Ok(())
+    }
+}
+
+/// Whether arg sets the rpc server of `soroban invoke`
+fn is_rpc_url_arg(arg: &str) -> bool {
+    arg == "--rpc-url" || arg.starts_with("--rpc-url=")
+}
+
+/// Modification times of the files under dir, so that changes can be detected by polling
+fn modification_times(dir: &Path) -> Result<BTreeMap<PathBuf, SystemTime>, Error> {
+    let mut times = BTreeMap::new();
+    collect_modification_times(dir, &mut times).map_err(|error| Error::CannotWatch {
+        dir: dir.to_path_buf(),
+        error,
+    })?;
+    Ok(times)
+}
+
+fn collect_modification_times(
+    dir: &Path,
+    times: &mut BTreeMap<PathBuf, SystemTime>,
+) -> Result<(), io::Error> {
+    for entry in fs::read_dir(dir)? {
+        let entry = entry?;
+        let path = entry.path();
+        let file_type = entry.file_type()?;
+        if file_type.is_dir() {
+            let ignored = path
+                .file_name()
+                .map_or(false, |name| IGNORED_DIRS.iter().any(|d| name == *d));
+            if !ignored {
+                collect_modification_times(&path, times)?;
+            }
+        } else if file_type.is_file() {
+            times.insert(path, entry.metadata()?.modified()?);
+        }
+    }
+    Ok(())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_is_rpc_url_arg() {
+        assert!(is_rpc_url_arg("--rpc-url"));
+        assert!(is_rpc_url_arg("--rpc-url=http://localhost:8000"));
+        assert!(!is_rpc_url_arg("--fn"));
+        assert!(!is_rpc_url_arg("--rpc-urls"));
+    }
+
+    #[test]
+    fn test_modification_times() {
+        let dir = std::env::temp_dir().join(format!("soroban-dev-{}", std::process::id()));
+        fs::create_dir_all(dir.join("src")).unwrap();
+        fs::create_dir_all(dir.join("target")).unwrap();
+        fs::write(dir.join("src/lib.rs"), "").unwrap();
+        fs::write(dir.join("target/contract.wasm"), "").unwrap();
+
+        let times = modification_times(&dir).unwrap();
+        assert_eq!(
+            times.keys().collect::<Vec<_>>(),
+            vec![&dir.join("src/lib.rs")]
+        );
+
+        fs::write(dir.join("Cargo.toml"), "").unwrap();
+        assert_ne!(modification_times(&dir).unwrap(), times);
+        fs::remove_dir_all(dir).unwrap();
+    }
+}
@@ -0,0 +1,96 @@
+use std::{
+    fmt::Debug,
+    fs,
+    io::{self, Write},
+};
+
+use clap::Parser;
+use hex::FromHexError;
+use soroban_env_host::xdr::{
+    Error as XdrError, ReadXdr, ScContractCode, ScObject, ScStatic, ScVal,
+};
+
+use crate::rpc::{self, Client};
+use crate::{utils, HEADING_RPC};
+
+#[derive(Parser, Debug)]
+pub struct Cmd {
+    /// Contract ID to fetch
+    #[clap(long = "id")]
+    contract_id: String,
+    /// Where to write the WASM file (defaults to stdout)
+    #[clap(long, short = 'o', parse(from_os_str))]
+    out_file: Option<std::path::PathBuf>,
+
+    /// RPC server endpoint
+    #[clap(long, env = "SOROBAN_RPC_URL", help_heading = HEADING_RPC)]
+    rpc_url: String,
+}
+
+#[derive(thiserror::Error, Debug)]
+pub enum Error {
+    #[error("cannot parse contract ID {contract_id}: {error}")]
+    CannotParseContractId {
+        contract_id: String,
+        error: FromHexError,
+    },
+    #[error("contract {0} is a built-in token contract and has no WASM")]
+    TokenContract(String),
+    #[error("unexpected contract code data type: {0:?}")]
+    UnexpectedContractCodeDataType(ScVal),
+    #[error("writing file {filepath}: {error}")]
+    CannotWriteContractFile {
+        filepath: std::path::PathBuf,
+        error: io::Error,
+    },
+    #[error("writing to stdout: {0}")]
+    CannotWriteStdout(io::Error),
+    #[error("xdr processing error: {0}")]
+    Xdr(#[from] XdrError),
+    #[error(transparent)]
+    Rpc(#[from] rpc::Error),
+}
+
+impl Cmd {
+    pub async fn run(&self) -> Result<(), Error> {
+        let wasm = fetch_wasm(&self.rpc_url, &self.contract_id).await?;
+
+        if let Some(f) = &self.out_file {
+            fs::write(f, wasm).map_err(|e| Error::CannotWriteContractFile {
+                filepath: f.clone(),
+                error: e,
+            })?;
+        } else {
+            let mut stdout = io::stdout();
+            stdout.write_all(&wasm).map_err(Error::CannotWriteStdout)?;
+            stdout.flush().map_err(Error::CannotWriteStdout)?;
+        }
+        Ok(())
+    }
+}
+
+/// Fetch the WASM of the contract deployed with the given (hex) ID
+pub async fn fetch_wasm(rpc_url: &str, contract_id: &str) -> Result<Vec<u8>, Error> {
+    let id: [u8; 32] =
+        utils::contract_id_from_str(contract_id).map_err(|e| Error::CannotParseContractId {
+            contract_id: contract_id.to_string(),
+            error: e,
+        })?;
+
+    let client = Client::new(rpc_url);
+    let contract_data = client
+        .get_contract_data(
+            &hex::encode(id),
+            ScVal::Static(ScStatic::LedgerKeyContractCode),
+        )
+        .await?;
+    match ScVal::from_xdr_base64(contract_data.xdr)? {
+        ScVal::Object(Some(ScObject::ContractCode(ScContractCode::Wasm(wasm)))) => {
+            Ok(wasm.to_vec())
+        }
+        ScVal::Object(Some(ScObject::ContractCode(ScContractCode::Token))) => {
+            Err(Error::TokenContract(contract_id.to_string()))
+        }
+        scval => Err(Error::UnexpectedContractCodeDataType(scval)),
+    }
+}
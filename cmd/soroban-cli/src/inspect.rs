@@ -1,16 +1,45 @@
-use clap::Parser;
-use soroban_env_host::xdr::{Error as XdrError, ReadXdr, ScEnvMetaEntry, ScSpecEntry};
+use clap::{ArgEnum, Parser};
+use serde::Serialize;
+use soroban_env_host::xdr::{
+    Error as XdrError, ReadXdr, ScEnvMetaEntry, ScSpecEntry, ScSpecFunctionV0, ScSpecTypeDef,
+    ScSpecUdtEnumV0, ScSpecUdtErrorEnumV0, ScSpecUdtStructV0, ScSpecUdtUnionV0,
+};
 use std::{
     fmt::Debug,
     fs,
     io::{self, Cursor},
 };
 
+use crate::{fetch, HEADING_RPC};
+
 #[derive(Parser, Debug)]
 pub struct Cmd {
     /// WASM file to inspect
-    #[clap(long, parse(from_os_str))]
-    wasm: std::path::PathBuf,
+    #[clap(
+        long,
+        parse(from_os_str),
+        conflicts_with = "contract-id",
+        required_unless_present = "contract-id"
+    )]
+    wasm: Option<std::path::PathBuf>,
+    /// Contract ID of a deployed contract to inspect
+    #[clap(long = "id", requires = "rpc-url")]
+    contract_id: Option<String>,
+    /// Type of output to generate
+    #[clap(long, arg_enum, default_value("string"))]
+    output: Output,
+
+    /// RPC server endpoint
+    #[clap(long, env = "SOROBAN_RPC_URL", help_heading = HEADING_RPC)]
+    rpc_url: Option<String>,
+}
+
+#[derive(Clone, Copy, Debug, Eq, Hash, PartialEq, ArgEnum)]
+pub enum Output {
+    /// Human readable listing of the contract spec and meta
+    String,
+    /// Json, for tooling consumption
+    Json,
 }
 
 #[derive(thiserror::Error, Debug)]
@@ -20,83 +49,342 @@ pub enum Error {
         filepath: std::path::PathBuf,
         error: io::Error,
     },
-    #[error("cannot parse wasm file {file}: {error}")]
-    CannotParseWasm {
-        file: std::path::PathBuf,
-        error: wasmparser::BinaryReaderError,
-    },
+    #[error("cannot parse wasm: {0}")]
+    CannotParseWasm(wasmparser::BinaryReaderError),
+    #[error("cannot print json: {0}")]
+    CannotPrintJson(serde_json::Error),
     #[error("xdr processing error: {0}")]
     Xdr(#[from] XdrError),
+    #[error(transparent)]
+    Fetch(#[from] fetch::Error),
+}
+
+/// Contract spec and meta of a contract, as found in the custom sections of its WASM
+#[derive(Serialize, Debug, Default)]
+#[serde(rename_all = "camelCase")]
+pub struct Contract {
+    /// Base64 encoded XDR of the contractenvmetav0 section
+    pub env_meta_xdr: Option<String>,
+    pub interface_version: Option<u64>,
+    /// Base64 encoded XDR of the contractspecv0 section
+    pub spec_xdr: Option<String>,
+    pub functions: Vec<Function>,
+    pub types: Vec<Type>,
+}
+
+#[derive(Serialize, Debug)]
+pub struct Function {
+    pub name: String,
+    pub inputs: Vec<Field>,
+    pub outputs: Vec<String>,
+}
+
+#[derive(Serialize, Debug)]
+pub struct Field {
+    pub name: String,
+    #[serde(rename = "type")]
+    pub type_: String,
+}
+
+#[derive(Serialize, Debug)]
+#[serde(tag = "kind", rename_all = "camelCase")]
+pub enum Type {
+    Struct {
+        lib: String,
+        name: String,
+        fields: Vec<Field>,
+    },
+    Union {
+        lib: String,
+        name: String,
+        cases: Vec<UnionCase>,
+    },
+    Enum {
+        lib: String,
+        name: String,
+        cases: Vec<EnumCase>,
+    },
+    ErrorEnum {
+        lib: String,
+        name: String,
+        cases: Vec<EnumCase>,
+    },
+}
+
+#[derive(Serialize, Debug)]
+pub struct UnionCase {
+    pub name: String,
+    #[serde(rename = "type", skip_serializing_if = "Option::is_none")]
+    pub type_: Option<String>,
+}
+
+#[derive(Serialize, Debug)]
+pub struct EnumCase {
+    pub name: String,
+    pub value: u32,
 }
 
 impl Cmd {
-    pub fn run(&self) -> Result<(), Error> {
-        println!("File: {}", self.wasm.to_string_lossy());
-
-        let contents = fs::read(&self.wasm).map_err(|e| Error::CannotReadContractFile {
-            filepath: self.wasm.clone(),
-            error: e,
-        })?;
-
-        let mut env_meta: Option<&[u8]> = None;
-        let mut spec: Option<&[u8]> = None;
-        for payload in wasmparser::Parser::new(0).parse_all(&contents) {
-            let payload = payload.map_err(|e| Error::CannotParseWasm {
-                file: self.wasm.clone(),
-                error: e,
-            })?;
-            if let wasmparser::Payload::CustomSection(section) = payload {
-                let out = match section.name() {
-                    "contractenvmetav0" => &mut env_meta,
-                    "contractspecv0" => &mut spec,
-                    _ => continue,
-                };
-                *out = Some(section.data());
-            };
+    pub async fn run(&self) -> Result<(), Error> {
+        let (source_name, contents) = match (&self.wasm, &self.contract_id, &self.rpc_url) {
+            (Some(wasm), _, _) => {
+                let contents = fs::read(wasm).map_err(|e| Error::CannotReadContractFile {
+                    filepath: wasm.clone(),
+                    error: e,
+                })?;
+                (format!("File: {}", wasm.to_string_lossy()), contents)
+            }
+            (None, Some(contract_id), Some(rpc_url)) => (
+                format!("Contract: {contract_id}"),
+                fetch::fetch_wasm(rpc_url, contract_id).await?,
+            ),
+            _ => unreachable!("clap requires either --wasm or --id and --rpc-url"),
+        };
+
+        let contract = inspect(&contents)?;
+        match self.output {
+            Output::String => {
+                println!("{source_name}");
+                print_contract(&contract);
+            }
+            Output::Json => println!(
+                "{}",
+                serde_json::to_string_pretty(&contract).map_err(Error::CannotPrintJson)?
+            ),
         }
+        Ok(())
+    }
+}
 
-        if let Some(env_meta) = env_meta {
-            println!("Env Meta: {}", base64::encode(env_meta));
-            let mut cursor = Cursor::new(env_meta);
-            for env_meta_entry in ScEnvMetaEntry::read_xdr_iter(&mut cursor) {
-                match env_meta_entry? {
-                    ScEnvMetaEntry::ScEnvMetaKindInterfaceVersion(v) => {
-                        println!(" • Interface Version: {v}");
-                    }
+/// Parse the contract spec and env meta of a contract WASM
+pub fn inspect(wasm: &[u8]) -> Result<Contract, Error> {
+    let mut env_meta: Option<&[u8]> = None;
+    let mut spec: Option<&[u8]> = None;
+    for payload in wasmparser::Parser::new(0).parse_all(wasm) {
+        let payload = payload.map_err(Error::CannotParseWasm)?;
+        if let wasmparser::Payload::CustomSection(section) = payload {
+            let out = match section.name() {
+                "contractenvmetav0" => &mut env_meta,
+                "contractspecv0" => &mut spec,
+                _ => continue,
+            };
+            *out = Some(section.data());
+        };
+    }
+
+    let mut contract = Contract::default();
+    if let Some(env_meta) = env_meta {
+        contract.env_meta_xdr = Some(base64::encode(env_meta));
+        let mut cursor = Cursor::new(env_meta);
+        for env_meta_entry in ScEnvMetaEntry::read_xdr_iter(&mut cursor) {
+            match env_meta_entry? {
+                ScEnvMetaEntry::ScEnvMetaKindInterfaceVersion(v) => {
+                    contract.interface_version = Some(v);
                 }
             }
+        }
+    }
+    if let Some(spec) = spec {
+        contract.spec_xdr = Some(base64::encode(spec));
+        let mut cursor = Cursor::new(spec);
+        for spec_entry in ScSpecEntry::read_xdr_iter(&mut cursor) {
+            match spec_entry? {
+                ScSpecEntry::FunctionV0(f) => contract.functions.push(function(&f)?),
+                ScSpecEntry::UdtStructV0(udt) => contract.types.push(struct_type(&udt)?),
+                ScSpecEntry::UdtUnionV0(udt) => contract.types.push(union_type(&udt)?),
+                ScSpecEntry::UdtEnumV0(udt) => contract.types.push(enum_type(&udt)?),
+                ScSpecEntry::UdtErrorEnumV0(udt) => contract.types.push(error_enum_type(&udt)?),
+            }
+        }
+    }
+    Ok(contract)
+}
+
+fn function(f: &ScSpecFunctionV0) -> Result<Function, XdrError> {
+    Ok(Function {
+        name: f.name.to_string()?,
+        inputs: f
+            .inputs
+            .iter()
+            .map(|input| {
+                Ok(Field {
+                    name: input.name.to_string()?,
+                    type_: type_name(&input.type_)?,
+                })
+            })
+            .collect::<Result<_, XdrError>>()?,
+        outputs: f.outputs.iter().map(type_name).collect::<Result<_, _>>()?,
+    })
+}
+
+fn struct_type(udt: &ScSpecUdtStructV0) -> Result<Type, XdrError> {
+    Ok(Type::Struct {
+        lib: udt.lib.to_string()?,
+        name: udt.name.to_string()?,
+        fields: udt
+            .fields
+            .iter()
+            .map(|field| {
+                Ok(Field {
+                    name: field.name.to_string()?,
+                    type_: type_name(&field.type_)?,
+                })
+            })
+            .collect::<Result<_, XdrError>>()?,
+    })
+}
+
+fn union_type(udt: &ScSpecUdtUnionV0) -> Result<Type, XdrError> {
+    Ok(Type::Union {
+        lib: udt.lib.to_string()?,
+        name: udt.name.to_string()?,
+        cases: udt
+            .cases
+            .iter()
+            .map(|case| {
+                Ok(UnionCase {
+                    name: case.name.to_string()?,
+                    type_: case.type_.as_ref().map(type_name).transpose()?,
+                })
+            })
+            .collect::<Result<_, XdrError>>()?,
+    })
+}
+
+fn enum_type(udt: &ScSpecUdtEnumV0) -> Result<Type, XdrError> {
+    Ok(Type::Enum {
+        lib: udt.lib.to_string()?,
+        name: udt.name.to_string()?,
+        cases: udt
+            .cases
+            .iter()
+            .map(|case| {
+                Ok(EnumCase {
+                    name: case.name.to_string()?,
+                    value: case.value,
+                })
+            })
+            .collect::<Result<_, XdrError>>()?,
+    })
+}
+
+fn error_enum_type(udt: &ScSpecUdtErrorEnumV0) -> Result<Type, XdrError> {
+    Ok(Type::ErrorEnum {
+        lib: udt.lib.to_string()?,
+        name: udt.name.to_string()?,
+        cases: udt
+            .cases
+            .iter()
+            .map(|case| {
+                Ok(EnumCase {
+                    name: case.name.to_string()?,
+                    value: case.value,
+                })
+            })
+            .collect::<Result<_, XdrError>>()?,
+    })
+}
+
+/// Name of a spec type, using the Rust SDK notation (e.g. `vec<symbol>`, `map<u32, bytes>`)
+fn type_name(t: &ScSpecTypeDef) -> Result<String, XdrError> {
+    Ok(match t {
+        ScSpecTypeDef::Option(o) => format!("option<{}>", type_name(&o.value_type)?),
+        ScSpecTypeDef::Result(r) => format!(
+            "result<{}, {}>",
+            type_name(&r.ok_type)?,
+            type_name(&r.error_type)?
+        ),
+        ScSpecTypeDef::Vec(v) => format!("vec<{}>", type_name(&v.element_type)?),
+        ScSpecTypeDef::Set(s) => format!("set<{}>", type_name(&s.element_type)?),
+        ScSpecTypeDef::Map(m) => format!(
+            "map<{}, {}>",
+            type_name(&m.key_type)?,
+            type_name(&m.value_type)?
+        ),
+        ScSpecTypeDef::Tuple(t) => format!(
+            "({})",
+            t.value_types
+                .iter()
+                .map(type_name)
+                .collect::<Result<Vec<_>, _>>()?
+                .join(", ")
+        ),
+        ScSpecTypeDef::BytesN(b) => format!("bytesn<{}>", b.n),
+        ScSpecTypeDef::Udt(udt) => udt.name.to_string()?,
+        // The remaining types are plain values (u32, symbol, account_id, ...)
+        t => to_snake_case(&format!("{t:?}")),
+    })
+}
+
+fn to_snake_case(s: &str) -> String {
+    let mut snake = String::with_capacity(s.len());
+    for (i, c) in s.chars().enumerate() {
+        if c.is_ascii_uppercase() {
+            if i > 0 {
+                snake.push('_');
+            }
+            snake.push(c.to_ascii_lowercase());
         } else {
-            println!("Env Meta: None");
+            snake.push(c);
         }
+    }
+    snake
+}
 
-        if let Some(spec) = spec {
-            println!("Contract Spec: {}", base64::encode(spec));
-            let mut cursor = Cursor::new(spec);
-            for spec_entry in ScSpecEntry::read_xdr_iter(&mut cursor) {
-                match spec_entry? {
-                    ScSpecEntry::FunctionV0(f) => println!(
-                        " • Function: {} ({:?}) -> ({:?})",
-                        f.name.to_string()?,
-                        f.inputs.as_slice(),
-                        f.outputs.as_slice(),
-                    ),
-                    ScSpecEntry::UdtUnionV0(udt) => {
-                        println!(" • Union: {udt:?}");
-                    }
-                    ScSpecEntry::UdtStructV0(udt) => {
-                        println!(" • Struct: {udt:?}");
-                    }
-                    ScSpecEntry::UdtEnumV0(udt) => {
-                        println!(" • Enum: {udt:?}");
-                    }
-                    ScSpecEntry::UdtErrorEnumV0(udt) => {
-                        println!(" • Error: {udt:?}");
+fn print_contract(contract: &Contract) {
+    match &contract.env_meta_xdr {
+        Some(xdr) => println!("Env Meta: {xdr}"),
+        None => println!("Env Meta: None"),
+    }
+    if let Some(v) = contract.interface_version {
+        println!(" • Interface Version: {v}");
+    }
+
+    match &contract.spec_xdr {
+        Some(xdr) => println!("Contract Spec: {xdr}"),
+        None => println!("Contract Spec: None"),
+    }
+    for f in &contract.functions {
+        let inputs = f
+            .inputs
+            .iter()
+            .map(|input| format!("{}: {}", input.name, input.type_))
+            .collect::<Vec<_>>()
+            .join(", ");
+        println!(
+            " • Function: {}({inputs}) -> ({})",
+            f.name,
+            f.outputs.join(", ")
+        );
+    }
+    for t in &contract.types {
+        match t {
+            Type::Struct { name, fields, .. } => {
+                println!(" • Struct: {name}");
+                for field in fields {
+                    println!("     {}: {}", field.name, field.type_);
+                }
+            }
+            Type::Union { name, cases, .. } => {
+                println!(" • Union: {name}");
+                for case in cases {
+                    match &case.type_ {
+                        Some(t) => println!("     {}({t})", case.name),
+                        None => println!("     {}", case.name),
                     }
                 }
             }
-        } else {
-            println!("Contract Spec: None");
+            Type::Enum { name, cases, .. } | Type::ErrorEnum { name, cases, .. } => {
+                let kind = if matches!(t, Type::Enum { .. }) {
+                    "Enum"
+                } else {
+                    "Error"
+                };
+                println!(" • {kind}: {name}");
+                for case in cases {
+                    println!("     {} = {}", case.name, case.value);
+                }
+            }
         }
-        Ok(())
     }
 }
@@ -11,10 +11,11 @@ use soroban_env_host::xdr::{
 use soroban_env_host::{
     budget::{Budget, CostType},
     events::HostEvent,
+    im_rc::OrdMap,
     storage::Storage,
     xdr::{
-        AccountId, Error as XdrError, HostFunction, PublicKey, ReadXdr, ScHostStorageErrorCode,
-        ScObject, ScSpecEntry, ScStatus, ScVal, Uint256,
+        AccountId, Error as XdrError, HostFunction, LedgerEntry, PublicKey, ReadXdr,
+        ScHostStorageErrorCode, ScObject, ScSpecEntry, ScStatus, ScVal, Uint256,
     },
     Host, HostError,
 };
@@ -26,7 +27,9 @@ use crate::utils::{
     contract_code_to_spec_entries, create_ledger_footprint, default_account_ledger_entry,
 };
 use crate::{
-    rpc, snapshot,
+    rpc,
+    signer::{self, Signer},
+    snapshot,
     strval::{self, StrValError},
     utils,
 };
@@ -55,6 +58,10 @@ pub struct Cmd {
     /// Output the footprint to stderr
     #[clap(long = "footprint")]
     footprint: bool,
+    /// Simulate the invocation, outputting the footprint, cost and ledger changes to stderr,
+    /// without committing or submitting it
+    #[clap(long = "dry-run")]
+    dry_run: bool,
 
     /// Account ID to invoke as
     #[clap(
@@ -75,7 +82,7 @@ pub struct Cmd {
     )]
     ledger_file: std::path::PathBuf,
 
-    /// Secret 'S' key used to sign the transaction sent to the rpc server
+    /// Secret 'S' key used to sign the transaction sent to the rpc server, "keychain:<name>" for a key stored in the OS keychain or "ledger[:<account index>]" for a Ledger device
     #[clap(
         long = "secret-key",
         requires = "rpc-url",
@@ -101,6 +108,22 @@ pub struct Cmd {
         help_heading = HEADING_RPC,
     )]
     network_passphrase: Option<String>,
+    /// Secret 'S' key (or signer, see --secret-key) of the account paying the fee, wrapping the transaction in a fee bump transaction
+    #[clap(
+        long = "fee-bump-source",
+        requires = "rpc-url",
+        requires = "fee-bump-fee",
+        env = "SOROBAN_FEE_BUMP_SOURCE",
+        help_heading = HEADING_RPC,
+    )]
+    fee_bump_source: Option<String>,
+    /// Total fee (in stroops) paid by the fee bump transaction
+    #[clap(
+        long = "fee-bump-fee",
+        requires = "fee-bump-source",
+        help_heading = HEADING_RPC,
+    )]
+    fee_bump_fee: Option<i64>,
 }
 
 #[derive(thiserror::Error, Debug)]
@@ -155,8 +178,10 @@ pub enum Error {
     Xdr(#[from] XdrError),
     #[error("error parsing int: {0}")]
     ParseIntError(#[from] ParseIntError),
-    #[error("cannot parse secret key")]
-    CannotParseSecretKey,
+    #[error(transparent)]
+    Signer(#[from] signer::Error),
+    #[error("fee bump source: {0}")]
+    CannotParseFeeBumpSourceKey(signer::Error),
     #[error(transparent)]
     Rpc(#[from] rpc::Error),
     #[error("unexpected contract code data type: {0:?}")]
@@ -166,92 +191,48 @@ pub enum Error {
 }
 
 #[derive(Clone, Debug)]
-enum Arg {
+pub(crate) enum Arg {
     Arg(String),
     ArgXdr(String),
 }
 
-impl Cmd {
-    fn build_host_function_parameters(
-        &self,
-        contract_id: [u8; 32],
-        spec_entries: &[ScSpecEntry],
+impl Arg {
+    /// Re-assemble the --arg and --arg-xdr values of a command, to match the order given on the
+    /// command line
+    pub(crate) fn from_matches(
         matches: &clap::ArgMatches,
-    ) -> Result<ScVec, Error> {
-        // Get the function spec from the contract code
-        let spec = spec_entries
-            .iter()
-            .find_map(|e| {
-                if let ScSpecEntry::FunctionV0(f) = e {
-                    if f.name.to_string_lossy() == self.function {
-                        return Some(f);
-                    }
-                }
-                None
-            })
-            .ok_or_else(|| Error::FunctionNotFoundInContractSpec(self.function.clone()))?;
-
-        // Re-assemble the function args, to match the order given on the command line
+        args_id: &str,
+        args: &[String],
+        args_xdr_id: &str,
+        args_xdr: &[String],
+    ) -> Vec<Arg> {
         let indexed_args: Vec<(usize, Arg)> = matches
-            .indices_of("args")
+            .indices_of(args_id)
             .unwrap_or_default()
-            .zip(self.args.iter())
+            .zip(args.iter())
             .map(|(a, b)| (a, Arg::Arg(b.to_string())))
             .collect();
         let indexed_args_xdr: Vec<(usize, Arg)> = matches
-            .indices_of("args-xdr")
+            .indices_of(args_xdr_id)
             .unwrap_or_default()
-            .zip(self.args_xdr.iter())
+            .zip(args_xdr.iter())
             .map(|(a, b)| (a, Arg::ArgXdr(b.to_string())))
             .collect();
         let mut all_indexed_args: Vec<(usize, Arg)> = [indexed_args, indexed_args_xdr].concat();
         all_indexed_args.sort_by(|a, b| a.0.cmp(&b.0));
+        all_indexed_args.into_iter().map(|(_, arg)| arg).collect()
+    }
+}
 
-        // Parse the function arguments
-        let inputs = &spec.inputs;
-        if all_indexed_args.len() != inputs.len() {
-            return Err(Error::UnexpectedArgumentCount {
-                provided: all_indexed_args.len(),
-                expected: inputs.len(),
-                function: self.function.clone(),
-            });
-        }
-
-        let parsed_args = all_indexed_args
-            .iter()
-            .zip(inputs.iter())
-            .map(|(arg, input)| match &arg.1 {
-                Arg::ArgXdr(s) => ScVal::from_xdr_base64(s).map_err(|e| Error::CannotParseXdrArg {
-                    arg: s.clone(),
-                    error: e,
-                }),
-                Arg::Arg(s) => {
-                    strval::from_string(s, &input.type_).map_err(|e| Error::CannotParseArg {
-                        arg: s.clone(),
-                        error: e,
-                    })
-                }
-            })
-            .collect::<Result<Vec<_>, _>>()?;
-
-        // Add the contract ID and the function name to the arguments
-        let mut complete_args = vec![
-            ScVal::Object(Some(ScObject::Bytes(contract_id.try_into().unwrap()))),
-            ScVal::Symbol(
-                (&self.function)
-                    .try_into()
-                    .map_err(|_| Error::FunctionNameTooLong(self.function.clone()))?,
-            ),
-        ];
-        complete_args.extend_from_slice(parsed_args.as_slice());
-        let complete_args_len = complete_args.len();
-
-        complete_args
-            .try_into()
-            .map_err(|_| Error::MaxNumberOfArgumentsReached {
-                current: complete_args_len,
-                maximum: ScVec::default().max_len(),
-            })
+impl Cmd {
+    fn build_host_function_parameters(
+        &self,
+        contract_id: [u8; 32],
+        spec_entries: &[ScSpecEntry],
+        matches: &clap::ArgMatches,
+    ) -> Result<ScVec, Error> {
+        let args = Arg::from_matches(matches, "args", &self.args, "args-xdr", &self.args_xdr);
+        build_host_function_parameters(contract_id, spec_entries, &self.function, &args)
     }
 
     pub async fn run(&self, matches: &clap::ArgMatches) -> Result<(), Error> {
@@ -276,11 +257,10 @@ impl Cmd {
         matches: &clap::ArgMatches,
     ) -> Result<(), Error> {
         let client = Client::new(self.rpc_url.as_ref().unwrap());
-        let key = utils::parse_secret_key(self.secret_key.as_ref().unwrap())
-            .map_err(|_| Error::CannotParseSecretKey)?;
+        let key = signer::from_str(self.secret_key.as_ref().unwrap())?;
 
         // Get the account sequence number
-        let public_strkey = StrkeyPublicKeyEd25519(key.public.to_bytes()).to_string();
+        let public_strkey = StrkeyPublicKeyEd25519(key.public_key()).to_string();
         let account_details = client.get_account(&public_strkey).await?;
         // TODO: create a cmdline parameter for the fee instead of simply using the minimum fee
         let fee: u32 = 100;
@@ -324,9 +304,20 @@ impl Cmd {
         let simulation_response = client.simulate_transaction(&tx_without_footprint).await?;
         let footprint = LedgerFootprint::from_xdr_base64(simulation_response.footprint)?;
 
-        if self.footprint {
+        if self.footprint || self.dry_run {
             eprintln!("Footprint: {}", serde_json::to_string(&footprint).unwrap(),);
         }
+        if self.dry_run {
+            // The simulation doesn't report the ledger entries it changes,
+            // only which ones it could write to
+            for key in footprint.read_write.iter() {
+                eprintln!("May modify: {}", serde_json::to_string(key).unwrap());
+            }
+            eprintln!("Cpu Insns: {}", simulation_response.cost.cpu_insns);
+            eprintln!("Mem Bytes: {}", simulation_response.cost.mem_bytes);
+            eprintln!("Fee: {fee}");
+            return Ok(());
+        }
 
         // Send the final transaction with the actual footprint
         let tx = build_invoke_contract_tx(
@@ -337,6 +328,19 @@ impl Cmd {
             self.network_passphrase.as_ref().unwrap(),
             &key,
         )?;
+        let tx = match (&self.fee_bump_source, tx) {
+            (Some(fee_bump_source), TransactionEnvelope::Tx(inner)) => {
+                let fee_bump_key = signer::from_str(fee_bump_source)
+                    .map_err(Error::CannotParseFeeBumpSourceKey)?;
+                utils::fee_bump_transaction(
+                    &fee_bump_key,
+                    inner,
+                    self.fee_bump_fee.unwrap(),
+                    self.network_passphrase.as_ref().unwrap(),
+                )?
+            }
+            (_, tx) => tx,
+        };
 
         let results = client.send_transaction(&tx).await?;
         if results.is_empty() {
@@ -420,14 +424,14 @@ impl Cmd {
             ))
         })?;
 
-        if self.footprint {
+        if self.footprint || self.dry_run {
             eprintln!(
                 "Footprint: {}",
                 serde_json::to_string(&create_ledger_footprint(&storage.footprint)).unwrap(),
             );
         }
 
-        if self.cost {
+        if self.cost || self.dry_run {
             eprintln!("Cpu Insns: {}", budget.get_cpu_insns_count());
             eprintln!("Mem Bytes: {}", budget.get_mem_bytes_count());
             for cost_type in CostType::variants() {
@@ -445,6 +449,11 @@ impl Cmd {
             }
         }
 
+        if self.dry_run {
+            print_ledger_changes(&state.1, &storage.map);
+            return Ok(());
+        }
+
         snapshot::commit(state.1, ledger_info, &storage.map, &self.ledger_file).map_err(|e| {
             Error::CannotCommitLedgerFile {
                 filepath: self.ledger_file.clone(),
@@ -455,13 +464,101 @@ impl Cmd {
     }
 }
 
-fn build_invoke_contract_tx(
+// Output to stderr the ledger entries created, modified or deleted by an invocation
+fn print_ledger_changes<'a, I>(state: &OrdMap<LedgerKey, LedgerEntry>, storage_map: I)
+where
+    I: IntoIterator<Item = (&'a Box<LedgerKey>, &'a Option<Box<LedgerEntry>>)>,
+{
+    for (lk, ole) in storage_map {
+        match (state.get(&**lk), ole) {
+            (None, Some(le)) => {
+                eprintln!("Created: {}", serde_json::to_string(&**le).unwrap());
+            }
+            (Some(old), Some(le)) if old != &**le => {
+                eprintln!("Modified: {}", serde_json::to_string(&**le).unwrap());
+            }
+            (Some(_), None) => {
+                eprintln!("Deleted: {}", serde_json::to_string(&**lk).unwrap());
+            }
+            // Entries which were only read, or written with the same value
+            _ => {}
+        }
+    }
+}
+
+pub(crate) fn build_host_function_parameters(
+    contract_id: [u8; 32],
+    spec_entries: &[ScSpecEntry],
+    function: &str,
+    args: &[Arg],
+) -> Result<ScVec, Error> {
+    // Get the function spec from the contract code
+    let spec = spec_entries
+        .iter()
+        .find_map(|e| {
+            if let ScSpecEntry::FunctionV0(f) = e {
+                if f.name.to_string_lossy() == function {
+                    return Some(f);
+                }
+            }
+            None
+        })
+        .ok_or_else(|| Error::FunctionNotFoundInContractSpec(function.to_string()))?;
+
+    // Parse the function arguments
+    let inputs = &spec.inputs;
+    if args.len() != inputs.len() {
+        return Err(Error::UnexpectedArgumentCount {
+            provided: args.len(),
+            expected: inputs.len(),
+            function: function.to_string(),
+        });
+    }
+
+    let parsed_args = args
+        .iter()
+        .zip(inputs.iter())
+        .map(|(arg, input)| match arg {
+            Arg::ArgXdr(s) => ScVal::from_xdr_base64(s).map_err(|e| Error::CannotParseXdrArg {
+                arg: s.clone(),
+                error: e,
+            }),
+            Arg::Arg(s) => {
+                strval::from_string(s, &input.type_).map_err(|e| Error::CannotParseArg {
+                    arg: s.clone(),
+                    error: e,
+                })
+            }
+        })
+        .collect::<Result<Vec<_>, _>>()?;
+
+    // Add the contract ID and the function name to the arguments
+    let mut complete_args = vec![
+        ScVal::Object(Some(ScObject::Bytes(contract_id.try_into().unwrap()))),
+        ScVal::Symbol(
+            (&function.to_string())
+                .try_into()
+                .map_err(|_| Error::FunctionNameTooLong(function.to_string()))?,
+        ),
+    ];
+    complete_args.extend_from_slice(parsed_args.as_slice());
+    let complete_args_len = complete_args.len();
+
+    complete_args
+        .try_into()
+        .map_err(|_| Error::MaxNumberOfArgumentsReached {
+            current: complete_args_len,
+            maximum: ScVec::default().max_len(),
+        })
+}
+
+pub(crate) fn build_invoke_contract_tx(
     parameters: ScVec,
     footprint: Option<LedgerFootprint>,
     sequence: i64,
     fee: u32,
     network_passphrase: &str,
-    key: &ed25519_dalek::Keypair,
+    key: &dyn Signer,
 ) -> Result<TransactionEnvelope, Error> {
     // Use a default footprint if none provided
     let final_footprint = footprint.unwrap_or(LedgerFootprint {
@@ -477,7 +574,7 @@ fn build_invoke_contract_tx(
         }),
     };
     let tx = Transaction {
-        source_account: MuxedAccount::Ed25519(Uint256(key.public.to_bytes())),
+        source_account: MuxedAccount::Ed25519(Uint256(key.public_key())),
         fee,
         seq_num: SequenceNumber(sequence),
         cond: Preconditions::None,
@@ -0,0 +1,123 @@
+use clap::Parser;
+use hyper::{body, Body, Client, StatusCode, Uri};
+use hyper_rustls::HttpsConnectorBuilder;
+
+use crate::signer;
+use crate::HEADING_RPC;
+
+#[derive(Parser, Debug)]
+pub struct Cmd {
+    /// Account ID (G...) of the account to fund
+    #[clap(long = "account-id", required_unless_present = "secret-key")]
+    account_id: Option<String>,
+    /// Fund the account of this secret 'S' key, "keychain:<name>" for a key stored in the OS keychain or "ledger[:<account index>]" for a Ledger device
+    #[clap(long = "secret-key", env = "SOROBAN_SECRET_KEY")]
+    secret_key: Option<String>,
+    /// Friendbot endpoint, defaults to the /friendbot endpoint of the host of --rpc-url (as exposed by the quickstart image)
+    #[clap(
+        long,
+        env = "SOROBAN_FRIENDBOT_URL",
+        required_unless_present = "rpc-url"
+    )]
+    friendbot_url: Option<String>,
+    /// RPC server endpoint
+    #[clap(long, env = "SOROBAN_RPC_URL", help_heading = HEADING_RPC)]
+    rpc_url: Option<String>,
+}
+
+#[derive(thiserror::Error, Debug)]
+pub enum Error {
+    #[error(transparent)]
+    Signer(#[from] signer::Error),
+    #[error("invalid url {url}: {error}")]
+    InvalidUrl {
+        url: String,
+        error: hyper::http::uri::InvalidUri,
+    },
+    #[error("friendbot request failed: {0}")]
+    Http(#[from] hyper::Error),
+    #[error("friendbot could not fund the account ({status}): {body}")]
+    FundingFailed { status: StatusCode, body: String },
+}
+
+impl Cmd {
+    pub async fn run(&self) -> Result<(), Error> {
+        // --account-id takes precedence over a secret key set through the environment
+        let account_id = match (&self.account_id, &self.secret_key) {
+            (Some(account_id), _) => account_id.clone(),
+            (None, Some(secret_key)) => {
+                let key = signer::from_str(secret_key)?;
+                stellar_strkey::StrkeyPublicKeyEd25519(key.public_key()).to_string()
+            }
+            _ => unreachable!("clap requires either --account-id or --secret-key"),
+        };
+        let friendbot_url = match (&self.friendbot_url, &self.rpc_url) {
+            (Some(friendbot_url), _) => friendbot_url.clone(),
+            (None, Some(rpc_url)) => default_friendbot_url(rpc_url)?,
+            _ => unreachable!("clap requires either --friendbot-url or --rpc-url"),
+        };
+        fund(&friendbot_url, &account_id).await?;
+        eprintln!("funded {account_id}");
+        Ok(())
+    }
+}
+
+/// Friendbot endpoint served next to the RPC server, as in the quickstart image
+/// (e.g. http://localhost:8000/friendbot for http://localhost:8000/soroban/rpc)
+pub fn default_friendbot_url(rpc_url: &str) -> Result<String, Error> {
+    let uri = parse_uri(rpc_url)?;
+    Ok(format!(
+        "{}://{}/friendbot",
+        uri.scheme_str().unwrap_or("http"),
+        uri.authority().map_or("", |a| a.as_str())
+    ))
+}
+
+/// Create (and fund) the given account through friendbot
+pub async fn fund(friendbot_url: &str, account_id: &str) -> Result<(), Error> {
+    let separator = if friendbot_url.contains('?') {
+        '&'
+    } else {
+        '?'
+    };
+    let uri = parse_uri(&format!("{friendbot_url}{separator}addr={account_id}"))?;
+    let https = HttpsConnectorBuilder::new()
+        .with_webpki_roots()
+        .https_or_http()
+        .enable_http1()
+        .build();
+    let response = Client::builder().build::<_, Body>(https).get(uri).await?;
+    let status = response.status();
+    if status.is_success() {
+        return Ok(());
+    }
+    let body = body::to_bytes(response.into_body()).await?;
+    Err(Error::FundingFailed {
+        status,
+        body: String::from_utf8_lossy(&body).into_owned(),
+    })
+}
+
+fn parse_uri(url: &str) -> Result<Uri, Error> {
+    url.parse().map_err(|error| Error::InvalidUrl {
+        url: url.to_string(),
+        error,
+    })
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_default_friendbot_url() {
+        assert_eq!(
+            default_friendbot_url("http://localhost:8000/soroban/rpc").unwrap(),
+            "http://localhost:8000/friendbot"
+        );
+        assert_eq!(
+            default_friendbot_url("https://example.com/rpc?key=1").unwrap(),
+            "https://example.com/friendbot"
+        );
+    }
+}
@@ -0,0 +1,32 @@
+use std::fmt::Debug;
+
+use clap::{Parser, Subcommand};
+
+pub mod fund;
+
+#[derive(Parser, Debug)]
+pub struct Root {
+    #[clap(subcommand)]
+    cmd: Cmd,
+}
+
+#[derive(Subcommand, Debug)]
+enum Cmd {
+    /// Fund an account on a test network using friendbot
+    Fund(fund::Cmd),
+}
+
+#[derive(thiserror::Error, Debug)]
+pub enum Error {
+    #[error(transparent)]
+    Fund(#[from] fund::Error),
+}
+
+impl Root {
+    pub async fn run(&self) -> Result<(), Error> {
+        match &self.cmd {
+            Cmd::Fund(fund) => fund.run().await?,
+        }
+        Ok(())
+    }
+}
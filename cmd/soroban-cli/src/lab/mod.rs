@@ -0,0 +1,37 @@
+use std::fmt::Debug;
+
+use clap::{Parser, Subcommand};
+
+pub mod snapshot;
+
+#[derive(Parser, Debug)]
+pub struct Root {
+    #[clap(subcommand)]
+    cmd: Cmd,
+}
+
+#[derive(Subcommand, Debug)]
+enum Cmd {
+    /// Export and import the sandbox ledger state
+    Snapshot(snapshot::Root),
+    /// Decode and encode XDR
+    Xdr(crate::xdr::Cmd),
+}
+
+#[derive(thiserror::Error, Debug)]
+pub enum Error {
+    #[error(transparent)]
+    Snapshot(#[from] snapshot::Error),
+    #[error(transparent)]
+    Xdr(#[from] crate::xdr::Error),
+}
+
+impl Root {
+    pub fn run(&self) -> Result<(), Error> {
+        match &self.cmd {
+            Cmd::Snapshot(snapshot) => snapshot.run()?,
+            Cmd::Xdr(xdr) => xdr.run()?,
+        }
+        Ok(())
+    }
+}
@@ -0,0 +1,110 @@
+use std::fmt::Debug;
+
+use clap::{Parser, Subcommand};
+
+use crate::{snapshot, HEADING_SANDBOX};
+
+#[derive(Parser, Debug)]
+pub struct Root {
+    #[clap(subcommand)]
+    cmd: Cmd,
+}
+
+#[derive(Subcommand, Debug)]
+enum Cmd {
+    /// Write the sandbox ledger state (accounts, contract code and data) to a file
+    Export(ExportCmd),
+    /// Replace the sandbox ledger state with the contents of a file created by export
+    Import(ImportCmd),
+}
+
+#[derive(Parser, Debug)]
+pub struct ExportCmd {
+    /// File to write the snapshot to
+    #[clap(long, parse(from_os_str))]
+    out: std::path::PathBuf,
+
+    /// File to persist ledger state
+    #[clap(
+        long,
+        parse(from_os_str),
+        default_value(".soroban/ledger.json"),
+        env = "SOROBAN_LEDGER_FILE",
+        help_heading = HEADING_SANDBOX,
+    )]
+    ledger_file: std::path::PathBuf,
+}
+
+#[derive(Parser, Debug)]
+pub struct ImportCmd {
+    /// Snapshot file to import
+    #[clap(long, parse(from_os_str))]
+    file: std::path::PathBuf,
+
+    /// File to persist ledger state
+    #[clap(
+        long,
+        parse(from_os_str),
+        default_value(".soroban/ledger.json"),
+        env = "SOROBAN_LEDGER_FILE",
+        help_heading = HEADING_SANDBOX,
+    )]
+    ledger_file: std::path::PathBuf,
+}
+
+#[derive(thiserror::Error, Debug)]
+pub enum Error {
+    #[error("reading file {filepath}: {error}")]
+    CannotReadLedgerFile {
+        filepath: std::path::PathBuf,
+        error: snapshot::Error,
+    },
+    #[error("snapshot file {0} does not exist")]
+    SnapshotFileNotFound(std::path::PathBuf),
+    #[error("writing file {filepath}: {error}")]
+    CannotWriteLedgerFile {
+        filepath: std::path::PathBuf,
+        error: snapshot::Error,
+    },
+}
+
+impl Root {
+    pub fn run(&self) -> Result<(), Error> {
+        match &self.cmd {
+            Cmd::Export(export) => export.run()?,
+            Cmd::Import(import) => import.run()?,
+        }
+        Ok(())
+    }
+}
+
+impl ExportCmd {
+    pub fn run(&self) -> Result<(), Error> {
+        copy(&self.ledger_file, &self.out)
+    }
+}
+
+impl ImportCmd {
+    pub fn run(&self) -> Result<(), Error> {
+        // snapshot::read treats a missing file as an empty ledger, which is
+        // not what the user meant when importing
+        if !self.file.exists() {
+            return Err(Error::SnapshotFileNotFound(self.file.clone()));
+        }
+        copy(&self.file, &self.ledger_file)
+    }
+}
+
+// Parse the ledger state from input_file and write it to output_file, so that
+// invalid snapshots are rejected instead of being copied verbatim
+fn copy(input_file: &std::path::PathBuf, output_file: &std::path::PathBuf) -> Result<(), Error> {
+    let (ledger_info, entries) =
+        snapshot::read(input_file).map_err(|e| Error::CannotReadLedgerFile {
+            filepath: input_file.clone(),
+            error: e,
+        })?;
+    snapshot::write(entries, ledger_info, output_file).map_err(|e| Error::CannotWriteLedgerFile {
+        filepath: output_file.clone(),
+        error: e,
+    })
+}
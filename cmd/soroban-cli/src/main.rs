@@ -2,18 +2,24 @@ use clap::{AppSettings, CommandFactory, FromArgMatches, Parser, Subcommand};
 
 mod completion;
 mod deploy;
+mod dev;
+mod fetch;
 mod gen;
 mod inspect;
 mod invoke;
 mod jsonrpc;
+mod keys;
+mod lab;
 mod network;
 mod optimize;
 mod read;
 mod rpc;
 mod serve;
+mod signer;
 mod snapshot;
 mod strval;
 mod token;
+mod tx;
 mod utils;
 mod version;
 mod xdr;
@@ -31,6 +37,11 @@ const HEADING_RPC: &str = "OPTIONS (RPC)";
 )]
 #[clap(global_setting(AppSettings::DeriveDisplayOrder))]
 struct Root {
+    /// Name of the network profile providing the RPC options (see `soroban network`)
+    // applied by network::apply_profile before the arguments are parsed
+    #[allow(dead_code)]
+    #[clap(long, global = true, env = "SOROBAN_NETWORK", help_heading = HEADING_RPC)]
+    network: Option<String>,
     #[clap(subcommand)]
     cmd: Cmd,
 }
@@ -39,7 +50,7 @@ struct Root {
 enum Cmd {
     /// Invoke a contract function in a WASM file
     Invoke(invoke::Cmd),
-    /// Inspect a WASM file listing contract functions, meta, etc
+    /// Inspect a WASM file or a deployed contract listing contract functions, types, meta, etc
     Inspect(inspect::Cmd),
     /// Optimize a WASM file
     Optimize(optimize::Cmd),
@@ -49,10 +60,22 @@ enum Cmd {
     Serve(serve::Cmd),
     /// Wrap, create, and manage token contracts
     Token(token::Root),
+    /// Build transactions against the network
+    Tx(tx::Root),
+    /// Manage the accounts of identities
+    Keys(keys::Root),
+    /// Manage the network profiles selected with --network
+    Network(network::Root),
     /// Deploy a WASM file as a contract
     Deploy(deploy::Cmd),
+    /// Build, deploy and invoke a contract in the sandbox, again on every change with --watch
+    Dev(dev::Cmd),
+    /// Fetch the WASM of a contract deployed on the network
+    Fetch(fetch::Cmd),
     /// Generate code client bindings for a contract
     Gen(gen::Cmd),
+    /// Experimental tools for working with the sandbox
+    Lab(lab::Root),
 
     /// Decode xdr
     Xdr(xdr::Cmd),
@@ -80,16 +103,28 @@ enum CmdError {
     #[error(transparent)]
     Token(#[from] token::Error),
     #[error(transparent)]
+    Tx(#[from] tx::Error),
+    #[error(transparent)]
+    Keys(#[from] keys::Error),
+    #[error(transparent)]
+    Network(#[from] network::Error),
+    #[error(transparent)]
     Gen(#[from] gen::Error),
     #[error(transparent)]
+    Lab(#[from] lab::Error),
+    #[error(transparent)]
     Deploy(#[from] deploy::Error),
     #[error(transparent)]
+    Dev(#[from] dev::Error),
+    #[error(transparent)]
+    Fetch(#[from] fetch::Error),
+    #[error(transparent)]
     Xdr(#[from] xdr::Error),
 }
 
 async fn run(cmd: Cmd, matches: &mut clap::ArgMatches) -> Result<(), CmdError> {
     match cmd {
-        Cmd::Inspect(inspect) => inspect.run()?,
+        Cmd::Inspect(inspect) => inspect.run().await?,
         Cmd::Optimize(opt) => opt.run()?,
         Cmd::Invoke(invoke) => {
             let (_, sub_arg_matches) = matches.remove_subcommand().unwrap();
@@ -98,8 +133,17 @@ async fn run(cmd: Cmd, matches: &mut clap::ArgMatches) -> Result<(), CmdError> {
         Cmd::Read(read) => read.run()?,
         Cmd::Serve(serve) => serve.run().await?,
         Cmd::Token(token) => token.run().await?,
+        Cmd::Tx(tx) => tx.run().await?,
+        Cmd::Keys(keys) => keys.run().await?,
+        Cmd::Network(network) => network.run()?,
         Cmd::Gen(gen) => gen.run()?,
-        Cmd::Deploy(deploy) => deploy.run().await?,
+        Cmd::Lab(lab) => lab.run()?,
+        Cmd::Deploy(deploy) => {
+            let (_, sub_arg_matches) = matches.remove_subcommand().unwrap();
+            deploy.run(&sub_arg_matches).await?;
+        }
+        Cmd::Dev(dev) => dev.run().await?,
+        Cmd::Fetch(fetch) => fetch.run().await?,
         Cmd::Xdr(xdr) => xdr.run()?,
         Cmd::Version(version) => version.run(),
         Cmd::Completion(completion) => completion.run(&mut Root::command()),
@@ -109,6 +153,12 @@ async fn run(cmd: Cmd, matches: &mut clap::ArgMatches) -> Result<(), CmdError> {
 
 #[tokio::main]
 async fn main() {
+    let args: Vec<String> = std::env::args().collect();
+    if let Err(e) = network::apply_profile(&args) {
+        eprintln!("error: {e}");
+        return;
+    }
+
     // We expand the Root::parse() invocation, so that we can save
     // Clap's ArgMatches (for later argument processing)
     let mut matches = Root::command().get_matches();
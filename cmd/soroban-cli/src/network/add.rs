@@ -0,0 +1,42 @@
+use clap::Parser;
+
+use super::{config_file, Config, Error, Network};
+
+#[derive(Parser, Debug)]
+pub struct Cmd {
+    /// Name of the network profile
+    name: String,
+    /// RPC server endpoint
+    #[clap(long)]
+    rpc_url: String,
+    /// Network passphrase to sign the transactions sent to the rpc server
+    #[clap(long = "network-passphrase")]
+    network_passphrase: String,
+    /// Friendbot endpoint used to fund accounts (test networks only)
+    #[clap(long)]
+    friendbot_url: Option<String>,
+    /// Replace the profile if it already exists
+    #[clap(long)]
+    force: bool,
+}
+
+impl Cmd {
+    pub fn run(&self) -> Result<(), Error> {
+        let filepath = config_file();
+        let mut config = Config::load(&filepath)?;
+        if config.networks.contains_key(&self.name) && !self.force {
+            return Err(Error::NetworkExists {
+                name: self.name.clone(),
+            });
+        }
+        config.networks.insert(
+            self.name.clone(),
+            Network {
+                rpc_url: self.rpc_url.clone(),
+                network_passphrase: self.network_passphrase.clone(),
+                friendbot_url: self.friendbot_url.clone(),
+            },
+        );
+        config.save(&filepath)
+    }
+}
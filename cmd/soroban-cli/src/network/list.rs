@@ -0,0 +1,33 @@
+use clap::Parser;
+
+use super::{config_file, Config, Error};
+
+#[derive(Parser, Debug)]
+pub struct Cmd {
+    /// Print the rpc url and network passphrase of every profile
+    #[clap(long, short = 'l')]
+    long: bool,
+}
+
+impl Cmd {
+    pub fn run(&self) -> Result<(), Error> {
+        let config = Config::load(&config_file())?;
+        for (name, network) in &config.networks {
+            // the network selected with `soroban network use` is marked with a '*'
+            let marker = if config.default.as_ref() == Some(name) {
+                "*"
+            } else {
+                " "
+            };
+            if self.long {
+                println!(
+                    "{marker} {name}\t{}\t{}",
+                    network.rpc_url, network.network_passphrase
+                );
+            } else {
+                println!("{marker} {name}");
+            }
+        }
+        Ok(())
+    }
+}
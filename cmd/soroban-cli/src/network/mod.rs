@@ -0,0 +1,242 @@
+use std::{collections::BTreeMap, fs, io, path::PathBuf};
+
+use clap::{Parser, Subcommand};
+use serde::{Deserialize, Serialize};
+
+pub mod add;
+pub mod list;
+pub mod select;
+
+pub static SANDBOX_NETWORK_PASSPHRASE: &str = "Local Sandbox Stellar Network ; September 2022";
+
+/// File storing the network profiles, relative to the current directory like the sandbox ledger file
+pub const DEFAULT_CONFIG_FILE: &str = ".soroban/networks.json";
+
+#[derive(Parser, Debug)]
+pub struct Root {
+    #[clap(subcommand)]
+    cmd: Cmd,
+}
+
+#[derive(Subcommand, Debug)]
+enum Cmd {
+    /// Add (or replace) a network profile
+    Add(add::Cmd),
+    /// List the network profiles
+    #[clap(alias = "ls")]
+    List(list::Cmd),
+    /// Select the network profile used when --network isn't provided
+    Use(select::Cmd),
+}
+
+#[derive(thiserror::Error, Debug)]
+pub enum Error {
+    #[error("reading network config {filepath}: {error}")]
+    CannotReadConfig { filepath: PathBuf, error: io::Error },
+    #[error("parsing network config {filepath}: {error}")]
+    CannotParseConfig {
+        filepath: PathBuf,
+        error: serde_json::Error,
+    },
+    #[error("writing network config {filepath}: {error}")]
+    CannotWriteConfig { filepath: PathBuf, error: io::Error },
+    #[error("network {name} not found, add it with `soroban network add`")]
+    NetworkNotFound { name: String },
+    #[error("network {name} already exists, use --force to replace it")]
+    NetworkExists { name: String },
+}
+
+impl Root {
+    pub fn run(&self) -> Result<(), Error> {
+        match &self.cmd {
+            Cmd::Add(add) => add.run()?,
+            Cmd::List(list) => list.run()?,
+            Cmd::Use(select) => select.run()?,
+        }
+        Ok(())
+    }
+}
+
+/// Settings of a network profile
+#[derive(Serialize, Deserialize, Debug, Clone, PartialEq, Eq)]
+#[serde(rename_all = "camelCase")]
+pub struct Network {
+    pub rpc_url: String,
+    pub network_passphrase: String,
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub friendbot_url: Option<String>,
+}
+
+/// Network profiles, along with the one selected by `soroban network use`
+#[derive(Serialize, Deserialize, Debug, Default, PartialEq, Eq)]
+#[serde(rename_all = "camelCase")]
+pub struct Config {
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub default: Option<String>,
+    #[serde(default)]
+    pub networks: BTreeMap<String, Network>,
+}
+
+/// Path of the network config, which can be overridden with SOROBAN_NETWORKS_FILE
+pub fn config_file() -> PathBuf {
+    std::env::var_os("SOROBAN_NETWORKS_FILE")
+        .map_or_else(|| PathBuf::from(DEFAULT_CONFIG_FILE), PathBuf::from)
+}
+
+impl Config {
+    /// Read the config, a missing file is an empty config
+    pub fn load(filepath: &PathBuf) -> Result<Config, Error> {
+        let contents = match fs::read(filepath) {
+            Ok(contents) => contents,
+            Err(e) if e.kind() == io::ErrorKind::NotFound => return Ok(Config::default()),
+            Err(error) => {
+                return Err(Error::CannotReadConfig {
+                    filepath: filepath.clone(),
+                    error,
+                })
+            }
+        };
+        serde_json::from_slice(&contents).map_err(|error| Error::CannotParseConfig {
+            filepath: filepath.clone(),
+            error,
+        })
+    }
+
+    pub fn save(&self, filepath: &PathBuf) -> Result<(), Error> {
+        let write_err = |error| Error::CannotWriteConfig {
+            filepath: filepath.clone(),
+            error,
+        };
+        if let Some(dir) = filepath.parent() {
+            fs::create_dir_all(dir).map_err(write_err)?;
+        }
+        let contents =
+            serde_json::to_vec_pretty(self).map_err(|error| Error::CannotParseConfig {
+                filepath: filepath.clone(),
+                error,
+            })?;
+        fs::write(filepath, contents).map_err(write_err)
+    }
+
+    pub fn get(&self, name: &str) -> Result<&Network, Error> {
+        self.networks
+            .get(name)
+            .ok_or_else(|| Error::NetworkNotFound {
+                name: name.to_string(),
+            })
+    }
+}
+
+/// Name of the network selected on the command line (--network <name> or --network=<name>)
+fn network_arg(args: &[String]) -> Option<String> {
+    let mut args = args.iter();
+    while let Some(arg) = args.next() {
+        if arg == "--" {
+            break;
+        }
+        if arg == "--network" {
+            return args.next().cloned();
+        }
+        if let Some(name) = arg.strip_prefix("--network=") {
+            return Some(name.to_string());
+        }
+    }
+    None
+}
+
+/// Resolve the network profile selected with --network, SOROBAN_NETWORK or `soroban network use`
+/// and expose its settings through the environment variables read by the --rpc-url,
+/// --network-passphrase and --friendbot-url options of every command, so that explicit options
+/// still take precedence. The profile selected with `soroban network use` doesn't override
+/// variables which are already set, and isn't applied to `soroban dev`.
+pub fn apply_profile(args: &[String]) -> Result<(), Error> {
+    // the profiles are managed with the network command itself
+    if args.get(1).map(String::as_str) == Some("network") {
+        return Ok(());
+    }
+    let selected = network_arg(args).or_else(|| std::env::var("SOROBAN_NETWORK").ok());
+    // the dev loop only runs in the sandbox, so the default profile doesn't apply to it
+    // (a profile selected explicitly is still applied, for the loop to reject it)
+    if selected.is_none() && args.get(1).map(String::as_str) == Some("dev") {
+        return Ok(());
+    }
+    let override_env = selected.is_some();
+    let config = Config::load(&config_file())?;
+    let name = match selected.or_else(|| config.default.clone()) {
+        Some(name) => name,
+        None => return Ok(()),
+    };
+    let network = config.get(&name)?;
+    let mut vars = vec![
+        ("SOROBAN_RPC_URL", network.rpc_url.as_str()),
+        (
+            "SOROBAN_NETWORK_PASSPHRASE",
+            network.network_passphrase.as_str(),
+        ),
+    ];
+    if let Some(friendbot_url) = &network.friendbot_url {
+        vars.push(("SOROBAN_FRIENDBOT_URL", friendbot_url.as_str()));
+    }
+    for (var, value) in vars {
+        if override_env || std::env::var_os(var).is_none() {
+            std::env::set_var(var, value);
+        }
+    }
+    Ok(())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn args(args: &[&str]) -> Vec<String> {
+        args.iter().map(ToString::to_string).collect()
+    }
+
+    #[test]
+    fn test_network_arg() {
+        assert_eq!(
+            network_arg(&args(&["soroban", "invoke", "--network", "testnet"])),
+            Some("testnet".to_string())
+        );
+        assert_eq!(
+            network_arg(&args(&["soroban", "deploy", "--network=testnet"])),
+            Some("testnet".to_string())
+        );
+        assert_eq!(
+            network_arg(&args(&["soroban", "deploy", "--network-passphrase", "x"])),
+            None
+        );
+        assert_eq!(
+            network_arg(&args(&["soroban", "invoke", "--", "--network", "x"])),
+            None
+        );
+    }
+
+    #[test]
+    fn test_config_roundtrip() {
+        let dir = std::env::temp_dir().join(format!("soroban-networks-{}", std::process::id()));
+        let filepath = dir.join("networks.json");
+        assert_eq!(Config::load(&filepath).unwrap(), Config::default());
+
+        let mut config = Config {
+            default: Some("testnet".to_string()),
+            ..Config::default()
+        };
+        config.networks.insert(
+            "testnet".to_string(),
+            Network {
+                rpc_url: "https://rpc.example.com".to_string(),
+                network_passphrase: "Test SDF Future Network ; October 2022".to_string(),
+                friendbot_url: None,
+            },
+        );
+        config.save(&filepath).unwrap();
+        assert_eq!(Config::load(&filepath).unwrap(), config);
+        assert!(matches!(
+            config.get("mainnet"),
+            Err(Error::NetworkNotFound { .. })
+        ));
+        fs::remove_dir_all(dir).unwrap();
+    }
+}
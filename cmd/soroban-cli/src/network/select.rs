@@ -0,0 +1,25 @@
+use clap::Parser;
+
+use super::{config_file, Config, Error};
+
+#[derive(Parser, Debug)]
+pub struct Cmd {
+    /// Name of the network profile to use by default
+    #[clap(required_unless_present = "unset")]
+    name: Option<String>,
+    /// Stop using a network profile by default (commands run in the sandbox unless --rpc-url is provided)
+    #[clap(long, conflicts_with = "name")]
+    unset: bool,
+}
+
+impl Cmd {
+    pub fn run(&self) -> Result<(), Error> {
+        let filepath = config_file();
+        let mut config = Config::load(&filepath)?;
+        if let Some(name) = &self.name {
+            config.get(name)?;
+        }
+        config.default = self.name.clone();
+        config.save(&filepath)
+    }
+}
@@ -0,0 +1,59 @@
+//! Secret keys stored in the OS keychain, under the `soroban` service.
+//!
+//! Keys are added with the tools of the OS, e.g. on macOS:
+//!   security add-generic-password -s soroban -a <name> -w <secret key>
+//! and on Linux (with the Secret Service API, e.g. GNOME Keyring or KWallet):
+//!   secret-tool store --label="soroban <name>" service soroban account <name>
+
+use std::{io, process::Command};
+
+use crate::utils;
+
+#[cfg(unix)]
+const SERVICE: &str = "soroban";
+
+#[derive(thiserror::Error, Debug)]
+pub enum Error {
+    #[error("running {command}: {error}")]
+    CannotRunCommand { command: String, error: io::Error },
+    #[error("key {0} was not found in the keychain")]
+    KeyNotFound(String),
+    #[error("key {0} of the keychain is not a valid secret key")]
+    InvalidKey(String),
+    #[cfg(not(unix))]
+    #[error("the OS keychain is not supported on this platform")]
+    UnsupportedPlatform,
+}
+
+/// Load the secret key stored in the keychain with the given name
+pub fn load(name: &str) -> Result<ed25519_dalek::Keypair, Error> {
+    let mut command = lookup_command(name)?;
+    let output = command.output().map_err(|e| Error::CannotRunCommand {
+        command: format!("{:?}", command.get_program()),
+        error: e,
+    })?;
+    if !output.status.success() {
+        return Err(Error::KeyNotFound(name.to_string()));
+    }
+    let secret = String::from_utf8_lossy(&output.stdout);
+    utils::parse_secret_key(secret.trim()).map_err(|_| Error::InvalidKey(name.to_string()))
+}
+
+#[cfg(target_os = "macos")]
+fn lookup_command(name: &str) -> Result<Command, Error> {
+    let mut command = Command::new("security");
+    command.args(["find-generic-password", "-s", SERVICE, "-a", name, "-w"]);
+    Ok(command)
+}
+
+#[cfg(all(unix, not(target_os = "macos")))]
+fn lookup_command(name: &str) -> Result<Command, Error> {
+    let mut command = Command::new("secret-tool");
+    command.args(["lookup", "service", SERVICE, "account", name]);
+    Ok(command)
+}
+
+#[cfg(not(unix))]
+fn lookup_command(_name: &str) -> Result<Command, Error> {
+    Err(Error::UnsupportedPlatform)
+}
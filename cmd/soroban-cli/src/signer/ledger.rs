@@ -0,0 +1,236 @@
+//! Signing with a Ledger hardware wallet running the Stellar app, which must have hash signing
+//! enabled in its settings. The device is accessed through the Linux hidraw interface.
+
+use std::{fmt::Debug, io};
+
+const CLA: u8 = 0xe0;
+const INS_GET_PUBLIC_KEY: u8 = 0x02;
+const INS_SIGN_TX_HASH: u8 = 0x08;
+
+const SW_OK: u16 = 0x9000;
+const SW_DENIED: u16 = 0x6985;
+const SW_HASH_SIGNING_DISABLED: u16 = 0x6c66;
+
+/// BIP-44 hardened path of the Stellar accounts, to which the account index is appended
+const STELLAR_PATH: [u32; 2] = [44, 148];
+const HARDENED: u32 = 0x8000_0000;
+
+#[derive(thiserror::Error, Debug)]
+pub enum Error {
+    #[error("invalid ledger account index {0}")]
+    InvalidAccountIndex(String),
+    #[error("no Ledger device was found, make sure it is connected, unlocked and running the Stellar app")]
+    DeviceNotFound,
+    #[error("communicating with the Ledger device: {0}")]
+    Io(#[from] io::Error),
+    #[error("unexpected response from the Ledger device")]
+    UnexpectedResponse,
+    #[error("the transaction was rejected on the Ledger device")]
+    Denied,
+    #[error(
+        "hash signing must be enabled in the settings of the Stellar app of the Ledger device"
+    )]
+    HashSigningDisabled,
+    #[error("the Ledger device returned status {0:#06x}")]
+    Status(u16),
+    #[cfg(not(target_os = "linux"))]
+    #[error("Ledger devices are only supported on Linux")]
+    UnsupportedPlatform,
+}
+
+/// An account of a Ledger device, its public key is obtained when the signer is created
+pub struct LedgerSigner {
+    index: u32,
+    public_key: [u8; 32],
+}
+
+impl LedgerSigner {
+    pub fn new(index: u32) -> Result<Self, Error> {
+        if index >= HARDENED {
+            return Err(Error::InvalidAccountIndex(index.to_string()));
+        }
+        let response = exchange(INS_GET_PUBLIC_KEY, &path(index))?;
+        let public_key = response
+            .get(..32)
+            .ok_or(Error::UnexpectedResponse)?
+            .try_into()
+            .map_err(|_| Error::UnexpectedResponse)?;
+        Ok(Self { index, public_key })
+    }
+}
+
+impl super::Signer for LedgerSigner {
+    fn public_key(&self) -> [u8; 32] {
+        self.public_key
+    }
+
+    fn sign_hash(&self, hash: &[u8; 32]) -> Result<[u8; 64], super::Error> {
+        eprintln!("Confirm the transaction on the Ledger device");
+        let mut data = path(self.index);
+        data.extend_from_slice(hash);
+        let response = exchange(INS_SIGN_TX_HASH, &data)?;
+        Ok(response
+            .get(..64)
+            .ok_or(Error::UnexpectedResponse)?
+            .try_into()
+            .map_err(|_| Error::UnexpectedResponse)?)
+    }
+}
+
+fn path(index: u32) -> Vec<u8> {
+    let components = [STELLAR_PATH[0], STELLAR_PATH[1], index];
+    let mut path = vec![u8::try_from(components.len()).unwrap()];
+    for component in components {
+        path.extend_from_slice(&(component | HARDENED).to_be_bytes());
+    }
+    path
+}
+
+/// Send an APDU command to the Stellar app and return the response data
+fn exchange(ins: u8, data: &[u8]) -> Result<Vec<u8>, Error> {
+    let mut apdu = vec![CLA, ins, 0x00, 0x00];
+    apdu.push(u8::try_from(data.len()).map_err(|_| Error::UnexpectedResponse)?);
+    apdu.extend_from_slice(data);
+    let mut response = hid::exchange(&apdu)?;
+    if response.len() < 2 {
+        return Err(Error::UnexpectedResponse);
+    }
+    let status_bytes = response.split_off(response.len() - 2);
+    match u16::from_be_bytes([status_bytes[0], status_bytes[1]]) {
+        SW_OK => Ok(response),
+        SW_DENIED => Err(Error::Denied),
+        SW_HASH_SIGNING_DISABLED => Err(Error::HashSigningDisabled),
+        status => Err(Error::Status(status)),
+    }
+}
+
+#[cfg(target_os = "linux")]
+mod hid {
+    //! Ledger HID transport: APDUs are split in 64 byte packets prefixed by the channel,
+    //! a tag and the packet sequence number (the first packet also contains the APDU length).
+
+    use std::{
+        fs::{self, File, OpenOptions},
+        io::{Read, Write},
+        path::PathBuf,
+    };
+
+    use super::Error;
+
+    const VENDOR_ID: u32 = 0x2c97;
+    const CHANNEL: u16 = 0x0101;
+    const TAG_APDU: u8 = 0x05;
+    const PACKET_SIZE: usize = 64;
+
+    pub fn exchange(apdu: &[u8]) -> Result<Vec<u8>, Error> {
+        let mut device = OpenOptions::new()
+            .read(true)
+            .write(true)
+            .open(find_device()?)?;
+        write_apdu(&mut device, apdu)?;
+        read_apdu(&mut device)
+    }
+
+    /// Find the hidraw node of the first interface of a Ledger device
+    fn find_device() -> Result<PathBuf, Error> {
+        let entries = match fs::read_dir("/sys/class/hidraw") {
+            Ok(entries) => entries,
+            Err(_) => return Err(Error::DeviceNotFound),
+        };
+        let mut nodes: Vec<_> = entries.filter_map(Result::ok).collect();
+        nodes.sort_by_key(fs::DirEntry::file_name);
+        for node in nodes {
+            let uevent = match fs::read_to_string(node.path().join("device/uevent")) {
+                Ok(uevent) => uevent,
+                Err(_) => continue,
+            };
+            let mut is_ledger = false;
+            let mut is_first_interface = false;
+            for line in uevent.lines() {
+                // e.g. HID_ID=0003:00002C97:00004011
+                if let Some(id) = line.strip_prefix("HID_ID=") {
+                    is_ledger = id
+                        .split(':')
+                        .nth(1)
+                        .and_then(|vendor| u32::from_str_radix(vendor, 16).ok())
+                        == Some(VENDOR_ID);
+                }
+                // e.g. HID_PHYS=usb-0000:00:14.0-1/input0
+                if let Some(phys) = line.strip_prefix("HID_PHYS=") {
+                    is_first_interface = phys.ends_with("input0");
+                }
+            }
+            if is_ledger && is_first_interface {
+                return Ok(PathBuf::from("/dev").join(node.file_name()));
+            }
+        }
+        Err(Error::DeviceNotFound)
+    }
+
+    fn write_apdu(device: &mut File, apdu: &[u8]) -> Result<(), Error> {
+        let length = u16::try_from(apdu.len()).map_err(|_| Error::UnexpectedResponse)?;
+        let mut data = length.to_be_bytes().to_vec();
+        data.extend_from_slice(apdu);
+        for (sequence, chunk) in data.chunks(PACKET_SIZE - 5).enumerate() {
+            // hidraw expects the report number (always 0) before the packet
+            let mut packet = vec![0u8];
+            packet.extend_from_slice(&CHANNEL.to_be_bytes());
+            packet.push(TAG_APDU);
+            packet.extend_from_slice(&u16::try_from(sequence).unwrap_or(u16::MAX).to_be_bytes());
+            packet.extend_from_slice(chunk);
+            packet.resize(PACKET_SIZE + 1, 0);
+            device.write_all(&packet)?;
+        }
+        Ok(())
+    }
+
+    fn read_apdu(device: &mut File) -> Result<Vec<u8>, Error> {
+        let mut data = Vec::new();
+        let mut length = None;
+        let mut sequence: u16 = 0;
+        loop {
+            let mut packet = [0u8; PACKET_SIZE];
+            device.read_exact(&mut packet)?;
+            if packet[..2] != CHANNEL.to_be_bytes()
+                || packet[2] != TAG_APDU
+                || packet[3..5] != sequence.to_be_bytes()
+            {
+                return Err(Error::UnexpectedResponse);
+            }
+            let mut payload = &packet[5..];
+            if length.is_none() {
+                length = Some(usize::from(u16::from_be_bytes([payload[0], payload[1]])));
+                payload = &payload[2..];
+            }
+            data.extend_from_slice(payload);
+            let expected = length.unwrap_or_default();
+            if data.len() >= expected {
+                data.truncate(expected);
+                return Ok(data);
+            }
+            sequence = sequence.wrapping_add(1);
+        }
+    }
+}
+
+#[cfg(not(target_os = "linux"))]
+mod hid {
+    use super::Error;
+
+    pub fn exchange(_apdu: &[u8]) -> Result<Vec<u8>, Error> {
+        Err(Error::UnsupportedPlatform)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_path() {
+        assert_eq!(
+            path(1),
+            vec![3, 0x80, 0, 0, 44, 0x80, 0, 0, 148, 0x80, 0, 0, 1]
+        );
+    }
+}
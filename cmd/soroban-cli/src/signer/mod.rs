@@ -0,0 +1,59 @@
+//! Providers of the keys signing the transactions sent to the rpc server, so that secret keys
+//! can be kept in the OS keychain or in a hardware wallet instead of being passed in plaintext.
+
+use soroban_env_host::xdr::Error as XdrError;
+
+pub mod keychain;
+pub mod ledger;
+
+#[derive(thiserror::Error, Debug)]
+pub enum Error {
+    #[error("cannot parse secret key")]
+    CannotParseSecretKey,
+    #[error(transparent)]
+    Keychain(#[from] keychain::Error),
+    #[error(transparent)]
+    Ledger(#[from] ledger::Error),
+    #[error("xdr processing error: {0}")]
+    Xdr(#[from] XdrError),
+}
+
+/// Signer is implemented by the signing providers
+pub trait Signer {
+    /// ed25519 public key of the signing account
+    fn public_key(&self) -> [u8; 32];
+    /// Sign the hash of a transaction signature payload
+    fn sign_hash(&self, hash: &[u8; 32]) -> Result<[u8; 64], Error>;
+}
+
+impl Signer for ed25519_dalek::Keypair {
+    fn public_key(&self) -> [u8; 32] {
+        self.public.to_bytes()
+    }
+
+    fn sign_hash(&self, hash: &[u8; 32]) -> Result<[u8; 64], Error> {
+        Ok(ed25519_dalek::Signer::sign(self, hash).to_bytes())
+    }
+}
+
+/// Obtain the signer described by `s`, which is one of:
+///  * a secret 'S' key
+///  * `keychain:<name>`, a secret key stored in the OS keychain (see [`keychain`])
+///  * `ledger` or `ledger:<account index>`, an account of a Ledger hardware wallet running the Stellar app
+pub fn from_str(s: &str) -> Result<Box<dyn Signer>, Error> {
+    if let Some(name) = s.strip_prefix("keychain:") {
+        return Ok(Box::new(keychain::load(name)?));
+    }
+    if s == "ledger" {
+        return Ok(Box::new(ledger::LedgerSigner::new(0)?));
+    }
+    if let Some(index) = s.strip_prefix("ledger:") {
+        let index = index
+            .parse()
+            .map_err(|_| ledger::Error::InvalidAccountIndex(index.to_string()))?;
+        return Ok(Box::new(ledger::LedgerSigner::new(index)?));
+    }
+    crate::utils::parse_secret_key(s)
+        .map(|key| Box::new(key) as Box<dyn Signer>)
+        .map_err(|_| Error::CannotParseSecretKey)
+}
@@ -97,13 +97,6 @@ where
     I: IntoIterator<Item = (&'a Box<LedgerKey>, &'a Option<Box<LedgerEntry>>)>,
 {
     //Need to start off with the existing snapshot (new_state) since it's possible the storage_map did not touch every existing entry
-    if let Some(dir) = output_file.parent() {
-        if !dir.exists() {
-            create_dir_all(dir)?;
-        }
-    }
-
-    let file = File::create(output_file)?;
     for (lk, ole) in storage_map {
         if let Some(le) = ole {
             new_state.insert(*lk.clone(), *(*le).clone());
@@ -111,9 +104,24 @@ where
             new_state.remove(lk);
         }
     }
+    write(new_state, ledger_info, output_file)
+}
 
+// Write the ledger entries and ledger info to output_file, replacing its contents
+pub fn write(
+    state: OrdMap<LedgerKey, LedgerEntry>,
+    ledger_info: LedgerInfo,
+    output_file: &std::path::PathBuf,
+) -> Result<(), Error> {
+    if let Some(dir) = output_file.parent() {
+        if !dir.exists() {
+            create_dir_all(dir)?;
+        }
+    }
+
+    let file = File::create(output_file)?;
     let vec_new_state: VecM<(LedgerKey, LedgerEntry)> =
-        new_state.into_iter().collect::<Vec<_>>().try_into()?;
+        state.into_iter().collect::<Vec<_>>().try_into()?;
 
     let output = SerializableState {
         ledger_entries: vec_new_state,
@@ -20,6 +20,7 @@ use stellar_strkey::StrkeyPublicKeyEd25519;
 
 use crate::{
     rpc::{Client, Error as SorobanRpcError},
+    signer::{self, Signer},
     snapshot, utils, HEADING_RPC, HEADING_SANDBOX,
 };
 
@@ -35,8 +36,8 @@ pub enum Error {
         filepath: std::path::PathBuf,
         error: snapshot::Error,
     },
-    #[error("cannot parse secret key")]
-    CannotParseSecretKey,
+    #[error(transparent)]
+    Signer(#[from] signer::Error),
     #[error("cannot parse salt: {salt}")]
     CannotParseSalt { salt: String },
     #[error(transparent)]
@@ -97,7 +98,7 @@ pub struct Cmd {
         help_heading = HEADING_RPC,
     )]
     rpc_url: Option<String>,
-    /// Secret key to sign the transaction sent to the rpc server
+    /// Secret 'S' key used to sign the transaction sent to the rpc server, "keychain:<name>" for a key stored in the OS keychain or "ledger[:<account index>]" for a Ledger device
     #[clap(
         long = "secret-key",
         env = "SOROBAN_SECRET_KEY",
@@ -221,8 +222,7 @@ impl Cmd {
         decimal: u32,
     ) -> Result<String, Error> {
         let client = Client::new(self.rpc_url.as_ref().unwrap());
-        let key = utils::parse_secret_key(self.secret_key.as_ref().unwrap())
-            .map_err(|_| Error::CannotParseSecretKey)?;
+        let key = signer::from_str(self.secret_key.as_ref().unwrap())?;
         let salt_val = if salt == [0; 32] {
             rand::thread_rng().gen::<[u8; 32]>()
         } else {
@@ -230,12 +230,11 @@ impl Cmd {
         };
 
         let admin_key = AccountId(PublicKey::PublicKeyTypeEd25519(Uint256(
-            admin.unwrap_or_else(|| key.public.to_bytes()),
+            admin.unwrap_or_else(|| key.public_key()),
         )));
 
         // Get the account sequence number
-        let public_strkey =
-            stellar_strkey::StrkeyPublicKeyEd25519(key.public.to_bytes()).to_string();
+        let public_strkey = stellar_strkey::StrkeyPublicKeyEd25519(key.public_key()).to_string();
         // TODO: use symbols for the method names (both here and in serve)
         let account_details = client.get_account(&public_strkey).await?;
         // TODO: create a cmdline parameter for the fee instead of simply using the minimum fee
@@ -285,10 +284,10 @@ fn build_tx(
     sequence: i64,
     fee: u32,
     network_passphrase: &str,
-    key: &ed25519_dalek::Keypair,
+    key: &dyn Signer,
 ) -> Result<TransactionEnvelope, Error> {
     let tx = Transaction {
-        source_account: MuxedAccount::Ed25519(Uint256(key.public.to_bytes())),
+        source_account: MuxedAccount::Ed25519(Uint256(key.public_key())),
         fee,
         seq_num: SequenceNumber(sequence),
         cond: Preconditions::None,
@@ -19,6 +19,7 @@ use stellar_strkey::StrkeyPublicKeyEd25519;
 
 use crate::{
     rpc::{Client, Error as SorobanRpcError},
+    signer::{self, Signer},
     snapshot, utils, HEADING_RPC, HEADING_SANDBOX,
 };
 
@@ -28,8 +29,8 @@ pub enum Error {
     CannotParseAccountId { account_id: String },
     #[error("cannot parse asset: {asset}")]
     CannotParseAsset { asset: String },
-    #[error("cannot parse secret key")]
-    CannotParseSecretKey,
+    #[error(transparent)]
+    Signer(#[from] signer::Error),
     #[error("reading file {filepath}: {error}")]
     CannotReadLedgerFile {
         filepath: std::path::PathBuf,
@@ -73,7 +74,7 @@ pub struct Cmd {
     )]
     ledger_file: std::path::PathBuf,
 
-    /// Secret key to sign the transaction sent to the rpc server
+    /// Secret 'S' key used to sign the transaction sent to the rpc server, "keychain:<name>" for a key stored in the OS keychain or "ledger[:<account index>]" for a Ledger device
     #[clap(
         long = "secret-key",
         env = "SOROBAN_SECRET_KEY",
@@ -162,12 +163,10 @@ impl Cmd {
 
     async fn run_against_rpc_server(&self, asset: Asset) -> Result<String, Error> {
         let client = Client::new(self.rpc_url.as_ref().unwrap());
-        let key = utils::parse_secret_key(self.secret_key.as_ref().unwrap())
-            .map_err(|_| Error::CannotParseSecretKey)?;
+        let key = signer::from_str(self.secret_key.as_ref().unwrap())?;
 
         // Get the account sequence number
-        let public_strkey =
-            stellar_strkey::StrkeyPublicKeyEd25519(key.public.to_bytes()).to_string();
+        let public_strkey = stellar_strkey::StrkeyPublicKeyEd25519(key.public_key()).to_string();
         // TODO: use symbols for the method names (both here and in serve)
         let account_details = client.get_account(&public_strkey).await?;
         // TODO: create a cmdline parameter for the fee instead of simply using the minimum fee
@@ -201,7 +200,7 @@ fn build_wrap_token_tx(
     sequence: i64,
     fee: u32,
     network_passphrase: &str,
-    key: &ed25519_dalek::Keypair,
+    key: &dyn Signer,
 ) -> Result<TransactionEnvelope, Error> {
     let mut read_write = vec![
         ContractData(LedgerKeyContractData {
@@ -237,7 +236,7 @@ fn build_wrap_token_tx(
         }),
     };
     let tx = Transaction {
-        source_account: MuxedAccount::Ed25519(Uint256(key.public.to_bytes())),
+        source_account: MuxedAccount::Ed25519(Uint256(key.public_key())),
         fee,
         seq_num: SequenceNumber(sequence),
         cond: Preconditions::None,
@@ -0,0 +1,187 @@
+use std::fmt::Debug;
+
+use clap::Parser;
+use soroban_env_host::xdr::{
+    Error as XdrError, LedgerFootprint, MuxedAccount, OperationBody, Preconditions, ReadXdr,
+    Transaction, TransactionEnvelope, TransactionExt, TransactionV0Envelope, TransactionV1Envelope,
+    VecM, WriteXdr,
+};
+
+use crate::rpc::{self, Client, SimulateTransactionResponse};
+use crate::HEADING_RPC;
+
+#[derive(Parser, Debug)]
+pub struct Cmd {
+    /// Unsigned transaction envelope (base64 encoded XDR) invoking a host function
+    #[clap(long)]
+    xdr: String,
+
+    /// RPC server endpoint
+    #[clap(long, env = "SOROBAN_RPC_URL", help_heading = HEADING_RPC)]
+    rpc_url: String,
+}
+
+#[derive(thiserror::Error, Debug)]
+pub enum Error {
+    #[error("parsing transaction envelope: {0}")]
+    CannotParseEnvelope(XdrError),
+    #[error("fee bump transactions can't be assembled, assemble the inner transaction instead")]
+    FeeBumpTransaction,
+    #[error("transaction must contain a single invoke host function operation")]
+    NotAHostFunctionTransaction,
+    #[error("xdr processing error: {0}")]
+    Xdr(#[from] XdrError),
+    #[error(transparent)]
+    Rpc(#[from] rpc::Error),
+}
+
+impl Cmd {
+    pub async fn run(&self) -> Result<(), Error> {
+        let envelope =
+            TransactionEnvelope::from_xdr_base64(&self.xdr).map_err(Error::CannotParseEnvelope)?;
+        let tx = match envelope {
+            TransactionEnvelope::Tx(TransactionV1Envelope { tx, .. }) => tx,
+            TransactionEnvelope::TxV0(TransactionV0Envelope { tx, .. }) => Transaction {
+                source_account: MuxedAccount::Ed25519(tx.source_account_ed25519),
+                fee: tx.fee,
+                seq_num: tx.seq_num,
+                cond: tx
+                    .time_bounds
+                    .map_or(Preconditions::None, Preconditions::Time),
+                memo: tx.memo,
+                operations: tx.operations,
+                ext: TransactionExt::V0,
+            },
+            TransactionEnvelope::TxFeeBump(_) => return Err(Error::FeeBumpTransaction),
+        };
+
+        let client = Client::new(&self.rpc_url);
+        let assembled = simulate_and_assemble(&client, &tx).await?;
+        println!(
+            "{}",
+            TransactionEnvelope::Tx(TransactionV1Envelope {
+                tx: assembled,
+                signatures: VecM::default(),
+            })
+            .to_xdr_base64()?
+        );
+        Ok(())
+    }
+}
+
+/// Simulate the transaction against the RPC server and return it with the simulation results applied
+pub async fn simulate_and_assemble(
+    client: &Client,
+    tx: &Transaction,
+) -> Result<Transaction, Error> {
+    let unsigned = TransactionEnvelope::Tx(TransactionV1Envelope {
+        tx: tx.clone(),
+        signatures: VecM::default(),
+    });
+    let simulation = client.simulate_transaction(&unsigned).await?;
+    assemble(tx, &simulation)
+}
+
+/// Apply the results of simulating a transaction (i.e. the ledger footprint of its host function
+/// invocation) to it. The transaction must be signed again afterwards, since its hash changes.
+pub fn assemble(
+    tx: &Transaction,
+    simulation: &SimulateTransactionResponse,
+) -> Result<Transaction, Error> {
+    let footprint = LedgerFootprint::from_xdr_base64(&simulation.footprint)?;
+    let mut operations = tx.operations.to_vec();
+    match operations.as_mut_slice() {
+        [op] => match &mut op.body {
+            OperationBody::InvokeHostFunction(invoke) => invoke.footprint = footprint,
+            _ => return Err(Error::NotAHostFunctionTransaction),
+        },
+        _ => return Err(Error::NotAHostFunctionTransaction),
+    }
+    Ok(Transaction {
+        operations: operations.try_into()?,
+        ..tx.clone()
+    })
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::rpc::Cost;
+    use soroban_env_host::xdr::{
+        AccountId, Asset, HostFunction, InvokeHostFunctionOp, LedgerKey, LedgerKeyAccount, Memo,
+        Operation, PaymentOp, PublicKey, ScVec, SequenceNumber, Uint256,
+    };
+
+    fn transaction(body: OperationBody) -> Transaction {
+        Transaction {
+            source_account: MuxedAccount::Ed25519(Uint256([0; 32])),
+            fee: 100,
+            seq_num: SequenceNumber(1),
+            cond: Preconditions::None,
+            memo: Memo::None,
+            operations: vec![Operation {
+                source_account: None,
+                body,
+            }]
+            .try_into()
+            .unwrap(),
+            ext: TransactionExt::V0,
+        }
+    }
+
+    fn simulation(footprint: &LedgerFootprint) -> SimulateTransactionResponse {
+        SimulateTransactionResponse {
+            footprint: footprint.to_xdr_base64().unwrap(),
+            cost: Cost {
+                cpu_insns: "10".to_string(),
+                mem_bytes: "20".to_string(),
+            },
+            error: None,
+        }
+    }
+
+    #[test]
+    fn test_assemble() {
+        let footprint = LedgerFootprint {
+            read_only: vec![LedgerKey::Account(LedgerKeyAccount {
+                account_id: AccountId(PublicKey::PublicKeyTypeEd25519(Uint256([1; 32]))),
+            })]
+            .try_into()
+            .unwrap(),
+            read_write: VecM::default(),
+        };
+        let tx = transaction(OperationBody::InvokeHostFunction(InvokeHostFunctionOp {
+            function: HostFunction::InvokeContract,
+            parameters: ScVec::default(),
+            footprint: LedgerFootprint {
+                read_only: VecM::default(),
+                read_write: VecM::default(),
+            },
+        }));
+
+        let assembled = assemble(&tx, &simulation(&footprint)).unwrap();
+        match &assembled.operations[0].body {
+            OperationBody::InvokeHostFunction(invoke) => assert_eq!(invoke.footprint, footprint),
+            body => panic!("unexpected operation {body:?}"),
+        }
+        assert_eq!(assembled.seq_num, tx.seq_num);
+        assert_eq!(assembled.fee, tx.fee);
+    }
+
+    #[test]
+    fn test_assemble_requires_host_function() {
+        let tx = transaction(OperationBody::Payment(PaymentOp {
+            destination: MuxedAccount::Ed25519(Uint256([1; 32])),
+            asset: Asset::Native,
+            amount: 10,
+        }));
+        let footprint = LedgerFootprint {
+            read_only: VecM::default(),
+            read_write: VecM::default(),
+        };
+        assert!(matches!(
+            assemble(&tx, &simulation(&footprint)),
+            Err(Error::NotAHostFunctionTransaction)
+        ));
+    }
+}
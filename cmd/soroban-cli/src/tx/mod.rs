@@ -0,0 +1,93 @@
+use std::fmt::Debug;
+
+use clap::{Parser, Subcommand};
+use soroban_env_host::xdr::{
+    Error as XdrError, MuxedAccount, Preconditions, ReadXdr, Transaction, TransactionEnvelope,
+    TransactionExt, TransactionV0Envelope, TransactionV1Envelope,
+};
+
+pub mod assemble;
+pub mod new;
+pub mod op;
+pub mod send;
+pub mod sign;
+
+#[derive(Parser, Debug)]
+pub struct Root {
+    #[clap(subcommand)]
+    cmd: Cmd,
+}
+
+#[derive(Subcommand, Debug)]
+enum Cmd {
+    /// Simulate an unsigned transaction and apply the simulation results to it, outputting a
+    /// transaction envelope ready to be signed
+    Assemble(assemble::Cmd),
+    /// Create a transaction without operations, outputting its unsigned envelope
+    New(new::Cmd),
+    /// Add operations to a transaction
+    Op(op::Root),
+    /// Sign a transaction with one or more keys, adding to its existing signatures
+    Sign(sign::Cmd),
+    /// Submit a signed transaction to the rpc server and wait for its results
+    Send(send::Cmd),
+}
+
+#[derive(thiserror::Error, Debug)]
+pub enum Error {
+    #[error(transparent)]
+    Assemble(#[from] assemble::Error),
+    #[error(transparent)]
+    New(#[from] new::Error),
+    #[error(transparent)]
+    Op(#[from] op::Error),
+    #[error(transparent)]
+    Sign(#[from] sign::Error),
+    #[error(transparent)]
+    Send(#[from] send::Error),
+}
+
+impl Root {
+    pub async fn run(&self) -> Result<(), Error> {
+        match &self.cmd {
+            Cmd::Assemble(assemble) => assemble.run().await?,
+            Cmd::New(new) => new.run().await?,
+            Cmd::Op(op) => op.run()?,
+            Cmd::Sign(sign) => sign.run()?,
+            Cmd::Send(send) => send.run().await?,
+        }
+        Ok(())
+    }
+}
+
+#[derive(thiserror::Error, Debug)]
+pub enum EnvelopeError {
+    #[error("parsing transaction envelope: {0}")]
+    CannotParse(XdrError),
+    #[error("fee bump transactions aren't supported, use the inner transaction instead")]
+    FeeBumpTransaction,
+}
+
+/// Parse a base64 encoded transaction envelope, converting v0 envelopes (and their signatures) to v1
+pub fn parse_envelope(xdr: &str) -> Result<TransactionV1Envelope, EnvelopeError> {
+    match TransactionEnvelope::from_xdr_base64(xdr).map_err(EnvelopeError::CannotParse)? {
+        TransactionEnvelope::Tx(envelope) => Ok(envelope),
+        TransactionEnvelope::TxV0(TransactionV0Envelope { tx, signatures }) => {
+            Ok(TransactionV1Envelope {
+                tx: Transaction {
+                    source_account: MuxedAccount::Ed25519(tx.source_account_ed25519),
+                    fee: tx.fee,
+                    seq_num: tx.seq_num,
+                    cond: tx
+                        .time_bounds
+                        .map_or(Preconditions::None, Preconditions::Time),
+                    memo: tx.memo,
+                    operations: tx.operations,
+                    ext: TransactionExt::V0,
+                },
+                signatures,
+            })
+        }
+        TransactionEnvelope::TxFeeBump(_) => Err(EnvelopeError::FeeBumpTransaction),
+    }
+}
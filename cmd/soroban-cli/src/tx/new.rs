@@ -0,0 +1,76 @@
+use std::fmt::Debug;
+use std::num::ParseIntError;
+
+use clap::Parser;
+use soroban_env_host::xdr::{
+    Error as XdrError, Memo, MuxedAccount, Preconditions, SequenceNumber, Transaction,
+    TransactionEnvelope, TransactionExt, TransactionV1Envelope, Uint256, VecM, WriteXdr,
+};
+use stellar_strkey::StrkeyPublicKeyEd25519;
+
+use crate::rpc::{self, Client};
+use crate::HEADING_RPC;
+
+#[derive(Parser, Debug)]
+pub struct Cmd {
+    /// Account ID of the source account of the transaction
+    #[clap(long)]
+    source: StrkeyPublicKeyEd25519,
+    /// Sequence number of the transaction, by default the next sequence number of the source
+    /// account is obtained from the rpc server
+    #[clap(long, conflicts_with = "rpc-url")]
+    sequence: Option<i64>,
+
+    /// RPC server endpoint, to obtain the sequence number of the source account
+    #[clap(
+        long,
+        required_unless_present = "sequence",
+        env = "SOROBAN_RPC_URL",
+        help_heading = HEADING_RPC,
+    )]
+    rpc_url: Option<String>,
+}
+
+#[derive(thiserror::Error, Debug)]
+pub enum Error {
+    #[error("xdr processing error: {0}")]
+    Xdr(#[from] XdrError),
+    #[error("error parsing int: {0}")]
+    ParseIntError(#[from] ParseIntError),
+    #[error(transparent)]
+    Rpc(#[from] rpc::Error),
+}
+
+impl Cmd {
+    pub async fn run(&self) -> Result<(), Error> {
+        let sequence = match (self.sequence, &self.rpc_url) {
+            (Some(sequence), _) => sequence,
+            (None, Some(rpc_url)) => {
+                let account = Client::new(rpc_url)
+                    .get_account(&self.source.to_string())
+                    .await?;
+                account.sequence.parse::<i64>()? + 1
+            }
+            (None, None) => unreachable!("clap requires --rpc-url without --sequence"),
+        };
+        let envelope = TransactionEnvelope::Tx(TransactionV1Envelope {
+            tx: new_transaction(self.source.0, sequence),
+            signatures: VecM::default(),
+        });
+        println!("{}", envelope.to_xdr_base64()?);
+        Ok(())
+    }
+}
+
+/// Build a transaction without operations, its fee is raised as operations are added to it
+pub fn new_transaction(source: [u8; 32], sequence: i64) -> Transaction {
+    Transaction {
+        source_account: MuxedAccount::Ed25519(Uint256(source)),
+        fee: 0,
+        seq_num: SequenceNumber(sequence),
+        cond: Preconditions::None,
+        memo: Memo::None,
+        operations: VecM::default(),
+        ext: TransactionExt::V0,
+    }
+}
@@ -0,0 +1,260 @@
+use std::fmt::Debug;
+
+use clap::{Parser, Subcommand};
+use hex::FromHexError;
+use soroban_env_host::xdr::{
+    AccountId, Asset, CreateAccountOp, Error as XdrError, HostFunction, InvokeHostFunctionOp,
+    LedgerFootprint, MuxedAccount, Operation, OperationBody, PaymentOp, PublicKey, ReadXdr,
+    ScObject, ScVal, ScVec, TransactionEnvelope, Uint256, VecM, WriteXdr,
+};
+use stellar_strkey::StrkeyPublicKeyEd25519;
+
+use super::EnvelopeError;
+use crate::utils;
+
+#[derive(Parser, Debug)]
+pub struct Root {
+    #[clap(subcommand)]
+    cmd: Cmd,
+}
+
+#[derive(Subcommand, Debug)]
+enum Cmd {
+    /// Append an operation to a transaction, outputting its unsigned envelope. The existing
+    /// signatures are dropped, since the hash of the transaction changes.
+    Add(AddCmd),
+}
+
+#[derive(Parser, Debug)]
+pub struct AddCmd {
+    /// Transaction envelope (base64 encoded XDR) to add the operation to
+    #[clap(long)]
+    xdr: String,
+    /// Account ID of the source account of the operation, by default the source account of the
+    /// transaction
+    #[clap(long)]
+    source: Option<StrkeyPublicKeyEd25519>,
+    /// Fee (in stroops) added to the transaction fee for the operation
+    #[clap(long, default_value = "100")]
+    fee: u32,
+    #[clap(subcommand)]
+    op: Op,
+}
+
+#[derive(Subcommand, Debug)]
+enum Op {
+    /// Send a payment in lumens
+    Payment {
+        /// Account ID of the recipient
+        #[clap(long)]
+        destination: StrkeyPublicKeyEd25519,
+        /// Amount of stroops to send
+        #[clap(long)]
+        amount: i64,
+    },
+    /// Create and fund an account
+    CreateAccount {
+        /// Account ID of the account to create
+        #[clap(long)]
+        destination: StrkeyPublicKeyEd25519,
+        /// Amount of stroops to fund the account with
+        #[clap(long)]
+        starting_balance: i64,
+    },
+    /// Invoke a contract function. Since the contract spec isn't available, the arguments are
+    /// given as xdr, and the transaction must be assembled (see `soroban tx assemble`) before
+    /// being signed.
+    Invoke {
+        /// Contract ID to invoke
+        #[clap(long = "id")]
+        contract_id: String,
+        /// Function name to execute
+        #[clap(long = "fn")]
+        function: String,
+        /// Argument to pass to the function (base64-encoded xdr)
+        #[clap(long = "arg-xdr", value_name = "arg-xdr", multiple = true)]
+        args_xdr: Vec<String>,
+    },
+}
+
+#[derive(thiserror::Error, Debug)]
+pub enum Error {
+    #[error(transparent)]
+    Envelope(#[from] EnvelopeError),
+    #[error("cannot parse contract ID {contract_id}: {error}")]
+    CannotParseContractId {
+        contract_id: String,
+        error: FromHexError,
+    },
+    #[error("parsing XDR arg {arg}: {error}")]
+    CannotParseXdrArg { arg: String, error: XdrError },
+    #[error("function name {0} is too long")]
+    FunctionNameTooLong(String),
+    #[error("the transaction fee overflows")]
+    FeeOverflow,
+    #[error("xdr processing error: {0}")]
+    Xdr(#[from] XdrError),
+}
+
+impl Root {
+    pub fn run(&self) -> Result<(), Error> {
+        match &self.cmd {
+            Cmd::Add(add) => add.run(),
+        }
+    }
+}
+
+impl AddCmd {
+    pub fn run(&self) -> Result<(), Error> {
+        let mut envelope = super::parse_envelope(&self.xdr)?;
+        let op = Operation {
+            source_account: self
+                .source
+                .as_ref()
+                .map(|source| MuxedAccount::Ed25519(Uint256(source.0))),
+            body: self.op.body()?,
+        };
+        let mut operations = envelope.tx.operations.to_vec();
+        operations.push(op);
+        envelope.tx.operations = operations.try_into()?;
+        envelope.tx.fee = envelope
+            .tx
+            .fee
+            .checked_add(self.fee)
+            .ok_or(Error::FeeOverflow)?;
+        envelope.signatures = VecM::default();
+        println!("{}", TransactionEnvelope::Tx(envelope).to_xdr_base64()?);
+        Ok(())
+    }
+}
+
+impl Op {
+    fn body(&self) -> Result<OperationBody, Error> {
+        Ok(match self {
+            Op::Payment {
+                destination,
+                amount,
+            } => OperationBody::Payment(PaymentOp {
+                destination: MuxedAccount::Ed25519(Uint256(destination.0)),
+                asset: Asset::Native,
+                amount: *amount,
+            }),
+            Op::CreateAccount {
+                destination,
+                starting_balance,
+            } => OperationBody::CreateAccount(CreateAccountOp {
+                destination: AccountId(PublicKey::PublicKeyTypeEd25519(Uint256(destination.0))),
+                starting_balance: *starting_balance,
+            }),
+            Op::Invoke {
+                contract_id,
+                function,
+                args_xdr,
+            } => OperationBody::InvokeHostFunction(InvokeHostFunctionOp {
+                function: HostFunction::InvokeContract,
+                parameters: invoke_parameters(contract_id, function, args_xdr)?,
+                footprint: LedgerFootprint {
+                    read_only: VecM::default(),
+                    read_write: VecM::default(),
+                },
+            }),
+        })
+    }
+}
+
+/// Build the host function parameters of a contract invocation: the contract ID, the function
+/// name and the arguments
+fn invoke_parameters(
+    contract_id: &String,
+    function: &str,
+    args_xdr: &[String],
+) -> Result<ScVec, Error> {
+    let contract_id =
+        utils::contract_id_from_str(contract_id).map_err(|e| Error::CannotParseContractId {
+            contract_id: contract_id.clone(),
+            error: e,
+        })?;
+    let mut parameters = vec![
+        ScVal::Object(Some(ScObject::Bytes(contract_id.try_into()?))),
+        ScVal::Symbol(
+            (&function.to_string())
+                .try_into()
+                .map_err(|_| Error::FunctionNameTooLong(function.to_string()))?,
+        ),
+    ];
+    for arg in args_xdr {
+        parameters.push(
+            ScVal::from_xdr_base64(arg).map_err(|e| Error::CannotParseXdrArg {
+                arg: arg.clone(),
+                error: e,
+            })?,
+        );
+    }
+    Ok(parameters.try_into()?)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::tx::new::new_transaction;
+    use soroban_env_host::xdr::TransactionV1Envelope;
+
+    fn add(xdr: &str, op: Op) -> TransactionV1Envelope {
+        let mut envelope = crate::tx::parse_envelope(xdr).unwrap();
+        let mut operations = envelope.tx.operations.to_vec();
+        operations.push(Operation {
+            source_account: None,
+            body: op.body().unwrap(),
+        });
+        envelope.tx.operations = operations.try_into().unwrap();
+        envelope
+    }
+
+    #[test]
+    fn test_compose_operations() {
+        let xdr = TransactionEnvelope::Tx(TransactionV1Envelope {
+            tx: new_transaction([0; 32], 1),
+            signatures: VecM::default(),
+        })
+        .to_xdr_base64()
+        .unwrap();
+        let envelope = add(
+            &xdr,
+            Op::Payment {
+                destination: StrkeyPublicKeyEd25519([1; 32]),
+                amount: 10,
+            },
+        );
+        let xdr = TransactionEnvelope::Tx(envelope).to_xdr_base64().unwrap();
+        let envelope = add(
+            &xdr,
+            Op::Invoke {
+                contract_id: "1".to_string(),
+                function: "hello".to_string(),
+                args_xdr: vec![ScVal::U32(7).to_xdr_base64().unwrap()],
+            },
+        );
+
+        let operations = envelope.tx.operations.to_vec();
+        assert_eq!(operations.len(), 2);
+        assert!(matches!(
+            &operations[0].body,
+            OperationBody::Payment(PaymentOp { amount: 10, .. })
+        ));
+        match &operations[1].body {
+            OperationBody::InvokeHostFunction(invoke) => {
+                assert_eq!(invoke.parameters.len(), 3);
+                assert_eq!(invoke.parameters[2], ScVal::U32(7));
+            }
+            body => panic!("unexpected operation {body:?}"),
+        }
+    }
+
+    #[test]
+    fn test_invoke_parameters_validate_contract_id() {
+        assert!(matches!(
+            invoke_parameters(&"not hex".to_string(), "hello", &[]),
+            Err(Error::CannotParseContractId { .. })
+        ));
+    }
+}
@@ -0,0 +1,51 @@
+use std::fmt::Debug;
+
+use clap::Parser;
+use soroban_env_host::xdr::{Error as XdrError, ReadXdr, TransactionEnvelope};
+
+use crate::rpc::{self, Client};
+use crate::HEADING_RPC;
+
+#[derive(Parser, Debug)]
+pub struct Cmd {
+    /// Signed transaction envelope (base64 encoded XDR) to submit
+    #[clap(long)]
+    xdr: String,
+
+    /// RPC server endpoint
+    #[clap(long, env = "SOROBAN_RPC_URL", help_heading = HEADING_RPC)]
+    rpc_url: String,
+}
+
+#[derive(thiserror::Error, Debug)]
+pub enum Error {
+    #[error("parsing transaction envelope: {0}")]
+    CannotParseEnvelope(XdrError),
+    #[error("the transaction isn't signed, sign it with `soroban tx sign`")]
+    Unsigned,
+    #[error(transparent)]
+    Rpc(#[from] rpc::Error),
+}
+
+impl Cmd {
+    pub async fn run(&self) -> Result<(), Error> {
+        let envelope =
+            TransactionEnvelope::from_xdr_base64(&self.xdr).map_err(Error::CannotParseEnvelope)?;
+        let signed = match &envelope {
+            TransactionEnvelope::Tx(e) => !e.signatures.is_empty(),
+            TransactionEnvelope::TxV0(e) => !e.signatures.is_empty(),
+            TransactionEnvelope::TxFeeBump(e) => !e.signatures.is_empty(),
+        };
+        if !signed {
+            return Err(Error::Unsigned);
+        }
+        let results = Client::new(&self.rpc_url)
+            .send_transaction(&envelope)
+            .await?;
+        // One result (base64 encoded XDR) per host function invocation of the transaction
+        for result in results {
+            println!("{}", result.xdr);
+        }
+        Ok(())
+    }
+}
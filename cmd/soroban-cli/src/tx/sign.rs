@@ -0,0 +1,100 @@
+use std::fmt::Debug;
+
+use clap::Parser;
+use soroban_env_host::xdr::{
+    Error as XdrError, TransactionEnvelope, TransactionV1Envelope, WriteXdr,
+};
+
+use super::EnvelopeError;
+use crate::signer;
+use crate::utils;
+use crate::HEADING_RPC;
+
+#[derive(Parser, Debug)]
+pub struct Cmd {
+    /// Transaction envelope (base64 encoded XDR) to sign
+    #[clap(long)]
+    xdr: String,
+    /// Secret 'S' key signing the transaction, "keychain:<name>" for a key stored in the OS keychain or "ledger[:<account index>]" for a Ledger device. Can be repeated to sign with multiple keys.
+    #[clap(long = "secret-key", required = true, multiple_occurrences = true)]
+    secret_keys: Vec<String>,
+    /// Network passphrase to sign the transaction with
+    #[clap(
+        long = "network-passphrase",
+        env = "SOROBAN_NETWORK_PASSPHRASE",
+        help_heading = HEADING_RPC,
+    )]
+    network_passphrase: String,
+}
+
+#[derive(thiserror::Error, Debug)]
+pub enum Error {
+    #[error(transparent)]
+    Envelope(#[from] EnvelopeError),
+    #[error(transparent)]
+    Signer(#[from] signer::Error),
+    #[error("xdr processing error: {0}")]
+    Xdr(#[from] XdrError),
+}
+
+impl Cmd {
+    pub fn run(&self) -> Result<(), Error> {
+        let mut envelope = super::parse_envelope(&self.xdr)?;
+        for secret_key in &self.secret_keys {
+            let key = signer::from_str(secret_key)?;
+            envelope = add_signature(envelope, key.as_ref(), &self.network_passphrase)?;
+        }
+        println!("{}", TransactionEnvelope::Tx(envelope).to_xdr_base64()?);
+        Ok(())
+    }
+}
+
+/// Sign the transaction of an envelope, keeping its existing signatures
+pub fn add_signature(
+    envelope: TransactionV1Envelope,
+    key: &dyn signer::Signer,
+    network_passphrase: &str,
+) -> Result<TransactionV1Envelope, signer::Error> {
+    let signed = match utils::sign_transaction(key, &envelope.tx, network_passphrase)? {
+        TransactionEnvelope::Tx(signed) => signed,
+        _ => unreachable!("sign_transaction returns v1 envelopes"),
+    };
+    let mut signatures = envelope.signatures.to_vec();
+    signatures.extend(signed.signatures.to_vec());
+    Ok(TransactionV1Envelope {
+        tx: envelope.tx,
+        signatures: signatures.try_into()?,
+    })
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::tx::new::new_transaction;
+    use soroban_env_host::xdr::VecM;
+
+    fn keypair(seed: u8) -> ed25519_dalek::Keypair {
+        let secret = ed25519_dalek::SecretKey::from_bytes(&[seed; 32]).unwrap();
+        let public = (&secret).into();
+        ed25519_dalek::Keypair { secret, public }
+    }
+
+    #[test]
+    fn test_add_signatures() {
+        let first = keypair(1);
+        let second = keypair(2);
+        let envelope = TransactionV1Envelope {
+            tx: new_transaction(first.public.to_bytes(), 1),
+            signatures: VecM::default(),
+        };
+
+        let envelope = add_signature(envelope, &first, "passphrase").unwrap();
+        let envelope = add_signature(envelope, &second, "passphrase").unwrap();
+        assert_eq!(envelope.signatures.len(), 2);
+        assert_eq!(envelope.signatures[0].hint.0, first.public.to_bytes()[28..]);
+        assert_eq!(
+            envelope.signatures[1].hint.0,
+            second.public.to_bytes()[28..]
+        );
+    }
+}
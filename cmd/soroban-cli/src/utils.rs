@@ -1,11 +1,11 @@
-use ed25519_dalek::Signer;
 use hex::FromHexError;
 use sha2::{Digest, Sha256};
 use soroban_env_host::storage::{AccessType, Footprint};
 use soroban_env_host::xdr::{
-    AccountEntry, AccountEntryExt, AccountId, DecoratedSignature, LedgerFootprint, ScSpecEntry,
-    SequenceNumber, Signature, SignatureHint, StringM, Thresholds, TransactionEnvelope,
-    TransactionV1Envelope, VecM,
+    AccountEntry, AccountEntryExt, AccountId, DecoratedSignature, FeeBumpTransaction,
+    FeeBumpTransactionEnvelope, FeeBumpTransactionExt, FeeBumpTransactionInnerTx, LedgerFootprint,
+    MuxedAccount, ScSpecEntry, SequenceNumber, Signature, SignatureHint, StringM, Thresholds,
+    TransactionEnvelope, TransactionV1Envelope, Uint256, VecM,
 };
 use soroban_env_host::{
     im_rc::OrdMap,
@@ -19,6 +19,8 @@ use soroban_env_host::{
 use soroban_spec::read::FromWasmError;
 use stellar_strkey::StrkeyPrivateKeyEd25519;
 
+use crate::signer::{self, Signer};
+
 pub fn add_contract_to_ledger_entries(
     entries: &mut OrdMap<LedgerKey, LedgerEntry>,
     contract_id: [u8; 32],
@@ -63,16 +65,16 @@ pub fn transaction_hash(tx: &Transaction, network_passphrase: &str) -> Result<[u
 }
 
 pub fn sign_transaction(
-    key: &ed25519_dalek::Keypair,
+    key: &dyn Signer,
     tx: &Transaction,
     network_passphrase: &str,
-) -> Result<TransactionEnvelope, XdrError> {
+) -> Result<TransactionEnvelope, signer::Error> {
     let tx_hash = transaction_hash(tx, network_passphrase)?;
-    let tx_signature = key.sign(&tx_hash);
+    let tx_signature = key.sign_hash(&tx_hash)?;
 
     let decorated_signature = DecoratedSignature {
-        hint: SignatureHint(key.public.to_bytes()[28..].try_into()?),
-        signature: Signature(tx_signature.to_bytes().try_into()?),
+        hint: signature_hint(&key.public_key()),
+        signature: Signature(tx_signature.try_into()?),
     };
 
     Ok(TransactionEnvelope::Tx(TransactionV1Envelope {
@@ -81,6 +83,52 @@ pub fn sign_transaction(
     }))
 }
 
+/// The hint of a signature is the last 4 bytes of the public key
+fn signature_hint(public_key: &[u8; 32]) -> SignatureHint {
+    let mut hint = [0u8; 4];
+    hint.copy_from_slice(&public_key[28..]);
+    SignatureHint(hint)
+}
+
+pub fn fee_bump_transaction_hash(
+    tx: &FeeBumpTransaction,
+    network_passphrase: &str,
+) -> Result<[u8; 32], XdrError> {
+    let signature_payload = TransactionSignaturePayload {
+        network_id: Hash(Sha256::digest(network_passphrase).into()),
+        tagged_transaction: TransactionSignaturePayloadTaggedTransaction::TxFeeBump(tx.clone()),
+    };
+    Ok(Sha256::digest(signature_payload.to_xdr()?).into())
+}
+
+/// Wraps a signed transaction in a fee bump transaction paying `fee` (in stroops)
+/// from the account of `key`, which signs it
+pub fn fee_bump_transaction(
+    key: &dyn Signer,
+    inner: TransactionV1Envelope,
+    fee: i64,
+    network_passphrase: &str,
+) -> Result<TransactionEnvelope, signer::Error> {
+    let tx = FeeBumpTransaction {
+        fee_source: MuxedAccount::Ed25519(Uint256(key.public_key())),
+        fee,
+        inner_tx: FeeBumpTransactionInnerTx::Tx(inner),
+        ext: FeeBumpTransactionExt::V0,
+    };
+    let tx_hash = fee_bump_transaction_hash(&tx, network_passphrase)?;
+    let tx_signature = key.sign_hash(&tx_hash)?;
+
+    let decorated_signature = DecoratedSignature {
+        hint: signature_hint(&key.public_key()),
+        signature: Signature(tx_signature.try_into()?),
+    };
+
+    Ok(TransactionEnvelope::TxFeeBump(FeeBumpTransactionEnvelope {
+        tx,
+        signatures: vec![decorated_signature].try_into()?,
+    }))
+}
+
 pub fn contract_id_from_str(contract_id: &String) -> Result<[u8; 32], FromHexError> {
     padded_hex_from_str(contract_id, 32)?
         .try_into()
@@ -1,4 +1,5 @@
 mod decode;
+mod encode;
 
 use std::fmt::Debug;
 
@@ -14,12 +15,16 @@ pub struct Cmd {
 enum SubCmd {
     /// Decode XDR
     Dec(decode::Cmd),
+    /// Encode XDR from its JSON representation
+    Enc(encode::Cmd),
 }
 
 #[derive(thiserror::Error, Debug)]
 pub enum Error {
     #[error("decode: {0}")]
     Decode(#[from] decode::Error),
+    #[error("encode: {0}")]
+    Encode(#[from] encode::Error),
 }
 
 impl Cmd {
@@ -27,6 +32,7 @@ impl Cmd {
     pub fn run(&self) -> Result<(), Error> {
         match &self.sub {
             SubCmd::Dec(d) => d.run()?,
+            SubCmd::Enc(e) => e.run()?,
         };
         Ok(())
     }
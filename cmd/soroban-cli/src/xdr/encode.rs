@@ -0,0 +1,92 @@
+use std::io::{self, Read};
+
+use clap::Parser;
+use soroban_env_host::xdr::{self, WriteXdr};
+
+#[derive(Parser, Debug)]
+pub struct Cmd {
+    /// XDR type to encode from
+    #[clap(long, possible_values(xdr::TypeVariant::VARIANTS_STR))]
+    r#type: xdr::TypeVariant,
+    /// JSON representation to encode (as output by `dec --output json`), read from stdin when omitted
+    #[clap(long)]
+    json: Option<String>,
+}
+
+#[derive(thiserror::Error, Debug)]
+pub enum Error {
+    #[error("reading json: {0}")]
+    Io(#[from] io::Error),
+    #[error("parsing json: {0}")]
+    Json(#[from] serde_json::Error),
+    #[error("generating xdr: {0}")]
+    Xdr(#[from] xdr::Error),
+    #[error("encoding {0:?} from json is not supported")]
+    UnsupportedType(xdr::TypeVariant),
+}
+
+impl Cmd {
+    pub fn run(&self) -> Result<(), Error> {
+        let json = match &self.json {
+            Some(json) => json.clone(),
+            None => {
+                let mut json = String::new();
+                io::stdin().read_to_string(&mut json)?;
+                json
+            }
+        };
+        println!("{}", encode(self.r#type, &json)?);
+        Ok(())
+    }
+}
+
+macro_rules! encode_types {
+    ($variant:expr, $json:expr, $($t:ident),*) => {
+        match $variant {
+            $(xdr::TypeVariant::$t => serde_json::from_str::<xdr::$t>($json)?.to_xdr_base64()?,)*
+            variant => return Err(Error::UnsupportedType(variant)),
+        }
+    };
+}
+
+/// Encode the JSON representation of a value of the given type to base64 XDR
+fn encode(variant: xdr::TypeVariant, json: &str) -> Result<String, Error> {
+    Ok(encode_types!(
+        variant,
+        json,
+        TransactionEnvelope,
+        TransactionResult,
+        TransactionMeta,
+        LedgerKey,
+        LedgerEntry,
+        LedgerFootprint,
+        ScVal,
+        ScObject
+    ))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_encode_roundtrip() {
+        let value = xdr::ScVal::Object(Some(xdr::ScObject::Vec(
+            vec![
+                xdr::ScVal::U32(7),
+                xdr::ScVal::Symbol("foo".try_into().unwrap()),
+            ]
+            .try_into()
+            .unwrap(),
+        )));
+        let json = serde_json::to_string(&value).unwrap();
+        assert_eq!(
+            encode(xdr::TypeVariant::ScVal, &json).unwrap(),
+            value.to_xdr_base64().unwrap()
+        );
+        assert!(matches!(
+            encode(xdr::TypeVariant::Uint32, "1"),
+            Err(Error::UnsupportedType(xdr::TypeVariant::Uint32))
+        ));
+    }
+}
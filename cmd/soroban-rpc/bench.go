@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/bench"
+)
+
+func newBenchCommand() *cobra.Command {
+	var cfg bench.Config
+	var mix, paramsFile string
+	cmd := &cobra.Command{
+		Use:   "bench",
+		Short: "Generate synthetic load against a soroban-rpc endpoint and report the latency of every method",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			var err error
+			if cfg.Mix, err = bench.ParseMix(mix); err != nil {
+				return fmt.Errorf("could not parse the method mix: %v", err)
+			}
+			cfg.Params = map[string]json.RawMessage{}
+			if paramsFile != "" {
+				if cfg.Params, err = bench.LoadParams(paramsFile); err != nil {
+					return fmt.Errorf("could not load the method params: %v", err)
+				}
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+			report, err := bench.Run(ctx, cfg)
+			if err != nil {
+				return err
+			}
+			return report.Print(os.Stdout)
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&cfg.Endpoint, "endpoint", "http://localhost:8000", "JSON RPC endpoint to benchmark")
+	flags.IntVar(&cfg.Concurrency, "concurrency", 10, "number of requests in flight")
+	flags.DurationVar(&cfg.Duration, "duration", 30*time.Second, "how long the load is generated for")
+	flags.Float64Var(&cfg.Rate, "rate", 0, "maximum number of requests per second (0 is unlimited)")
+	flags.StringVar(&mix, "mix", "getHealth=1,getLatestLedger=1", "comma separated list of method=weight pairs describing the generated requests (e.g. simulateTransaction=5,getLedgerEntries=3)")
+	flags.StringVar(&paramsFile, "params-file", "", "JSON file mapping method names to the params they are called with (e.g. {\"getLedgerEntries\": {\"keys\": [\"...\"]}})")
+	return cmd
+}
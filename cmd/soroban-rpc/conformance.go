@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/conformance"
+)
+
+func newConformanceCommand() *cobra.Command {
+	var cfg conformance.Config
+	var checks string
+	var jsonOutput, list bool
+	cmd := &cobra.Command{
+		Use:   "conformance",
+		Short: "Check that a JSON RPC endpoint implements the soroban-rpc protocol and report the results",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if list {
+				for _, check := range conformance.Checks {
+					fmt.Printf("%s: %s\n", check.Name, check.Description)
+				}
+				return nil
+			}
+			for _, check := range strings.Split(checks, ",") {
+				if check = strings.TrimSpace(check); check != "" {
+					cfg.Checks = append(cfg.Checks, check)
+				}
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+			report, err := conformance.Run(ctx, cfg)
+			if err != nil {
+				return err
+			}
+			if jsonOutput {
+				err = report.PrintJSON(os.Stdout)
+			} else {
+				err = report.Print(os.Stdout)
+			}
+			if err != nil {
+				return err
+			}
+			if !report.Passed() {
+				return fmt.Errorf("%s doesn't conform to the soroban-rpc protocol", cfg.Endpoint)
+			}
+			return nil
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&cfg.Endpoint, "endpoint", "http://localhost:8000", "JSON RPC endpoint to check")
+	flags.StringVar(&checks, "checks", "", "comma separated list of the checks to run, all of them are run by default (see --list)")
+	flags.DurationVar(&cfg.Timeout, "timeout", 30*time.Second, "maximum duration of every check")
+	flags.BoolVar(&jsonOutput, "json", false, "print the report as JSON")
+	flags.BoolVar(&list, "list", false, "list the checks instead of running them")
+	return cmd
+}
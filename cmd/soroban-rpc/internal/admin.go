@@ -0,0 +1,30 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/metrics"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/profiling"
+)
+
+// NewAdminHandler constructs the HTTP handler serving the internal endpoints
+// (metrics, profiling, etc ...) which should not be exposed publicly.
+func NewAdminHandler(logger *log.Entry, registry *prometheus.Registry) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler(registry))
+
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	profiles := profiling.Handler(logger, profiling.NewCapturer(registry))
+	mux.Handle("/profiles", profiles)
+	mux.Handle("/profiles/", profiles)
+	return mux
+}
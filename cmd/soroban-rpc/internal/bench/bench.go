@@ -0,0 +1,247 @@
+// Package bench generates synthetic JSON RPC load against a soroban-rpc endpoint and reports
+// the latency of every method, to help operators size their deployments.
+package bench
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/jhttp"
+	"golang.org/x/time/rate"
+)
+
+// Config describes the load generated by Run
+type Config struct {
+	Endpoint string
+	// Concurrency is the number of requests in flight
+	Concurrency int
+	// Duration is how long the load is generated for
+	Duration time.Duration
+	// Rate is the maximum number of requests per second, zero means unlimited
+	Rate float64
+	// Mix is the relative weight of every method in the generated requests
+	Mix map[string]int
+	// Params are the params sent with every method, methods without params are called without them
+	Params map[string]json.RawMessage
+	// HTTPClient is optional, http.DefaultClient is used by default
+	HTTPClient *http.Client
+}
+
+// MethodReport holds the latencies of the calls to a method
+type MethodReport struct {
+	Method string
+	Calls  int
+	Errors int
+	P50    time.Duration
+	P90    time.Duration
+	P99    time.Duration
+	Max    time.Duration
+}
+
+// Report is the result of a benchmark
+type Report struct {
+	Duration time.Duration
+	Methods  []MethodReport
+}
+
+// Throughput returns the number of calls per second
+func (r Report) Throughput() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	calls := 0
+	for _, m := range r.Methods {
+		calls += m.Calls
+	}
+	return float64(calls) / r.Duration.Seconds()
+}
+
+// Print writes the report as a table
+func (r Report) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "method\tcalls\terrors\tp50\tp90\tp99\tmax\t")
+	for _, m := range r.Methods {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%v\t%v\t%v\t%v\t\n", m.Method, m.Calls, m.Errors,
+			m.P50.Round(time.Microsecond), m.P90.Round(time.Microsecond),
+			m.P99.Round(time.Microsecond), m.Max.Round(time.Microsecond))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%.1f requests/s over %v\n", r.Throughput(), r.Duration.Round(time.Millisecond))
+	return err
+}
+
+// ParseMix parses a comma separated list of method=weight pairs
+// (e.g. "simulateTransaction=5,getLedgerEntries=3,getHealth=1")
+func ParseMix(s string) (map[string]int, error) {
+	result := map[string]int{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		method, weight, found := strings.Cut(entry, "=")
+		if !found {
+			result[method] = 1
+			continue
+		}
+		w, err := strconv.Atoi(strings.TrimSpace(weight))
+		if err != nil || w <= 0 {
+			return nil, fmt.Errorf("invalid weight in %q, expected a positive integer", entry)
+		}
+		result[strings.TrimSpace(method)] = w
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("the mix doesn't contain any method")
+	}
+	return result, nil
+}
+
+// LoadParams reads a JSON file mapping method names to the params they are called with
+func LoadParams(path string) (map[string]json.RawMessage, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var params map[string]json.RawMessage
+	if err := json.Unmarshal(contents, &params); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %v", path, err)
+	}
+	return params, nil
+}
+
+type sample struct {
+	method  string
+	latency time.Duration
+	failed  bool
+}
+
+// Run calls the methods of the mix concurrently until the configured duration elapses
+// (or the context is canceled) and reports their latencies. JSON RPC errors are counted
+// as errors, not failures of the benchmark.
+func Run(ctx context.Context, cfg Config) (Report, error) {
+	if cfg.Concurrency <= 0 {
+		return Report{}, fmt.Errorf("concurrency must be positive")
+	}
+	methods, err := weightedMethods(cfg.Mix)
+	if err != nil {
+		return Report{}, err
+	}
+	var limiter *rate.Limiter
+	if cfg.Rate > 0 {
+		limiter = rate.NewLimiter(rate.Limit(cfg.Rate), 1)
+	}
+	var channelOptions *jhttp.ChannelOptions
+	if cfg.HTTPClient != nil {
+		channelOptions = &jhttp.ChannelOptions{Client: cfg.HTTPClient}
+	}
+	client := jrpc2.NewClient(jhttp.NewChannel(cfg.Endpoint, channelOptions), nil)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+	samples := make(chan sample, cfg.Concurrency)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			random := rand.New(rand.NewSource(seed))
+			for {
+				if limiter != nil && limiter.Wait(ctx) != nil {
+					return
+				}
+				if ctx.Err() != nil {
+					return
+				}
+				method := methods[random.Intn(len(methods))]
+				var params interface{}
+				if p, ok := cfg.Params[method]; ok {
+					params = p
+				}
+				callStart := time.Now()
+				_, err := client.Call(ctx, method, params)
+				// calls interrupted by the end of the benchmark aren't accounted
+				if ctx.Err() != nil {
+					return
+				}
+				samples <- sample{method: method, latency: time.Since(callStart), failed: err != nil}
+			}
+		}(time.Now().UnixNano() + int64(i))
+	}
+	go func() {
+		wg.Wait()
+		close(samples)
+	}()
+
+	latencies := map[string][]time.Duration{}
+	errors := map[string]int{}
+	for s := range samples {
+		latencies[s.method] = append(latencies[s.method], s.latency)
+		if s.failed {
+			errors[s.method]++
+		}
+	}
+	report := Report{Duration: time.Since(start)}
+	for method := range cfg.Mix {
+		report.Methods = append(report.Methods, methodReport(method, latencies[method], errors[method]))
+	}
+	sort.Slice(report.Methods, func(i, j int) bool {
+		return report.Methods[i].Method < report.Methods[j].Method
+	})
+	return report, nil
+}
+
+// weightedMethods repeats every method of the mix according to its weight, so that
+// methods can be picked uniformly
+func weightedMethods(mix map[string]int) ([]string, error) {
+	var methods []string
+	for method, weight := range mix {
+		if weight <= 0 {
+			return nil, fmt.Errorf("the weight of %s must be positive", method)
+		}
+		for i := 0; i < weight; i++ {
+			methods = append(methods, method)
+		}
+	}
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("the mix doesn't contain any method")
+	}
+	return methods, nil
+}
+
+func methodReport(method string, latencies []time.Duration, errors int) MethodReport {
+	report := MethodReport{Method: method, Calls: len(latencies), Errors: errors}
+	if len(latencies) == 0 {
+		return report
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	report.P50 = percentile(latencies, 50)
+	report.P90 = percentile(latencies, 90)
+	report.P99 = percentile(latencies, 99)
+	report.Max = latencies[len(latencies)-1]
+	return report
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
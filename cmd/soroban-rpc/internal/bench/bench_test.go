@@ -0,0 +1,73 @@
+package bench
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/creachadair/jrpc2/handler"
+	"github.com/creachadair/jrpc2/jhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	var received []string
+	bridge := jhttp.NewBridge(handler.Map{
+		"echo": handler.New(func(_ context.Context, params []string) (string, error) {
+			received = params
+			return params[0], nil
+		}),
+		"fail": handler.New(func(context.Context) error {
+			return errors.New("failed")
+		}),
+	}, nil)
+	defer bridge.Close()
+	server := httptest.NewServer(bridge)
+	defer server.Close()
+
+	report, err := Run(context.Background(), Config{
+		Endpoint:    server.URL,
+		Concurrency: 1,
+		Duration:    200 * time.Millisecond,
+		Rate:        100,
+		Mix:         map[string]int{"echo": 1, "fail": 1},
+		Params:      map[string]json.RawMessage{"echo": json.RawMessage(`["hello"]`)},
+	})
+	require.NoError(t, err)
+	require.Len(t, report.Methods, 2)
+	echo, fail := report.Methods[0], report.Methods[1]
+	assert.Equal(t, "echo", echo.Method)
+	assert.Positive(t, echo.Calls)
+	assert.Zero(t, echo.Errors)
+	assert.LessOrEqual(t, echo.P50, echo.Max)
+	assert.Equal(t, []string{"hello"}, received)
+	assert.Equal(t, "fail", fail.Method)
+	assert.Equal(t, fail.Calls, fail.Errors)
+	// the rate limit caps the throughput
+	assert.LessOrEqual(t, report.Throughput(), 110.0)
+}
+
+func TestParseMix(t *testing.T) {
+	mix, err := ParseMix("simulateTransaction=5, getHealth")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"simulateTransaction": 5, "getHealth": 1}, mix)
+
+	_, err = ParseMix("getHealth=0")
+	assert.Error(t, err)
+	_, err = ParseMix("")
+	assert.Error(t, err)
+}
+
+func TestPercentile(t *testing.T) {
+	var latencies []time.Duration
+	for i := 1; i <= 100; i++ {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, 50*time.Millisecond, percentile(latencies, 50))
+	assert.Equal(t, 99*time.Millisecond, percentile(latencies, 99))
+	assert.Equal(t, time.Millisecond, percentile(latencies[:1], 99))
+}
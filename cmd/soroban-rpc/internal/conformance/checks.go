@@ -0,0 +1,273 @@
+package conformance
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/code"
+
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/methods"
+)
+
+// Checks are all the conformance checks, in the order they are run
+var Checks = []Check{
+	{
+		Name:        "unknown_method",
+		Description: "unknown methods fail with the method not found error code",
+		Run:         checkUnknownMethod,
+	},
+	{
+		Name:        "batch",
+		Description: "batches are answered with one response per request, in order",
+		Run:         checkBatch,
+	},
+	{
+		Name:        "get_health",
+		Description: "getHealth reports a known status and the latest ledger",
+		Run:         checkGetHealth,
+	},
+	{
+		Name:        "get_network",
+		Description: "getNetwork reports the network passphrase and protocol version",
+		Run:         checkGetNetwork,
+	},
+	{
+		Name:        "get_latest_ledger",
+		Description: "getLatestLedger returns a chain of recent ledger headers, newest first",
+		Run:         checkGetLatestLedger,
+	},
+	{
+		Name:        "get_latest_ledger_invalid_window",
+		Description: "getLatestLedger rejects negative windows with the invalid params error code",
+		Run:         checkGetLatestLedgerInvalidWindow,
+	},
+	{
+		Name:        "get_ledgers_invalid_params",
+		Description: "getLedgers rejects requests without startLedger or cursor with the invalid params error code",
+		Run:         checkGetLedgersInvalidParams,
+	},
+	{
+		Name:        "get_ledgers_pagination",
+		Description: "getLedgers pages through consecutive ledgers in ascending order and stops at endLedger",
+		Run:         checkGetLedgersPagination,
+	},
+	{
+		Name:        "ledger_retention",
+		Description: "the ledger range stamped on the responses is consistent and its oldest ledger can be retrieved",
+		Run:         checkLedgerRetention,
+	},
+	{
+		Name:        "get_transaction_status_not_found",
+		Description: "getTransactionStatus reports unknown transactions with the tx_not_found error",
+		Run:         checkTransactionNotFound,
+	},
+	{
+		Name:        "send_transaction_invalid_xdr",
+		Description: "sendTransaction reports undecodable transactions with the invalid_xdr error, without submitting them",
+		Run:         checkSendInvalidTransaction,
+	},
+}
+
+func checkUnknownMethod(ctx context.Context, client *jrpc2.Client) error {
+	return expectError(ctx, client, "conformanceUnknownMethod", nil, code.MethodNotFound)
+}
+
+func checkBatch(ctx context.Context, client *jrpc2.Client) error {
+	responses, err := client.Batch(ctx, []jrpc2.Spec{
+		{Method: "getHealth"},
+		{Method: "conformanceUnknownMethod"},
+	})
+	if err != nil {
+		return fmt.Errorf("batch failed: %v", err)
+	}
+	if len(responses) != 2 {
+		return fmt.Errorf("got %d responses to a batch of 2 requests", len(responses))
+	}
+	if err := responses[1].Error(); err == nil || err.Code != code.MethodNotFound {
+		return fmt.Errorf("the unknown method of the batch didn't fail with the method not found error code: %v", err)
+	}
+	return nil
+}
+
+func checkGetHealth(ctx context.Context, client *jrpc2.Client) error {
+	var health methods.HealthCheckResult
+	if err := call(ctx, client, "getHealth", nil, &health); err != nil {
+		return err
+	}
+	if health.Status != methods.HealthStatusHealthy && health.Status != methods.HealthStatusUnhealthy {
+		return fmt.Errorf("unknown status %q", health.Status)
+	}
+	if health.Status == methods.HealthStatusHealthy && health.LatestLedger <= 0 {
+		return fmt.Errorf("healthy without a latest ledger")
+	}
+	return nil
+}
+
+func checkGetNetwork(ctx context.Context, client *jrpc2.Client) error {
+	var network methods.GetNetworkResponse
+	if err := call(ctx, client, "getNetwork", nil, &network); err != nil {
+		return err
+	}
+	if network.Passphrase == "" {
+		return fmt.Errorf("empty passphrase")
+	}
+	if network.ProtocolVersion <= 0 {
+		return fmt.Errorf("invalid protocol version %d", network.ProtocolVersion)
+	}
+	return nil
+}
+
+func checkGetLatestLedger(ctx context.Context, client *jrpc2.Client) error {
+	var latest methods.GetLatestLedgerResponse
+	if err := call(ctx, client, "getLatestLedger", methods.GetLatestLedgerRequest{Window: 3}, &latest); err != nil {
+		return err
+	}
+	if err := checkLedgerHeader(latest.LedgerHeader); err != nil {
+		return err
+	}
+	recent := latest.RecentLedgers
+	if len(recent) == 0 || recent[0].Sequence != latest.Sequence {
+		return fmt.Errorf("the recent ledgers don't start with the latest ledger %d", latest.Sequence)
+	}
+	for i := 1; i < len(recent); i++ {
+		if recent[i].Sequence != recent[i-1].Sequence-1 || recent[i-1].PreviousHash != recent[i].Hash {
+			return fmt.Errorf("recent ledger %d doesn't precede ledger %d", recent[i].Sequence, recent[i-1].Sequence)
+		}
+	}
+	return nil
+}
+
+func checkGetLatestLedgerInvalidWindow(ctx context.Context, client *jrpc2.Client) error {
+	return expectError(ctx, client, "getLatestLedger", methods.GetLatestLedgerRequest{Window: -1}, code.InvalidParams)
+}
+
+func checkGetLedgersInvalidParams(ctx context.Context, client *jrpc2.Client) error {
+	return expectError(ctx, client, "getLedgers", json.RawMessage(`{}`), code.InvalidParams)
+}
+
+func checkGetLedgersPagination(ctx context.Context, client *jrpc2.Client) error {
+	var latest methods.GetLatestLedgerResponse
+	if err := call(ctx, client, "getLatestLedger", nil, &latest); err != nil {
+		return err
+	}
+	var ledgerRange methods.LedgerRange
+	if err := call(ctx, client, "getLatestLedger", nil, &ledgerRange); err != nil {
+		return err
+	}
+	start := uint32(2)
+	if ledgerRange.OldestLedger > int64(start) {
+		start = uint32(ledgerRange.OldestLedger)
+	}
+	if latest.Sequence < start+2 {
+		return skip("at least 3 ledgers are needed, the latest ledger is %d", latest.Sequence)
+	}
+	end := start + 2
+
+	var first methods.GetLedgersResponse
+	if err := call(ctx, client, "getLedgers", methods.GetLedgersRequest{StartLedger: start, EndLedger: end, Limit: 2}, &first); err != nil {
+		return err
+	}
+	if err := checkLedgerPage(first, start, 2); err != nil {
+		return fmt.Errorf("first page: %v", err)
+	}
+	if first.Cursor == "" {
+		return fmt.Errorf("the first page has no cursor although endLedger wasn't reached")
+	}
+	var second methods.GetLedgersResponse
+	if err := call(ctx, client, "getLedgers", methods.GetLedgersRequest{EndLedger: end, Cursor: first.Cursor, Limit: 2}, &second); err != nil {
+		return err
+	}
+	if err := checkLedgerPage(second, start+2, 1); err != nil {
+		return fmt.Errorf("second page: %v", err)
+	}
+	if first.Ledgers[1].Hash != second.Ledgers[0].PreviousHash {
+		return fmt.Errorf("the pages aren't consecutive")
+	}
+	if second.Cursor != "" {
+		return fmt.Errorf("the last page has a cursor although endLedger was reached")
+	}
+	return nil
+}
+
+func checkLedgerRetention(ctx context.Context, client *jrpc2.Client) error {
+	var ledgerRange methods.LedgerRange
+	if err := call(ctx, client, "getLatestLedger", nil, &ledgerRange); err != nil {
+		return err
+	}
+	if ledgerRange.LatestLedger == 0 && ledgerRange.OldestLedger == 0 {
+		return skip("the responses aren't stamped with the ledger range")
+	}
+	if ledgerRange.OldestLedger <= 0 || ledgerRange.OldestLedger > ledgerRange.LatestLedger {
+		return fmt.Errorf("invalid ledger range [%d, %d]", ledgerRange.OldestLedger, ledgerRange.LatestLedger)
+	}
+	if ledgerRange.OldestLedgerCloseTime > ledgerRange.LatestLedgerCloseTime {
+		return fmt.Errorf("the oldest ledger closed after the latest one")
+	}
+	oldest := uint32(ledgerRange.OldestLedger)
+	var page methods.GetLedgersResponse
+	if err := call(ctx, client, "getLedgers", methods.GetLedgersRequest{StartLedger: oldest, Limit: 1}, &page); err != nil {
+		return err
+	}
+	if len(page.Ledgers) != 1 || page.Ledgers[0].Sequence != oldest {
+		return fmt.Errorf("the oldest ledger %d couldn't be retrieved", oldest)
+	}
+	return nil
+}
+
+func checkTransactionNotFound(ctx context.Context, client *jrpc2.Client) error {
+	var status methods.TransactionStatusResponse
+	hash := hex.EncodeToString(make([]byte, 32))
+	if err := call(ctx, client, "getTransactionStatus", methods.GetTransactionStatusRequest{Hash: hash}, &status); err != nil {
+		return err
+	}
+	if status.Status != methods.TransactionError || status.Error == nil || status.Error.Code != "tx_not_found" {
+		return fmt.Errorf("unexpected status %q (error %+v) for an unknown transaction", status.Status, status.Error)
+	}
+	return nil
+}
+
+func checkSendInvalidTransaction(ctx context.Context, client *jrpc2.Client) error {
+	var response methods.SendTransactionResponse
+	if err := call(ctx, client, "sendTransaction", methods.SendTransactionRequest{Transaction: "not xdr"}, &response); err != nil {
+		return err
+	}
+	if response.Status != methods.TransactionError || response.Error == nil || response.Error.Code != "invalid_xdr" {
+		return fmt.Errorf("unexpected status %q (error %+v) for an undecodable transaction", response.Status, response.Error)
+	}
+	return nil
+}
+
+func checkLedgerHeader(header methods.LedgerHeader) error {
+	if header.Sequence == 0 {
+		return fmt.Errorf("the ledger has no sequence")
+	}
+	if hash, err := hex.DecodeString(header.Hash); err != nil || len(hash) != 32 {
+		return fmt.Errorf("ledger %d has an invalid hash %q", header.Sequence, header.Hash)
+	}
+	if header.HeaderXDR == "" {
+		return fmt.Errorf("ledger %d has no header xdr", header.Sequence)
+	}
+	return nil
+}
+
+// checkLedgerPage checks that a page contains count consecutive ledgers starting at start
+func checkLedgerPage(page methods.GetLedgersResponse, start uint32, count int) error {
+	if len(page.Ledgers) != count {
+		return fmt.Errorf("got %d ledgers, expected %d", len(page.Ledgers), count)
+	}
+	for i, ledger := range page.Ledgers {
+		if ledger.Sequence != start+uint32(i) {
+			return fmt.Errorf("got ledger %d, expected ledger %d", ledger.Sequence, start+uint32(i))
+		}
+		if err := checkLedgerHeader(ledger); err != nil {
+			return err
+		}
+		if i > 0 && ledger.PreviousHash != page.Ledgers[i-1].Hash {
+			return fmt.Errorf("ledger %d doesn't follow ledger %d", ledger.Sequence, page.Ledgers[i-1].Sequence)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+// Package conformance checks that a JSON RPC endpoint implements the soroban-rpc protocol:
+// the semantics of its methods, its error codes, the pagination of getLedgers and the
+// retention of ledgers. The checks only use the JSON RPC interface, so that they can be
+// run against soroban-rpc deployments as well as alternative implementations.
+package conformance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/code"
+	"github.com/creachadair/jrpc2/jhttp"
+
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/rpcerror"
+)
+
+// Config describes the endpoint checked by Run
+type Config struct {
+	Endpoint string
+	// Checks are the names of the checks to run, all of them are run when empty
+	Checks []string
+	// Timeout is the maximum duration of every check
+	Timeout time.Duration
+	// HTTPClient is optional, http.DefaultClient is used by default
+	HTTPClient *http.Client
+}
+
+// Check is a conformance check, Run returns an error describing the non-conformance
+type Check struct {
+	Name        string
+	Description string
+	Run         func(ctx context.Context, client *jrpc2.Client) error
+}
+
+// errSkipped is wrapped by the errors of the checks which couldn't run against the endpoint,
+// e.g. because the method they check is disabled
+var errSkipped = errors.New("skipped")
+
+func skip(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", errSkipped, fmt.Sprintf(format, args...))
+}
+
+const (
+	StatusPassed  = "passed"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+)
+
+// Result is the outcome of a check
+type Result struct {
+	Check    string        `json:"check"`
+	Status   string        `json:"status"`
+	Message  string        `json:"message,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Report holds the results of the checks, in the order they were run
+type Report struct {
+	Endpoint string   `json:"endpoint"`
+	Results  []Result `json:"results"`
+}
+
+// Passed returns true when none of the checks failed
+func (r Report) Passed() bool {
+	for _, result := range r.Results {
+		if result.Status == StatusFailed {
+			return false
+		}
+	}
+	return true
+}
+
+// Print writes the report as a table
+func (r Report) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "check\tstatus\tduration\tmessage")
+	counts := map[string]int{}
+	for _, result := range r.Results {
+		counts[result.Status]++
+		fmt.Fprintf(tw, "%s\t%s\t%v\t%s\n", result.Check, result.Status, result.Duration.Round(time.Millisecond), result.Message)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%d passed, %d failed, %d skipped against %s\n",
+		counts[StatusPassed], counts[StatusFailed], counts[StatusSkipped], r.Endpoint)
+	return err
+}
+
+// PrintJSON writes the report as JSON
+func (r Report) PrintJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// Run runs the configured checks against the endpoint, one after the other. The failures of
+// the checks are recorded in the report, an error is only returned for unknown checks.
+func Run(ctx context.Context, cfg Config) (Report, error) {
+	checks, err := selectChecks(cfg.Checks)
+	if err != nil {
+		return Report{}, err
+	}
+	var channelOptions *jhttp.ChannelOptions
+	if cfg.HTTPClient != nil {
+		channelOptions = &jhttp.ChannelOptions{Client: cfg.HTTPClient}
+	}
+	client := jrpc2.NewClient(jhttp.NewChannel(cfg.Endpoint, channelOptions), nil)
+	defer client.Close()
+
+	report := Report{Endpoint: cfg.Endpoint, Results: []Result{}}
+	for _, check := range checks {
+		if ctx.Err() != nil {
+			break
+		}
+		report.Results = append(report.Results, runCheck(ctx, client, check, cfg.Timeout))
+	}
+	return report, nil
+}
+
+func runCheck(ctx context.Context, client *jrpc2.Client, check Check, timeout time.Duration) Result {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	err := check.Run(ctx, client)
+	result := Result{Check: check.Name, Status: StatusPassed, Duration: time.Since(start)}
+	switch {
+	case errors.Is(err, errSkipped):
+		result.Status = StatusSkipped
+		result.Message = strings.TrimPrefix(err.Error(), errSkipped.Error()+": ")
+	case err != nil:
+		result.Status = StatusFailed
+		result.Message = err.Error()
+	}
+	return result
+}
+
+func selectChecks(names []string) ([]Check, error) {
+	if len(names) == 0 {
+		return Checks, nil
+	}
+	byName := map[string]Check{}
+	for _, check := range Checks {
+		byName[check.Name] = check
+	}
+	var selected []Check
+	for _, name := range names {
+		check, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown check %q", name)
+		}
+		selected = append(selected, check)
+	}
+	return selected, nil
+}
+
+// call calls a method, the checks of methods which are disabled (or not implemented) are skipped
+func call(ctx context.Context, client *jrpc2.Client, method string, params, result interface{}) error {
+	err := client.CallResult(ctx, method, params, result)
+	var rpcErr *jrpc2.Error
+	if errors.As(err, &rpcErr) && (rpcErr.Code == code.MethodNotFound || rpcErr.Code == rpcerror.MethodNotAllowed) {
+		return skip("%s is not available: %s", method, rpcErr.Message)
+	}
+	if err != nil {
+		return fmt.Errorf("%s failed: %v", method, err)
+	}
+	return nil
+}
+
+// expectError calls a method which must fail with the given error code
+func expectError(ctx context.Context, client *jrpc2.Client, method string, params interface{}, expected code.Code) error {
+	var result json.RawMessage
+	err := client.CallResult(ctx, method, params, &result)
+	if err == nil {
+		return fmt.Errorf("%s succeeded, expected error code %d", method, expected)
+	}
+	var rpcErr *jrpc2.Error
+	if !errors.As(err, &rpcErr) {
+		return fmt.Errorf("%s failed: %v", method, err)
+	}
+	if rpcErr.Code == expected {
+		return nil
+	}
+	if rpcErr.Code == code.MethodNotFound && expected != code.MethodNotFound || rpcErr.Code == rpcerror.MethodNotAllowed {
+		return skip("%s is not available: %s", method, rpcErr.Message)
+	}
+	return fmt.Errorf("%s returned error code %d (%s), expected %d", method, rpcErr.Code, rpcErr.Message, expected)
+}
@@ -0,0 +1,72 @@
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/daemon"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/mockbackend"
+)
+
+func startDaemon(t *testing.T, cfg daemon.Config) string {
+	d, err := daemon.NewDaemon(cfg)
+	require.NoError(t, err)
+	require.NoError(t, d.Start())
+	t.Cleanup(func() { d.Close() })
+	return "http://" + d.Addr().String()
+}
+
+func TestRun(t *testing.T) {
+	backend := mockbackend.New(mockbackend.StandaloneNetworkPassphrase)
+	defer backend.Close()
+	for i := 0; i < 4; i++ {
+		backend.CloseLedger()
+	}
+	endpoint := startDaemon(t, backend.DaemonConfig())
+
+	report, err := Run(context.Background(), Config{Endpoint: endpoint, Timeout: 10 * time.Second})
+	require.NoError(t, err)
+	require.Len(t, report.Results, len(Checks))
+	for _, result := range report.Results {
+		assert.Equal(t, StatusPassed, result.Status, "%s: %s", result.Check, result.Message)
+	}
+	assert.True(t, report.Passed())
+
+	var printed bytes.Buffer
+	require.NoError(t, report.Print(&printed))
+	assert.Contains(t, printed.String(), "11 passed, 0 failed, 0 skipped")
+	printed.Reset()
+	require.NoError(t, report.PrintJSON(&printed))
+	var decoded Report
+	require.NoError(t, json.Unmarshal(printed.Bytes(), &decoded))
+	assert.Equal(t, report, decoded)
+
+	_, err = Run(context.Background(), Config{Endpoint: endpoint, Checks: []string{"unknown"}})
+	assert.Error(t, err)
+}
+
+func TestRunSkipsDisabledMethods(t *testing.T) {
+	backend := mockbackend.New(mockbackend.StandaloneNetworkPassphrase)
+	defer backend.Close()
+	cfg := backend.DaemonConfig()
+	cfg.ReadOnly = true
+	endpoint := startDaemon(t, cfg)
+
+	report, err := Run(context.Background(), Config{
+		Endpoint: endpoint,
+		Checks:   []string{"send_transaction_invalid_xdr", "get_ledgers_pagination"},
+	})
+	require.NoError(t, err)
+	require.Len(t, report.Results, 2)
+	// sendTransaction is disabled and a single ledger isn't enough to check the pagination
+	for _, result := range report.Results {
+		assert.Equal(t, StatusSkipped, result.Status, "%s: %s", result.Check, result.Message)
+	}
+	assert.True(t, report.Passed())
+}
@@ -0,0 +1,764 @@
+// Package daemon wires the soroban-rpc components together, allowing the server
+// to be embedded in other Go programs (e.g. testing frameworks) instead of running the binary.
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/clients/stellarcore"
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/logging"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/methods"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/metrics"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/middleware"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/tracing"
+)
+
+const (
+	defaultShutdownGracePeriod = 10 * time.Second
+	defaultReadTimeout         = 5 * time.Second
+	passphraseCheckTimeout     = 10 * time.Second
+	defaultSnapshotInterval    = 30 * time.Second
+)
+
+// Config contains the settings of a Daemon
+type Config struct {
+	// Logger is optional, a new logger is created when nil. The daemon logs through a child
+	// logger forwarding its entries to Logger, so that its hooks don't affect the other users of Logger.
+	// The entries aren't forwarded when Logging replaces the output of the logs.
+	Logger *log.Entry
+	// LogLevel is the level of the daemon logs, it can be changed with Daemon.SetLogLevel().
+	// The zero value (logrus.PanicLevel) defaults to logrus.InfoLevel.
+	LogLevel logrus.Level
+
+	// Endpoint is the address the JSON RPC server listens on: a TCP address (e.g. "localhost:8000"),
+	// a Unix domain socket ("unix:<path>") or a socket passed by systemd ("systemd:<name or index>").
+	// A ":0" port picks a free port, which can be obtained through Daemon.Addr().
+	Endpoint string
+	// AdditionalEndpoints are other addresses (with the same format as Endpoint) the JSON RPC
+	// server listens on, e.g. to listen on both IPv4 and IPv6
+	AdditionalEndpoints []string
+	// InternalEndpoints are addresses serving the JSON RPC requests without rate limits, API keys
+	// nor IP lists, e.g. for the other services of the operator. They should only be accessible
+	// to trusted clients.
+	InternalEndpoints []string
+	// AdminEndpoint is the address the admin server listens on (with the same format as Endpoint),
+	// the admin server is disabled when empty
+	AdminEndpoint string
+	// TLSCertFile and TLSKeyFile enable TLS on the JSON RPC endpoints (including the internal ones)
+	// when both are set
+	TLSCertFile string
+	TLSKeyFile  string
+
+	HorizonURL        string
+	StellarCoreURL    string
+	NetworkPassphrase string
+
+	TxConcurrency           int
+	TxQueueSize             int
+	TxSubmissionMaxAttempts int
+	TxSubmissionBackoff     time.Duration
+	// TxSubmissionHorizonURLs are additional Horizon instances to submit transactions to. Every
+	// submission goes to the healthiest of them and HorizonURL (which are probed every
+	// TxSubmissionProbeInterval, 10 seconds by default) and fails over to the others.
+	TxSubmissionHorizonURLs   []string
+	TxSubmissionProbeInterval time.Duration
+	TxWebhooksEnabled         bool
+	TxWebhookTimeout          time.Duration
+	// TxWebhookAllowPrivate allows webhooks to be delivered to loopback,
+	// private and link-local addresses
+	TxWebhookAllowPrivate bool
+	// TxStatusMaxWait is the maximum waitSeconds of getTransactionStatus requests
+	TxStatusMaxWait time.Duration
+	// TxStoreSnapshotFile, when set, is where the transaction store is saved (every
+	// TxStoreSnapshotInterval, 30 seconds by default, and on Close) and restored from,
+	// so that the submission errors survive restarts
+	TxStoreSnapshotFile     string
+	TxStoreSnapshotInterval time.Duration
+	// TxStoreMemoryLimit is the approximate memory budget (in bytes) of the transaction store,
+	// zero means unlimited
+	TxStoreMemoryLimit int64
+
+	PreflightConcurrency int
+	PreflightQueueSize   int
+	PreflightTimeout     time.Duration
+	PreflightBudget      methods.PreflightBudget
+
+	MaxHealthyLedgerLatency time.Duration
+	// ShutdownGracePeriod is how long Close waits for in-flight requests, it defaults to 10 seconds
+	ShutdownGracePeriod time.Duration
+
+	CORSAllowedOrigins    []string
+	MaxBatchSize          int
+	MaxRequestConcurrency int
+	MaxRequestSize        int64
+	MaxResponseSize       int
+	// MethodTimeouts are per-method deadlines, downstream work is canceled once they elapse
+	MethodTimeouts map[string]time.Duration
+	// EnabledMethods, when not empty, restricts the methods which can be called. DisabledMethods
+	// can't be called, nor can sendTransaction when ReadOnly is set.
+	EnabledMethods  []string
+	DisabledMethods []string
+	ReadOnly        bool
+
+	MethodRateLimits map[string]float64
+	IPRateLimit      float64
+	// DynamicRateLimits creates the rate limiter even when no limits are configured, so that
+	// limits can be set later on through Daemon.RateLimiter()
+	DynamicRateLimits bool
+	// APIKeys, when not empty, are required to call the JSON RPC methods
+	APIKeys []middleware.APIKey
+	// IPAllowList, when not empty, restricts the clients to the given ranges. The clients in
+	// IPDenyList are always rejected.
+	IPAllowList []*net.IPNet
+	IPDenyList  []*net.IPNet
+	// TrustedProxies are the ranges of the proxies whose X-Forwarded-For header is used
+	// to resolve the client IP (for the IP lists, the IP rate limit and the logs)
+	TrustedProxies []*net.IPNet
+
+	RequestLogSampleRatio float64
+	SlowRequestThreshold  time.Duration
+	// ResponseCacheSize is the maximum number of cached results of idempotent methods, zero disables the cache
+	ResponseCacheSize int
+	ResponseCacheTTL  time.Duration
+	// GetMethods are the read-only methods also served over HTTP GET (e.g. GET /getLatestLedger)
+	GetMethods []string
+	// GetCacheMaxAge is the max-age of the Cache-Control header of successful GET responses
+	GetCacheMaxAge time.Duration
+	// ResponseCompression are the encodings the responses can be compressed with, in order of preference
+	ResponseCompression        []string
+	ResponseCompressionMinSize int
+	// ContractStatsSize is the maximum number of contracts whose usage is tracked, zero disables the tracking
+	ContractStatsSize int
+	// ContractStatsMetricsTop is the number of most invoked contracts exported in the metrics
+	ContractStatsMetricsTop int
+
+	Tracing tracing.Config
+	// MetricsHistograms configures the buckets of the histograms
+	MetricsHistograms metrics.HistogramConfig
+	// LedgerFreshnessInterval is how often the freshness of the latest ledger is measured, zero disables the measurements
+	LedgerFreshnessInterval time.Duration
+	// LedgerFreshnessSLOs are the freshness thresholds whose violations are counted
+	LedgerFreshnessSLOs []time.Duration
+	// Logging configures the format and destinations of the logs
+	Logging logging.Config
+}
+
+// Daemon is a soroban-rpc server
+type Daemon struct {
+	cfg             Config
+	logger          *log.Entry
+	handler         internal.Handler
+	horizonClient   *horizonclient.Client
+	coreClient      *stellarcore.Client
+	metricsRegistry *prometheus.Registry
+	rateLimiter     *middleware.RateLimiter
+	server          *http.Server
+	internalServer  *http.Server
+	adminServer     *http.Server
+	// listeners are the listeners of server, starting with the one of Config.Endpoint
+	listeners         []net.Listener
+	internalListeners []net.Listener
+	shutdownTracing   func(context.Context) error
+	closeLogging      func() error
+	txStore           *methods.MemoryTransactionStore
+	freshness         *methods.FreshnessTracker
+	stopFreshness     context.CancelFunc
+	stopSnapshots     context.CancelFunc
+	snapshotsDone     chan struct{}
+	// serveErrors receives the errors of the servers which stopped unexpectedly
+	serveErrors    chan error
+	closed         chan struct{}
+	closeOnce      sync.Once
+	backgroundOnce sync.Once
+	backgroundErr  error
+}
+
+// forwardHook logs the entries of a child logger with its parent logger, which applies
+// its own level, fields and outputs to them
+type forwardHook struct {
+	parent *log.Entry
+}
+
+// Levels implements logrus.Hook
+func (h forwardHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook
+func (h forwardHook) Fire(entry *logrus.Entry) error {
+	logger := h.parent.WithFields(log.F(entry.Data))
+	switch entry.Level {
+	case logrus.TraceLevel, logrus.DebugLevel:
+		logger.Debug(entry.Message)
+	case logrus.InfoLevel:
+		logger.Info(entry.Message)
+	case logrus.WarnLevel:
+		logger.Warn(entry.Message)
+	default:
+		// the child logger takes care of exiting or panicking after fatal and panic entries
+		logger.Error(entry.Message)
+	}
+	return nil
+}
+
+// newChildLogger returns a logger whose entries are logged with parent (which filters them
+// with its own level too). Hooks can be added to it without modifying parent.
+func newChildLogger(parent *log.Entry, level logrus.Level) *log.Entry {
+	child := log.New()
+	child.SetLevel(level)
+	child.SetOutput(io.Discard)
+	child.AddHook(forwardHook{parent: parent})
+	return child
+}
+
+// registerTxStoreMetrics exposes the memory used by the transaction store and its evictions
+func registerTxStoreMetrics(registry *prometheus.Registry, txStore *methods.MemoryTransactionStore) {
+	registry.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metrics.PrometheusNamespace,
+			Subsystem: "transaction_store",
+			Name:      "entries",
+			Help:      "number of submitted transactions in the transaction store",
+		}, func() float64 { return float64(txStore.Len()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metrics.PrometheusNamespace,
+			Subsystem: "transaction_store",
+			Name:      "size_bytes",
+			Help:      "approximate memory used by the transaction store",
+		}, func() float64 { return float64(txStore.SizeBytes()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metrics.PrometheusNamespace,
+			Subsystem: "transaction_store",
+			Name:      "max_bytes",
+			Help:      "memory budget of the transaction store, 0 when it is unlimited",
+		}, func() float64 { return float64(txStore.MaxBytes()) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: metrics.PrometheusNamespace,
+			Subsystem: "transaction_store",
+			Name:      "evicted_total",
+			Help:      "number of submitted transactions evicted from the transaction store to fit in its memory budget",
+		}, func() float64 { return float64(txStore.Evicted()) }),
+	)
+}
+
+// registerSubmissionMetrics exposes the outcome of the submissions to every upstream and its health
+func registerSubmissionMetrics(registry *prometheus.Registry, histograms metrics.HistogramConfig, pool *methods.SubmissionPool) {
+	submissions := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.PrometheusNamespace,
+		Subsystem: "transaction_submission",
+		Name:      "upstream_submissions_total",
+		Help:      "number of transaction submissions, by upstream and outcome (success, rejected or error)",
+	}, []string{"upstream", "outcome"})
+	durations := prometheus.NewHistogramVec(histograms.Apply(prometheus.HistogramOpts{
+		Namespace: metrics.PrometheusNamespace,
+		Subsystem: "transaction_submission",
+		Name:      "upstream_duration_seconds",
+		Help:      "duration of the transaction submissions, by upstream",
+		Buckets:   prometheus.DefBuckets,
+	}), []string{"upstream"})
+	registry.MustRegister(submissions, durations)
+	for i, status := range pool.Statuses() {
+		i := i
+		registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   metrics.PrometheusNamespace,
+			Subsystem:   "transaction_submission",
+			Name:        "upstream_healthy",
+			Help:        "whether the upstream is considered healthy (1) or not (0) for submissions",
+			ConstLabels: prometheus.Labels{"upstream": status.Name},
+		}, func() float64 {
+			if pool.Statuses()[i].Healthy {
+				return 1
+			}
+			return 0
+		}))
+	}
+	pool.OnSubmission = func(upstream, outcome string, duration time.Duration) {
+		submissions.With(prometheus.Labels{"upstream": upstream, "outcome": outcome}).Inc()
+		durations.With(prometheus.Labels{"upstream": upstream}).Observe(duration.Seconds())
+	}
+}
+
+// registerFreshnessMetrics exposes the freshness of the ledgers and the violations of the freshness SLOs
+func registerFreshnessMetrics(registry *prometheus.Registry, histograms metrics.HistogramConfig, tracker *methods.FreshnessTracker) {
+	freshness := prometheus.NewHistogram(histograms.Apply(prometheus.HistogramOpts{
+		Namespace: metrics.PrometheusNamespace,
+		Subsystem: "ledger_freshness",
+		Name:      "latency_seconds",
+		Help:      "time elapsed between the close of the ledgers by the network and their observation in the ingested ledgers",
+		Buckets:   []float64{1, 2, 5, 10, 20, 30, 60, 120, 300},
+	}))
+	registry.MustRegister(
+		freshness,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metrics.PrometheusNamespace,
+			Subsystem: "ledger_freshness",
+			Name:      "latest_ledger",
+			Help:      "sequence of the latest ingested ledger",
+		}, func() float64 { return float64(tracker.Status().LatestLedger) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metrics.PrometheusNamespace,
+			Subsystem: "ledger_freshness",
+			Name:      "age_seconds",
+			Help:      "time elapsed since the network closed the latest ingested ledger, it keeps growing while the ingestion stalls",
+		}, func() float64 { return tracker.Status().Age(time.Now()).Seconds() }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metrics.PrometheusNamespace,
+			Subsystem: "ledger_freshness",
+			Name:      "ledger_lag",
+			Help:      "number of ledgers closed by stellar core which aren't ingested yet",
+		}, func() float64 { return float64(tracker.Status().LedgerLag()) }),
+	)
+	for i, threshold := range tracker.SLOs {
+		i := i
+		labels := prometheus.Labels{"threshold": strconv.FormatFloat(threshold.Seconds(), 'f', -1, 64)}
+		registry.MustRegister(
+			prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+				Namespace:   metrics.PrometheusNamespace,
+				Subsystem:   "ledger_freshness",
+				Name:        "slo_threshold_seconds",
+				Help:        "freshness threshold of the SLO",
+				ConstLabels: labels,
+			}, func() float64 { return threshold.Seconds() }),
+			prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+				Namespace:   metrics.PrometheusNamespace,
+				Subsystem:   "ledger_freshness",
+				Name:        "slo_met",
+				Help:        "whether the age of the latest ingested ledger is within the SLO threshold (1) or not (0)",
+				ConstLabels: labels,
+			}, func() float64 {
+				status := tracker.Status()
+				if status.LatestLedger != 0 && status.Age(time.Now()) <= threshold {
+					return 1
+				}
+				return 0
+			}),
+			prometheus.NewCounterFunc(prometheus.CounterOpts{
+				Namespace:   metrics.PrometheusNamespace,
+				Subsystem:   "ledger_freshness",
+				Name:        "slo_violations_total",
+				Help:        "number of ledgers observed later than the SLO threshold after being closed",
+				ConstLabels: labels,
+			}, func() float64 { return float64(tracker.Status().Violations[i]) }),
+		)
+	}
+	tracker.OnLedger = func(latency time.Duration) {
+		freshness.Observe(latency.Seconds())
+	}
+}
+
+func newHorizonClient(url string) *horizonclient.Client {
+	hc := &horizonclient.Client{
+		HorizonURL: url,
+		HTTP: &http.Client{
+			Timeout: horizonclient.HorizonTimeout,
+		},
+		AppName: "Soroban RPC",
+	}
+	hc.SetHorizonTimeout(horizonclient.HorizonTimeout)
+	return hc
+}
+
+// NewDaemon creates a Daemon from the given configuration, the servers aren't started until Start() is called.
+func NewDaemon(cfg Config) (*Daemon, error) {
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return nil, errors.New("both the tls certificate and key files must be provided to enable TLS")
+	}
+	if err := cfg.MetricsHistograms.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.ShutdownGracePeriod == 0 {
+		cfg.ShutdownGracePeriod = defaultShutdownGracePeriod
+	}
+	if cfg.TxStoreSnapshotInterval == 0 {
+		cfg.TxStoreSnapshotInterval = defaultSnapshotInterval
+	}
+	if cfg.LogLevel == logrus.PanicLevel {
+		cfg.LogLevel = logrus.InfoLevel
+	}
+	// the entries aren't forwarded to cfg.Logger when their output is replaced, since
+	// they would be written twice and the parent would keep its own format
+	logger := log.New()
+	logger.SetLevel(cfg.LogLevel)
+	if cfg.Logger != nil && !cfg.Logging.ReplacesOutput() {
+		logger = newChildLogger(cfg.Logger, cfg.LogLevel)
+	}
+
+	closeLogging, err := logging.Setup(logger, cfg.Logging)
+	if err != nil {
+		return nil, fmt.Errorf("could not configure logging: %v", err)
+	}
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		closeLogging()
+		return nil, fmt.Errorf("could not configure tracing: %v", err)
+	}
+	// cleanup releases the resources acquired so far when the daemon can't be created
+	cleanup := func() {
+		shutdownTracing(context.Background())
+		closeLogging()
+	}
+
+	hc := newHorizonClient(cfg.HorizonURL)
+
+	txStore := methods.NewMemoryTransactionStoreWithBudget(cfg.TxStoreMemoryLimit)
+	if cfg.TxStoreSnapshotFile != "" {
+		if err := txStore.LoadSnapshot(cfg.TxStoreSnapshotFile); err != nil {
+			cleanup()
+			return nil, fmt.Errorf("could not restore the transaction store: %v", err)
+		}
+	}
+
+	var webhookNotifier *methods.WebhookNotifier
+	if cfg.TxWebhooksEnabled {
+		webhookNotifier = methods.NewWebhookNotifier(logger, cfg.TxConcurrency, cfg.TxQueueSize, cfg.TxWebhookTimeout)
+		webhookNotifier.AllowPrivateDestinations = cfg.TxWebhookAllowPrivate
+	}
+	var submissionPool *methods.SubmissionPool
+	if len(cfg.TxSubmissionHorizonURLs) > 0 {
+		upstreams := []methods.SubmissionUpstream{{Name: cfg.HorizonURL, Client: hc}}
+		for _, url := range cfg.TxSubmissionHorizonURLs {
+			upstreams = append(upstreams, methods.SubmissionUpstream{Name: url, Client: newHorizonClient(url)})
+		}
+		submissionPool = methods.NewSubmissionPool(logger, upstreams, cfg.TxSubmissionProbeInterval)
+	}
+	transactionProxy := methods.NewTransactionProxy(
+		hc,
+		cfg.TxConcurrency,
+		cfg.TxQueueSize,
+		cfg.NetworkPassphrase,
+		5*time.Minute,
+		txStore,
+		webhookNotifier,
+		methods.SubmissionRetryPolicy{
+			MaxAttempts: cfg.TxSubmissionMaxAttempts,
+			Backoff:     cfg.TxSubmissionBackoff,
+		},
+		submissionPool,
+	)
+
+	var rateLimiter *middleware.RateLimiter
+	if len(cfg.MethodRateLimits) > 0 || cfg.IPRateLimit > 0 || cfg.DynamicRateLimits {
+		rateLimiter = middleware.NewRateLimiter(cfg.MethodRateLimits, cfg.IPRateLimit)
+	}
+	var apiKeyAuth *middleware.APIKeyAuth
+	if len(cfg.APIKeys) > 0 {
+		if apiKeyAuth, err = middleware.NewAPIKeyAuth(cfg.APIKeys); err != nil {
+			cleanup()
+			return nil, fmt.Errorf("invalid api keys: %v", err)
+		}
+	}
+
+	var ipFilter *middleware.IPFilter
+	if len(cfg.IPAllowList) > 0 || len(cfg.IPDenyList) > 0 || len(cfg.TrustedProxies) > 0 {
+		ipFilter = middleware.NewIPFilter(cfg.IPAllowList, cfg.IPDenyList, cfg.TrustedProxies)
+	}
+
+	coreClient := &stellarcore.Client{URL: cfg.StellarCoreURL}
+	metricsRegistry := metrics.NewRegistry(cfg.NetworkPassphrase)
+	logger.AddHook(metrics.NewLogMetricsHook(metricsRegistry))
+	registerTxStoreMetrics(metricsRegistry, txStore)
+	if submissionPool != nil {
+		registerSubmissionMetrics(metricsRegistry, cfg.MetricsHistograms, submissionPool)
+	}
+	var freshness *methods.FreshnessTracker
+	if cfg.LedgerFreshnessInterval > 0 {
+		freshness = methods.NewFreshnessTracker(logger, hc, coreClient, cfg.LedgerFreshnessInterval, cfg.LedgerFreshnessSLOs)
+		registerFreshnessMetrics(metricsRegistry, cfg.MetricsHistograms, freshness)
+	}
+	handler, err := internal.NewJSONRPCHandler(internal.HandlerParams{
+		AccountStore:               methods.AccountStore{Client: hc},
+		Logger:                     logger,
+		NetworkPassphrase:          cfg.NetworkPassphrase,
+		TransactionProxy:           transactionProxy,
+		MetricsRegistry:            metricsRegistry,
+		MetricsHistograms:          cfg.MetricsHistograms,
+		PreflightQueue:             methods.NewPreflightQueue(cfg.PreflightConcurrency, cfg.PreflightQueueSize, cfg.PreflightTimeout),
+		PreflightBudget:            cfg.PreflightBudget,
+		HorizonClient:              hc,
+		CoreClient:                 coreClient,
+		MaxHealthyLedgerLatency:    cfg.MaxHealthyLedgerLatency,
+		MaxTransactionStatusWait:   cfg.TxStatusMaxWait,
+		CORSAllowedOrigins:         cfg.CORSAllowedOrigins,
+		MaxBatchSize:               cfg.MaxBatchSize,
+		MaxRequestConcurrency:      cfg.MaxRequestConcurrency,
+		MaxRequestSize:             cfg.MaxRequestSize,
+		MaxResponseSize:            cfg.MaxResponseSize,
+		MethodTimeouts:             cfg.MethodTimeouts,
+		EnabledMethods:             cfg.EnabledMethods,
+		DisabledMethods:            cfg.DisabledMethods,
+		ReadOnly:                   cfg.ReadOnly,
+		RateLimiter:                rateLimiter,
+		APIKeyAuth:                 apiKeyAuth,
+		IPFilter:                   ipFilter,
+		RequestLogger:              middleware.NewRequestLogger(logger, cfg.RequestLogSampleRatio, cfg.SlowRequestThreshold),
+		ResponseCacheSize:          cfg.ResponseCacheSize,
+		ResponseCacheTTL:           cfg.ResponseCacheTTL,
+		GetMethods:                 cfg.GetMethods,
+		GetCacheMaxAge:             cfg.GetCacheMaxAge,
+		ResponseCompression:        cfg.ResponseCompression,
+		ResponseCompressionMinSize: cfg.ResponseCompressionMinSize,
+		ContractStatsSize:          cfg.ContractStatsSize,
+		ContractStatsMetricsTop:    cfg.ContractStatsMetricsTop,
+	})
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("could not create handler: %v", err)
+	}
+
+	d := &Daemon{
+		cfg:             cfg,
+		logger:          logger,
+		handler:         handler,
+		horizonClient:   hc,
+		coreClient:      coreClient,
+		metricsRegistry: metricsRegistry,
+		rateLimiter:     rateLimiter,
+		shutdownTracing: shutdownTracing,
+		closeLogging:    closeLogging,
+		txStore:         txStore,
+		freshness:       freshness,
+		closed:          make(chan struct{}),
+		server: &http.Server{
+			Handler:     handler,
+			ReadTimeout: defaultReadTimeout,
+		},
+	}
+	if len(cfg.InternalEndpoints) > 0 {
+		d.internalServer = &http.Server{
+			Handler:     handler.Internal,
+			ReadTimeout: defaultReadTimeout,
+		}
+	}
+	if cfg.AdminEndpoint != "" {
+		d.adminServer = &http.Server{
+			Handler: internal.NewAdminHandler(logger, metricsRegistry),
+		}
+	}
+	return d, nil
+}
+
+// Start starts the background workers (see StartBackground()) and the servers. It returns once
+// the servers are listening, the requests are served in the background until Close() is called
+// (see Wait() for the errors of the servers).
+func (d *Daemon) Start() error {
+	if err := d.StartBackground(); err != nil {
+		return err
+	}
+	listeners, err := listenAll(append([]string{d.cfg.Endpoint}, d.cfg.AdditionalEndpoints...))
+	if err != nil {
+		return err
+	}
+	internalListeners, err := listenAll(d.cfg.InternalEndpoints)
+	if err != nil {
+		closeAll(listeners)
+		return err
+	}
+	var adminListener net.Listener
+	if d.adminServer != nil {
+		if adminListener, err = listen(d.cfg.AdminEndpoint); err != nil {
+			closeAll(listeners)
+			closeAll(internalListeners)
+			return fmt.Errorf("could not listen on %s: %v", d.cfg.AdminEndpoint, err)
+		}
+	}
+	d.listeners = listeners
+	d.internalListeners = internalListeners
+	d.serveErrors = make(chan error, len(listeners)+len(internalListeners)+1)
+
+	for _, listener := range listeners {
+		d.logger.Infof("Starting Soroban JSON RPC server on %v", listener.Addr())
+		go d.serve(d.server, listener)
+	}
+	for _, listener := range internalListeners {
+		d.logger.Infof("Starting Soroban JSON RPC internal server on %v", listener.Addr())
+		go d.serve(d.internalServer, listener)
+	}
+	if adminListener != nil {
+		d.logger.Infof("Starting Soroban JSON RPC admin server on %v", adminListener.Addr())
+		go func() {
+			if err := d.adminServer.Serve(adminListener); err != nil && err != http.ErrServerClosed {
+				d.serveErrors <- fmt.Errorf("could not run admin server: %v", err)
+			}
+		}()
+	}
+	return nil
+}
+
+// StartBackground starts the workers needed to serve the requests: the transaction submission
+// and webhook workers, the ledger range and freshness tracking and the transaction store snapshots.
+// Start() calls it, a program mounting Handler() in its own server must call it instead of Start().
+// The daemon refuses to start when Horizon or Stellar Core are connected to another network.
+// Calling it more than once has no effect.
+func (d *Daemon) StartBackground() error {
+	d.backgroundOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), passphraseCheckTimeout)
+		err := methods.VerifyNetworkPassphrase(ctx, d.cfg.NetworkPassphrase, d.horizonClient, d.coreClient)
+		cancel()
+		if err != nil {
+			d.backgroundErr = err
+			return
+		}
+		d.handler.Start()
+		if d.freshness != nil {
+			var freshnessCtx context.Context
+			freshnessCtx, d.stopFreshness = context.WithCancel(context.Background())
+			d.freshness.Start(freshnessCtx)
+		}
+		if d.cfg.TxStoreSnapshotFile != "" {
+			var snapshotsCtx context.Context
+			snapshotsCtx, d.stopSnapshots = context.WithCancel(context.Background())
+			d.snapshotsDone = make(chan struct{})
+			go d.saveSnapshots(snapshotsCtx)
+		}
+	})
+	return d.backgroundErr
+}
+
+func (d *Daemon) serve(server *http.Server, listener net.Listener) {
+	var err error
+	if d.cfg.TLSCertFile != "" {
+		err = server.ServeTLS(listener, d.cfg.TLSCertFile, d.cfg.TLSKeyFile)
+	} else {
+		err = server.Serve(listener)
+	}
+	if err != nil && err != http.ErrServerClosed {
+		d.serveErrors <- fmt.Errorf("could not run server on %v: %v", listener.Addr(), err)
+	}
+}
+
+// Wait blocks until one of the servers started by Start() fails, returning its error,
+// or until the daemon is closed, returning nil.
+func (d *Daemon) Wait() error {
+	select {
+	case err := <-d.serveErrors:
+		return err
+	case <-d.closed:
+		return nil
+	}
+}
+
+// Close stops the servers, waiting (up to the shutdown grace period) for the in-flight
+// requests to be drained, and then releases all the resources held by the daemon.
+func (d *Daemon) Close() error {
+	var err error
+	d.closeOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), d.cfg.ShutdownGracePeriod)
+		defer cancel()
+		if len(d.listeners) > 0 {
+			d.logger.Infof("Shutting down, draining in-flight requests (up to %v)", d.cfg.ShutdownGracePeriod)
+			if shutdownErr := d.server.Shutdown(ctx); shutdownErr != nil {
+				err = fmt.Errorf("could not shut down server: %v", shutdownErr)
+			}
+		}
+		if len(d.internalListeners) > 0 {
+			if shutdownErr := d.internalServer.Shutdown(ctx); shutdownErr != nil && err == nil {
+				err = fmt.Errorf("could not shut down internal server: %v", shutdownErr)
+			}
+		}
+		// The handler must only be closed once the in-flight requests are drained
+		d.handler.Close()
+		if d.stopFreshness != nil {
+			d.stopFreshness()
+		}
+		if d.stopSnapshots != nil {
+			d.stopSnapshots()
+			<-d.snapshotsDone
+		}
+		if d.cfg.TxStoreSnapshotFile != "" {
+			if snapshotErr := d.txStore.SaveSnapshot(d.cfg.TxStoreSnapshotFile); snapshotErr != nil {
+				d.logger.WithError(snapshotErr).Warn("could not save the transaction store")
+			}
+		}
+		if d.adminServer != nil {
+			if shutdownErr := d.adminServer.Shutdown(ctx); shutdownErr != nil {
+				d.logger.WithError(shutdownErr).Warn("could not shut down admin server")
+			}
+		}
+		if flushErr := d.shutdownTracing(context.Background()); flushErr != nil {
+			d.logger.WithError(flushErr).Warn("could not flush traces")
+		}
+		if closeErr := d.closeLogging(); closeErr != nil && err == nil {
+			err = fmt.Errorf("could not close logs: %v", closeErr)
+		}
+		close(d.closed)
+	})
+	return err
+}
+
+// saveSnapshots periodically saves the transaction store until ctx is canceled
+func (d *Daemon) saveSnapshots(ctx context.Context) {
+	defer close(d.snapshotsDone)
+	ticker := time.NewTicker(d.cfg.TxStoreSnapshotInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := d.txStore.SaveSnapshot(d.cfg.TxStoreSnapshotFile); err != nil {
+				d.logger.WithError(err).Warn("could not save the transaction store")
+			}
+		}
+	}
+}
+
+// Addr returns the address the JSON RPC server listens on (the one of Config.Endpoint),
+// it is nil until the daemon is started.
+func (d *Daemon) Addr() net.Addr {
+	if len(d.listeners) == 0 {
+		return nil
+	}
+	return d.listeners[0].Addr()
+}
+
+// Addrs returns all the addresses the JSON RPC server listens on, starting with Addr(),
+// followed by the ones of the internal endpoints
+func (d *Daemon) Addrs() []net.Addr {
+	var addrs []net.Addr
+	for _, listener := range append(append([]net.Listener{}, d.listeners...), d.internalListeners...) {
+		addrs = append(addrs, listener.Addr())
+	}
+	return addrs
+}
+
+// Handler returns the http.Handler serving the JSON RPC requests, which can be mounted
+// in another server instead of calling Start(). StartBackground() must still be called
+// before serving requests.
+func (d *Daemon) Handler() http.Handler {
+	return d.handler
+}
+
+// MetricsRegistry returns the registry containing the metrics of the daemon
+func (d *Daemon) MetricsRegistry() *prometheus.Registry {
+	return d.metricsRegistry
+}
+
+// RateLimiter returns the rate limiter of the daemon, or nil when requests aren't rate limited
+func (d *Daemon) RateLimiter() *middleware.RateLimiter {
+	return d.rateLimiter
+}
+
+// Logger returns the logger used by the daemon
+func (d *Daemon) Logger() *log.Entry {
+	return d.logger
+}
+
+// SetLogLevel changes the level of the daemon logs, it is safe to call it while requests are being served.
+// The level of Config.Logger isn't changed.
+func (d *Daemon) SetLogLevel(level logrus.Level) {
+	d.logger.SetLevel(level)
+}
@@ -0,0 +1,182 @@
+package daemon
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/jhttp"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/logging"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/methods"
+)
+
+func TestDaemon(t *testing.T) {
+	core := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/info", r.URL.Path)
+		_, _ = w.Write([]byte(`{"info": {"build": "v19.5.0", "protocol_version": 20, "ledger": {"version": 20}}}`))
+	}))
+	defer core.Close()
+
+	d, err := NewDaemon(Config{
+		Endpoint:             "localhost:0",
+		StellarCoreURL:       core.URL,
+		NetworkPassphrase:    "Standalone Network ; February 2017",
+		TxConcurrency:        1,
+		TxQueueSize:          1,
+		PreflightConcurrency: 1,
+		PreflightQueueSize:   1,
+	})
+	require.NoError(t, err)
+	assert.Nil(t, d.Addr())
+	assert.Nil(t, d.RateLimiter())
+	assert.NotNil(t, d.MetricsRegistry())
+	require.NoError(t, d.Start())
+	defer d.Close()
+
+	ch := jhttp.NewChannel("http://"+d.Addr().String(), nil)
+	client := jrpc2.NewClient(ch, nil)
+	defer client.Close()
+	var result methods.GetVersionInfoResponse
+	require.NoError(t, client.CallResult(context.Background(), "getVersionInfo", nil, &result))
+	assert.Equal(t, "v19.5.0", result.CoreVersion)
+	assert.Equal(t, 20, result.CoreMaxProtocolVersion)
+
+	require.NoError(t, d.Close())
+	_, err = http.Get("http://" + d.Addr().String())
+	assert.Error(t, err)
+}
+
+func TestDaemonMultipleEndpoints(t *testing.T) {
+	core := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"info": {"build": "v19.5.0", "protocol_version": 20, "ledger": {"version": 20}}}`))
+	}))
+	defer core.Close()
+
+	d, err := NewDaemon(Config{
+		Endpoint:             "127.0.0.1:0",
+		AdditionalEndpoints:  []string{"localhost:0"},
+		InternalEndpoints:    []string{"127.0.0.1:0"},
+		StellarCoreURL:       core.URL,
+		NetworkPassphrase:    "Standalone Network ; February 2017",
+		TxConcurrency:        1,
+		TxQueueSize:          1,
+		PreflightConcurrency: 1,
+		PreflightQueueSize:   1,
+		MethodRateLimits:     map[string]float64{"getVersionInfo": 0.001},
+	})
+	require.NoError(t, err)
+	require.NoError(t, d.Start())
+	defer d.Close()
+
+	addrs := d.Addrs()
+	require.Len(t, addrs, 3)
+	assert.Equal(t, d.Addr(), addrs[0])
+	call := func(addr string) error {
+		client := jrpc2.NewClient(jhttp.NewChannel("http://"+addr, nil), nil)
+		defer client.Close()
+		_, err := client.Call(context.Background(), "getVersionInfo", nil)
+		return err
+	}
+	// the public endpoints share the rate limit
+	assert.NoError(t, call(addrs[0].String()))
+	assert.Error(t, call(addrs[1].String()))
+	// which doesn't apply to the internal endpoint
+	assert.NoError(t, call(addrs[2].String()))
+	assert.NoError(t, call(addrs[2].String()))
+
+	require.NoError(t, d.Close())
+	for _, addr := range addrs {
+		_, err = http.Get("http://" + addr.String())
+		assert.Error(t, err)
+	}
+}
+
+func TestNewDaemonValidatesTLS(t *testing.T) {
+	_, err := NewDaemon(Config{TLSCertFile: "cert.pem"})
+	assert.EqualError(t, err, "both the tls certificate and key files must be provided to enable TLS")
+}
+
+func TestDaemonWait(t *testing.T) {
+	core := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"info": {"build": "v19.5.0", "protocol_version": 20, "ledger": {"version": 20}}}`))
+	}))
+	defer core.Close()
+
+	parent := log.New()
+	done := parent.StartTest(logrus.InfoLevel)
+	d, err := NewDaemon(Config{
+		Logger:               parent,
+		Endpoint:             "localhost:0",
+		StellarCoreURL:       core.URL,
+		NetworkPassphrase:    "Standalone Network ; February 2017",
+		TxConcurrency:        1,
+		TxQueueSize:          1,
+		PreflightConcurrency: 1,
+		PreflightQueueSize:   1,
+	})
+	require.NoError(t, err)
+	require.NoError(t, d.Start())
+	defer d.Close()
+
+	// the entries of the daemon are logged by the parent logger
+	entries := done()
+	require.NotEmpty(t, entries)
+	assert.Contains(t, entries[0].Message, "Starting Soroban JSON RPC server")
+
+	// the failure of a server is reported instead of exiting
+	require.NoError(t, d.listeners[0].Close())
+	waitErr := make(chan error, 1)
+	go func() {
+		waitErr <- d.Wait()
+	}()
+	select {
+	case err := <-waitErr:
+		assert.ErrorContains(t, err, "could not run server")
+	case <-time.After(5 * time.Second):
+		t.Fatal("server failure was not reported")
+	}
+
+	require.NoError(t, d.Close())
+	assert.NoError(t, d.Wait())
+}
+
+func TestDaemonLogFileLevel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "soroban-rpc.log")
+	parent := log.New()
+	done := parent.StartTest(logrus.TraceLevel)
+	d, err := NewDaemon(Config{
+		Logger:               parent,
+		LogLevel:             logrus.InfoLevel,
+		Logging:              logging.Config{File: path},
+		Endpoint:             "localhost:0",
+		NetworkPassphrase:    "Standalone Network ; February 2017",
+		TxConcurrency:        1,
+		TxQueueSize:          1,
+		PreflightConcurrency: 1,
+		PreflightQueueSize:   1,
+	})
+	require.NoError(t, err)
+	d.Logger().Debug("filtered entry")
+	d.Logger().Info("logged entry")
+	d.SetLogLevel(logrus.DebugLevel)
+	d.Logger().Debug("reloaded entry")
+	require.NoError(t, d.Close())
+
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(contents), "filtered entry")
+	assert.Contains(t, string(contents), "logged entry")
+	assert.Contains(t, string(contents), "reloaded entry")
+	// the entries written to the log file aren't forwarded to the parent logger
+	assert.Empty(t, done())
+}
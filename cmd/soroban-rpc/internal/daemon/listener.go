@@ -0,0 +1,96 @@
+package daemon
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	unixAddressPrefix    = "unix:"
+	systemdAddressPrefix = "systemd:"
+	// systemdFirstFD is the first file descriptor passed by systemd (SD_LISTEN_FDS_START)
+	systemdFirstFD = 3
+)
+
+// listen creates a listener for the given address, which is either:
+//   - a TCP address (e.g. "localhost:8000")
+//   - a Unix domain socket path prefixed with "unix:" (e.g. "unix:/run/soroban-rpc.sock")
+//   - a socket passed by systemd socket activation, prefixed with "systemd:" and followed
+//     by either the FileDescriptorName of the socket unit or its index (e.g. "systemd:0")
+func listen(address string) (net.Listener, error) {
+	switch {
+	case strings.HasPrefix(address, unixAddressPrefix):
+		path := strings.TrimPrefix(address, unixAddressPrefix)
+		// remove the socket left behind by a previous process which wasn't shut down cleanly
+		if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+			if err := os.Remove(path); err != nil {
+				return nil, fmt.Errorf("could not remove stale socket %s: %v", path, err)
+			}
+		}
+		return net.Listen("unix", path)
+	case strings.HasPrefix(address, systemdAddressPrefix):
+		return systemdListener(strings.TrimPrefix(address, systemdAddressPrefix))
+	default:
+		return net.Listen("tcp", address)
+	}
+}
+
+// listenAll listens on all the addresses, closing the listeners already opened if one fails
+func listenAll(addresses []string) ([]net.Listener, error) {
+	var listeners []net.Listener
+	for _, address := range addresses {
+		listener, err := listen(address)
+		if err != nil {
+			closeAll(listeners)
+			return nil, fmt.Errorf("could not listen on %s: %v", address, err)
+		}
+		listeners = append(listeners, listener)
+	}
+	return listeners, nil
+}
+
+func closeAll(listeners []net.Listener) {
+	for _, listener := range listeners {
+		listener.Close()
+	}
+}
+
+// systemdListener returns the listener of a socket passed by systemd, following the
+// sd_listen_fds(3) protocol (LISTEN_PID, LISTEN_FDS and LISTEN_FDNAMES environment variables).
+func systemdListener(name string) (net.Listener, error) {
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return nil, fmt.Errorf("no sockets were passed by systemd")
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return nil, fmt.Errorf("no sockets were passed by systemd")
+	}
+	index := -1
+	if names := os.Getenv("LISTEN_FDNAMES"); names != "" {
+		for i, fdName := range strings.Split(names, ":") {
+			if fdName == name {
+				index = i
+				break
+			}
+		}
+	}
+	if index < 0 {
+		if index, err = strconv.Atoi(name); err != nil {
+			return nil, fmt.Errorf("systemd did not pass a socket named %q", name)
+		}
+	}
+	if index < 0 || index >= count {
+		return nil, fmt.Errorf("invalid systemd socket index %d, %d sockets were passed", index, count)
+	}
+
+	file := os.NewFile(uintptr(systemdFirstFD+index), name)
+	defer file.Close()
+	listener, err := net.FileListener(file)
+	if err != nil {
+		return nil, fmt.Errorf("systemd socket %q is not a stream socket: %v", name, err)
+	}
+	return listener, nil
+}
@@ -0,0 +1,60 @@
+package daemon
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "soroban-rpc.sock")
+	listener, err := listen("unix:" + path)
+	require.NoError(t, err)
+	assert.Equal(t, "unix", listener.Addr().Network())
+
+	conn, err := net.Dial("unix", path)
+	require.NoError(t, err)
+	conn.Close()
+	require.NoError(t, listener.Close())
+
+	// a socket left behind by a previous process is replaced
+	stale, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+	_, err = os.Stat(path)
+	require.NoError(t, err)
+	listener, err = listen("unix:" + path)
+	require.NoError(t, err)
+	listener.Close()
+
+	// other files are never removed
+	regular := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(regular, nil, 0o600))
+	_, err = listen("unix:" + regular)
+	assert.Error(t, err)
+	_, err = os.Stat(regular)
+	assert.NoError(t, err)
+}
+
+func TestListenSystemd(t *testing.T) {
+	_, err := listen("systemd:0")
+	assert.EqualError(t, err, "no sockets were passed by systemd")
+
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("LISTEN_FDS", "2")
+	t.Setenv("LISTEN_FDNAMES", "rpc:admin")
+	_, err = listen("systemd:metrics")
+	assert.EqualError(t, err, `systemd did not pass a socket named "metrics"`)
+	_, err = listen("systemd:2")
+	assert.EqualError(t, err, "invalid systemd socket index 2, 2 sockets were passed")
+
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	_, err = listen("systemd:rpc")
+	assert.EqualError(t, err, "no sockets were passed by systemd")
+}
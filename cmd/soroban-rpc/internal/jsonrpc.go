@@ -2,28 +2,46 @@ package internal
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/code"
 	"github.com/creachadair/jrpc2/handler"
-	"github.com/creachadair/jrpc2/jhttp"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/cors"
+	"go.opentelemetry.io/otel/attribute"
 
+	"github.com/stellar/go/clients/horizonclient"
 	"github.com/stellar/go/clients/stellarcore"
 	"github.com/stellar/go/support/log"
 	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/methods"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/metrics"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/middleware"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/rpcerror"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/tracing"
 )
 
 // Handler is the HTTP handler which serves the Soroban JSON RPC responses
 type Handler struct {
-	bridge           jhttp.Bridge
+	bridge           middleware.Bridge
 	logger           *log.Entry
 	transactionProxy *methods.TransactionProxy
+	ledgerRange      *methods.LedgerRangeTracker
 	http.Handler
+	// Internal serves the same requests as Handler, bypassing the rate limiter, the API keys
+	// and the IP filter. It must only be exposed to trusted clients.
+	Internal http.Handler
 }
 
 // Start spawns the background workers necessary for the JSON RPC handlers.
 func (h Handler) Start() {
 	h.transactionProxy.Start(context.Background())
+	h.ledgerRange.Start(context.Background())
 }
 
 // Close closes all the resources held by the Handler instances.
@@ -33,27 +51,279 @@ func (h Handler) Close() {
 		h.logger.WithError(err).Warn("could not close bridge")
 	}
 	h.transactionProxy.Close()
+	h.ledgerRange.Close()
 }
 
 type HandlerParams struct {
-	AccountStore     methods.AccountStore
-	TransactionProxy *methods.TransactionProxy
-	CoreClient       *stellarcore.Client
-	Logger           *log.Entry
+	AccountStore            methods.AccountStore
+	TransactionProxy        *methods.TransactionProxy
+	PreflightQueue          *methods.PreflightQueue
+	PreflightBudget         methods.PreflightBudget
+	HorizonClient           *horizonclient.Client
+	CoreClient              *stellarcore.Client
+	Logger                  *log.Entry
+	NetworkPassphrase       string
+	MetricsRegistry         *prometheus.Registry
+	MetricsHistograms       metrics.HistogramConfig
+	MaxHealthyLedgerLatency time.Duration
+	// MaxTransactionStatusWait is how long getTransactionStatus requests can wait for pending transactions
+	MaxTransactionStatusWait time.Duration
+	// CORSAllowedOrigins are the origins browsers are allowed to send requests from.
+	// All origins are allowed when empty.
+	CORSAllowedOrigins []string
+	// MaxBatchSize is the maximum number of requests in a JSON RPC batch. Zero disables the limit.
+	MaxBatchSize int
+	// MaxRequestConcurrency is the maximum number of requests (including the requests
+	// of a batch) handled concurrently. Zero defaults to the number of CPUs.
+	MaxRequestConcurrency int
+	// MaxRequestSize is the maximum size (in bytes) of the HTTP request bodies. Zero disables the limit.
+	MaxRequestSize int64
+	// MaxResponseSize is the maximum size (in bytes) of the result of a method. Zero disables the limit.
+	MaxResponseSize int
+	// MethodTimeouts are the deadlines of the methods, after which their handlers are canceled
+	MethodTimeouts map[string]time.Duration
+	// EnabledMethods, when not empty, are the only methods which can be called
+	EnabledMethods []string
+	// DisabledMethods are methods which can't be called, they return a MethodNotAllowed error
+	DisabledMethods []string
+	// ReadOnly disables the writeMethods, e.g. for public data endpoints which must not
+	// proxy transaction submissions
+	ReadOnly bool
+	// RateLimiter is optional, when nil requests are not rate limited
+	RateLimiter *middleware.RateLimiter
+	// APIKeyAuth is optional, when nil requests don't require an API key
+	APIKeyAuth *middleware.APIKeyAuth
+	// IPFilter is optional, when nil the client IP is the remote address of the connection
+	// and clients aren't filtered
+	IPFilter *middleware.IPFilter
+	// RequestLogger is optional, when nil requests are not logged
+	RequestLogger *middleware.RequestLogger
+	// ResponseCacheSize is the maximum number of results of the cachedMethods kept in memory.
+	// Zero disables the cache.
+	ResponseCacheSize int
+	// ResponseCacheTTL is the maximum age of the cached results
+	ResponseCacheTTL time.Duration
+	// GetMethods are the read-only methods (among getMethodParams) which are also served
+	// over HTTP GET, e.g. GET /getLatestLedger?window=10
+	GetMethods []string
+	// GetCacheMaxAge is how long HTTP caches may reuse the responses of GetMethods (only private caches
+	// when APIKeyAuth is set). Zero disables caching.
+	GetCacheMaxAge time.Duration
+	// ResponseCompression are the encodings (gzip, zstd) the responses can be compressed with,
+	// in order of preference. Responses aren't compressed when empty.
+	ResponseCompression []string
+	// ResponseCompressionMinSize is the minimum size (in bytes) of the compressed responses
+	ResponseCompressionMinSize int
+	// ContractStatsSize is the maximum number of contracts whose usage is tracked (and reported by
+	// getContractStats). Zero disables the tracking.
+	ContractStatsSize int
+	// ContractStatsMetricsTop is the number of most invoked contracts whose usage is exported in the
+	// metrics, bounding their cardinality
+	ContractStatsMetricsTop int
+}
+
+// cachedMethods are the idempotent methods whose results can be cached until a new ledger is closed
+var cachedMethods = []string{"getLedgerEntries", "getNetwork", "getTokenBalance", "simulateTransaction"}
+
+// cacheKeys derive the cache keys of the cachedMethods whose results can be shared between different params
+var cacheKeys = map[string]middleware.CacheKeyFunc{
+	"simulateTransaction": methods.SimulateTransactionCacheKey,
+}
+
+// contractStatsMethods are the methods whose invocations are recorded in the contract stats
+var contractStatsMethods = []string{"simulateTransaction", "sendTransaction"}
+
+// ledgerRangeMaxAge is how often the ledger range added to the results of the read methods is refreshed
+const ledgerRangeMaxAge = time.Second
+
+// writeMethods are the methods which change the state of the network, they are disabled in read-only mode
+var writeMethods = []string{"sendTransaction"}
+
+// getMethodParams are the read-only methods which can be served over HTTP GET, along with the
+// type of their request (nil for methods without parameters)
+var getMethodParams = map[string]interface{}{
+	"getHealth":            nil,
+	"getAccount":           methods.AccountRequest{},
+	"getAccountInfo":       methods.GetAccountInfoRequest{},
+	"getTransactionStatus": methods.GetTransactionStatusRequest{},
+	"getContractData":      methods.GetContractDataRequest{},
+	"getLedgerEntries":     methods.GetLedgerEntriesRequest{},
+	"getTokenBalance":      methods.GetTokenBalanceRequest{},
+	"getFeeStats":          nil,
+	"getVersionInfo":       nil,
+	"getLatestLedger":      methods.GetLatestLedgerRequest{},
+	"getLedgers":           methods.GetLedgersRequest{},
+	"getNetwork":           nil,
+	"getContractStats":     methods.GetContractStatsRequest{},
 }
 
 // NewJSONRPCHandler constructs a Handler instance
 func NewJSONRPCHandler(params HandlerParams) (Handler, error) {
-	bridge := jhttp.NewBridge(handler.Map{
-		"getHealth":            methods.NewHealthCheck(),
+	getMethods := make(map[string]middleware.QueryParamsFunc, len(params.GetMethods))
+	for _, method := range params.GetMethods {
+		request, ok := getMethodParams[method]
+		if !ok {
+			return Handler{}, fmt.Errorf("method %q can't be served over HTTP GET", method)
+		}
+		getMethods[method] = func(query url.Values) (json.RawMessage, error) {
+			return methods.QueryParams(request, query)
+		}
+	}
+	healthChecker := methods.HealthChecker{
+		Logger:            params.Logger,
+		HorizonClient:     params.HorizonClient,
+		CoreClient:        params.CoreClient,
+		MaxLedgerLatency:  params.MaxHealthyLedgerLatency,
+		NetworkPassphrase: params.NetworkPassphrase,
+	}
+	var contractStats *methods.ContractStats
+	if params.ContractStatsSize > 0 {
+		contractStats = methods.NewContractStats(params.ContractStatsSize)
+	}
+	methodHandlers := handler.Map{
+		"getHealth":            methods.NewHealthCheck(healthChecker),
 		"getAccount":           methods.NewAccountHandler(params.AccountStore),
-		"getTransactionStatus": methods.NewGetTransactionStatusHandler(params.TransactionProxy),
+		"getAccountInfo":       methods.NewGetAccountInfoHandler(params.Logger, params.CoreClient),
+		"getTransactionStatus": methods.NewGetTransactionStatusHandler(params.TransactionProxy, params.MaxTransactionStatusWait),
 		"sendTransaction":      methods.NewSendTransactionHandler(params.TransactionProxy),
-		"simulateTransaction":  methods.NewSimulateTransactionHandler(params.Logger, params.CoreClient),
+		"simulateTransaction":  methods.NewSimulateTransactionHandler(params.Logger, params.CoreClient, params.PreflightQueue, params.PreflightBudget),
+		"validateTransaction":  methods.NewValidateTransactionHandler(params.Logger, params.HorizonClient, params.NetworkPassphrase),
 		"getContractData":      methods.NewGetContractDataHandler(params.Logger, params.CoreClient),
-	}, nil)
+		"getLedgerEntries":     methods.NewGetLedgerEntriesHandler(params.Logger, params.CoreClient),
+		"getTokenBalance":      methods.NewGetTokenBalanceHandler(params.Logger, params.CoreClient, params.PreflightQueue),
+		"getFeeStats":          methods.NewGetFeeStatsHandler(params.Logger, params.HorizonClient),
+		"getVersionInfo":       methods.NewGetVersionInfoHandler(params.Logger, params.CoreClient),
+		"getLatestLedger":      methods.NewGetLatestLedgerHandler(params.Logger, params.HorizonClient),
+		"getLedgers":           methods.NewGetLedgersHandler(params.Logger, params.HorizonClient),
+		"getNetwork":           methods.NewGetNetworkHandler(params.Logger, params.NetworkPassphrase, params.HorizonClient, params.CoreClient),
+		"getContractStats":     methods.NewGetContractStatsHandler(contractStats),
+	}
+	if contractStats != nil {
+		registerContractStatsMetrics(params.MetricsRegistry, contractStats, params.ContractStatsMetricsTop)
+		// the usage is recorded before the cache, so that only the simulations actually run are counted
+		for _, method := range contractStatsMethods {
+			methodHandlers[method] = methods.RecordContractUsage(contractStats, method, methodHandlers[method])
+		}
+	}
+	if err := disableMethods(methodHandlers, params.EnabledMethods, params.DisabledMethods, params.ReadOnly); err != nil {
+		return Handler{}, err
+	}
+	for method, timeout := range params.MethodTimeouts {
+		h, ok := methodHandlers[method]
+		if !ok {
+			return Handler{}, fmt.Errorf("cannot set the timeout of unknown method %q", method)
+		}
+		methodHandlers[method] = middleware.Deadline(method, timeout, h)
+	}
+	// the ledger range is both the source of the ledger stamped on the results and of the ledger
+	// keying the cached results, so that results are never stamped with a later ledger
+	ledgerRange := &methods.LedgerRangeTracker{
+		HorizonClient: params.HorizonClient,
+		CoreClient:    params.CoreClient,
+		MaxAge:        ledgerRangeMaxAge,
+		Logger:        params.Logger,
+	}
+	if params.ResponseCacheSize > 0 {
+		cache := middleware.NewResponseCache(params.ResponseCacheSize, params.ResponseCacheTTL, func(ctx context.Context) (int64, error) {
+			current, err := ledgerRange.Get(ctx)
+			if err != nil {
+				return 0, err
+			}
+			return current.LatestLedger, nil
+		})
+		registerCacheMetrics(params.MetricsRegistry, cache)
+		for _, method := range cachedMethods {
+			methodHandlers[method] = cache.WrapWithKey(method, cacheKeys[method], methodHandlers[method])
+		}
+	}
+	stamp := func(ctx context.Context) (interface{}, error) {
+		return ledgerRange.Get(ctx)
+	}
+	for method, h := range methodHandlers {
+		if !isWriteMethod(method) {
+			methodHandlers[method] = middleware.StampLedger(params.Logger, method, stamp, h)
+		}
+	}
+	if params.MaxResponseSize > 0 {
+		for method, h := range methodHandlers {
+			methodHandlers[method] = middleware.ResponseSizeLimit(method, params.MaxResponseSize, h)
+		}
+	}
+	if params.RequestLogger != nil {
+		for method, h := range methodHandlers {
+			methodHandlers[method] = params.RequestLogger.Wrap(method, h)
+		}
+	}
+	bridge := middleware.NewBridge(instrumentHandlers(params.MetricsRegistry, params.MetricsHistograms, methodHandlers), &jrpc2.ServerOptions{
+		Concurrency: params.MaxRequestConcurrency,
+	})
+	registerQueueMetrics(params.MetricsRegistry, params.PreflightQueue)
+
+	mux := http.NewServeMux()
+	mux.Handle("/health", healthChecker)
+	mux.Handle("/", bridge)
+	var httpHandler http.Handler = mux
+	if params.MaxBatchSize > 0 {
+		httpHandler = middleware.BatchSizeLimit(params.Logger, params.MaxBatchSize, httpHandler)
+	}
+	internalHandler := httpHandler
+	if params.RateLimiter != nil {
+		rateLimitCounter := prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metrics.PrometheusNamespace,
+			Subsystem: "network",
+			Name:      "rate_limit_exceeded_total",
+			Help:      "number of JSON RPC requests rejected by the rate limiter",
+		}, []string{"method", "limit"})
+		params.MetricsRegistry.MustRegister(rateLimitCounter)
+		params.RateLimiter.OnLimitExceeded = func(method, limitType string) {
+			rateLimitCounter.With(prometheus.Labels{"method": method, "limit": limitType}).Inc()
+		}
+		httpHandler = params.RateLimiter.Middleware(params.Logger, httpHandler)
+	}
+	if params.APIKeyAuth != nil {
+		registerAPIKeyMetrics(params.MetricsRegistry, params.APIKeyAuth, methodHandlers)
+		httpHandler = params.APIKeyAuth.Middleware(params.Logger, httpHandler)
+	}
+	var compressor *middleware.ResponseCompressor
+	if len(params.ResponseCompression) > 0 {
+		var err error
+		compressor, err = middleware.NewResponseCompressor(params.ResponseCompression, params.ResponseCompressionMinSize)
+		if err != nil {
+			return Handler{}, fmt.Errorf("invalid response compression: %v", err)
+		}
+		registerCompressionMetrics(params.MetricsRegistry, compressor)
+	}
+	// the size limit, the GET translation and the compression are shared with the internal handler
+	wrapTransport := func(h http.Handler, authenticated bool) http.Handler {
+		if params.MaxRequestSize > 0 {
+			h = middleware.RequestSizeLimit(params.Logger, params.MaxRequestSize, h)
+		}
+		if len(getMethods) > 0 {
+			// GET requests must be translated before reaching the other middlewares, which only handle POST requests
+			h = middleware.HTTPGet(params.Logger, getMethods, params.GetCacheMaxAge, authenticated, h)
+		}
+		if compressor != nil {
+			h = compressor.Middleware(params.Logger, h)
+		}
+		return h
+	}
+	httpHandler = wrapTransport(httpHandler, params.APIKeyAuth != nil)
+	// API keys are not required on the internal endpoints
+	internalHandler = wrapTransport(internalHandler, false)
+	if params.IPFilter != nil {
+		rejectedCounter := prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metrics.PrometheusNamespace,
+			Subsystem: "network",
+			Name:      "ip_filter_rejected_total",
+			Help:      "number of HTTP requests rejected because of the IP of the client",
+		})
+		params.MetricsRegistry.MustRegister(rejectedCounter)
+		params.IPFilter.OnRejected = rejectedCounter.Inc
+		// the client IP is resolved before any other middleware uses it
+		httpHandler = params.IPFilter.Middleware(params.Logger, httpHandler)
+	}
 	corsMiddleware := cors.New(cors.Options{
-		AllowedOrigins: []string{"*"},
+		AllowedOrigins: params.CORSAllowedOrigins,
 		AllowedHeaders: []string{"*"},
 		AllowedMethods: []string{"GET", "PUT", "POST", "PATCH", "DELETE", "HEAD", "OPTIONS"},
 	})
@@ -62,6 +332,269 @@ func NewJSONRPCHandler(params HandlerParams) (Handler, error) {
 		bridge:           bridge,
 		logger:           params.Logger,
 		transactionProxy: params.TransactionProxy,
-		Handler:          corsMiddleware.Handler(bridge),
+		ledgerRange:      ledgerRange,
+		Handler:          corsMiddleware.Handler(httpHandler),
+		Internal:         corsMiddleware.Handler(internalHandler),
 	}, nil
 }
+
+func isWriteMethod(method string) bool {
+	for _, m := range writeMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// disableMethods replaces the handlers of the methods which aren't enabled, are disabled or
+// are writeMethods in read-only mode, so that calling them returns a MethodNotAllowed error
+func disableMethods(methodHandlers handler.Map, enabled, disabled []string, readOnly bool) error {
+	toDisable := map[string]bool{}
+	if len(enabled) > 0 {
+		for method := range methodHandlers {
+			toDisable[method] = true
+		}
+		for _, method := range enabled {
+			if _, ok := methodHandlers[method]; !ok {
+				return fmt.Errorf("cannot enable unknown method %q", method)
+			}
+			delete(toDisable, method)
+		}
+	}
+	for _, method := range disabled {
+		if _, ok := methodHandlers[method]; !ok {
+			return fmt.Errorf("cannot disable unknown method %q", method)
+		}
+		toDisable[method] = true
+	}
+	if readOnly {
+		for _, method := range writeMethods {
+			toDisable[method] = true
+		}
+	}
+	for method := range toDisable {
+		err := &jrpc2.Error{
+			Code:    rpcerror.MethodNotAllowed,
+			Message: fmt.Sprintf("method %s is disabled on this server", method),
+		}
+		methodHandlers[method] = handler.Func(func(context.Context, *jrpc2.Request) (interface{}, error) {
+			return nil, err
+		})
+	}
+	return nil
+}
+
+// instrumentHandlers decorates the method handlers so that they are traced and record the
+// request duration, the request payload size and the error codes of every method. The durations
+// of traced requests are linked to their trace through exemplars.
+func instrumentHandlers(registry *prometheus.Registry, histograms metrics.HistogramConfig, methodHandlers handler.Map) handler.Map {
+	requestDuration := prometheus.NewHistogramVec(histograms.Apply(prometheus.HistogramOpts{
+		Namespace: metrics.PrometheusNamespace,
+		Subsystem: "json_rpc",
+		Name:      "request_duration_seconds",
+		Help:      "JSON RPC request duration",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 15),
+	}), []string{"method"})
+	requestSize := prometheus.NewHistogramVec(histograms.Apply(prometheus.HistogramOpts{
+		Namespace: metrics.PrometheusNamespace,
+		Subsystem: "json_rpc",
+		Name:      "request_params_size_bytes",
+		Help:      "size of the parameters of JSON RPC requests",
+		Buckets:   prometheus.ExponentialBuckets(64, 4, 8),
+	}), []string{"method"})
+	requestErrors := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.PrometheusNamespace,
+		Subsystem: "json_rpc",
+		Name:      "request_errors_total",
+		Help:      "number of JSON RPC requests which resulted in an error, by error code",
+	}, []string{"method", "code"})
+	registry.MustRegister(requestDuration, requestSize, requestErrors)
+
+	instrumented := make(handler.Map, len(methodHandlers))
+	for method, h := range methodHandlers {
+		method, h := method, h
+		instrumented[method] = handler.Func(func(ctx context.Context, req *jrpc2.Request) (interface{}, error) {
+			startTime := time.Now()
+			ctx, span := tracing.StartSpan(ctx, "jsonrpc."+method, attribute.String("rpc.method", method))
+			result, err := h.Handle(ctx, req)
+			tracing.EndSpan(span, err)
+			labels := prometheus.Labels{"method": method}
+			metrics.Observe(ctx, requestDuration.With(labels), time.Since(startTime).Seconds())
+			requestSize.With(labels).Observe(float64(len(req.ParamString())))
+			if err != nil {
+				errorCode := strconv.Itoa(int(code.FromError(err)))
+				requestErrors.With(prometheus.Labels{"method": method, "code": errorCode}).Inc()
+			}
+			return result, err
+		})
+	}
+	return instrumented
+}
+
+// registerCacheMetrics exposes the hit ratio and the size of the response cache
+func registerCacheMetrics(registry *prometheus.Registry, cache *middleware.ResponseCache) {
+	hits := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.PrometheusNamespace,
+		Subsystem: "response_cache",
+		Name:      "hits_total",
+		Help:      "number of JSON RPC calls served from the response cache",
+	}, []string{"method"})
+	misses := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.PrometheusNamespace,
+		Subsystem: "response_cache",
+		Name:      "misses_total",
+		Help:      "number of JSON RPC calls of cached methods which were not found in the response cache",
+	}, []string{"method"})
+	entries := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metrics.PrometheusNamespace,
+		Subsystem: "response_cache",
+		Name:      "entries",
+		Help:      "number of results in the response cache",
+	}, func() float64 {
+		return float64(cache.Len())
+	})
+	registry.MustRegister(hits, misses, entries)
+	cache.OnHit = func(method string) {
+		hits.With(prometheus.Labels{"method": method}).Inc()
+	}
+	cache.OnMiss = func(method string) {
+		misses.With(prometheus.Labels{"method": method}).Inc()
+	}
+}
+
+// registerAPIKeyMetrics counts the calls of every API key, so that operators can bill their usage
+func registerAPIKeyMetrics(registry *prometheus.Registry, auth *middleware.APIKeyAuth, methodHandlers handler.Map) {
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.PrometheusNamespace,
+		Subsystem: "api_key",
+		Name:      "requests_total",
+		Help:      "number of JSON RPC calls accepted, by api key name and method",
+	}, []string{"api_key", "method"})
+	rejected := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.PrometheusNamespace,
+		Subsystem: "api_key",
+		Name:      "rejected_total",
+		Help:      "number of JSON RPC calls rejected, by api key name (empty if the key was invalid), method and reason",
+	}, []string{"api_key", "method", "reason"})
+	registry.MustRegister(requests, rejected)
+	// avoid creating a time series for every method name sent by clients
+	methodLabel := func(method string) string {
+		if _, ok := methodHandlers[method]; ok {
+			return method
+		}
+		return "unknown"
+	}
+	auth.OnRequest = func(name, method string) {
+		requests.With(prometheus.Labels{"api_key": name, "method": methodLabel(method)}).Inc()
+	}
+	auth.OnRejected = func(name, method, reason string) {
+		rejected.With(prometheus.Labels{"api_key": name, "method": methodLabel(method), "reason": reason}).Inc()
+	}
+}
+
+// registerCompressionMetrics exposes the bandwidth saved by compressing the responses
+func registerCompressionMetrics(registry *prometheus.Registry, compressor *middleware.ResponseCompressor) {
+	responses := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.PrometheusNamespace,
+		Subsystem: "response_compression",
+		Name:      "responses_total",
+		Help:      "number of compressed HTTP responses, by encoding",
+	}, []string{"encoding"})
+	uncompressed := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.PrometheusNamespace,
+		Subsystem: "response_compression",
+		Name:      "uncompressed_bytes_total",
+		Help:      "size of the compressed HTTP responses before compression, by encoding",
+	}, []string{"encoding"})
+	saved := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.PrometheusNamespace,
+		Subsystem: "response_compression",
+		Name:      "saved_bytes_total",
+		Help:      "number of bytes saved by compressing the HTTP responses, by encoding",
+	}, []string{"encoding"})
+	registry.MustRegister(responses, uncompressed, saved)
+	compressor.OnCompressed = func(encoding string, uncompressedBytes, compressedBytes int) {
+		labels := prometheus.Labels{"encoding": encoding}
+		responses.With(labels).Inc()
+		uncompressed.With(labels).Add(float64(uncompressedBytes))
+		saved.With(labels).Add(float64(uncompressedBytes - compressedBytes))
+	}
+}
+
+func registerQueueMetrics(registry *prometheus.Registry, preflightQueue *methods.PreflightQueue) {
+	registry.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metrics.PrometheusNamespace,
+			Subsystem: "preflight",
+			Name:      "queue_length",
+			Help:      "number of simulateTransaction requests waiting for a preflight worker",
+		}, func() float64 { return float64(preflightQueue.QueueLength()) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: metrics.PrometheusNamespace,
+			Subsystem: "preflight",
+			Name:      "rejected_total",
+			Help:      "number of simulateTransaction requests rejected because the preflight queue was full or timed out",
+		}, func() float64 { return float64(preflightQueue.Rejected()) }),
+	)
+}
+
+// contractStatsCollector exports the usage of the most invoked contracts. Only the top contracts
+// are exported (as gauges, since a contract can leave the top) to bound the number of time series,
+// the usage of all the contracts is exported as counters without contract label.
+type contractStatsCollector struct {
+	stats            *methods.ContractStats
+	top              int
+	topInvocations   *prometheus.Desc
+	topFees          *prometheus.Desc
+	invocationsTotal *prometheus.Desc
+	feesTotal        *prometheus.Desc
+	tracked          *prometheus.Desc
+	evicted          *prometheus.Desc
+}
+
+func (c contractStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.topInvocations
+	ch <- c.topFees
+	ch <- c.invocationsTotal
+	ch <- c.feesTotal
+	ch <- c.tracked
+	ch <- c.evicted
+}
+
+func (c contractStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, usage := range c.stats.Top(c.top) {
+		ch <- prometheus.MustNewConstMetric(c.topInvocations, prometheus.GaugeValue, float64(usage.Simulations), usage.ContractID, "simulateTransaction")
+		ch <- prometheus.MustNewConstMetric(c.topInvocations, prometheus.GaugeValue, float64(usage.Submissions), usage.ContractID, "sendTransaction")
+		ch <- prometheus.MustNewConstMetric(c.topFees, prometheus.GaugeValue, float64(usage.FeeTotal), usage.ContractID)
+	}
+	totals := c.stats.Totals()
+	ch <- prometheus.MustNewConstMetric(c.invocationsTotal, prometheus.CounterValue, float64(totals.Simulations), "simulateTransaction")
+	ch <- prometheus.MustNewConstMetric(c.invocationsTotal, prometheus.CounterValue, float64(totals.Submissions), "sendTransaction")
+	ch <- prometheus.MustNewConstMetric(c.feesTotal, prometheus.CounterValue, float64(totals.FeeTotal))
+	ch <- prometheus.MustNewConstMetric(c.tracked, prometheus.GaugeValue, float64(c.stats.Tracked()))
+	ch <- prometheus.MustNewConstMetric(c.evicted, prometheus.CounterValue, float64(c.stats.Evicted()))
+}
+
+// registerContractStatsMetrics exposes the usage of the top most invoked contracts
+func registerContractStatsMetrics(registry *prometheus.Registry, stats *methods.ContractStats, top int) {
+	name := func(name string) string {
+		return prometheus.BuildFQName(metrics.PrometheusNamespace, "contract_stats", name)
+	}
+	registry.MustRegister(contractStatsCollector{
+		stats: stats,
+		top:   top,
+		topInvocations: prometheus.NewDesc(name("top_invocations"),
+			"number of invocations of the most invoked contracts, by contract id and method", []string{"contract_id", "method"}, nil),
+		topFees: prometheus.NewDesc(name("top_fees_stroops"),
+			"sum of the fees bid by the submitted transactions invoking the most invoked contracts", []string{"contract_id"}, nil),
+		invocationsTotal: prometheus.NewDesc(name("invocations_total"),
+			"number of contract invocations, by method", []string{"method"}, nil),
+		feesTotal: prometheus.NewDesc(name("fees_stroops_total"),
+			"sum of the fees bid by the submitted transactions invoking contracts, once per invoked contract", nil, nil),
+		tracked: prometheus.NewDesc(name("tracked_contracts"),
+			"number of contracts whose usage is tracked", nil, nil),
+		evicted: prometheus.NewDesc(name("evicted_total"),
+			"number of contracts which stopped being tracked to make room for others", nil, nil),
+	})
+}
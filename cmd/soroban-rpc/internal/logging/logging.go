@@ -0,0 +1,115 @@
+// Package logging configures the format and destinations of the daemon logs
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/stellar/go/support/log"
+)
+
+const timestampFormat = "2006-01-02T15:04:05.000Z07:00"
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Config configures the output of the logs
+type Config struct {
+	// Format is either FormatText (the default) or FormatJSON
+	Format string
+	// File is the path of the file the logs are written to, instead of stderr
+	File string
+	// FileMaxSize is the size (in bytes) after which the log file is rotated, zero disables rotation
+	FileMaxSize int64
+	// FileMaxBackups is the number of rotated log files which are kept
+	FileMaxBackups int
+	// SyslogAddress enables sending the logs to syslog (in addition to the file or stderr): "local"
+	// for the local syslog daemon or a "udp://host:port" or "tcp://host:port" address.
+	SyslogAddress string
+}
+
+// ReplacesOutput reports whether Setup replaces the default output of the logger
+// (the syslog output is added to it)
+func (cfg Config) ReplacesOutput() bool {
+	return cfg.File != "" || cfg.Format == FormatJSON
+}
+
+// Setup configures the output of logger according to the configuration.
+// The returned function releases the log file and syslog connection and must be called on shutdown.
+func Setup(logger *log.Entry, cfg Config) (func() error, error) {
+	var formatter logrus.Formatter
+	switch cfg.Format {
+	case "", FormatText:
+		formatter = &logrus.TextFormatter{FullTimestamp: true, TimestampFormat: timestampFormat}
+	case FormatJSON:
+		formatter = &logrus.JSONFormatter{TimestampFormat: timestampFormat}
+	default:
+		return nil, fmt.Errorf("unknown log format %q, expected %q or %q", cfg.Format, FormatText, FormatJSON)
+	}
+
+	var closers []io.Closer
+	closeAll := func() error {
+		var err error
+		for _, c := range closers {
+			if closeErr := c.Close(); closeErr != nil && err == nil {
+				err = closeErr
+			}
+		}
+		return err
+	}
+	if cfg.SyslogAddress != "" {
+		hook, err := newSyslogHook(cfg.SyslogAddress, formatter)
+		if err != nil {
+			return nil, fmt.Errorf("could not connect to syslog: %v", err)
+		}
+		closers = append(closers, hook)
+		logger.AddHook(hook)
+	}
+	// The default output of the logger is kept unless it needs to be changed, since
+	// the formatter of log.Entry can't be replaced the logs are written by a hook instead
+	if cfg.ReplacesOutput() {
+		var output io.Writer = os.Stderr
+		if cfg.File != "" {
+			file, err := newRotatingFile(cfg.File, cfg.FileMaxSize, cfg.FileMaxBackups)
+			if err != nil {
+				closeAll()
+				return nil, fmt.Errorf("could not open log file: %v", err)
+			}
+			closers = append(closers, file)
+			output = file
+		}
+		logger.SetOutput(io.Discard)
+		logger.AddHook(&outputHook{formatter: formatter, output: output})
+	}
+	return closeAll, nil
+}
+
+// outputHook writes the log entries to output using formatter
+type outputHook struct {
+	lock      sync.Mutex
+	formatter logrus.Formatter
+	output    io.Writer
+}
+
+// Levels implements logrus.Hook
+func (h *outputHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook
+func (h *outputHook) Fire(entry *logrus.Entry) error {
+	line, err := h.formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	_, err = h.output.Write(line)
+	return err
+}
@@ -0,0 +1,70 @@
+package logging
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/support/log"
+)
+
+func TestSetupJSONFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "soroban-rpc.log")
+	logger := log.New()
+	logger.SetLevel(log.InfoLevel)
+	closeLogs, err := Setup(logger, Config{Format: FormatJSON, File: path})
+	require.NoError(t, err)
+	logger.WithField("method", "getHealth").Info("served request")
+	require.NoError(t, closeLogs())
+
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(contents, &entry))
+	assert.Equal(t, "served request", entry["msg"])
+	assert.Equal(t, "info", entry["level"])
+	assert.Equal(t, "getHealth", entry["method"])
+}
+
+func TestSetupInvalidConfig(t *testing.T) {
+	_, err := Setup(log.New(), Config{Format: "xml"})
+	assert.Error(t, err)
+	_, err = Setup(log.New(), Config{SyslogAddress: "http://localhost:514"})
+	assert.Error(t, err)
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "soroban-rpc.log")
+	file, err := newRotatingFile(path, 10, 2)
+	require.NoError(t, err)
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err := file.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.NoError(t, file.Close())
+
+	read := func(path string) string {
+		contents, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return string(contents)
+	}
+	assert.Equal(t, "fourth\n", read(path))
+	assert.Equal(t, "third\n", read(path+".1"))
+	assert.Equal(t, "second\n", read(path+".2"))
+	// only maxBackups rotated files are kept
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err))
+
+	// the size of existing files is taken into account
+	file, err = newRotatingFile(path, 10, 2)
+	require.NoError(t, err)
+	_, err = file.Write([]byte("fifth\n"))
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	assert.Equal(t, "fifth\n", read(path))
+	assert.Equal(t, "fourth\n", read(path+".1"))
+}
@@ -0,0 +1,85 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// rotatingFile is an io.Writer appending to a file which is rotated once it exceeds maxSize,
+// keeping up to maxBackups rotated files (<path>.1 being the most recent one)
+type rotatingFile struct {
+	lock       sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func newRotatingFile(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	f := &rotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// rotate should only be called while the lock is held
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	if f.maxBackups > 0 {
+		for i := f.maxBackups - 1; i > 0; i-- {
+			err := os.Rename(backupPath(f.path, i), backupPath(f.path, i+1))
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if err := os.Rename(f.path, backupPath(f.path, 1)); err != nil {
+			return err
+		}
+	} else if err := os.Remove(f.path); err != nil {
+		return err
+	}
+	return f.open()
+}
+
+func (f *rotatingFile) Close() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.file.Close()
+}
+
+func backupPath(path string, index int) string {
+	return fmt.Sprintf("%s.%d", path, index)
+}
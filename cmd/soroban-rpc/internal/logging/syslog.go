@@ -0,0 +1,63 @@
+//go:build !windows
+
+package logging
+
+import (
+	"fmt"
+	"log/syslog"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// syslogHook sends the log entries to syslog, with the syslog severity of their level
+type syslogHook struct {
+	writer    *syslog.Writer
+	formatter logrus.Formatter
+}
+
+func newSyslogHook(address string, formatter logrus.Formatter) (*syslogHook, error) {
+	var network, raddr string
+	if address != "local" {
+		parts := strings.SplitN(address, "://", 2)
+		if len(parts) != 2 || (parts[0] != "udp" && parts[0] != "tcp") {
+			return nil, fmt.Errorf("invalid syslog address %q, expected \"local\", udp://<host:port> or tcp://<host:port>", address)
+		}
+		network, raddr = parts[0], parts[1]
+	}
+	writer, err := syslog.Dial(network, raddr, syslog.LOG_INFO|syslog.LOG_DAEMON, "soroban-rpc")
+	if err != nil {
+		return nil, err
+	}
+	return &syslogHook{writer: writer, formatter: formatter}, nil
+}
+
+// Levels implements logrus.Hook
+func (h *syslogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook
+func (h *syslogHook) Fire(entry *logrus.Entry) error {
+	line, err := h.formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	message := strings.TrimSuffix(string(line), "\n")
+	switch entry.Level {
+	case logrus.PanicLevel, logrus.FatalLevel:
+		return h.writer.Crit(message)
+	case logrus.ErrorLevel:
+		return h.writer.Err(message)
+	case logrus.WarnLevel:
+		return h.writer.Warning(message)
+	case logrus.InfoLevel:
+		return h.writer.Info(message)
+	default:
+		return h.writer.Debug(message)
+	}
+}
+
+func (h *syslogHook) Close() error {
+	return h.writer.Close()
+}
@@ -0,0 +1,19 @@
+package logging
+
+import (
+	"errors"
+
+	"github.com/sirupsen/logrus"
+)
+
+type syslogHook struct {
+	logrus.Hook
+}
+
+func newSyslogHook(string, logrus.Formatter) (*syslogHook, error) {
+	return nil, errors.New("syslog is not supported on windows")
+}
+
+func (h *syslogHook) Close() error {
+	return nil
+}
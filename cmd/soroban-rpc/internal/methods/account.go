@@ -2,11 +2,14 @@ package methods
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/creachadair/jrpc2"
 	"github.com/creachadair/jrpc2/code"
 	"github.com/creachadair/jrpc2/handler"
 	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/rpcerror"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/tracing"
 )
 
 type AccountInfo struct {
@@ -22,8 +25,10 @@ type AccountStore struct {
 	Client *horizonclient.Client
 }
 
-func (a AccountStore) GetAccount(request AccountRequest) (AccountInfo, error) {
+func (a AccountStore) GetAccount(ctx context.Context, request AccountRequest) (AccountInfo, error) {
+	_, span := tracing.StartSpan(ctx, "horizon.account_detail")
 	details, err := a.Client.AccountDetail(horizonclient.AccountRequest{AccountID: request.Address})
+	tracing.EndSpan(span, err)
 	if err != nil {
 		return AccountInfo{}, err
 	}
@@ -37,11 +42,15 @@ func (a AccountStore) GetAccount(request AccountRequest) (AccountInfo, error) {
 // NewAccountHandler returns a json rpc handler to fetch account info
 func NewAccountHandler(store AccountStore) jrpc2.Handler {
 	return handler.New(func(ctx context.Context, request AccountRequest) (AccountInfo, error) {
-		response, err := store.GetAccount(request)
+		response, err := store.GetAccount(ctx, request)
 		if err != nil {
 			if herr, ok := err.(*horizonclient.Error); ok {
+				errorCode := code.InvalidRequest
+				if herr.Problem.Status == http.StatusNotFound {
+					errorCode = rpcerror.NotFound
+				}
 				return response, (&jrpc2.Error{
-					Code:    code.InvalidRequest,
+					Code:    errorCode,
 					Message: herr.Problem.Title,
 				}).WithData(herr.Problem.Extras)
 			}
@@ -0,0 +1,273 @@
+package methods
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"sync"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/code"
+	"github.com/creachadair/jrpc2/handler"
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/rpcerror"
+)
+
+const defaultContractStatsLimit = 10
+
+// ContractUsage is the load a contract put on the server since it started being tracked
+type ContractUsage struct {
+	ContractID string `json:"contractId"`
+	// Simulations is the number of simulated invocations of the contract
+	Simulations uint64 `json:"simulations,string"`
+	// Submissions is the number of invocations of the contract in the accepted submissions
+	Submissions uint64 `json:"submissions,string"`
+	// FeeTotal is the sum of the fees (in stroops) bid by the submitted transactions invoking
+	// the contract, which bound the fees they were charged
+	FeeTotal int64 `json:"feeTotal,string"`
+}
+
+// Invocations is the number of simulated and submitted invocations of the contract
+func (u ContractUsage) Invocations() uint64 {
+	return u.Simulations + u.Submissions
+}
+
+// ContractStats tracks the usage of the contracts invoked through simulateTransaction and
+// sendTransaction. To bound its memory, at most maxContracts are tracked: once it is full,
+// the least invoked contract is evicted to make room for a new one.
+type ContractStats struct {
+	lock         sync.Mutex
+	maxContracts int
+	contracts    map[string]*ContractUsage
+	totals       ContractUsage
+	evicted      uint64
+}
+
+// NewContractStats creates a ContractStats tracking up to maxContracts contracts
+func NewContractStats(maxContracts int) *ContractStats {
+	return &ContractStats{
+		maxContracts: maxContracts,
+		contracts:    make(map[string]*ContractUsage, maxContracts),
+	}
+}
+
+func (s *ContractStats) usage(contractID string) *ContractUsage {
+	if usage, ok := s.contracts[contractID]; ok {
+		return usage
+	}
+	if len(s.contracts) >= s.maxContracts {
+		var leastInvoked *ContractUsage
+		for _, usage := range s.contracts {
+			if leastInvoked == nil || usage.Invocations() < leastInvoked.Invocations() {
+				leastInvoked = usage
+			}
+		}
+		delete(s.contracts, leastInvoked.ContractID)
+		s.evicted++
+	}
+	usage := &ContractUsage{ContractID: contractID}
+	s.contracts[contractID] = usage
+	return usage
+}
+
+// RecordSimulation records the invocations of a simulated transaction
+func (s *ContractStats) RecordSimulation(envelope xdr.TransactionEnvelope) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, contractID := range invokedContracts(envelope) {
+		s.usage(contractID).Simulations++
+		s.totals.Simulations++
+	}
+}
+
+// RecordSubmission records the invocations of a submitted transaction, the fee of the
+// transaction is added to the fee total of every contract it invokes
+func (s *ContractStats) RecordSubmission(envelope xdr.TransactionEnvelope) {
+	fee := int64(envelope.Fee())
+	if envelope.IsFeeBump() {
+		fee = envelope.FeeBumpFee()
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	contracts := invokedContracts(envelope)
+	for _, contractID := range contracts {
+		s.usage(contractID).Submissions++
+		s.totals.Submissions++
+	}
+	for _, contractID := range uniqueStrings(contracts) {
+		s.usage(contractID).FeeTotal += fee
+		s.totals.FeeTotal += fee
+	}
+}
+
+// Get returns the usage of a contract, if it is tracked
+func (s *ContractStats) Get(contractID string) (ContractUsage, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	usage, ok := s.contracts[contractID]
+	if !ok {
+		return ContractUsage{}, false
+	}
+	return *usage, true
+}
+
+// Top returns the usage of the limit most invoked contracts, from the most invoked one
+func (s *ContractStats) Top(limit int) []ContractUsage {
+	s.lock.Lock()
+	top := make([]ContractUsage, 0, len(s.contracts))
+	for _, usage := range s.contracts {
+		top = append(top, *usage)
+	}
+	s.lock.Unlock()
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Invocations() != top[j].Invocations() {
+			return top[i].Invocations() > top[j].Invocations()
+		}
+		return top[i].ContractID < top[j].ContractID
+	})
+	if len(top) > limit {
+		top = top[:limit]
+	}
+	return top
+}
+
+// Totals returns the usage of all the contracts, including the evicted ones. Its ContractID is empty.
+func (s *ContractStats) Totals() ContractUsage {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.totals
+}
+
+// Tracked returns the number of tracked contracts
+func (s *ContractStats) Tracked() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.contracts)
+}
+
+// Evicted returns the number of contracts evicted to make room for others
+func (s *ContractStats) Evicted() uint64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.evicted
+}
+
+// invokedContracts returns the hex encoded ids of the contracts invoked by the operations of a
+// transaction, once per invocation
+func invokedContracts(envelope xdr.TransactionEnvelope) []string {
+	var contracts []string
+	for _, op := range envelope.Operations() {
+		invoke, ok := op.Body.GetInvokeHostFunctionOp()
+		if !ok || invoke.Function != xdr.HostFunctionHostFnInvokeContract || len(invoke.Parameters) == 0 {
+			continue
+		}
+		obj, ok := invoke.Parameters[0].GetObj()
+		if !ok || obj == nil {
+			continue
+		}
+		if contractID, ok := obj.GetBin(); ok && len(contractID) == len(xdr.Hash{}) {
+			contracts = append(contracts, hex.EncodeToString(contractID))
+		}
+	}
+	return contracts
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var unique []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
+
+// RecordContractUsage decorates the handler of simulateTransaction or sendTransaction so that
+// the contracts invoked by the transactions it handles are recorded in stats. Submissions are
+// only recorded when the transaction is queued, i.e. not for invalid or duplicate transactions.
+func RecordContractUsage(stats *ContractStats, method string, h jrpc2.Handler) jrpc2.Handler {
+	return handler.Func(func(ctx context.Context, req *jrpc2.Request) (interface{}, error) {
+		result, err := h.Handle(ctx, req)
+		if err != nil {
+			return result, err
+		}
+		var request struct {
+			Transaction string `json:"transaction"`
+		}
+		// positional params aren't recorded
+		if json.Unmarshal([]byte(req.ParamString()), &request) != nil {
+			return result, err
+		}
+		var envelope xdr.TransactionEnvelope
+		if xdr.SafeUnmarshalBase64(request.Transaction, &envelope) != nil {
+			return result, err
+		}
+		switch method {
+		case "simulateTransaction":
+			stats.RecordSimulation(envelope)
+		case "sendTransaction":
+			if response, ok := result.(SendTransactionResponse); ok && response.Status == TransactionPending && !response.Duplicate {
+				stats.RecordSubmission(envelope)
+			}
+		}
+		return result, err
+	})
+}
+
+type GetContractStatsRequest struct {
+	// ContractID, when set, restricts the response to the usage of a contract
+	ContractID string `json:"contractId,omitempty"`
+	// Limit is the maximum number of contracts returned, 10 by default
+	Limit int `json:"limit,omitempty"`
+}
+
+type GetContractStatsResponse struct {
+	// Contracts are the most invoked contracts, from the most invoked one
+	Contracts []ContractUsage `json:"contracts"`
+	// Totals is the usage of all the contracts, including the evicted ones
+	Totals           ContractUsage `json:"totals"`
+	TrackedContracts int           `json:"trackedContracts"`
+	// EvictedContracts is the number of contracts which stopped being tracked to make room for others
+	EvictedContracts uint64 `json:"evictedContracts,string"`
+}
+
+// NewGetContractStatsHandler returns a json rpc handler reporting the usage of the contracts.
+// A nil stats means the usage isn't tracked, the method is then not allowed.
+func NewGetContractStatsHandler(stats *ContractStats) jrpc2.Handler {
+	return withOptionalParams(GetContractStatsRequest{}, handler.New(func(ctx context.Context, request GetContractStatsRequest) (GetContractStatsResponse, error) {
+		if stats == nil {
+			return GetContractStatsResponse{}, &jrpc2.Error{
+				Code:    rpcerror.MethodNotAllowed,
+				Message: "contract stats are disabled on this server",
+			}
+		}
+		if request.Limit < 0 {
+			return GetContractStatsResponse{}, &jrpc2.Error{
+				Code:    code.InvalidParams,
+				Message: "limit must not be negative",
+			}
+		}
+		limit := request.Limit
+		if limit == 0 {
+			limit = defaultContractStatsLimit
+		}
+		response := GetContractStatsResponse{
+			Contracts:        []ContractUsage{},
+			Totals:           stats.Totals(),
+			TrackedContracts: stats.Tracked(),
+			EvictedContracts: stats.Evicted(),
+		}
+		if request.ContractID != "" {
+			if usage, ok := stats.Get(request.ContractID); ok {
+				response.Contracts = append(response.Contracts, usage)
+			}
+			return response, nil
+		}
+		response.Contracts = stats.Top(limit)
+		return response, nil
+	}))
+}
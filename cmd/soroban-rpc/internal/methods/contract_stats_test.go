@@ -0,0 +1,64 @@
+package methods
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/go/xdr"
+)
+
+// invokeEnvelope builds a transaction with a fee of 100 invoking the given contracts
+func invokeEnvelope(contractIDs ...xdr.Hash) xdr.TransactionEnvelope {
+	var ops []xdr.Operation
+	for _, contractID := range contractIDs {
+		bin := append([]byte{}, contractID[:]...)
+		obj := &xdr.ScObject{Type: xdr.ScObjectTypeScoBytes, Bin: &bin}
+		ops = append(ops, xdr.Operation{
+			Body: xdr.OperationBody{
+				Type: xdr.OperationTypeInvokeHostFunction,
+				InvokeHostFunctionOp: &xdr.InvokeHostFunctionOp{
+					Function:   xdr.HostFunctionHostFnInvokeContract,
+					Parameters: xdr.ScVec{{Type: xdr.ScValTypeScvObject, Obj: &obj}},
+				},
+			},
+		})
+	}
+	return xdr.TransactionEnvelope{
+		Type: xdr.EnvelopeTypeEnvelopeTypeTx,
+		V1: &xdr.TransactionV1Envelope{
+			Tx: xdr.Transaction{
+				SourceAccount: xdr.MustMuxedAddress("GAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAWHF"),
+				Fee:           100,
+				Operations:    ops,
+			},
+		},
+	}
+}
+
+func TestContractStats(t *testing.T) {
+	a, b, c := xdr.Hash{1}, xdr.Hash{2}, xdr.Hash{3}
+	stats := NewContractStats(2)
+
+	stats.RecordSimulation(invokeEnvelope(a))
+	stats.RecordSubmission(invokeEnvelope(a, a, b))
+	usage, ok := stats.Get(hex.EncodeToString(a[:]))
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1), usage.Simulations)
+	assert.Equal(t, uint64(2), usage.Submissions)
+	// the fee of a transaction is only counted once per contract
+	assert.Equal(t, int64(100), usage.FeeTotal)
+
+	// the least invoked contract is evicted when the stats are full
+	stats.RecordSimulation(invokeEnvelope(c))
+	_, ok = stats.Get(hex.EncodeToString(b[:]))
+	assert.False(t, ok)
+	assert.Equal(t, 2, stats.Tracked())
+	assert.Equal(t, uint64(1), stats.Evicted())
+	assert.Equal(t, ContractUsage{Simulations: 2, Submissions: 3, FeeTotal: 200}, stats.Totals())
+
+	top := stats.Top(1)
+	assert.Len(t, top, 1)
+	assert.Equal(t, hex.EncodeToString(a[:]), top[0].ContractID)
+}
@@ -0,0 +1,162 @@
+package methods
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/clients/stellarcore"
+	"github.com/stellar/go/support/log"
+)
+
+// ParseFreshnessSLOs parses a comma separated list of distinct freshness thresholds in seconds (e.g. 10,30)
+func ParseFreshnessSLOs(s string) ([]time.Duration, error) {
+	var thresholds []time.Duration
+	seen := map[time.Duration]bool{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		seconds, err := strconv.ParseFloat(entry, 64)
+		if err != nil || seconds <= 0 {
+			return nil, fmt.Errorf("invalid freshness threshold %q, expected a positive number of seconds", entry)
+		}
+		threshold := time.Duration(seconds * float64(time.Second))
+		if seen[threshold] {
+			return nil, fmt.Errorf("duplicate freshness threshold %q", entry)
+		}
+		seen[threshold] = true
+		thresholds = append(thresholds, threshold)
+	}
+	return thresholds, nil
+}
+
+// FreshnessStatus describes how up to date the ledgers served by soroban-rpc (i.e. the ledgers
+// ingested by Horizon) are
+type FreshnessStatus struct {
+	// LatestLedger is the latest ledger ingested by Horizon, zero until it is first observed
+	LatestLedger      uint32
+	LatestLedgerClose time.Time
+	// Freshness is the time elapsed between the close of LatestLedger by the network and its
+	// observation, i.e. the end-to-end ingestion latency. It includes up to a poll interval.
+	Freshness time.Duration
+	// CoreLedger is the latest ledger closed by Stellar Core, zero when it couldn't be obtained
+	CoreLedger uint32
+	// Violations are the number of ledgers observed later than each SLO threshold after closing
+	Violations []uint64
+}
+
+// Age is the time elapsed since the network closed the latest ingested ledger, it keeps growing
+// when the ingestion stalls
+func (s FreshnessStatus) Age(now time.Time) time.Duration {
+	if s.LatestLedger == 0 {
+		return 0
+	}
+	return now.Sub(s.LatestLedgerClose)
+}
+
+// LedgerLag is the number of ledgers closed by Stellar Core which Horizon hasn't ingested yet
+func (s FreshnessStatus) LedgerLag() int64 {
+	if s.CoreLedger == 0 || s.LatestLedger == 0 {
+		return 0
+	}
+	return int64(s.CoreLedger) - int64(s.LatestLedger)
+}
+
+// FreshnessTracker periodically compares the close time of the latest ledger ingested by Horizon
+// with the time it is observed, and counts the ledgers exceeding the SLO thresholds, so that
+// operators can alert on stale data rather than on the liveness of the process.
+type FreshnessTracker struct {
+	logger        *log.Entry
+	horizonClient *horizonclient.Client
+	coreClient    *stellarcore.Client
+	interval      time.Duration
+	// SLOs are the freshness thresholds, in the order they were configured
+	SLOs   []time.Duration
+	lock   sync.Mutex
+	status FreshnessStatus
+	// OnLedger, when set, is invoked with the freshness of every newly observed ledger
+	OnLedger func(freshness time.Duration)
+	// now can be replaced by tests
+	now func() time.Time
+}
+
+// NewFreshnessTracker creates a FreshnessTracker polling the latest ledger every interval once it is started
+func NewFreshnessTracker(logger *log.Entry, horizonClient *horizonclient.Client, coreClient *stellarcore.Client, interval time.Duration, slos []time.Duration) *FreshnessTracker {
+	return &FreshnessTracker{
+		logger:        logger,
+		horizonClient: horizonClient,
+		coreClient:    coreClient,
+		interval:      interval,
+		SLOs:          slos,
+		status:        FreshnessStatus{Violations: make([]uint64, len(slos))},
+		now:           time.Now,
+	}
+}
+
+// Start polls the latest ledger in the background until ctx is done
+func (t *FreshnessTracker) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(t.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				t.poll(ctx)
+			}
+		}
+	}()
+}
+
+func (t *FreshnessTracker) poll(ctx context.Context) {
+	var coreLedger uint32
+	if info, err := t.coreClient.Info(ctx); err == nil {
+		coreLedger = uint32(info.Info.Ledger.Num)
+	}
+	page, err := t.horizonClient.Ledgers(horizonclient.LedgerRequest{Order: horizonclient.OrderDesc, Limit: 1})
+	now := t.now()
+
+	t.lock.Lock()
+	t.status.CoreLedger = coreLedger
+	if err != nil || len(page.Embedded.Records) == 0 {
+		t.lock.Unlock()
+		if err != nil {
+			t.logger.WithError(err).Debug("could not obtain the latest ledger from horizon")
+		}
+		return
+	}
+	ledger := page.Embedded.Records[0]
+	if uint32(ledger.Sequence) <= t.status.LatestLedger {
+		t.lock.Unlock()
+		return
+	}
+	freshness := now.Sub(ledger.ClosedAt)
+	t.status.LatestLedger = uint32(ledger.Sequence)
+	t.status.LatestLedgerClose = ledger.ClosedAt
+	t.status.Freshness = freshness
+	for i, threshold := range t.SLOs {
+		if freshness > threshold {
+			t.status.Violations[i]++
+		}
+	}
+	t.lock.Unlock()
+	if t.OnLedger != nil {
+		t.OnLedger(freshness)
+	}
+}
+
+// Status returns the freshness of the latest observed ledger
+func (t *FreshnessTracker) Status() FreshnessStatus {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	status := t.status
+	status.Violations = append([]uint64{}, t.status.Violations...)
+	return status
+}
@@ -0,0 +1,81 @@
+package methods
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/clients/stellarcore"
+	"github.com/stellar/go/protocols/horizon"
+	proto "github.com/stellar/go/protocols/stellarcore"
+	"github.com/stellar/go/support/log"
+)
+
+func TestParseFreshnessSLOs(t *testing.T) {
+	slos, err := ParseFreshnessSLOs("10, 0.5,")
+	require.NoError(t, err)
+	assert.Equal(t, []time.Duration{10 * time.Second, 500 * time.Millisecond}, slos)
+
+	for _, invalid := range []string{"a", "-1", "0", "10,10"} {
+		_, err := ParseFreshnessSLOs(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestFreshnessTracker(t *testing.T) {
+	closedAt := time.Date(2022, 12, 1, 0, 0, 0, 0, time.UTC)
+	var sequence int32 = 10
+	horizonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var page horizon.LedgersPage
+		page.Embedded.Records = []horizon.Ledger{{Sequence: atomic.LoadInt32(&sequence), ClosedAt: closedAt}}
+		json.NewEncoder(w).Encode(page)
+	}))
+	defer horizonServer.Close()
+	coreServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var info proto.InfoResponse
+		info.Info.Ledger.Num = 12
+		json.NewEncoder(w).Encode(info)
+	}))
+	defer coreServer.Close()
+
+	tracker := NewFreshnessTracker(
+		log.DefaultLogger,
+		&horizonclient.Client{HorizonURL: horizonServer.URL + "/"},
+		&stellarcore.Client{URL: coreServer.URL},
+		time.Second,
+		[]time.Duration{5 * time.Second, 20 * time.Second},
+	)
+	var observed []time.Duration
+	tracker.OnLedger = func(freshness time.Duration) {
+		observed = append(observed, freshness)
+	}
+	now := closedAt.Add(10 * time.Second)
+	tracker.now = func() time.Time { return now }
+
+	tracker.poll(context.Background())
+	status := tracker.Status()
+	assert.Equal(t, uint32(10), status.LatestLedger)
+	assert.Equal(t, 10*time.Second, status.Freshness)
+	assert.Equal(t, int64(2), status.LedgerLag())
+	assert.Equal(t, 15*time.Second, status.Age(closedAt.Add(15*time.Second)))
+	// only the 5 seconds threshold was exceeded
+	assert.Equal(t, []uint64{1, 0}, status.Violations)
+
+	// the same ledger is only observed once
+	now = now.Add(time.Minute)
+	tracker.poll(context.Background())
+	assert.Equal(t, []uint64{1, 0}, tracker.Status().Violations)
+
+	atomic.StoreInt32(&sequence, 11)
+	tracker.poll(context.Background())
+	assert.Equal(t, []uint64{2, 1}, tracker.Status().Violations)
+	assert.Equal(t, []time.Duration{10 * time.Second, 70 * time.Second}, observed)
+}
@@ -0,0 +1,124 @@
+package methods
+
+import (
+	"context"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/code"
+	"github.com/creachadair/jrpc2/handler"
+
+	"github.com/stellar/go/clients/stellarcore"
+	proto "github.com/stellar/go/protocols/stellarcore"
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/xdr"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/rpcerror"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/tracing"
+)
+
+type GetAccountInfoRequest struct {
+	Address string `json:"address"`
+}
+
+type AccountThresholds struct {
+	MasterWeight uint8 `json:"masterWeight"`
+	Low          uint8 `json:"low"`
+	Medium       uint8 `json:"medium"`
+	High         uint8 `json:"high"`
+}
+
+type AccountSigner struct {
+	// Key is the strkey of the signer (e.g. a G... address for ed25519 keys)
+	Key    string `json:"key"`
+	Weight uint32 `json:"weight"`
+}
+
+type GetAccountInfoResponse struct {
+	ID       string `json:"id"`
+	Sequence int64  `json:"sequence,string"`
+	// Balance is the native balance of the account, in stroops
+	Balance       int64             `json:"balance,string"`
+	NumSubEntries uint32            `json:"numSubEntries"`
+	Thresholds    AccountThresholds `json:"thresholds"`
+	// Signers does not include the master key, whose weight is part of Thresholds
+	Signers            []AccountSigner `json:"signers"`
+	LastModifiedLedger int64           `json:"lastModifiedLedgerSeq,string"`
+	LatestLedger       int64           `json:"latestLedger,string"`
+}
+
+// NewGetAccountInfoHandler returns a json rpc handler to retrieve the sequence number, native balance
+// and signers of an account from its ledger entry in stellar core.
+func NewGetAccountInfoHandler(logger *log.Entry, coreClient *stellarcore.Client) jrpc2.Handler {
+	return handler.New(func(ctx context.Context, request GetAccountInfoRequest) (GetAccountInfoResponse, error) {
+		var accountID xdr.AccountId
+		if err := accountID.SetAddress(request.Address); err != nil {
+			return GetAccountInfoResponse{}, &jrpc2.Error{
+				Code:    code.InvalidParams,
+				Message: "invalid account address",
+			}
+		}
+		lk := xdr.LedgerKey{
+			Type:    xdr.LedgerEntryTypeAccount,
+			Account: &xdr.LedgerKeyAccount{AccountId: accountID},
+		}
+
+		coreCtx, span := tracing.StartSpan(ctx, "stellar_core.get_ledger_entry")
+		coreResponse, err := coreClient.GetLedgerEntry(coreCtx, lk)
+		tracing.EndSpan(span, err)
+		if err != nil {
+			logger.WithError(err).WithField("request", request).
+				Info("could not submit getLedgerEntry request to core")
+			return GetAccountInfoResponse{}, rpcerror.NewUpstreamUnavailable(rpcerror.UpstreamStellarCore, "could not submit request to core")
+		}
+
+		if coreResponse.State == proto.DeadState {
+			return GetAccountInfoResponse{}, (&jrpc2.Error{
+				Code:    rpcerror.NotFound,
+				Message: "account not found",
+			}).WithData(map[string]string{"address": request.Address})
+		}
+
+		var ledgerEntry xdr.LedgerEntry
+		if err = xdr.SafeUnmarshalBase64(coreResponse.Entry, &ledgerEntry); err != nil {
+			logger.WithError(err).WithField("request", request).
+				WithField("response", coreResponse).
+				Info("could not parse ledger entry")
+			return GetAccountInfoResponse{}, &jrpc2.Error{
+				Code:    code.InternalError,
+				Message: "could not parse core response",
+			}
+		}
+		account, ok := ledgerEntry.Data.GetAccount()
+		if !ok {
+			logger.WithField("request", request).
+				WithField("response", coreResponse).
+				Info("ledger entry does not contain an account")
+			return GetAccountInfoResponse{}, &jrpc2.Error{
+				Code:    code.InternalError,
+				Message: "ledger entry does not contain an account",
+			}
+		}
+
+		response := GetAccountInfoResponse{
+			ID:            request.Address,
+			Sequence:      int64(account.SeqNum),
+			Balance:       int64(account.Balance),
+			NumSubEntries: uint32(account.NumSubEntries),
+			Thresholds: AccountThresholds{
+				MasterWeight: account.Thresholds.MasterKeyWeight(),
+				Low:          account.Thresholds.ThresholdLow(),
+				Medium:       account.Thresholds.ThresholdMedium(),
+				High:         account.Thresholds.ThresholdHigh(),
+			},
+			Signers:            make([]AccountSigner, 0, len(account.Signers)),
+			LastModifiedLedger: int64(ledgerEntry.LastModifiedLedgerSeq),
+			LatestLedger:       coreResponse.Ledger,
+		}
+		for _, signer := range account.Signers {
+			response.Signers = append(response.Signers, AccountSigner{
+				Key:    signer.Key.Address(),
+				Weight: uint32(signer.Weight),
+			})
+		}
+		return response, nil
+	})
+}
@@ -12,22 +12,31 @@ import (
 	proto "github.com/stellar/go/protocols/stellarcore"
 	"github.com/stellar/go/support/log"
 	"github.com/stellar/go/xdr"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/rpcerror"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/tracing"
 )
 
 type GetContractDataRequest struct {
 	ContractID string `json:"contractId"`
 	Key        string `json:"key"`
+	// Format is either "base64" (default) or "json"
+	Format string `json:"format,omitempty"`
 }
 
 type GetContractDataResponse struct {
 	XDR                string `json:"xdr"`
 	LastModifiedLedger int64  `json:"lastModifiedLedgerSeq,string"`
 	LatestLedger       int64  `json:"latestLedger,string"`
+	// JSON is the decoded contract data value, it is only set when requesting the json format
+	JSON interface{} `json:"json,omitempty"`
 }
 
 // NewGetContractDataHandler returns a json rpc handler to retrieve a contract data ledger entry from stellar cre
 func NewGetContractDataHandler(logger *log.Entry, coreClient *stellarcore.Client) jrpc2.Handler {
-	return handler.New(func(ctx context.Context, request GetContractDataRequest) (GetContractDataResponse, error) {
+	return withOptionalParams(GetContractDataRequest{}, handler.New(func(ctx context.Context, request GetContractDataRequest) (GetContractDataResponse, error) {
+		if err := validateFormat(request.Format); err != nil {
+			return GetContractDataResponse{}, err
+		}
 		var scVal xdr.ScVal
 		if err := xdr.SafeUnmarshalBase64(request.Key, &scVal); err != nil {
 			logger.WithError(err).WithField("request", request).
@@ -62,21 +71,20 @@ func NewGetContractDataHandler(logger *log.Entry, coreClient *stellarcore.Client
 			},
 		}
 
-		coreResponse, err := coreClient.GetLedgerEntry(ctx, lk)
+		coreCtx, span := tracing.StartSpan(ctx, "stellar_core.get_ledger_entry")
+		coreResponse, err := coreClient.GetLedgerEntry(coreCtx, lk)
+		tracing.EndSpan(span, err)
 		if err != nil {
 			logger.WithError(err).WithField("request", request).
 				Info("could not submit getLedgerEntry request to core")
-			return GetContractDataResponse{}, &jrpc2.Error{
-				Code:    code.InternalError,
-				Message: "could not submit request to core",
-			}
+			return GetContractDataResponse{}, rpcerror.NewUpstreamUnavailable(rpcerror.UpstreamStellarCore, "could not submit request to core")
 		}
 
 		if coreResponse.State == proto.DeadState {
-			return GetContractDataResponse{}, &jrpc2.Error{
-				Code:    code.InvalidRequest,
+			return GetContractDataResponse{}, (&jrpc2.Error{
+				Code:    rpcerror.NotFound,
 				Message: "not found",
-			}
+			}).WithData(map[string]string{"contractId": request.ContractID, "key": request.Key})
 		}
 
 		var ledgerEntry xdr.LedgerEntry
@@ -113,7 +121,17 @@ func NewGetContractDataHandler(logger *log.Entry, coreClient *stellarcore.Client
 				Message: "could not serialize contract data scval",
 			}
 		}
+		if request.Format == FormatJSON {
+			if response.JSON, err = xdrToJSON(contractData.Val); err != nil {
+				logger.WithError(err).WithField("request", request).
+					Info("could not convert contract data scval to json")
+				return GetContractDataResponse{}, &jrpc2.Error{
+					Code:    code.InternalError,
+					Message: "could not convert contract data scval to json",
+				}
+			}
+		}
 
 		return response, nil
-	})
+	}))
 }
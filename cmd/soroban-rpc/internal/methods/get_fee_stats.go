@@ -0,0 +1,45 @@
+package methods
+
+import (
+	"context"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/handler"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/rpcerror"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/tracing"
+)
+
+type GetFeeStatsResponse struct {
+	LatestLedger        int64   `json:"latestLedger,string"`
+	LatestLedgerBaseFee int64   `json:"latestLedgerBaseFee,string"`
+	LedgerCapacityUsage float64 `json:"ledgerCapacityUsage,string"`
+	// InclusionFeeCharged is the distribution of the inclusion fees charged in the recent ledgers
+	InclusionFeeCharged FeeDistribution `json:"inclusionFeeCharged"`
+	// InclusionFeeBid is the distribution of the maximum inclusion fees bid in the recent ledgers
+	InclusionFeeBid FeeDistribution `json:"inclusionFeeBid"`
+}
+
+// NewGetFeeStatsHandler returns a json rpc handler to retrieve the fee statistics of the recent ledgers.
+// The statistics are computed by Horizon over its latest ingested ledgers.
+func NewGetFeeStatsHandler(logger *log.Entry, horizonClient *horizonclient.Client) jrpc2.Handler {
+	return handler.New(func(ctx context.Context) (GetFeeStatsResponse, error) {
+		_, span := tracing.StartSpan(ctx, "horizon.fee_stats")
+		feeStats, err := horizonClient.FeeStats()
+		tracing.EndSpan(span, err)
+		if err != nil {
+			logger.WithError(err).Info("could not obtain fee stats from horizon")
+			return GetFeeStatsResponse{}, rpcerror.NewUpstreamUnavailable(rpcerror.UpstreamHorizon, "could not obtain fee stats from horizon")
+		}
+
+		return GetFeeStatsResponse{
+			LatestLedger:        int64(feeStats.LastLedger),
+			LatestLedgerBaseFee: feeStats.LastLedgerBaseFee,
+			LedgerCapacityUsage: feeStats.LedgerCapacityUsage,
+			InclusionFeeCharged: newFeeDistribution(feeStats.FeeCharged),
+			InclusionFeeBid:     newFeeDistribution(feeStats.MaxFee),
+		}, nil
+	})
+}
@@ -0,0 +1,97 @@
+package methods
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/code"
+	"github.com/creachadair/jrpc2/handler"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/rpcerror"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/tracing"
+)
+
+// MaxLatestLedgerWindow is the maximum number of recent ledger headers returned by getLatestLedger
+const MaxLatestLedgerWindow = 20
+
+type GetLatestLedgerRequest struct {
+	// Window is optional, it is the number of recent ledger headers to return,
+	// including the latest one
+	Window int `json:"window,omitempty"`
+}
+
+type LedgerHeader struct {
+	// Hash is the hex encoded hash of the ledger header, i.e. the SHA-256 hash of HeaderXDR
+	Hash            string `json:"hash"`
+	PreviousHash    string `json:"previousHash"`
+	Sequence        uint32 `json:"sequence"`
+	ProtocolVersion int32  `json:"protocolVersion"`
+	// CloseTime is the ledger close time, as a unix timestamp
+	CloseTime int64 `json:"closeTime,string"`
+	// HeaderXDR is the base64 encoded LedgerHeader
+	HeaderXDR string `json:"headerXdr"`
+}
+
+type GetLatestLedgerResponse struct {
+	LedgerHeader
+	// RecentLedgers is only set when requesting a window, it contains the latest
+	// ledger headers, newest first
+	RecentLedgers []LedgerHeader `json:"recentLedgers,omitempty"`
+}
+
+func newLedgerHeader(ledger horizon.Ledger) LedgerHeader {
+	return LedgerHeader{
+		Hash:            ledger.Hash,
+		PreviousHash:    ledger.PrevHash,
+		Sequence:        uint32(ledger.Sequence),
+		ProtocolVersion: ledger.ProtocolVersion,
+		CloseTime:       ledger.ClosedAt.Unix(),
+		HeaderXDR:       ledger.HeaderXDR,
+	}
+}
+
+// NewGetLatestLedgerHandler returns a json rpc handler to retrieve the header of the latest
+// ledger ingested by Horizon and, optionally, the headers of the ledgers preceding it.
+func NewGetLatestLedgerHandler(logger *log.Entry, horizonClient *horizonclient.Client) jrpc2.Handler {
+	return handler.New(func(ctx context.Context, request GetLatestLedgerRequest) (GetLatestLedgerResponse, error) {
+		if request.Window < 0 || request.Window > MaxLatestLedgerWindow {
+			return GetLatestLedgerResponse{}, &jrpc2.Error{
+				Code:    code.InvalidParams,
+				Message: fmt.Sprintf("window must be between 0 and %d", MaxLatestLedgerWindow),
+			}
+		}
+		limit := uint(1)
+		if request.Window > 0 {
+			limit = uint(request.Window)
+		}
+
+		_, span := tracing.StartSpan(ctx, "horizon.ledgers")
+		page, err := horizonClient.Ledgers(horizonclient.LedgerRequest{
+			Order: horizonclient.OrderDesc,
+			Limit: limit,
+		})
+		tracing.EndSpan(span, err)
+		if err != nil {
+			logger.WithError(err).Info("could not obtain the latest ledgers from horizon")
+			return GetLatestLedgerResponse{}, rpcerror.NewUpstreamUnavailable(rpcerror.UpstreamHorizon, "could not obtain the latest ledgers from horizon")
+		}
+		if len(page.Embedded.Records) == 0 {
+			return GetLatestLedgerResponse{}, &jrpc2.Error{
+				Code:    code.InternalError,
+				Message: "horizon has not ingested any ledgers",
+			}
+		}
+
+		response := GetLatestLedgerResponse{LedgerHeader: newLedgerHeader(page.Embedded.Records[0])}
+		if request.Window > 0 {
+			for _, ledger := range page.Embedded.Records {
+				response.RecentLedgers = append(response.RecentLedgers, newLedgerHeader(ledger))
+			}
+		}
+		return response, nil
+	})
+}
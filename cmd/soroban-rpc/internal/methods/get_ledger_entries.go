@@ -0,0 +1,141 @@
+package methods
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/code"
+	"github.com/creachadair/jrpc2/handler"
+
+	"github.com/stellar/go/clients/stellarcore"
+	proto "github.com/stellar/go/protocols/stellarcore"
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/xdr"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/tracing"
+)
+
+// MaxLedgerEntriesKeys is the maximum number of keys accepted by a single getLedgerEntries request
+const MaxLedgerEntriesKeys = 200
+
+type GetLedgerEntriesRequest struct {
+	Keys []string `json:"keys"`
+	// Format is either "base64" (default) or "json"
+	Format string `json:"format,omitempty"`
+}
+
+type LedgerEntryResult struct {
+	// Key is the base64 encoded LedgerKey, as provided in the request
+	Key string `json:"key"`
+	// XDR is the base64 encoded LedgerEntryData. It is empty if the entry was not found
+	XDR string `json:"xdr,omitempty"`
+	// JSON is the decoded LedgerEntryData, it is only set when requesting the json format
+	JSON               interface{} `json:"json,omitempty"`
+	LastModifiedLedger int64       `json:"lastModifiedLedgerSeq,string,omitempty"`
+	NotFound           bool        `json:"notFound,omitempty"`
+	// Error will be empty unless the entry could not be retrieved
+	Error string `json:"error,omitempty"`
+}
+
+type GetLedgerEntriesResponse struct {
+	Entries      []LedgerEntryResult `json:"entries"`
+	LatestLedger int64               `json:"latestLedger,string"`
+}
+
+// Cacheable reports whether all the entries were retrieved, errors may be transient
+func (r GetLedgerEntriesResponse) Cacheable() bool {
+	for _, entry := range r.Entries {
+		if entry.Error != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// NewGetLedgerEntriesHandler returns a json rpc handler to retrieve multiple ledger entries from stellar core
+func NewGetLedgerEntriesHandler(logger *log.Entry, coreClient *stellarcore.Client) jrpc2.Handler {
+	return withOptionalParams(GetLedgerEntriesRequest{}, handler.New(func(ctx context.Context, request GetLedgerEntriesRequest) (GetLedgerEntriesResponse, error) {
+		if err := validateFormat(request.Format); err != nil {
+			return GetLedgerEntriesResponse{}, err
+		}
+		if len(request.Keys) == 0 {
+			return GetLedgerEntriesResponse{}, &jrpc2.Error{
+				Code:    code.InvalidParams,
+				Message: "no keys provided",
+			}
+		}
+		if len(request.Keys) > MaxLedgerEntriesKeys {
+			return GetLedgerEntriesResponse{}, &jrpc2.Error{
+				Code:    code.InvalidParams,
+				Message: fmt.Sprintf("too many keys provided (maximum is %d)", MaxLedgerEntriesKeys),
+			}
+		}
+
+		response := GetLedgerEntriesResponse{
+			Entries: make([]LedgerEntryResult, 0, len(request.Keys)),
+		}
+		for _, key := range request.Keys {
+			result, latestLedger := getLedgerEntry(ctx, logger, coreClient, key, request.Format)
+			if latestLedger > response.LatestLedger {
+				response.LatestLedger = latestLedger
+			}
+			response.Entries = append(response.Entries, result)
+		}
+		return response, nil
+	}))
+}
+
+func getLedgerEntry(ctx context.Context, logger *log.Entry, coreClient *stellarcore.Client, key string, format string) (LedgerEntryResult, int64) {
+	result := LedgerEntryResult{Key: key}
+
+	var ledgerKey xdr.LedgerKey
+	if err := xdr.SafeUnmarshalBase64(key, &ledgerKey); err != nil {
+		logger.WithError(err).WithField("key", key).
+			Info("could not unmarshal ledger key from getLedgerEntries request")
+		result.Error = "cannot unmarshal ledger key"
+		return result, 0
+	}
+
+	ctx, span := tracing.StartSpan(ctx, "stellar_core.get_ledger_entry")
+	coreResponse, err := coreClient.GetLedgerEntry(ctx, ledgerKey)
+	tracing.EndSpan(span, err)
+	if err != nil {
+		logger.WithError(err).WithField("key", key).
+			Info("could not submit getLedgerEntry request to core")
+		result.Error = "could not submit request to core"
+		return result, 0
+	}
+
+	if coreResponse.State == proto.DeadState {
+		result.NotFound = true
+		return result, coreResponse.Ledger
+	}
+
+	var ledgerEntry xdr.LedgerEntry
+	if err = xdr.SafeUnmarshalBase64(coreResponse.Entry, &ledgerEntry); err != nil {
+		logger.WithError(err).WithField("key", key).
+			WithField("response", coreResponse).
+			Info("could not parse ledger entry")
+		result.Error = "could not parse core response"
+		return result, coreResponse.Ledger
+	}
+
+	if result.XDR, err = xdr.MarshalBase64(ledgerEntry.Data); err != nil {
+		logger.WithError(err).WithField("key", key).
+			WithField("response", coreResponse).
+			Info("could not serialize ledger entry data")
+		result.Error = "could not serialize ledger entry data"
+		return result, coreResponse.Ledger
+	}
+	if format == FormatJSON {
+		if result.JSON, err = xdrToJSON(ledgerEntry.Data); err != nil {
+			logger.WithError(err).WithField("key", key).
+				WithField("response", coreResponse).
+				Info("could not convert ledger entry data to json")
+			result.Error = "could not convert ledger entry data to json"
+			return result, coreResponse.Ledger
+		}
+	}
+	result.LastModifiedLedger = int64(ledgerEntry.LastModifiedLedgerSeq)
+	return result, coreResponse.Ledger
+}
@@ -0,0 +1,106 @@
+package methods
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/code"
+	"github.com/creachadair/jrpc2/handler"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/toid"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/rpcerror"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/tracing"
+)
+
+const (
+	// DefaultGetLedgersLimit is the number of ledgers returned by getLedgers when no limit is provided
+	DefaultGetLedgersLimit = 10
+	// MaxGetLedgersLimit is the maximum number of ledgers returned by a single getLedgers request
+	MaxGetLedgersLimit = 200
+)
+
+type GetLedgersRequest struct {
+	// StartLedger is the sequence of the first ledger to return, it is ignored when Cursor is set
+	StartLedger uint32 `json:"startLedger"`
+	// EndLedger is optional, when set no ledgers after it are returned
+	EndLedger uint32 `json:"endLedger,omitempty"`
+	// Cursor is optional, it continues the pagination of a previous request
+	Cursor string `json:"cursor,omitempty"`
+	Limit  uint   `json:"limit,omitempty"`
+}
+
+type GetLedgersResponse struct {
+	// Ledgers contains the ledger headers, in ascending order
+	Ledgers []LedgerHeader `json:"ledgers"`
+	// Cursor can be used to obtain the ledgers following the ones in the response,
+	// it is empty once EndLedger is reached
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// NewGetLedgersHandler returns a json rpc handler to retrieve a range of ledger headers from Horizon.
+// Ledger close meta is not available, since soroban-rpc doesn't ingest ledgers itself.
+func NewGetLedgersHandler(logger *log.Entry, horizonClient *horizonclient.Client) jrpc2.Handler {
+	return withOptionalParams(GetLedgersRequest{}, handler.New(func(ctx context.Context, request GetLedgersRequest) (GetLedgersResponse, error) {
+		if request.Limit > MaxGetLedgersLimit {
+			return GetLedgersResponse{}, &jrpc2.Error{
+				Code:    code.InvalidParams,
+				Message: fmt.Sprintf("limit must not exceed %d", MaxGetLedgersLimit),
+			}
+		}
+		if request.Limit == 0 {
+			request.Limit = DefaultGetLedgersLimit
+		}
+		cursor := request.Cursor
+		if cursor == "" {
+			if request.StartLedger == 0 {
+				return GetLedgersResponse{}, &jrpc2.Error{
+					Code:    code.InvalidParams,
+					Message: "startLedger or cursor must be provided",
+				}
+			}
+			// ledgers are paged by their operation id, so this cursor points right before StartLedger
+			cursor = strconv.FormatInt(toid.New(int32(request.StartLedger-1), 0, 0).ToInt64(), 10)
+		} else if _, err := strconv.ParseInt(cursor, 10, 64); err != nil {
+			return GetLedgersResponse{}, &jrpc2.Error{
+				Code:    code.InvalidParams,
+				Message: "invalid cursor",
+			}
+		}
+		if request.EndLedger > 0 && request.Cursor == "" && request.EndLedger < request.StartLedger {
+			return GetLedgersResponse{}, &jrpc2.Error{
+				Code:    code.InvalidParams,
+				Message: "endLedger must not be lower than startLedger",
+			}
+		}
+
+		_, span := tracing.StartSpan(ctx, "horizon.ledgers")
+		page, err := horizonClient.Ledgers(horizonclient.LedgerRequest{
+			Order:  horizonclient.OrderAsc,
+			Cursor: cursor,
+			Limit:  request.Limit,
+		})
+		tracing.EndSpan(span, err)
+		if err != nil {
+			logger.WithError(err).Info("could not obtain ledgers from horizon")
+			return GetLedgersResponse{}, rpcerror.NewUpstreamUnavailable(rpcerror.UpstreamHorizon, "could not obtain ledgers from horizon")
+		}
+
+		response := GetLedgersResponse{Ledgers: []LedgerHeader{}, Cursor: cursor}
+		for _, ledger := range page.Embedded.Records {
+			if request.EndLedger > 0 && uint32(ledger.Sequence) > request.EndLedger {
+				response.Cursor = ""
+				break
+			}
+			response.Ledgers = append(response.Ledgers, newLedgerHeader(ledger))
+			response.Cursor = ledger.PagingToken()
+		}
+		if n := len(response.Ledgers); n > 0 && request.EndLedger > 0 && response.Ledgers[n-1].Sequence == request.EndLedger {
+			response.Cursor = ""
+		}
+		return response, nil
+	}))
+}
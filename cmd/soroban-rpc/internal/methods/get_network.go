@@ -0,0 +1,96 @@
+package methods
+
+import (
+	"context"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/handler"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/clients/stellarcore"
+	"github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/rpcerror"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/tracing"
+)
+
+type FeeDistribution struct {
+	Max  int64 `json:"max,string"`
+	Min  int64 `json:"min,string"`
+	Mode int64 `json:"mode,string"`
+	P10  int64 `json:"p10,string"`
+	P20  int64 `json:"p20,string"`
+	P30  int64 `json:"p30,string"`
+	P40  int64 `json:"p40,string"`
+	P50  int64 `json:"p50,string"`
+	P60  int64 `json:"p60,string"`
+	P70  int64 `json:"p70,string"`
+	P80  int64 `json:"p80,string"`
+	P90  int64 `json:"p90,string"`
+	P95  int64 `json:"p95,string"`
+	P99  int64 `json:"p99,string"`
+}
+
+func newFeeDistribution(d horizon.FeeDistribution) FeeDistribution {
+	return FeeDistribution{
+		Max:  d.Max,
+		Min:  d.Min,
+		Mode: d.Mode,
+		P10:  d.P10,
+		P20:  d.P20,
+		P30:  d.P30,
+		P40:  d.P40,
+		P50:  d.P50,
+		P60:  d.P60,
+		P70:  d.P70,
+		P80:  d.P80,
+		P90:  d.P90,
+		P95:  d.P95,
+		P99:  d.P99,
+	}
+}
+
+type GetNetworkResponse struct {
+	Passphrase      string `json:"passphrase"`
+	ProtocolVersion int    `json:"protocolVersion"`
+	BaseFee         int64  `json:"baseFee,string"`
+	BaseReserve     int64  `json:"baseReserve,string"`
+	LatestLedger    int64  `json:"latestLedger,string"`
+	// FeeCharged is the distribution of the inclusion fees charged in the recent ledgers
+	FeeCharged FeeDistribution `json:"feeCharged"`
+}
+
+// NewGetNetworkHandler returns a json rpc handler to retrieve the network configuration
+func NewGetNetworkHandler(
+	logger *log.Entry,
+	networkPassphrase string,
+	horizonClient *horizonclient.Client,
+	coreClient *stellarcore.Client,
+) jrpc2.Handler {
+	return handler.New(func(ctx context.Context) (GetNetworkResponse, error) {
+		coreCtx, span := tracing.StartSpan(ctx, "stellar_core.info")
+		info, err := coreClient.Info(coreCtx)
+		tracing.EndSpan(span, err)
+		if err != nil {
+			logger.WithError(err).Info("could not submit info request to core")
+			return GetNetworkResponse{}, rpcerror.NewUpstreamUnavailable(rpcerror.UpstreamStellarCore, "could not submit request to core")
+		}
+
+		_, span = tracing.StartSpan(ctx, "horizon.fee_stats")
+		feeStats, err := horizonClient.FeeStats()
+		tracing.EndSpan(span, err)
+		if err != nil {
+			logger.WithError(err).Info("could not obtain fee stats from horizon")
+			return GetNetworkResponse{}, rpcerror.NewUpstreamUnavailable(rpcerror.UpstreamHorizon, "could not obtain fee stats from horizon")
+		}
+
+		return GetNetworkResponse{
+			Passphrase:      networkPassphrase,
+			ProtocolVersion: info.Info.Ledger.Version,
+			BaseFee:         int64(info.Info.Ledger.BaseFee),
+			BaseReserve:     int64(info.Info.Ledger.BaseReserve),
+			LatestLedger:    int64(info.Info.Ledger.Num),
+			FeeCharged:      newFeeDistribution(feeStats.FeeCharged),
+		}, nil
+	})
+}
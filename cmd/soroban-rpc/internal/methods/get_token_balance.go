@@ -0,0 +1,215 @@
+package methods
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/code"
+	"github.com/creachadair/jrpc2/handler"
+
+	"github.com/stellar/go/clients/stellarcore"
+	"github.com/stellar/go/keypair"
+	proto "github.com/stellar/go/protocols/stellarcore"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/xdr"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/rpcerror"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/tracing"
+)
+
+// tokenQuerySourceAccount is the source account of the read-only token invocations.
+// The token functions used don't require authorization so any account will do.
+var tokenQuerySourceAccount = strkey.MustEncode(strkey.VersionByteAccountID, make([]byte, 32))
+
+type GetTokenBalanceRequest struct {
+	// ContractID is the hex-encoded id of the token contract
+	ContractID string `json:"contractId"`
+	// Address is either an account (G...) or the hex-encoded id of a contract holding the tokens
+	Address string `json:"address"`
+}
+
+type GetTokenBalanceResponse struct {
+	// Balance is the decimal representation of the raw balance (i.e. not scaled by Decimals)
+	Balance string `json:"balance"`
+	// Decimals and Symbol are omitted when the token contract doesn't provide them
+	Decimals     *uint32 `json:"decimals,omitempty"`
+	Symbol       string  `json:"symbol,omitempty"`
+	LatestLedger int64   `json:"latestLedger,string"`
+}
+
+// NewGetTokenBalanceHandler returns a json rpc handler which obtains the balance of an address
+// by simulating the balance function of a token contract (both Stellar Asset Contracts and custom
+// tokens implementing the token interface)
+func NewGetTokenBalanceHandler(logger *log.Entry, coreClient *stellarcore.Client, queue *PreflightQueue) jrpc2.Handler {
+	return handler.New(func(ctx context.Context, request GetTokenBalanceRequest) (GetTokenBalanceResponse, error) {
+		contractID, err := parseContractID(request.ContractID)
+		if err != nil {
+			return GetTokenBalanceResponse{}, &jrpc2.Error{
+				Code:    code.InvalidParams,
+				Message: "invalid contract id: " + err.Error(),
+			}
+		}
+		identifier, err := tokenIdentifier(request.Address)
+		if err != nil {
+			return GetTokenBalanceResponse{}, &jrpc2.Error{
+				Code:    code.InvalidParams,
+				Message: "invalid address: " + err.Error(),
+			}
+		}
+
+		invoke := func(function string, args ...xdr.ScVal) (xdr.ScVal, int64, error) {
+			return invokeReadOnly(ctx, coreClient, queue, contractID, function, args)
+		}
+		val, latestLedger, err := invoke("balance", identifier)
+		if err != nil {
+			logger.WithError(err).WithField("request", request).
+				Info("could not simulate token balance invocation")
+			return GetTokenBalanceResponse{}, err
+		}
+		balance, err := decodeTokenAmount(val)
+		if err != nil {
+			return GetTokenBalanceResponse{}, &jrpc2.Error{
+				Code:    rpcerror.SimulationFailed,
+				Message: "unexpected balance value: " + err.Error(),
+			}
+		}
+		response := GetTokenBalanceResponse{
+			Balance:      balance.String(),
+			LatestLedger: latestLedger,
+		}
+
+		// the metadata is optional, not every custom token provides it
+		if val, _, err := invoke("decimals"); err == nil && val.Type == xdr.ScValTypeScvU32 {
+			decimals := uint32(*val.U32)
+			response.Decimals = &decimals
+		}
+		if val, _, err := invoke("symbol"); err == nil {
+			if obj, ok := val.GetObj(); ok && obj != nil && obj.Type == xdr.ScObjectTypeScoBytes {
+				response.Symbol = string(*obj.Bin)
+			}
+		}
+		return response, nil
+	})
+}
+
+func parseContractID(s string) (xdr.Hash, error) {
+	var contractID xdr.Hash
+	decoded, err := hex.DecodeString(s)
+	if err != nil {
+		return contractID, err
+	}
+	if len(decoded) != len(contractID) {
+		return contractID, fmt.Errorf("expected %d bytes, got %d", len(contractID), len(decoded))
+	}
+	copy(contractID[:], decoded)
+	return contractID, nil
+}
+
+// tokenIdentifier encodes an address as the Identifier enum of the token interface
+func tokenIdentifier(address string) (xdr.ScVal, error) {
+	var kind string
+	var obj *xdr.ScObject
+	if kp, err := keypair.ParseAddress(address); err == nil {
+		accountID := xdr.MustAddress(kp.Address())
+		kind = "Account"
+		obj = &xdr.ScObject{Type: xdr.ScObjectTypeScoAccountId, AccountId: &accountID}
+	} else {
+		contractID, err := parseContractID(address)
+		if err != nil {
+			return xdr.ScVal{}, fmt.Errorf("neither an account nor a contract id")
+		}
+		bin := contractID[:]
+		kind = "Contract"
+		obj = &xdr.ScObject{Type: xdr.ScObjectTypeScoBytes, Bin: &bin}
+	}
+	sym := xdr.ScSymbol(kind)
+	vec := &xdr.ScObject{Type: xdr.ScObjectTypeScoVec, Vec: &xdr.ScVec{
+		{Type: xdr.ScValTypeScvSymbol, Sym: &sym},
+		{Type: xdr.ScValTypeScvObject, Obj: &obj},
+	}}
+	return xdr.ScVal{Type: xdr.ScValTypeScvObject, Obj: &vec}, nil
+}
+
+// decodeTokenAmount decodes the amounts returned by token contracts, which are big integers,
+// also accepting the smaller integer types used by some custom tokens
+func decodeTokenAmount(val xdr.ScVal) (*big.Int, error) {
+	switch val.Type {
+	case xdr.ScValTypeScvU63:
+		return big.NewInt(int64(*val.U63)), nil
+	case xdr.ScValTypeScvU32:
+		return big.NewInt(int64(*val.U32)), nil
+	case xdr.ScValTypeScvObject:
+		obj := *val.Obj
+		if obj == nil {
+			break
+		}
+		switch obj.Type {
+		case xdr.ScObjectTypeScoBigInt:
+			amount := new(big.Int)
+			if obj.BigInt.Magnitude != nil {
+				amount.SetBytes(*obj.BigInt.Magnitude)
+			}
+			if obj.BigInt.Sign == xdr.ScNumSignNegative {
+				amount.Neg(amount)
+			}
+			return amount, nil
+		case xdr.ScObjectTypeScoI64:
+			return big.NewInt(int64(*obj.I64)), nil
+		case xdr.ScObjectTypeScoU64:
+			return new(big.Int).SetUint64(uint64(*obj.U64)), nil
+		}
+		return nil, fmt.Errorf("unsupported object type %s", obj.Type)
+	}
+	return nil, fmt.Errorf("unsupported value type %s", val.Type)
+}
+
+// invokeReadOnly simulates the invocation of a contract function through the preflight queue and
+// returns its result along with the ledger it was simulated at
+func invokeReadOnly(ctx context.Context, coreClient *stellarcore.Client, queue *PreflightQueue, contractID xdr.Hash, function string, args []xdr.ScVal) (xdr.ScVal, int64, error) {
+	bin := contractID[:]
+	contractObj := &xdr.ScObject{Type: xdr.ScObjectTypeScoBytes, Bin: &bin}
+	sym := xdr.ScSymbol(function)
+	parameters := xdr.ScVec{
+		{Type: xdr.ScValTypeScvObject, Obj: &contractObj},
+		{Type: xdr.ScValTypeScvSymbol, Sym: &sym},
+	}
+	op := xdr.InvokeHostFunctionOp{
+		Function:   xdr.HostFunctionHostFnInvokeContract,
+		Parameters: append(parameters, args...),
+	}
+
+	var coreResponse proto.PreflightResponse
+	err := queue.Run(ctx, func(ctx context.Context) error {
+		var err error
+		ctx, span := tracing.StartSpan(ctx, "stellar_core.preflight")
+		coreResponse, err = coreClient.Preflight(ctx, tokenQuerySourceAccount, op)
+		tracing.EndSpan(span, err)
+		return err
+	})
+	if err == errPreflightQueueFull || err == errPreflightTimeout {
+		return xdr.ScVal{}, 0, &jrpc2.Error{
+			Code:    rpcerror.PreflightRejected,
+			Message: "preflight request rejected: " + err.Error(),
+		}
+	}
+	if err != nil {
+		return xdr.ScVal{}, 0, rpcerror.NewUpstreamUnavailable(rpcerror.UpstreamStellarCore, "could not submit request to core")
+	}
+	if coreResponse.Status == proto.PreflightStatusError {
+		return xdr.ScVal{}, 0, &jrpc2.Error{
+			Code:    rpcerror.SimulationFailed,
+			Message: coreResponse.Detail,
+		}
+	}
+	var result xdr.ScVal
+	if err := xdr.SafeUnmarshalBase64(coreResponse.Result, &result); err != nil {
+		return xdr.ScVal{}, 0, &jrpc2.Error{
+			Code:    code.InternalError,
+			Message: "could not parse core response",
+		}
+	}
+	return result, coreResponse.Ledger, nil
+}
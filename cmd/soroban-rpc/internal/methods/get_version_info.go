@@ -0,0 +1,48 @@
+package methods
+
+import (
+	"context"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/handler"
+
+	"github.com/stellar/go/clients/stellarcore"
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/rpcerror"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/tracing"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/version"
+)
+
+type GetVersionInfoResponse struct {
+	Version        string `json:"version"`
+	CommitHash     string `json:"commitHash"`
+	BuildTimestamp string `json:"buildTimestamp"`
+	// CoreVersion is the build version of the stellar-core instance soroban-rpc is connected to
+	CoreVersion string `json:"coreVersion"`
+	// ProtocolVersion is the protocol version of the latest ledger
+	ProtocolVersion int `json:"protocolVersion"`
+	// CoreMaxProtocolVersion is the maximum protocol version supported by stellar-core
+	CoreMaxProtocolVersion int `json:"coreMaxProtocolVersion"`
+}
+
+// NewGetVersionInfoHandler returns a json rpc handler to retrieve the version of soroban-rpc and stellar-core
+func NewGetVersionInfoHandler(logger *log.Entry, coreClient *stellarcore.Client) jrpc2.Handler {
+	return handler.New(func(ctx context.Context) (GetVersionInfoResponse, error) {
+		coreCtx, span := tracing.StartSpan(ctx, "stellar_core.info")
+		info, err := coreClient.Info(coreCtx)
+		tracing.EndSpan(span, err)
+		if err != nil {
+			logger.WithError(err).Info("could not submit info request to core")
+			return GetVersionInfoResponse{}, rpcerror.NewUpstreamUnavailable(rpcerror.UpstreamStellarCore, "could not submit request to core")
+		}
+
+		return GetVersionInfoResponse{
+			Version:                version.Version,
+			CommitHash:             version.CommitHash,
+			BuildTimestamp:         version.BuildTimestamp,
+			CoreVersion:            info.Info.Build,
+			ProtocolVersion:        info.Info.Ledger.Version,
+			CoreMaxProtocolVersion: info.Info.ProtocolVersion,
+		}, nil
+	})
+}
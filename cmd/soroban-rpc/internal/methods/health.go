@@ -2,18 +2,191 @@ package methods
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/creachadair/jrpc2"
 	"github.com/creachadair/jrpc2/handler"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/clients/stellarcore"
+	"github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/tracing"
 )
 
-type HealthCheckResult struct {
+const (
+	HealthStatusHealthy   = "healthy"
+	HealthStatusUnhealthy = "unhealthy"
+)
+
+type DependencyStatus struct {
 	Status string `json:"status"`
+	// Error will be empty unless Status is equal to "unhealthy"
+	Error string `json:"error,omitempty"`
+}
+
+type HealthCheckResult struct {
+	Status                string           `json:"status"`
+	LatestLedger          int64            `json:"latestLedger,string"`
+	LatestLedgerCloseTime int64            `json:"latestLedgerCloseTime,string"`
+	LedgerLagSeconds      int64            `json:"ledgerLagSeconds,string"`
+	CoreSynced            bool             `json:"coreSynced"`
+	Horizon               DependencyStatus `json:"horizon"`
+	StellarCore           DependencyStatus `json:"stellarCore"`
+}
+
+// HealthChecker reports the status of soroban-rpc and of the services it depends on.
+type HealthChecker struct {
+	Logger        *log.Entry
+	HorizonClient *horizonclient.Client
+	CoreClient    *stellarcore.Client
+	// MaxLedgerLatency is the maximum age of the latest closed ledger
+	// before the service is reported as unhealthy
+	MaxLedgerLatency time.Duration
+	// NetworkPassphrase, when set, is compared with the passphrase of Horizon and Stellar Core,
+	// the service is reported as unhealthy when they are connected to another network
+	NetworkPassphrase string
+}
+
+// PassphraseMismatchError is returned when an upstream service is connected to another network
+type PassphraseMismatchError struct {
+	Upstream   string
+	Expected   string
+	Passphrase string
+}
+
+func (e *PassphraseMismatchError) Error() string {
+	return fmt.Sprintf("%s network passphrase mismatch: expected %q but got %q", e.Upstream, e.Expected, e.Passphrase)
+}
+
+func checkPassphrase(upstream, expected, passphrase string) error {
+	// the passphrase is not checked when it's unknown
+	if expected == "" || passphrase == "" || passphrase == expected {
+		return nil
+	}
+	return &PassphraseMismatchError{Upstream: upstream, Expected: expected, Passphrase: passphrase}
+}
+
+// VerifyNetworkPassphrase checks that Horizon and Stellar Core are connected to the network of
+// networkPassphrase. Unreachable services are not checked, since they may not be started yet.
+func VerifyNetworkPassphrase(ctx context.Context, networkPassphrase string, horizonClient *horizonclient.Client, coreClient *stellarcore.Client) error {
+	if root, err := horizonRoot(ctx, horizonClient); err == nil {
+		if err := checkPassphrase("horizon", networkPassphrase, root.NetworkPassphrase); err != nil {
+			return err
+		}
+	}
+	if info, err := coreClient.Info(ctx); err == nil {
+		if err := checkPassphrase("stellar core", networkPassphrase, info.Info.Network); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// horizonRoot fetches the Horizon root resource, giving up once ctx is done. horizonclient doesn't
+// accept a context for Root(), so an abandoned request still completes within the client timeout.
+func horizonRoot(ctx context.Context, client *horizonclient.Client) (horizon.Root, error) {
+	type rootResult struct {
+		root horizon.Root
+		err  error
+	}
+	ch := make(chan rootResult, 1)
+	go func() {
+		root, err := client.Root()
+		ch <- rootResult{root: root, err: err}
+	}()
+	select {
+	case result := <-ch:
+		return result.root, result.err
+	case <-ctx.Done():
+		return horizon.Root{}, ctx.Err()
+	}
+}
+
+// Check queries Horizon and Stellar Core and computes the health of the service.
+func (h HealthChecker) Check(ctx context.Context) HealthCheckResult {
+	result := HealthCheckResult{
+		Status:      HealthStatusHealthy,
+		Horizon:     DependencyStatus{Status: HealthStatusHealthy},
+		StellarCore: DependencyStatus{Status: HealthStatusHealthy},
+	}
+
+	horizonCtx, span := tracing.StartSpan(ctx, "horizon.root")
+	root, err := horizonRoot(horizonCtx, h.HorizonClient)
+	tracing.EndSpan(span, err)
+	if err != nil {
+		h.Logger.WithError(err).Info("health check could not reach horizon")
+		result.Status = HealthStatusUnhealthy
+		result.Horizon = DependencyStatus{
+			Status: HealthStatusUnhealthy,
+			Error:  fmt.Sprintf("could not reach horizon: %v", err),
+		}
+	} else if err := checkPassphrase("horizon", h.NetworkPassphrase, root.NetworkPassphrase); err != nil {
+		h.Logger.WithError(err).Error("health check found horizon connected to another network")
+		result.Status = HealthStatusUnhealthy
+		result.Horizon = DependencyStatus{Status: HealthStatusUnhealthy, Error: err.Error()}
+	}
+
+	coreCtx, span := tracing.StartSpan(ctx, "stellar_core.info")
+	info, err := h.CoreClient.Info(coreCtx)
+	tracing.EndSpan(span, err)
+	if err != nil {
+		h.Logger.WithError(err).Info("health check could not reach stellar core")
+		result.Status = HealthStatusUnhealthy
+		result.StellarCore = DependencyStatus{
+			Status: HealthStatusUnhealthy,
+			Error:  fmt.Sprintf("could not reach stellar core: %v", err),
+		}
+		return result
+	}
+
+	if err := checkPassphrase("stellar core", h.NetworkPassphrase, info.Info.Network); err != nil {
+		h.Logger.WithError(err).Error("health check found stellar core connected to another network")
+		result.Status = HealthStatusUnhealthy
+		result.StellarCore = DependencyStatus{Status: HealthStatusUnhealthy, Error: err.Error()}
+		return result
+	}
+
+	result.CoreSynced = info.IsSynced()
+	result.LatestLedger = int64(info.Info.Ledger.Num)
+	result.LatestLedgerCloseTime = int64(info.Info.Ledger.CloseTime)
+	result.LedgerLagSeconds = time.Now().Unix() - result.LatestLedgerCloseTime
+	if !result.CoreSynced {
+		result.Status = HealthStatusUnhealthy
+		result.StellarCore = DependencyStatus{
+			Status: HealthStatusUnhealthy,
+			Error:  fmt.Sprintf("stellar core is not synced (state: %s)", info.Info.State),
+		}
+	}
+	if lag := time.Duration(result.LedgerLagSeconds) * time.Second; h.MaxLedgerLatency > 0 && lag > h.MaxLedgerLatency {
+		latencyErr := fmt.Sprintf("latest ledger closed %v ago, exceeding the maximum healthy ledger latency of %v", lag, h.MaxLedgerLatency)
+		if result.StellarCore.Error != "" {
+			latencyErr = result.StellarCore.Error + "; " + latencyErr
+		}
+		result.Status = HealthStatusUnhealthy
+		result.StellarCore = DependencyStatus{Status: HealthStatusUnhealthy, Error: latencyErr}
+	}
+
+	return result
+}
+
+// ServeHTTP exposes the health check as a plain HTTP endpoint, responding with
+// 503 Service Unavailable when the service is unhealthy so that load balancers can use it.
+func (h HealthChecker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	result := h.Check(r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	if result.Status != HealthStatusHealthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		h.Logger.WithError(err).Warn("could not write health check response")
+	}
 }
 
 // NewHealthCheck returns a health check json rpc handler
-func NewHealthCheck() jrpc2.Handler {
-	return handler.New(func(context.Context) HealthCheckResult {
-		return HealthCheckResult{Status: "healthy"}
-	})
+func NewHealthCheck(checker HealthChecker) jrpc2.Handler {
+	return handler.New(checker.Check)
 }
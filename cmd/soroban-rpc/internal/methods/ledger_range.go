@@ -0,0 +1,118 @@
+package methods
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/clients/stellarcore"
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/tracing"
+)
+
+// LedgerRange describes the ledgers served by the node. It is included in the responses of the
+// read methods, so that clients can detect nodes serving stale data and fail over.
+type LedgerRange struct {
+	LatestLedger          int64 `json:"latestLedger,string"`
+	LatestLedgerCloseTime int64 `json:"latestLedgerCloseTime,string"`
+	OldestLedger          int64 `json:"oldestLedger,string"`
+	OldestLedgerCloseTime int64 `json:"oldestLedgerCloseTime,string"`
+}
+
+// ledgerRangeStaleRefreshes is the number of refresh intervals after which the LedgerRange is
+// considered stale (i.e. the background refreshes keep failing) and is fetched on demand again
+const ledgerRangeStaleRefreshes = 3
+
+// LedgerRangeTracker obtains the LedgerRange from Stellar Core (latest ledger) and Horizon
+// (oldest ledger of its history). Once started, it refreshes the range every MaxAge in the
+// background, so that requests never wait for the upstream services.
+type LedgerRangeTracker struct {
+	HorizonClient *horizonclient.Client
+	CoreClient    *stellarcore.Client
+	MaxAge        time.Duration
+	// Logger is optional, when set the refresh failures are logged
+	Logger *log.Entry
+
+	lock      sync.RWMutex
+	current   LedgerRange
+	fetchedAt time.Time
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+// Start refreshes the LedgerRange every MaxAge until ctx is done or Close() is called
+func (t *LedgerRangeTracker) Start(ctx context.Context) {
+	ctx, t.cancel = context.WithCancel(ctx)
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		ticker := time.NewTicker(t.MaxAge)
+		defer ticker.Stop()
+		for {
+			if _, err := t.refresh(ctx); err != nil && ctx.Err() == nil && t.Logger != nil {
+				t.Logger.WithError(err).Debug("could not refresh the ledger range")
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Close stops the background refresh (if it was started)
+func (t *LedgerRangeTracker) Close() {
+	if t.cancel != nil {
+		t.cancel()
+	}
+	t.wg.Wait()
+}
+
+// Get returns the last LedgerRange obtained. It is fetched on demand until the first refresh
+// succeeds and once the last refresh is stale, so that an upstream failure is reported instead
+// of serving the same range forever.
+func (t *LedgerRangeTracker) Get(ctx context.Context) (LedgerRange, error) {
+	t.lock.RLock()
+	current, fetchedAt := t.current, t.fetchedAt
+	t.lock.RUnlock()
+	if !fetchedAt.IsZero() && time.Since(fetchedAt) <= ledgerRangeStaleRefreshes*t.MaxAge {
+		return current, nil
+	}
+	return t.refresh(ctx)
+}
+
+// refresh fetches the LedgerRange, the lock isn't held during the upstream calls
+func (t *LedgerRangeTracker) refresh(ctx context.Context) (LedgerRange, error) {
+	coreCtx, span := tracing.StartSpan(ctx, "stellar_core.info")
+	info, err := t.CoreClient.Info(coreCtx)
+	tracing.EndSpan(span, err)
+	if err != nil {
+		return LedgerRange{}, fmt.Errorf("could not obtain the latest ledger from stellar core: %v", err)
+	}
+
+	_, span = tracing.StartSpan(ctx, "horizon.ledgers")
+	page, err := t.HorizonClient.Ledgers(horizonclient.LedgerRequest{Order: horizonclient.OrderAsc, Limit: 1})
+	tracing.EndSpan(span, err)
+	if err != nil {
+		return LedgerRange{}, fmt.Errorf("could not obtain the oldest ledger from horizon: %v", err)
+	}
+	if len(page.Embedded.Records) == 0 {
+		return LedgerRange{}, fmt.Errorf("horizon has not ingested any ledger")
+	}
+	oldest := page.Embedded.Records[0]
+
+	ledgerRange := LedgerRange{
+		LatestLedger:          int64(info.Info.Ledger.Num),
+		LatestLedgerCloseTime: int64(info.Info.Ledger.CloseTime),
+		OldestLedger:          int64(oldest.Sequence),
+		OldestLedgerCloseTime: oldest.ClosedAt.Unix(),
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.current = ledgerRange
+	t.fetchedAt = time.Now()
+	return ledgerRange, nil
+}
@@ -0,0 +1,120 @@
+package methods
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/clients/stellarcore"
+	"github.com/stellar/go/protocols/horizon"
+	proto "github.com/stellar/go/protocols/stellarcore"
+)
+
+func TestLedgerRangeTracker(t *testing.T) {
+	closedAt := time.Date(2022, 12, 1, 0, 0, 0, 0, time.UTC)
+	var latest int32 = 12
+	unblock := make(chan struct{})
+	var blocked int32
+	horizonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&blocked) == 1 {
+			<-unblock
+		}
+		var page horizon.LedgersPage
+		page.Embedded.Records = []horizon.Ledger{{Sequence: 3, ClosedAt: closedAt}}
+		json.NewEncoder(w).Encode(page)
+	}))
+	defer horizonServer.Close()
+	coreServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var info proto.InfoResponse
+		info.Info.Ledger.Num = int(atomic.LoadInt32(&latest))
+		info.Info.Ledger.CloseTime = int(closedAt.Add(time.Minute).Unix())
+		json.NewEncoder(w).Encode(info)
+	}))
+	defer coreServer.Close()
+
+	tracker := &LedgerRangeTracker{
+		HorizonClient: &horizonclient.Client{HorizonURL: horizonServer.URL + "/"},
+		CoreClient:    &stellarcore.Client{URL: coreServer.URL},
+		MaxAge:        100 * time.Millisecond,
+	}
+	// the range is fetched on demand before the first refresh
+	ledgerRange, err := tracker.Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, LedgerRange{
+		LatestLedger:          12,
+		LatestLedgerCloseTime: closedAt.Add(time.Minute).Unix(),
+		OldestLedger:          3,
+		OldestLedgerCloseTime: closedAt.Unix(),
+	}, ledgerRange)
+
+	tracker.Start(context.Background())
+	atomic.StoreInt32(&latest, 13)
+	assert.Eventually(t, func() bool {
+		ledgerRange, err := tracker.Get(context.Background())
+		return err == nil && ledgerRange.LatestLedger == 13
+	}, time.Second, 5*time.Millisecond)
+
+	// a slow upstream doesn't block the callers, which get the last range until it is stale
+	atomic.StoreInt32(&blocked, 1)
+	atomic.StoreInt32(&latest, 14)
+	time.Sleep(120 * time.Millisecond)
+	ledgerRange, err = tracker.Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(13), ledgerRange.LatestLedger)
+
+	close(unblock)
+	tracker.Close()
+}
+
+func TestLedgerRangeTrackerStale(t *testing.T) {
+	var failing int32
+	horizonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var page horizon.LedgersPage
+		page.Embedded.Records = []horizon.Ledger{{Sequence: 3}}
+		json.NewEncoder(w).Encode(page)
+	}))
+	defer horizonServer.Close()
+	coreServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var info proto.InfoResponse
+		info.Info.Ledger.Num = 12
+		json.NewEncoder(w).Encode(info)
+	}))
+	defer coreServer.Close()
+
+	tracker := &LedgerRangeTracker{
+		HorizonClient: &horizonclient.Client{HorizonURL: horizonServer.URL + "/"},
+		CoreClient:    &stellarcore.Client{URL: coreServer.URL},
+		MaxAge:        10 * time.Millisecond,
+	}
+	tracker.Start(context.Background())
+	defer tracker.Close()
+	assert.Eventually(t, func() bool {
+		_, err := tracker.Get(context.Background())
+		return err == nil
+	}, time.Second, 5*time.Millisecond)
+
+	// once the refreshes fail for longer than the stale bound, the failure is reported
+	atomic.StoreInt32(&failing, 1)
+	assert.Eventually(t, func() bool {
+		_, err := tracker.Get(context.Background())
+		return err != nil
+	}, time.Second, 5*time.Millisecond)
+
+	// and the range is served again once the upstream recovers
+	atomic.StoreInt32(&failing, 0)
+	ledgerRange, err := tracker.Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), ledgerRange.LatestLedger)
+}
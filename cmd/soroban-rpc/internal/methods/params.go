@@ -0,0 +1,149 @@
+package methods
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/code"
+)
+
+// withOptionalParams wraps a handler created by handler.New taking request parameters of the
+// same type as request. handler.New requires positional parameters to provide every field of the
+// struct, which breaks existing clients whenever an optional field is added. The wrapper converts
+// positional parameters omitting trailing fields into named parameters before invoking h.
+func withOptionalParams(request interface{}, h jrpc2.Handler) jrpc2.Handler {
+	names := paramNames(reflect.TypeOf(request))
+	return optionalParamsHandler{names: names, handler: h}
+}
+
+type optionalParamsHandler struct {
+	names   []string
+	handler jrpc2.Handler
+}
+
+func (o optionalParamsHandler) Handle(ctx context.Context, req *jrpc2.Request) (interface{}, error) {
+	params := bytes.TrimSpace([]byte(req.ParamString()))
+	if len(params) == 0 || params[0] != '[' {
+		return o.handler.Handle(ctx, req)
+	}
+	var positional []json.RawMessage
+	if err := json.Unmarshal(params, &positional); err != nil || len(positional) >= len(o.names) {
+		// let the wrapped handler report any errors
+		return o.handler.Handle(ctx, req)
+	}
+	named := make(map[string]json.RawMessage, len(positional))
+	for i, param := range positional {
+		named[o.names[i]] = param
+	}
+	encoded, err := json.Marshal(named)
+	if err != nil {
+		return nil, err
+	}
+	parsed := jrpc2.ParsedRequest{ID: req.ID(), Method: req.Method(), Params: encoded}
+	return o.handler.Handle(ctx, parsed.ToRequest())
+}
+
+// QueryParams converts URL query parameters into the named JSON-RPC parameters of a request
+// struct of the same type as request, using the field types to decide how values are encoded.
+// Repeated query parameters are used for slices (e.g. ?keys=a&keys=b). A nil request
+// corresponds to a method without parameters.
+func QueryParams(request interface{}, query url.Values) (json.RawMessage, error) {
+	if request == nil {
+		if len(query) > 0 {
+			return nil, &jrpc2.Error{Code: code.InvalidParams, Message: "method does not take parameters"}
+		}
+		return nil, nil
+	}
+	t := reflect.TypeOf(request)
+	fields := map[string]reflect.StructField{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch {
+		case name == "-":
+			continue
+		case name == "":
+			name = lowerFirst(field.Name)
+		}
+		fields[name] = field
+	}
+
+	params := make(map[string]interface{}, len(query))
+	for name, values := range query {
+		field, ok := fields[name]
+		if !ok {
+			return nil, &jrpc2.Error{Code: code.InvalidParams, Message: fmt.Sprintf("unknown parameter %q", name)}
+		}
+		if field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.String {
+			params[name] = values
+			continue
+		}
+		if len(values) > 1 {
+			return nil, &jrpc2.Error{Code: code.InvalidParams, Message: fmt.Sprintf("parameter %q must only be provided once", name)}
+		}
+		value, err := queryValue(field, values[0])
+		if err != nil {
+			return nil, &jrpc2.Error{Code: code.InvalidParams, Message: fmt.Sprintf("invalid parameter %q: %v", name, err)}
+		}
+		params[name] = value
+	}
+	return json.Marshal(params)
+}
+
+func queryValue(field reflect.StructField, value string) (interface{}, error) {
+	_, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+	quoted := false
+	for _, option := range strings.Split(options, ",") {
+		quoted = quoted || option == "string"
+	}
+	switch field.Type.Kind() {
+	case reflect.String:
+		return value, nil
+	case reflect.Bool:
+		return strconv.ParseBool(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return nil, fmt.Errorf("expected a number")
+		}
+		if quoted {
+			return value, nil
+		}
+		return json.Number(value), nil
+	default:
+		return nil, fmt.Errorf("parameters of type %s cannot be provided in the query", field.Type)
+	}
+}
+
+// paramNames returns the names of the parameters of a request struct, in declaration order,
+// following the same rules as handler.New
+func paramNames(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch {
+		case name == "-":
+			continue
+		case name == "":
+			// encoding/json matches untagged field names case-insensitively
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,105 @@
+package methods
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/channel"
+	"github.com/creachadair/jrpc2/code"
+	"github.com/creachadair/jrpc2/handler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type optionalParamsRequest struct {
+	Required string `json:"required"`
+	Optional string `json:"optional,omitempty"`
+}
+
+func TestWithOptionalParams(t *testing.T) {
+	clientChannel, serverChannel := channel.Direct()
+	server := jrpc2.NewServer(handler.Map{
+		"echo": withOptionalParams(optionalParamsRequest{}, handler.New(
+			func(ctx context.Context, request optionalParamsRequest) (optionalParamsRequest, error) {
+				return request, nil
+			},
+		)),
+	}, nil).Start(serverChannel)
+	defer server.Stop()
+	client := jrpc2.NewClient(clientChannel, nil)
+	defer client.Close()
+
+	for _, testCase := range []struct {
+		name     string
+		params   string
+		expected optionalParamsRequest
+	}{
+		{"all positional", `["a", "b"]`, optionalParamsRequest{Required: "a", Optional: "b"}},
+		{"omitted positional", `["a"]`, optionalParamsRequest{Required: "a"}},
+		{"named", `{"required": "a", "optional": "b"}`, optionalParamsRequest{Required: "a", Optional: "b"}},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			var result optionalParamsRequest
+			err := client.CallResult(context.Background(), "echo", json.RawMessage(testCase.params), &result)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expected, result)
+		})
+	}
+
+	t.Run("too many positional", func(t *testing.T) {
+		_, err := client.Call(context.Background(), "echo", json.RawMessage(`["a", "b", "c"]`))
+		assert.Error(t, err)
+	})
+}
+
+type queryParamsRequest struct {
+	Name    string   `json:"name"`
+	Keys    []string `json:"keys"`
+	Limit   uint     `json:"limit,omitempty"`
+	Amount  int64    `json:"amount,string,omitempty"`
+	Verbose bool     `json:"verbose,omitempty"`
+	Ignored string   `json:"-"`
+}
+
+func TestQueryParams(t *testing.T) {
+	params, err := QueryParams(queryParamsRequest{}, url.Values{
+		"name":    {"a"},
+		"keys":    {"k1", "k2"},
+		"limit":   {"10"},
+		"amount":  {"-5"},
+		"verbose": {"true"},
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"a","keys":["k1","k2"],"limit":10,"amount":"-5","verbose":true}`, string(params))
+	var request queryParamsRequest
+	require.NoError(t, json.Unmarshal(params, &request))
+	assert.Equal(t, queryParamsRequest{Name: "a", Keys: []string{"k1", "k2"}, Limit: 10, Amount: -5, Verbose: true}, request)
+
+	params, err = QueryParams(nil, url.Values{})
+	require.NoError(t, err)
+	assert.Nil(t, params)
+
+	for _, testCase := range []struct {
+		name    string
+		request interface{}
+		query   url.Values
+		message string
+	}{
+		{"no params", nil, url.Values{"name": {"a"}}, "method does not take parameters"},
+		{"unknown", queryParamsRequest{}, url.Values{"other": {"a"}}, `unknown parameter "other"`},
+		{"ignored", queryParamsRequest{}, url.Values{"Ignored": {"a"}}, `unknown parameter "Ignored"`},
+		{"repeated", queryParamsRequest{}, url.Values{"name": {"a", "b"}}, `parameter "name" must only be provided once`},
+		{"not a number", queryParamsRequest{}, url.Values{"limit": {"ten"}}, `invalid parameter "limit": expected a number`},
+		{"not a bool", queryParamsRequest{}, url.Values{"verbose": {"maybe"}}, `invalid parameter "verbose": strconv.ParseBool: parsing "maybe": invalid syntax`},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := QueryParams(testCase.request, testCase.query)
+			require.Error(t, err)
+			assert.Equal(t, code.InvalidParams, err.(*jrpc2.Error).Code)
+			assert.Equal(t, testCase.message, err.(*jrpc2.Error).Message)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package methods
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	errPreflightQueueFull = errors.New("preflight queue is full")
+	errPreflightTimeout   = errors.New("timed out waiting for a preflight worker")
+)
+
+// PreflightQueue bounds the number of preflight requests which are executed concurrently
+// and the number of requests waiting for execution.
+type PreflightQueue struct {
+	slots    chan struct{}
+	maxQueue int64
+	queued   int64
+	rejected uint64
+	timeout  time.Duration
+}
+
+// NewPreflightQueue creates a PreflightQueue executing up to workers preflight requests
+// concurrently, holding at most queueSize waiting requests. Requests are aborted once
+// timeout elapses (a zero timeout means requests never time out).
+func NewPreflightQueue(workers, queueSize int, timeout time.Duration) *PreflightQueue {
+	if workers < 1 {
+		workers = 1
+	}
+	return &PreflightQueue{
+		slots:    make(chan struct{}, workers),
+		maxQueue: int64(queueSize),
+		timeout:  timeout,
+	}
+}
+
+// QueueLength returns the number of preflight requests waiting for a worker.
+func (q *PreflightQueue) QueueLength() int64 {
+	return atomic.LoadInt64(&q.queued)
+}
+
+// Rejected returns the number of preflight requests rejected because the queue
+// was full or because they timed out waiting for a worker.
+func (q *PreflightQueue) Rejected() uint64 {
+	return atomic.LoadUint64(&q.rejected)
+}
+
+// Run waits until a worker is available and executes f with a context which is
+// canceled once the queue timeout elapses.
+func (q *PreflightQueue) Run(ctx context.Context, f func(ctx context.Context) error) error {
+	if q.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, q.timeout)
+		defer cancel()
+	}
+
+	select {
+	case q.slots <- struct{}{}:
+	default:
+		if atomic.AddInt64(&q.queued, 1) > q.maxQueue {
+			atomic.AddInt64(&q.queued, -1)
+			atomic.AddUint64(&q.rejected, 1)
+			return errPreflightQueueFull
+		}
+		select {
+		case q.slots <- struct{}{}:
+			atomic.AddInt64(&q.queued, -1)
+		case <-ctx.Done():
+			atomic.AddInt64(&q.queued, -1)
+			atomic.AddUint64(&q.rejected, 1)
+			return errPreflightTimeout
+		}
+	}
+	defer func() { <-q.slots }()
+
+	return f(ctx)
+}
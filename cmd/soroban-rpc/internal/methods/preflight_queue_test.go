@@ -0,0 +1,51 @@
+package methods
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreflightQueue(t *testing.T) {
+	queue := NewPreflightQueue(1, 1, 50*time.Millisecond)
+
+	running := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- queue.Run(context.Background(), func(ctx context.Context) error {
+			close(running)
+			<-release
+			return nil
+		})
+	}()
+	<-running
+
+	queued := make(chan error)
+	go func() {
+		queued <- queue.Run(context.Background(), func(ctx context.Context) error {
+			return nil
+		})
+	}()
+	assert.Eventually(t, func() bool { return queue.QueueLength() == 1 }, time.Second, time.Millisecond)
+
+	// the worker is busy and the queue is full
+	err := queue.Run(context.Background(), func(ctx context.Context) error {
+		t.Fatal("should not be executed")
+		return nil
+	})
+	assert.Equal(t, errPreflightQueueFull, err)
+
+	// the queued request times out while the worker is busy
+	assert.Equal(t, errPreflightTimeout, <-queued)
+	assert.Equal(t, int64(0), queue.QueueLength())
+	assert.Equal(t, uint64(2), queue.Rejected())
+
+	close(release)
+	assert.NoError(t, <-done)
+	assert.NoError(t, queue.Run(context.Background(), func(ctx context.Context) error {
+		return nil
+	}))
+}
@@ -2,18 +2,89 @@ package methods
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"time"
 
 	"github.com/stellar/go/clients/stellarcore"
 	proto "github.com/stellar/go/protocols/stellarcore"
 	"github.com/stellar/go/support/log"
 	"github.com/stellar/go/xdr"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/rpcerror"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/tracing"
 
 	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/code"
 	"github.com/creachadair/jrpc2/handler"
 )
 
+const (
+	BudgetResourceCPU    = "cpu"
+	BudgetResourceMemory = "memory"
+	BudgetResourceTime   = "time"
+)
+
+// PreflightBudget limits the resources of transaction simulations. Zero values mean no limit.
+type PreflightBudget struct {
+	// CPUInstructions and MemoryBytes are not enforced during the simulation: Stellar Core doesn't
+	// allow bounding the host budget of preflight requests, so they only filter the results of
+	// simulations which went over them (see filterResult). Use Timeout to bound the execution.
+	CPUInstructions uint64
+	MemoryBytes     uint64
+	// Timeout bounds the execution of the preflight request, it doesn't include
+	// the time spent waiting in the preflight queue
+	Timeout time.Duration
+}
+
+// restrict returns the budget resulting from applying the limits requested by a client,
+// which can only lower the limits configured by the operator
+func (b PreflightBudget) restrict(request SimulateTransactionRequest) PreflightBudget {
+	lower := func(limit, requested uint64) uint64 {
+		if requested > 0 && (limit == 0 || requested < limit) {
+			return requested
+		}
+		return limit
+	}
+	b.CPUInstructions = lower(b.CPUInstructions, request.CPUInstructionsLimit)
+	b.MemoryBytes = lower(b.MemoryBytes, request.MemoryBytesLimit)
+	timeout := time.Duration(request.TimeoutSeconds) * time.Second
+	if timeout > 0 && (b.Timeout == 0 || timeout < b.Timeout) {
+		b.Timeout = timeout
+	}
+	return b
+}
+
+// filterResult returns an error if the cost of a completed simulation exceeds the CPU or memory
+// limits of the budget, in which case its result is withheld from the client.
+func (b PreflightBudget) filterResult(cost SimulateTransactionCost) *BudgetExceededError {
+	if b.CPUInstructions > 0 && cost.CPUInstructions > b.CPUInstructions {
+		return &BudgetExceededError{Resource: BudgetResourceCPU, Limit: b.CPUInstructions, Used: cost.CPUInstructions}
+	}
+	if b.MemoryBytes > 0 && cost.MemoryBytes > b.MemoryBytes {
+		return &BudgetExceededError{Resource: BudgetResourceMemory, Limit: b.MemoryBytes, Used: cost.MemoryBytes}
+	}
+	return nil
+}
+
 type SimulateTransactionRequest struct {
 	Transaction string `json:"transaction"`
+	// CPUInstructionsLimit, MemoryBytesLimit and TimeoutSeconds are optional, they can
+	// only lower the limits configured in the server (see PreflightBudget)
+	CPUInstructionsLimit uint64 `json:"cpuInsnsLimit,string,omitempty"`
+	MemoryBytesLimit     uint64 `json:"memBytesLimit,string,omitempty"`
+	TimeoutSeconds       int    `json:"timeoutSeconds,omitempty"`
+}
+
+// BudgetExceededError describes which resource limit the simulation exceeded.
+// Limit and Used are expressed in instructions, bytes or milliseconds depending on Resource.
+type BudgetExceededError struct {
+	Resource string `json:"resource"`
+	Limit    uint64 `json:"limit,string"`
+	Used     uint64 `json:"used,string,omitempty"`
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("preflight %s budget exceeded (limit: %d)", e.Resource, e.Limit)
 }
 
 type SimulateTransactionCost struct {
@@ -26,27 +97,79 @@ type InvokeHostFunctionResult struct {
 }
 
 type SimulateTransactionResponse struct {
-	Error        string                     `json:"error,omitempty"`
-	Results      []InvokeHostFunctionResult `json:"results,omitempty"`
-	Footprint    string                     `json:"footprint"`
-	Cost         SimulateTransactionCost    `json:"cost"`
-	LatestLedger int64                      `json:"latestLedger,string"`
+	Error string `json:"error,omitempty"`
+	// ErrorCode is only set along with Error, it classifies the error using the JSON-RPC
+	// error codes of the rpcerror package (or code.InvalidParams for malformed transactions)
+	ErrorCode code.Code `json:"errorCode,omitempty"`
+	// BudgetExceeded is only set when the simulation was aborted because it exceeded the
+	// preflight timeout, or when its result was withheld because it exceeded the CPU or memory limits
+	BudgetExceeded *BudgetExceededError       `json:"budgetExceeded,omitempty"`
+	Results        []InvokeHostFunctionResult `json:"results,omitempty"`
+	Footprint      string                     `json:"footprint"`
+	Cost           SimulateTransactionCost    `json:"cost"`
+	LatestLedger   int64                      `json:"latestLedger,string"`
+}
+
+// Cacheable reports whether the simulation succeeded without writing to the ledger,
+// in which case its result can be reused until a new ledger is closed.
+func (r SimulateTransactionResponse) Cacheable() bool {
+	if r.Error != "" {
+		return false
+	}
+	var footprint xdr.LedgerFootprint
+	if err := xdr.SafeUnmarshalBase64(r.Footprint, &footprint); err != nil {
+		return false
+	}
+	return len(footprint.ReadWrite) == 0
+}
+
+// SimulateTransactionCacheKey keys simulations by what is sent to stellar core (the source account
+// and the invocation) along with the requested limits. This allows sharing the results of popular
+// read-only invocations between transactions with different sequence numbers, fees or signatures.
+func SimulateTransactionCacheKey(req *jrpc2.Request) (string, bool) {
+	var request SimulateTransactionRequest
+	// positional params are keyed by their raw value
+	if err := json.Unmarshal([]byte(req.ParamString()), &request); err != nil {
+		return "", false
+	}
+	var txEnvelope xdr.TransactionEnvelope
+	if err := xdr.SafeUnmarshalBase64(request.Transaction, &txEnvelope); err != nil || len(txEnvelope.Operations()) != 1 {
+		return "", false
+	}
+	op := txEnvelope.Operations()[0]
+	xdrOp, ok := op.Body.GetInvokeHostFunctionOp()
+	if !ok {
+		return "", false
+	}
+	opXDR, err := xdr.MarshalBase64(xdrOp)
+	if err != nil {
+		return "", false
+	}
+	sourceAccount := txEnvelope.SourceAccount().ToAccountId().Address()
+	if op.SourceAccount != nil {
+		sourceAccount = op.SourceAccount.ToAccountId().Address()
+	}
+	return fmt.Sprintf("%s/%s/%d/%d/%d", sourceAccount, opXDR,
+		request.CPUInstructionsLimit, request.MemoryBytesLimit, request.TimeoutSeconds), true
 }
 
 // NewSimulateTransactionHandler returns a json rpc handler to execute preflight requests to stellar core
-func NewSimulateTransactionHandler(logger *log.Entry, coreClient *stellarcore.Client) jrpc2.Handler {
-	return handler.New(func(ctx context.Context, request SimulateTransactionRequest) SimulateTransactionResponse {
+func NewSimulateTransactionHandler(logger *log.Entry, coreClient *stellarcore.Client, queue *PreflightQueue, budget PreflightBudget) jrpc2.Handler {
+	return withOptionalParams(SimulateTransactionRequest{}, handler.New(func(ctx context.Context, request SimulateTransactionRequest) SimulateTransactionResponse {
+		budget := budget.restrict(request)
 		var txEnvelope xdr.TransactionEnvelope
 		if err := xdr.SafeUnmarshalBase64(request.Transaction, &txEnvelope); err != nil {
 			logger.WithError(err).WithField("request", request).
 				Info("could not unmarshal simulate transaction envelope")
 			return SimulateTransactionResponse{
-				Error: "Could not unmarshal transaction",
+				Error:     "Could not unmarshal transaction",
+				ErrorCode: code.InvalidParams,
 			}
 		}
 		if len(txEnvelope.Operations()) != 1 {
 			return SimulateTransactionResponse{
-				Error: "Transaction contains more than one operation",
+				Error:     "Transaction contains more than one operation",
+				ErrorCode: code.InvalidParams,
 			}
 		}
 
@@ -60,34 +183,78 @@ func NewSimulateTransactionHandler(logger *log.Entry, coreClient *stellarcore.Cl
 		xdrOp, ok := txEnvelope.Operations()[0].Body.GetInvokeHostFunctionOp()
 		if !ok {
 			return SimulateTransactionResponse{
-				Error: "Transaction does not contain invoke host function operation",
+				Error:     "Transaction does not contain invoke host function operation",
+				ErrorCode: code.InvalidParams,
 			}
 		}
 
-		coreResponse, err := coreClient.Preflight(ctx, sourceAccount, xdrOp)
+		var coreResponse proto.PreflightResponse
+		err := queue.Run(ctx, func(ctx context.Context) error {
+			if budget.Timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, budget.Timeout)
+				defer cancel()
+			}
+			var err error
+			ctx, span := tracing.StartSpan(ctx, "stellar_core.preflight")
+			coreResponse, err = coreClient.Preflight(ctx, sourceAccount, xdrOp)
+			tracing.EndSpan(span, err)
+			if err != nil && budget.Timeout > 0 && ctx.Err() == context.DeadlineExceeded {
+				return &BudgetExceededError{Resource: BudgetResourceTime, Limit: uint64(budget.Timeout.Milliseconds())}
+			}
+			return err
+		})
+		if budgetErr, ok := err.(*BudgetExceededError); ok {
+			return SimulateTransactionResponse{
+				Error:          "Preflight request rejected: " + budgetErr.Error(),
+				ErrorCode:      rpcerror.PreflightRejected,
+				BudgetExceeded: budgetErr,
+			}
+		}
+		if err == errPreflightQueueFull || err == errPreflightTimeout {
+			logger.WithError(err).WithField("request", request).
+				Info("could not schedule preflight request")
+			return SimulateTransactionResponse{
+				Error:     "Preflight request rejected: " + err.Error(),
+				ErrorCode: rpcerror.PreflightRejected,
+			}
+		}
 		if err != nil {
 			logger.WithError(err).WithField("request", request).
 				Info("could not submit preflight request to core")
 			return SimulateTransactionResponse{
-				Error: "Could not submit request to core",
+				Error:     "Could not submit request to core",
+				ErrorCode: rpcerror.UpstreamUnavailable,
 			}
 		}
 
 		if coreResponse.Status == proto.PreflightStatusError {
 			return SimulateTransactionResponse{
 				Error:        coreResponse.Detail,
+				ErrorCode:    rpcerror.SimulationFailed,
 				LatestLedger: coreResponse.Ledger,
 			}
 		}
 
+		cost := SimulateTransactionCost{
+			CPUInstructions: coreResponse.CPUInstructions,
+			MemoryBytes:     coreResponse.MemoryBytes,
+		}
+		if budgetErr := budget.filterResult(cost); budgetErr != nil {
+			return SimulateTransactionResponse{
+				Error:          "Preflight request rejected: " + budgetErr.Error(),
+				ErrorCode:      rpcerror.PreflightRejected,
+				BudgetExceeded: budgetErr,
+				Cost:           cost,
+				LatestLedger:   coreResponse.Ledger,
+			}
+		}
+
 		return SimulateTransactionResponse{
-			Results:   []InvokeHostFunctionResult{{XDR: coreResponse.Result}},
-			Footprint: coreResponse.Footprint,
-			Cost: SimulateTransactionCost{
-				CPUInstructions: coreResponse.CPUInstructions,
-				MemoryBytes:     coreResponse.MemoryBytes,
-			},
+			Results:      []InvokeHostFunctionResult{{XDR: coreResponse.Result}},
+			Footprint:    coreResponse.Footprint,
+			Cost:         cost,
 			LatestLedger: coreResponse.Ledger,
 		}
-	})
+	}))
 }
@@ -0,0 +1,96 @@
+package methods
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/go/xdr"
+)
+
+func TestPreflightBudgetRestrict(t *testing.T) {
+	budget := PreflightBudget{CPUInstructions: 1000, Timeout: 10 * time.Second}
+
+	assert.Equal(t, budget, budget.restrict(SimulateTransactionRequest{}))
+	assert.Equal(t, budget, budget.restrict(SimulateTransactionRequest{
+		CPUInstructionsLimit: 2000,
+		TimeoutSeconds:       20,
+	}))
+	assert.Equal(t, PreflightBudget{CPUInstructions: 500, MemoryBytes: 100, Timeout: time.Second}, budget.restrict(SimulateTransactionRequest{
+		CPUInstructionsLimit: 500,
+		MemoryBytesLimit:     100,
+		TimeoutSeconds:       1,
+	}))
+}
+
+func TestPreflightBudgetFilterResult(t *testing.T) {
+	assert.Nil(t, PreflightBudget{}.filterResult(SimulateTransactionCost{CPUInstructions: 1000, MemoryBytes: 1000}))
+
+	budget := PreflightBudget{CPUInstructions: 1000, MemoryBytes: 100}
+	assert.Nil(t, budget.filterResult(SimulateTransactionCost{CPUInstructions: 1000, MemoryBytes: 100}))
+	assert.Equal(t,
+		&BudgetExceededError{Resource: BudgetResourceCPU, Limit: 1000, Used: 1001},
+		budget.filterResult(SimulateTransactionCost{CPUInstructions: 1001}),
+	)
+	assert.Equal(t,
+		&BudgetExceededError{Resource: BudgetResourceMemory, Limit: 100, Used: 200},
+		budget.filterResult(SimulateTransactionCost{CPUInstructions: 10, MemoryBytes: 200}),
+	)
+}
+
+func TestSimulateTransactionResponseCacheable(t *testing.T) {
+	readOnly, err := xdr.MarshalBase64(xdr.LedgerFootprint{})
+	require.NoError(t, err)
+	readWrite, err := xdr.MarshalBase64(xdr.LedgerFootprint{
+		ReadWrite: []xdr.LedgerKey{{
+			Type:    xdr.LedgerEntryTypeAccount,
+			Account: &xdr.LedgerKeyAccount{AccountId: xdr.MustAddress("GBRPYHIL2CI3FNQ4BXLFMNDLFJUNPU2HY3ZMFSHONUCEOASW7QC7OX2H")},
+		}},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, SimulateTransactionResponse{Footprint: readOnly}.Cacheable())
+	assert.False(t, SimulateTransactionResponse{Footprint: readWrite}.Cacheable())
+	assert.False(t, SimulateTransactionResponse{Footprint: readOnly, Error: "rejected"}.Cacheable())
+}
+
+func TestSimulateTransactionCacheKey(t *testing.T) {
+	source := keypair.MustRandom()
+	request := func(sequence int64, function string, cpuLimit uint64) *jrpc2.Request {
+		sym := xdr.ScSymbol(function)
+		tx, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+			SourceAccount: &txnbuild.SimpleAccount{AccountID: source.Address(), Sequence: sequence},
+			Operations: []txnbuild.Operation{&txnbuild.InvokeHostFunction{
+				Function:   xdr.HostFunctionHostFnInvokeContract,
+				Parameters: xdr.ScVec{{Type: xdr.ScValTypeScvSymbol, Sym: &sym}},
+			}},
+			BaseFee:       txnbuild.MinBaseFee,
+			Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewInfiniteTimeout()},
+		})
+		require.NoError(t, err)
+		txXDR, err := tx.Base64()
+		require.NoError(t, err)
+		params, err := json.Marshal(SimulateTransactionRequest{Transaction: txXDR, CPUInstructionsLimit: cpuLimit})
+		require.NoError(t, err)
+		return (&jrpc2.ParsedRequest{ID: "1", Method: "simulateTransaction", Params: params}).ToRequest()
+	}
+	key := func(req *jrpc2.Request) string {
+		key, ok := SimulateTransactionCacheKey(req)
+		require.True(t, ok)
+		return key
+	}
+
+	// the sequence number doesn't affect the simulation
+	assert.Equal(t, key(request(1, "price", 0)), key(request(2, "price", 0)))
+	assert.NotEqual(t, key(request(1, "price", 0)), key(request(1, "balance", 0)))
+	assert.NotEqual(t, key(request(1, "price", 0)), key(request(1, "price", 1000)))
+
+	_, ok := SimulateTransactionCacheKey((&jrpc2.ParsedRequest{ID: "1", Method: "simulateTransaction", Params: json.RawMessage(`["AAAA"]`)}).ToRequest())
+	assert.False(t, ok)
+}
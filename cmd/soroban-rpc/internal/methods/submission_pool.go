@@ -0,0 +1,185 @@
+package methods
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/support/log"
+)
+
+const (
+	// SubmissionSuccess is the outcome of the submissions included in a ledger
+	SubmissionSuccess = "success"
+	// SubmissionRejected is the outcome of the submissions rejected by Stellar Core (e.g. with
+	// a bad sequence number), they aren't submitted to another upstream
+	SubmissionRejected = "rejected"
+	// SubmissionError is the outcome of the submissions which the upstream couldn't process,
+	// they are submitted to the next upstream
+	SubmissionError = "error"
+
+	defaultProbeInterval = 10 * time.Second
+	// outcomeWeight is the weight of the latest submission in the success rate and latency of an upstream
+	outcomeWeight = 0.2
+)
+
+// SubmissionUpstream is a Horizon instance transactions can be submitted to
+type SubmissionUpstream struct {
+	// Name identifies the upstream in the logs and metrics
+	Name   string
+	Client *horizonclient.Client
+}
+
+// SubmissionUpstreamStatus describes the health of an upstream, as tracked by the SubmissionPool
+type SubmissionUpstreamStatus struct {
+	Name    string
+	Healthy bool
+	// SuccessRate is the exponentially weighted ratio of submissions which the upstream processed
+	SuccessRate float64
+	// Latency is the exponentially weighted duration of the submissions processed by the upstream
+	Latency time.Duration
+}
+
+// SubmissionPool submits transactions to the healthiest of several upstreams, failing over to the
+// next ones when an upstream can't process a submission. The health of the upstreams is tracked
+// through periodic probes and the outcome of the submissions.
+type SubmissionPool struct {
+	logger        *log.Entry
+	probeInterval time.Duration
+	lock          sync.Mutex
+	upstreams     []SubmissionUpstream
+	statuses      []SubmissionUpstreamStatus
+	// OnSubmission, when set, is invoked after every submission to an upstream
+	OnSubmission func(upstream, outcome string, duration time.Duration)
+}
+
+// NewSubmissionPool creates a SubmissionPool, the upstreams are probed every probeInterval
+// (10 seconds by default) once it is started. The upstreams are initially considered healthy,
+// ties are broken by their order.
+func NewSubmissionPool(logger *log.Entry, upstreams []SubmissionUpstream, probeInterval time.Duration) *SubmissionPool {
+	if probeInterval <= 0 {
+		probeInterval = defaultProbeInterval
+	}
+	statuses := make([]SubmissionUpstreamStatus, len(upstreams))
+	for i, upstream := range upstreams {
+		statuses[i] = SubmissionUpstreamStatus{Name: upstream.Name, Healthy: true, SuccessRate: 1}
+	}
+	return &SubmissionPool{
+		logger:        logger,
+		probeInterval: probeInterval,
+		upstreams:     upstreams,
+		statuses:      statuses,
+	}
+}
+
+// Start probes the upstreams in the background until ctx is done
+func (p *SubmissionPool) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(p.probeInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.probe()
+			}
+		}
+	}()
+}
+
+func (p *SubmissionPool) probe() {
+	for i, upstream := range p.upstreams {
+		_, err := upstream.Client.Root()
+		p.lock.Lock()
+		if p.statuses[i].Healthy != (err == nil) {
+			p.logger.WithField("upstream", upstream.Name).WithField("healthy", err == nil).Info("submission upstream health changed")
+		}
+		p.statuses[i].Healthy = err == nil
+		p.lock.Unlock()
+	}
+}
+
+// Statuses returns the health of the upstreams, in the order they were configured
+func (p *SubmissionPool) Statuses() []SubmissionUpstreamStatus {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return append([]SubmissionUpstreamStatus{}, p.statuses...)
+}
+
+// order returns the indexes of the upstreams from the healthiest to the least healthy one
+func (p *SubmissionPool) order() []int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	order := make([]int, len(p.statuses))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := p.statuses[order[i]], p.statuses[order[j]]
+		if a.Healthy != b.Healthy {
+			return a.Healthy
+		}
+		if a.SuccessRate != b.SuccessRate {
+			return a.SuccessRate > b.SuccessRate
+		}
+		return a.Latency < b.Latency
+	})
+	return order
+}
+
+func (p *SubmissionPool) record(i int, outcome string, duration time.Duration) {
+	p.lock.Lock()
+	status := &p.statuses[i]
+	processed := 0.0
+	if outcome != SubmissionError {
+		processed = 1
+		status.Latency += time.Duration(outcomeWeight * float64(duration-status.Latency))
+	} else {
+		// the upstream is avoided until it is probed successfully again
+		status.Healthy = false
+	}
+	status.SuccessRate += outcomeWeight * (processed - status.SuccessRate)
+	p.lock.Unlock()
+	if p.OnSubmission != nil {
+		p.OnSubmission(p.upstreams[i].Name, outcome, duration)
+	}
+}
+
+// submissionOutcome classifies the result of a submission
+func submissionOutcome(err error) string {
+	if err == nil {
+		return SubmissionSuccess
+	}
+	if herr, ok := err.(*horizonclient.Error); ok && herr.Problem.Status < http.StatusInternalServerError {
+		return SubmissionRejected
+	}
+	return SubmissionError
+}
+
+// Submit submits the transaction to the healthiest upstream, and to the next ones when it can't be
+// processed. The error of the last submission is returned when none of the upstreams processed it.
+func (p *SubmissionPool) Submit(ctx context.Context, transactionXDR string) (horizon.Transaction, error) {
+	var (
+		tx  horizon.Transaction
+		err error
+	)
+	for _, i := range p.order() {
+		if ctx.Err() != nil {
+			return tx, ctx.Err()
+		}
+		start := time.Now()
+		tx, err = p.upstreams[i].Client.SubmitTransactionXDR(transactionXDR)
+		outcome := submissionOutcome(err)
+		p.record(i, outcome, time.Since(start))
+		if outcome != SubmissionError {
+			return tx, err
+		}
+		p.logger.WithError(err).WithField("upstream", p.upstreams[i].Name).Info("could not submit transaction, failing over")
+	}
+	return tx, err
+}
@@ -0,0 +1,93 @@
+package methods
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/support/render/problem"
+)
+
+// newSubmissionServer emulates the submissions of Horizon, responding with the given status
+// (and a successful transaction when it is 200) and counting the submissions
+func newSubmissionServer(t *testing.T, status *int32, submissions *int32) *horizonclient.Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			json.NewEncoder(w).Encode(horizon.Root{})
+			return
+		}
+		atomic.AddInt32(submissions, 1)
+		s := int(atomic.LoadInt32(status))
+		if s != http.StatusOK {
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(s)
+			json.NewEncoder(w).Encode(problem.P{Status: s, Title: http.StatusText(s)})
+			return
+		}
+		json.NewEncoder(w).Encode(horizon.Transaction{Successful: true})
+	}))
+	t.Cleanup(server.Close)
+	return &horizonclient.Client{HorizonURL: server.URL + "/"}
+}
+
+func TestSubmissionPoolFailover(t *testing.T) {
+	var primaryStatus, secondaryStatus int32 = http.StatusServiceUnavailable, http.StatusOK
+	var primarySubmissions, secondarySubmissions int32
+	pool := NewSubmissionPool(log.DefaultLogger, []SubmissionUpstream{
+		{Name: "primary", Client: newSubmissionServer(t, &primaryStatus, &primarySubmissions)},
+		{Name: "secondary", Client: newSubmissionServer(t, &secondaryStatus, &secondarySubmissions)},
+	}, time.Hour)
+	var outcomes []string
+	pool.OnSubmission = func(upstream, outcome string, _ time.Duration) {
+		outcomes = append(outcomes, upstream+":"+outcome)
+	}
+
+	// the primary upstream fails, the submission fails over to the secondary one
+	tx, err := pool.Submit(context.Background(), "AAAA")
+	require.NoError(t, err)
+	assert.True(t, tx.Successful)
+	assert.Equal(t, []string{"primary:error", "secondary:success"}, outcomes)
+	statuses := pool.Statuses()
+	assert.False(t, statuses[0].Healthy)
+	assert.Less(t, statuses[0].SuccessRate, 1.0)
+	assert.True(t, statuses[1].Healthy)
+
+	// the unhealthy upstream is avoided until it is probed successfully
+	outcomes = nil
+	_, err = pool.Submit(context.Background(), "AAAA")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"secondary:success"}, outcomes)
+	pool.probe()
+	assert.True(t, pool.Statuses()[0].Healthy)
+	// the secondary upstream remains preferred thanks to its success rate
+	outcomes = nil
+	_, err = pool.Submit(context.Background(), "AAAA")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"secondary:success"}, outcomes)
+
+	// transactions rejected by Stellar Core aren't submitted again
+	outcomes = nil
+	atomic.StoreInt32(&secondaryStatus, http.StatusBadRequest)
+	_, err = pool.Submit(context.Background(), "AAAA")
+	assert.Error(t, err)
+	assert.Equal(t, []string{"secondary:rejected"}, outcomes)
+
+	// the error of the last upstream is returned when none of them processes the submission
+	outcomes = nil
+	atomic.StoreInt32(&secondaryStatus, http.StatusGatewayTimeout)
+	_, err = pool.Submit(context.Background(), "AAAA")
+	assert.True(t, isRetryableSubmissionError(err))
+	assert.Equal(t, []string{"secondary:error", "primary:error"}, outcomes)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&primarySubmissions))
+	assert.Equal(t, int32(5), atomic.LoadInt32(&secondarySubmissions))
+}
@@ -5,16 +5,20 @@ import (
 	"encoding/hex"
 	"fmt"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/code"
 	"github.com/creachadair/jrpc2/handler"
 
 	"github.com/stellar/go/clients/horizonclient"
 	"github.com/stellar/go/network"
 	"github.com/stellar/go/protocols/horizon"
 	"github.com/stellar/go/xdr"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/rpcerror"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/tracing"
 )
 
 const (
@@ -25,14 +29,31 @@ const (
 
 type SendTransactionRequest struct {
 	Transaction string `json:"transaction"`
+	// CallbackURL is optional. When set, the final status of the transaction is POSTed to it.
+	// It is ignored if the transaction was already submitted.
+	CallbackURL string `json:"callbackUrl,omitempty"`
 }
 
 type GetTransactionStatusRequest struct {
 	Hash string `json:"hash"`
+	// Format is either "base64" (default) or "json"
+	Format string `json:"format,omitempty"`
+	// WaitSeconds is optional. When set, a pending transaction is waited for (up to the maximum
+	// wait configured in the server) until it succeeds or fails, instead of returning right away.
+	WaitSeconds int `json:"waitSeconds,omitempty"`
+	// IncludeResultMeta is optional. When set, the result meta (the ledger changes) of transactions
+	// included in a ledger is returned, allowing to compute their effects.
+	IncludeResultMeta bool `json:"includeResultMeta,omitempty"`
+	// SourceAccount and Sequence identify the transaction when its hash is unknown, e.g. to recover
+	// a submission whose hash was lost. They are ignored when Hash is set.
+	SourceAccount string `json:"sourceAccount,omitempty"`
+	Sequence      int64  `json:"sequence,string,omitempty"`
 }
 
 type SCVal struct {
 	XDR string `json:"xdr"`
+	// JSON is the decoded XDR, it is only set when requesting the json format
+	JSON interface{} `json:"json,omitempty"`
 }
 
 type TransactionResponseError struct {
@@ -41,42 +62,93 @@ type TransactionResponseError struct {
 	Data    map[string]interface{} `json:"data"`
 }
 
+// FeeBumpInfo describes the hashes of a fee bump transaction and of the transaction it wraps
+type FeeBumpInfo struct {
+	OuterHash string `json:"outerHash"`
+	InnerHash string `json:"innerHash"`
+	// InnerMaxFee is only set once the transaction has been included in a ledger
+	InnerMaxFee int64 `json:"innerMaxFee,string,omitempty"`
+}
+
 type TransactionStatusResponse struct {
 	ID      string  `json:"id"`
 	Status  string  `json:"status"`
 	Results []SCVal `json:"results,omitempty"`
+	// FeeBump is only set for fee bump transactions
+	FeeBump *FeeBumpInfo `json:"feeBump,omitempty"`
+	// Attempts is the number of submissions to Horizon which have been retried so far,
+	// it is only set while Status is equal to "pending"
+	Attempts int `json:"attempts,omitempty"`
 	// Error will be nil unless Status is equal to "error"
 	Error *TransactionResponseError `json:"error,omitempty"`
+	// ResultMetaXDR is the TransactionMeta XDR of the transaction, it is only set when
+	// requested with IncludeResultMeta and the transaction was included in a ledger
+	ResultMetaXDR string `json:"resultMetaXdr,omitempty"`
+	// ResultMetaJSON is the decoded ResultMetaXDR, it is only set when requesting the json format
+	ResultMetaJSON interface{} `json:"resultMetaJson,omitempty"`
 }
 
 type SendTransactionResponse struct {
 	ID     string `json:"id"`
 	Status string `json:"status"`
+	// FeeBump is only set when submitting a fee bump transaction, in which case ID is its outer hash
+	FeeBump *FeeBumpInfo `json:"feeBump,omitempty"`
+	// Duplicate is set when the transaction had already been submitted, in which case it isn't
+	// submitted again and Status is its existing status
+	Duplicate bool `json:"duplicate,omitempty"`
 	// Error will be nil unless Status is equal to "error"
 	Error *TransactionResponseError `json:"error"`
 }
 
-type transactionResult struct {
-	timestamp time.Time
-	pending   bool
-	err       *TransactionResponseError
-}
-
 type horizonRequest struct {
 	txHash         string
 	transactionXDR string
+	callbackURL    string
+	// sourceAccount and sequence are the ones of the inner transaction of fee bump transactions,
+	// they are used to diagnose bad sequence numbers
+	sourceAccount string
+	sequence      int64
+}
+
+// SubmissionRetryPolicy configures how the TransactionProxy retries submissions which
+// are rejected because Stellar Core is overloaded or asked to try again later.
+type SubmissionRetryPolicy struct {
+	// MaxAttempts is the maximum number of submissions of a transaction,
+	// a value lower than 2 disables retries
+	MaxAttempts int
+	// Backoff is the delay before the first retry, it is doubled after each attempt
+	Backoff time.Duration
+}
+
+func (r SubmissionRetryPolicy) delay(attempt int) time.Duration {
+	return r.Backoff << (attempt - 1)
 }
 
 type TransactionProxy struct {
+	// lock serializes the lookups and updates of the store performed by the proxy
 	lock       sync.RWMutex
-	results    map[string]transactionResult
+	store      TransactionStore
 	client     *horizonclient.Client
 	passphrase string
 	queue      chan horizonRequest
 	workers    int
 	ttl        time.Duration
-	cancel     context.CancelFunc
-	wg         sync.WaitGroup
+	notifier   *WebhookNotifier
+	retry      SubmissionRetryPolicy
+	// pool is optional, when set the transactions are submitted through it instead of client
+	pool   *SubmissionPool
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	// waiters are the requests waiting for the status of a transaction, by transaction hash
+	waiters   map[string]*transactionWaiters
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+type transactionWaiters struct {
+	// updated is closed (and replaced) every time the result of the transaction changes
+	updated chan struct{}
+	count   int
 }
 
 func NewTransactionProxy(
@@ -84,33 +156,59 @@ func NewTransactionProxy(
 	workers, queueSize int,
 	networkPassphrase string,
 	ttl time.Duration,
+	store TransactionStore,
+	notifier *WebhookNotifier,
+	retry SubmissionRetryPolicy,
+	pool *SubmissionPool,
 ) *TransactionProxy {
 	if workers > queueSize {
 		queueSize = workers
 	}
 	return &TransactionProxy{
-		results:    map[string]transactionResult{},
+		store:      store,
 		client:     client,
 		passphrase: networkPassphrase,
 		queue:      make(chan horizonRequest, queueSize),
 		workers:    workers,
 		ttl:        ttl,
+		notifier:   notifier,
+		retry:      retry,
+		pool:       pool,
+		waiters:    map[string]*transactionWaiters{},
+		done:       make(chan struct{}),
 	}
 }
 
 func (p *TransactionProxy) Start(ctx context.Context) {
 	ctx, p.cancel = context.WithCancel(ctx)
+	if p.notifier != nil {
+		p.notifier.Start(ctx)
+	}
+	if p.pool != nil {
+		p.pool.Start(ctx)
+	}
 	p.wg.Add(p.workers)
 	for i := 0; i < p.workers; i++ {
 		go p.startWorker(ctx)
 	}
 }
 
+// Close stops the workers and releases the requests waiting for a transaction status,
+// calling it more than once has no effect
 func (p *TransactionProxy) Close() {
-	// signal the worker go routines to abort
-	p.cancel()
-	// wait until the worker go routines are done
-	p.wg.Wait()
+	p.closeOnce.Do(func() {
+		// release the requests waiting for a transaction status
+		close(p.done)
+		// signal the worker go routines to abort (if they were started)
+		if p.cancel != nil {
+			p.cancel()
+		}
+		// wait until the worker go routines are done
+		p.wg.Wait()
+		if p.notifier != nil {
+			p.notifier.Close()
+		}
+	})
 }
 
 func (p *TransactionProxy) SendTransaction(ctx context.Context, request SendTransactionRequest) SendTransactionResponse {
@@ -139,32 +237,80 @@ func (p *TransactionProxy) SendTransaction(ctx context.Context, request SendTran
 	}
 	txHash := hex.EncodeToString(hash[:])
 
+	var feeBump *FeeBumpInfo
+	if envelope.Type == xdr.EnvelopeTypeEnvelopeTypeTxFeeBump {
+		innerHash, err := network.HashTransaction(envelope.FeeBump.Tx.InnerTx.MustV1().Tx, p.passphrase)
+		if err != nil {
+			return SendTransactionResponse{
+				Status: TransactionError,
+				Error: &TransactionResponseError{
+					Code:    "invalid_hash",
+					Message: fmt.Sprintf("cannot hash inner transaction: %v", err),
+				},
+			}
+		}
+		feeBump = &FeeBumpInfo{OuterHash: txHash, InnerHash: hex.EncodeToString(innerHash[:])}
+	}
+
+	if request.CallbackURL != "" {
+		if p.notifier == nil {
+			return SendTransactionResponse{
+				ID:     txHash,
+				Status: TransactionError,
+				Error: &TransactionResponseError{
+					Code:    "callbacks_disabled",
+					Message: "Transaction status callbacks are not enabled",
+				},
+			}
+		}
+		if err := p.notifier.validateCallbackURL(request.CallbackURL); err != nil {
+			return SendTransactionResponse{
+				ID:     txHash,
+				Status: TransactionError,
+				Error: &TransactionResponseError{
+					Code:    "invalid_callback_url",
+					Message: fmt.Sprintf("invalid callback url: %v", err),
+				},
+			}
+		}
+	}
+
 	p.lock.Lock()
 	defer func() {
 		p.deleteExpiredEntries(time.Now())
 		p.lock.Unlock()
 	}()
 
-	result, ok := p.results[txHash]
+	result, ok := p.store.Get(txHash)
 	// if pending or completed without any errors use
 	// getTransactionStatus method with tx hash to obtain
 	// response
-	if result.pending || (ok && result.err == nil) {
+	if result.Pending || (ok && result.Err == nil) {
 		return SendTransactionResponse{
-			ID:     txHash,
-			Status: TransactionPending,
+			ID:        txHash,
+			Status:    TransactionPending,
+			FeeBump:   feeBump,
+			Duplicate: true,
 		}
 	}
 
-	p.results[txHash] = transactionResult{pending: true}
+	sourceAccount := envelope.SourceAccount().ToAccountId()
+	p.store.Put(txHash, TransactionResult{Pending: true, SourceAccount: sourceAccount.Address(), Sequence: envelope.SeqNum()})
 	select {
-	case p.queue <- horizonRequest{txHash: txHash, transactionXDR: request.Transaction}:
+	case p.queue <- horizonRequest{
+		txHash:         txHash,
+		transactionXDR: request.Transaction,
+		callbackURL:    request.CallbackURL,
+		sourceAccount:  sourceAccount.Address(),
+		sequence:       envelope.SeqNum(),
+	}:
 		return SendTransactionResponse{
-			ID:     txHash,
-			Status: TransactionPending,
+			ID:      txHash,
+			Status:  TransactionPending,
+			FeeBump: feeBump,
 		}
 	default:
-		delete(p.results, txHash)
+		p.store.Delete(txHash)
 		return SendTransactionResponse{
 			ID:     txHash,
 			Status: TransactionError,
@@ -176,16 +322,47 @@ func (p *TransactionProxy) SendTransaction(ctx context.Context, request SendTran
 	}
 }
 
-func (p *TransactionProxy) setTxResult(txHash string, result transactionResult) {
+func (p *TransactionProxy) setTxResult(txHash string, result TransactionResult) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
-	p.results[txHash] = result
+	p.store.Put(txHash, result)
+	p.notifyUpdate(txHash)
 }
 
 func (p *TransactionProxy) deletePendingEntry(txHash string) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
-	delete(p.results, txHash)
+	p.store.Delete(txHash)
+	p.notifyUpdate(txHash)
+}
+
+// notifyUpdate wakes up the requests waiting for the status of the transaction,
+// it should only be called while the write lock is held
+func (p *TransactionProxy) notifyUpdate(txHash string) {
+	if waiters, ok := p.waiters[txHash]; ok {
+		close(waiters.updated)
+		waiters.updated = make(chan struct{})
+	}
+}
+
+// watch registers a request waiting for the status of the transaction, the returned function
+// must be called once the request is done waiting
+func (p *TransactionProxy) watch(txHash string) func() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	waiters, ok := p.waiters[txHash]
+	if !ok {
+		waiters = &transactionWaiters{updated: make(chan struct{})}
+		p.waiters[txHash] = waiters
+	}
+	waiters.count++
+	return func() {
+		p.lock.Lock()
+		defer p.lock.Unlock()
+		if waiters.count--; waiters.count == 0 {
+			delete(p.waiters, txHash)
+		}
+	}
 }
 
 func (p *TransactionProxy) startWorker(ctx context.Context) {
@@ -195,30 +372,160 @@ func (p *TransactionProxy) startWorker(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case request := <-p.queue:
-			_, err := p.client.SubmitTransactionXDR(request.transactionXDR)
-			if err != nil {
-				result := transactionResult{timestamp: time.Now()}
-				if herr, ok := err.(*horizonclient.Error); ok {
-					result.err = &TransactionResponseError{
-						Code:    "tx_submission_failed",
-						Message: "Transaction submission failed",
-						Data:    herr.Problem.Extras,
-					}
-				} else {
-					result.err = &TransactionResponseError{
-						Code:    "http_error",
-						Message: fmt.Sprintf("transaction submission failed: %v", err),
-					}
-				}
-				p.setTxResult(request.txHash, result)
-			} else {
-				p.deletePendingEntry(request.txHash)
+			p.submit(ctx, request)
+		}
+	}
+}
+
+// isRetryableSubmissionError returns true if the submission failed because Stellar Core
+// could not accept the transaction at the time, in which case it is safe to submit it again.
+func isRetryableSubmissionError(err error) bool {
+	herr, ok := err.(*horizonclient.Error)
+	if !ok {
+		return false
+	}
+	switch herr.Problem.Status {
+	case http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		// Horizon responds with a timeout when Core answers TRY_AGAIN_LATER
+		// and the transaction doesn't make it into a ledger in time.
+		return true
+	}
+	codes, err := herr.ResultCodes()
+	return err == nil && codes.TransactionCode == "tx_try_again_later"
+}
+
+// isBadSequenceError returns true if the submission failed because the sequence number of the
+// transaction doesn't follow the one of its source account
+func isBadSequenceError(err error) bool {
+	herr, ok := err.(*horizonclient.Error)
+	if !ok {
+		return false
+	}
+	codes, err := herr.ResultCodes()
+	return err == nil && (codes.TransactionCode == "tx_bad_seq" || codes.InnerTransactionCode == "tx_bad_seq")
+}
+
+// badSequenceError describes a submission rejected with txBAD_SEQ, including the current
+// sequence number of the source account when it can be obtained from Horizon
+func (p *TransactionProxy) badSequenceError(ctx context.Context, request horizonRequest, herr *horizonclient.Error) *TransactionResponseError {
+	data := map[string]interface{}{}
+	for k, v := range herr.Problem.Extras {
+		data[k] = v
+	}
+	data["sourceAccount"] = request.sourceAccount
+	data["transactionSequence"] = strconv.FormatInt(request.sequence, 10)
+	message := fmt.Sprintf("bad sequence number %d for account %s", request.sequence, request.sourceAccount)
+
+	_, span := tracing.StartSpan(ctx, "horizon.account_detail")
+	account, err := p.client.AccountDetail(horizonclient.AccountRequest{AccountID: request.sourceAccount})
+	tracing.EndSpan(span, err)
+	if err == nil {
+		data["accountSequence"] = strconv.FormatInt(account.Sequence, 10)
+		data["expectedSequence"] = strconv.FormatInt(account.Sequence+1, 10)
+		message = fmt.Sprintf("%s, expected sequence number %d", message, account.Sequence+1)
+	}
+	return &TransactionResponseError{
+		Code:    "tx_bad_seq",
+		Message: message,
+		Data:    data,
+	}
+}
+
+func (p *TransactionProxy) submit(ctx context.Context, request horizonRequest) {
+	var (
+		tx  horizon.Transaction
+		err error
+	)
+	for attempt := 1; ; attempt++ {
+		_, span := tracing.StartSpan(ctx, "horizon.submit_transaction")
+		if p.pool != nil {
+			tx, err = p.pool.Submit(ctx, request.transactionXDR)
+		} else {
+			tx, err = p.client.SubmitTransactionXDR(request.transactionXDR)
+		}
+		tracing.EndSpan(span, err)
+		if err == nil || attempt >= p.retry.MaxAttempts || !isRetryableSubmissionError(err) {
+			break
+		}
+		p.setTxResult(request.txHash, TransactionResult{
+			Pending:       true,
+			Attempts:      attempt,
+			SourceAccount: request.sourceAccount,
+			Sequence:      request.sequence,
+		})
+		timer := time.NewTimer(p.retry.delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+
+	if err != nil && isBadSequenceError(err) {
+		// the transaction may have been included in a ledger already, having been submitted
+		// through another server (or before a restart), in which case it is a duplicate
+		_, span := tracing.StartSpan(ctx, "horizon.transaction_detail")
+		ledgerTx, detailErr := p.client.TransactionDetail(request.txHash)
+		tracing.EndSpan(span, detailErr)
+		if detailErr == nil {
+			tx, err = ledgerTx, nil
+		}
+	}
+
+	if err != nil {
+		result := TransactionResult{
+			Timestamp:     time.Now(),
+			SourceAccount: request.sourceAccount,
+			Sequence:      request.sequence,
+		}
+		if herr, ok := err.(*horizonclient.Error); ok && isBadSequenceError(err) {
+			result.Err = p.badSequenceError(ctx, request, herr)
+		} else if ok {
+			result.Err = &TransactionResponseError{
+				Code:    "tx_submission_failed",
+				Message: "Transaction submission failed",
+				Data:    herr.Problem.Extras,
+			}
+		} else {
+			result.Err = &TransactionResponseError{
+				Code:    "http_error",
+				Message: fmt.Sprintf("transaction submission failed: %v", err),
 			}
 		}
+		p.setTxResult(request.txHash, result)
+		p.notify(request, TransactionWebhookPayload{
+			ID:     request.txHash,
+			Status: TransactionError,
+			Error:  result.Err,
+		})
+		return
+	}
+
+	p.deletePendingEntry(request.txHash)
+	payload := TransactionWebhookPayload{
+		ID:        request.txHash,
+		Status:    TransactionSuccess,
+		ResultXDR: tx.ResultXdr,
 	}
+	if !tx.Successful {
+		payload.Status = TransactionError
+		payload.Error = &TransactionResponseError{
+			Code:    "tx_failed",
+			Message: "transaction included in ledger but failed",
+		}
+	}
+	p.notify(request, payload)
 }
 
-func parseResults(tx horizon.Transaction) ([]SCVal, *TransactionResponseError) {
+func (p *TransactionProxy) notify(request horizonRequest, payload TransactionWebhookPayload) {
+	if request.callbackURL == "" || p.notifier == nil {
+		return
+	}
+	p.notifier.Notify(request.callbackURL, payload)
+}
+
+func parseResults(tx horizon.Transaction, format string) ([]SCVal, *TransactionResponseError) {
 	var txResult xdr.TransactionResult
 	if err := xdr.SafeUnmarshalBase64(tx.ResultXdr, &txResult); err != nil {
 		return nil, &TransactionResponseError{
@@ -265,14 +572,92 @@ func parseResults(tx horizon.Transaction) ([]SCVal, *TransactionResponseError) {
 				},
 			}
 		}
-		scvals = append(scvals, SCVal{XDR: scvalB64})
+		value := SCVal{XDR: scvalB64}
+		if format == FormatJSON {
+			if value.JSON, err = xdrToJSON(scval); err != nil {
+				return nil, &TransactionResponseError{
+					Code:    "invalid_xdr",
+					Message: fmt.Sprintf("cannot convert scval to json: %v", err),
+					Data: map[string]interface{}{
+						"transaction": tx,
+					},
+				}
+			}
+		}
+		scvals = append(scvals, value)
 	}
 
 	return scvals, nil
 }
 
+// setResultMeta sets the result meta of a transaction ingested by Horizon in response
+func setResultMeta(response *TransactionStatusResponse, tx horizon.Transaction, format string) *TransactionResponseError {
+	response.ResultMetaXDR = tx.ResultMetaXdr
+	if format != FormatJSON {
+		return nil
+	}
+	var meta xdr.TransactionMeta
+	if err := xdr.SafeUnmarshalBase64(tx.ResultMetaXdr, &meta); err != nil {
+		return &TransactionResponseError{
+			Code:    "invalid_xdr",
+			Message: fmt.Sprintf("cannot unmarshal transaction result meta: %v", err),
+		}
+	}
+	var err error
+	if response.ResultMetaJSON, err = xdrToJSON(meta); err != nil {
+		return &TransactionResponseError{
+			Code:    "invalid_xdr",
+			Message: fmt.Sprintf("cannot convert transaction result meta to json: %v", err),
+		}
+	}
+	return nil
+}
+
+// feeBumpInfo returns the hashes of a fee bump transaction ingested by Horizon, or nil
+// if it isn't a fee bump transaction
+func feeBumpInfo(tx horizon.Transaction) *FeeBumpInfo {
+	if tx.FeeBumpTransaction == nil || tx.InnerTransaction == nil {
+		return nil
+	}
+	return &FeeBumpInfo{
+		OuterHash:   tx.FeeBumpTransaction.Hash,
+		InnerHash:   tx.InnerTransaction.Hash,
+		InnerMaxFee: tx.InnerTransaction.MaxFee,
+	}
+}
+
+// WaitForTransactionStatus returns the status of the transaction once it is no longer pending,
+// or its pending status if it is still being submitted after wait (or once ctx is done).
+func (p *TransactionProxy) WaitForTransactionStatus(ctx context.Context, request GetTransactionStatusRequest, wait time.Duration) TransactionStatusResponse {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	// only the updates of this transaction wake up the request
+	defer p.watch(request.Hash)()
+	for {
+		// obtain the channel before the status, so that no update is missed
+		p.lock.RLock()
+		updated := p.waiters[request.Hash].updated
+		p.lock.RUnlock()
+		response := p.GetTransactionStatus(ctx, request)
+		if response.Status != TransactionPending {
+			return response
+		}
+		select {
+		case <-updated:
+		case <-timer.C:
+			return response
+		case <-ctx.Done():
+			return response
+		case <-p.done:
+			return response
+		}
+	}
+}
+
 func (p *TransactionProxy) GetTransactionStatus(ctx context.Context, request GetTransactionStatusRequest) TransactionStatusResponse {
+	_, span := tracing.StartSpan(ctx, "horizon.transaction_detail")
 	tx, err := p.client.TransactionDetail(request.Hash)
+	tracing.EndSpan(span, err)
 	if err != nil {
 		if herr, ok := err.(*horizonclient.Error); ok {
 			if herr.Problem.Status != http.StatusNotFound {
@@ -297,10 +682,12 @@ func (p *TransactionProxy) GetTransactionStatus(ctx context.Context, request Get
 			}
 		}
 	} else {
+		var response TransactionStatusResponse
 		if !tx.Successful {
-			return TransactionStatusResponse{
-				ID:     request.Hash,
-				Status: TransactionError,
+			response = TransactionStatusResponse{
+				ID:      request.Hash,
+				Status:  TransactionError,
+				FeeBump: feeBumpInfo(tx),
 				Error: &TransactionResponseError{
 					Code:    "tx_failed",
 					Message: "transaction included in ledger but failed",
@@ -309,26 +696,34 @@ func (p *TransactionProxy) GetTransactionStatus(ctx context.Context, request Get
 					},
 				},
 			}
+		} else {
+			results, err := parseResults(tx, request.Format)
+			status := TransactionSuccess
+			if err != nil {
+				status = TransactionError
+			}
+			response = TransactionStatusResponse{
+				ID:      request.Hash,
+				Status:  status,
+				Results: results,
+				FeeBump: feeBumpInfo(tx),
+				Error:   err,
+			}
 		}
-
-		results, err := parseResults(tx)
-		status := TransactionSuccess
-		if err != nil {
-			status = TransactionError
-		}
-		return TransactionStatusResponse{
-			ID:      request.Hash,
-			Status:  status,
-			Results: results,
-			Error:   err,
+		if request.IncludeResultMeta {
+			if err := setResultMeta(&response, tx, request.Format); err != nil {
+				response.Status = TransactionError
+				response.Error = err
+			}
 		}
+		return response
 	}
 
 	// herr.Problem.Status == http.StatusNotFound
 	// if the tx is not found perform the request
 	p.lock.RLock()
 	defer p.lock.RUnlock()
-	result, ok := p.results[request.Hash]
+	result, ok := p.store.Get(request.Hash)
 	if !ok {
 		return TransactionStatusResponse{
 			ID:     request.Hash,
@@ -340,35 +735,137 @@ func (p *TransactionProxy) GetTransactionStatus(ctx context.Context, request Get
 		}
 	}
 
-	if result.pending {
+	if result.Pending {
 		return TransactionStatusResponse{
-			ID:     request.Hash,
-			Status: TransactionPending,
+			ID:       request.Hash,
+			Status:   TransactionPending,
+			Attempts: result.Attempts,
 		}
 	}
 
 	return TransactionStatusResponse{
 		ID:     request.Hash,
 		Status: TransactionError,
-		Error:  result.err,
+		Error:  result.Err,
 	}
 }
 
-// deleteExpiredEntries should only be called while the write lock is held
-func (p *TransactionProxy) deleteExpiredEntries(now time.Time) {
-	for key, val := range p.results {
-		if !val.pending && now.Sub(val.timestamp) > p.ttl {
-			delete(p.results, key)
+const (
+	// transactionLookupPageSize is the number of transactions of an account obtained at once by FindTransactionHash
+	transactionLookupPageSize = 200
+	// maxTransactionLookupPages bounds the transactions of an account walked by FindTransactionHash
+	maxTransactionLookupPages = 5
+)
+
+// FindTransactionHash returns the hash of the transaction with the given source account and
+// sequence number. The transactions submitted through the proxy are looked up first, then the
+// recent transactions of the account in Horizon (up to the latest 1000).
+func (p *TransactionProxy) FindTransactionHash(ctx context.Context, sourceAccount string, sequence int64) (string, bool, error) {
+	p.lock.RLock()
+	txHash, ok := p.store.Find(sourceAccount, sequence)
+	p.lock.RUnlock()
+	if ok {
+		return txHash, true, nil
+	}
+
+	cursor := ""
+	for page := 0; page < maxTransactionLookupPages; page++ {
+		_, span := tracing.StartSpan(ctx, "horizon.account_transactions")
+		transactions, err := p.client.Transactions(horizonclient.TransactionRequest{
+			ForAccount:    sourceAccount,
+			Order:         horizonclient.OrderDesc,
+			Cursor:        cursor,
+			Limit:         transactionLookupPageSize,
+			IncludeFailed: true,
+		})
+		tracing.EndSpan(span, err)
+		if herr, ok := err.(*horizonclient.Error); ok && herr.Problem.Status == http.StatusNotFound {
+			return "", false, nil
+		}
+		if err != nil {
+			return "", false, err
+		}
+		records := transactions.Embedded.Records
+		for _, tx := range records {
+			// the transactions of the account include the ones it only takes part in
+			if tx.Account != sourceAccount {
+				continue
+			}
+			if tx.AccountSequence == sequence {
+				return tx.Hash, true, nil
+			}
+			// the sequence numbers of the account decrease along the pages
+			if tx.AccountSequence < sequence {
+				return "", false, nil
+			}
+		}
+		if len(records) < transactionLookupPageSize {
+			return "", false, nil
 		}
+		cursor = records[len(records)-1].PagingToken()
 	}
+	return "", false, nil
 }
 
-// NewGetTransactionStatusHandler returns a get transaction json rpc handler
-func NewGetTransactionStatusHandler(proxy *TransactionProxy) jrpc2.Handler {
-	return handler.New(proxy.GetTransactionStatus)
+// deleteExpiredEntries should only be called while the write lock is held
+func (p *TransactionProxy) deleteExpiredEntries(now time.Time) {
+	p.store.DeleteExpired(now.Add(-p.ttl))
+}
+
+// NewGetTransactionStatusHandler returns a get transaction json rpc handler. Requests can wait
+// up to maxWait for pending transactions to complete, zero disables waiting.
+func NewGetTransactionStatusHandler(proxy *TransactionProxy, maxWait time.Duration) jrpc2.Handler {
+	return withOptionalParams(GetTransactionStatusRequest{}, handler.New(func(ctx context.Context, request GetTransactionStatusRequest) (TransactionStatusResponse, error) {
+		if err := validateFormat(request.Format); err != nil {
+			return TransactionStatusResponse{}, err
+		}
+		if request.WaitSeconds < 0 {
+			return TransactionStatusResponse{}, &jrpc2.Error{
+				Code:    code.InvalidParams,
+				Message: "waitSeconds must not be negative",
+			}
+		}
+		if request.Hash == "" {
+			if request.SourceAccount == "" || request.Sequence <= 0 {
+				return TransactionStatusResponse{}, &jrpc2.Error{
+					Code:    code.InvalidParams,
+					Message: "hash, or sourceAccount and sequence, must be provided",
+				}
+			}
+			var accountID xdr.AccountId
+			if err := accountID.SetAddress(request.SourceAccount); err != nil {
+				return TransactionStatusResponse{}, &jrpc2.Error{
+					Code:    code.InvalidParams,
+					Message: fmt.Sprintf("invalid sourceAccount: %v", err),
+				}
+			}
+			txHash, found, err := proxy.FindTransactionHash(ctx, request.SourceAccount, request.Sequence)
+			if err != nil {
+				return TransactionStatusResponse{}, rpcerror.NewUpstreamUnavailable(rpcerror.UpstreamHorizon, "could not obtain the transactions of the account from horizon")
+			}
+			if !found {
+				return TransactionStatusResponse{
+					Status: TransactionError,
+					Error: &TransactionResponseError{
+						Code:    "tx_not_found",
+						Message: fmt.Sprintf("no transaction of account %s with sequence number %d found", request.SourceAccount, request.Sequence),
+					},
+				}, nil
+			}
+			request.Hash = txHash
+		}
+		wait := time.Duration(request.WaitSeconds) * time.Second
+		if wait > maxWait {
+			wait = maxWait
+		}
+		if wait > 0 {
+			return proxy.WaitForTransactionStatus(ctx, request, wait), nil
+		}
+		return proxy.GetTransactionStatus(ctx, request), nil
+	}))
 }
 
 // NewSendTransactionHandler returns a submit transaction json rpc handler
 func NewSendTransactionHandler(proxy *TransactionProxy) jrpc2.Handler {
-	return handler.New(proxy.SendTransaction)
+	return withOptionalParams(SendTransactionRequest{}, handler.New(proxy.SendTransaction))
 }
@@ -0,0 +1,289 @@
+package methods
+
+import (
+	"container/heap"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// TransactionResult is the state of a transaction submitted through the TransactionProxy
+// which has not been ingested by Horizon yet.
+type TransactionResult struct {
+	Timestamp time.Time `json:"timestamp"`
+	Pending   bool      `json:"pending,omitempty"`
+	// Attempts is the number of failed submissions which have been retried
+	Attempts int `json:"attempts,omitempty"`
+	// Err will be nil unless the submission failed
+	Err *TransactionResponseError `json:"error,omitempty"`
+	// SourceAccount and Sequence identify the transaction along with its hash
+	SourceAccount string `json:"sourceAccount,omitempty"`
+	Sequence      int64  `json:"sequence,string,omitempty"`
+}
+
+// TransactionStore is the storage backend used by the TransactionProxy to keep track
+// of submitted transactions. Implementations must be safe for concurrent use.
+type TransactionStore interface {
+	// Get returns the result stored for the given transaction hash, if any.
+	Get(txHash string) (TransactionResult, bool)
+	// Put stores the result of the given transaction hash, replacing any existing one.
+	Put(txHash string, result TransactionResult)
+	// Delete removes the result of the given transaction hash.
+	Delete(txHash string)
+	// DeleteExpired removes all the results which are not pending
+	// and whose timestamp is older than cutoff.
+	DeleteExpired(cutoff time.Time)
+	// Find returns the hash of the latest result stored for the given source account and
+	// sequence number, if any.
+	Find(sourceAccount string, sequence int64) (string, bool)
+}
+
+// sourceSequence identifies a transaction by its source account and sequence number
+type sourceSequence struct {
+	sourceAccount string
+	sequence      int64
+}
+
+// queuedResult is a result which isn't pending, in the eviction queue
+type queuedResult struct {
+	txHash    string
+	timestamp time.Time
+	index     int
+}
+
+// evictionQueue is a min-heap of the results which aren't pending, ordered by timestamp
+type evictionQueue []*queuedResult
+
+func (q evictionQueue) Len() int           { return len(q) }
+func (q evictionQueue) Less(i, j int) bool { return q[i].timestamp.Before(q[j].timestamp) }
+func (q evictionQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *evictionQueue) Push(x interface{}) {
+	entry := x.(*queuedResult)
+	entry.index = len(*q)
+	*q = append(*q, entry)
+}
+
+func (q *evictionQueue) Pop() interface{} {
+	old := *q
+	entry := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return entry
+}
+
+// entryOverhead approximates the memory used by a result on top of its encoded size
+// (map bucket, string and struct headers)
+const entryOverhead = 128
+
+// MemoryTransactionStore is a TransactionStore keeping all the results in memory.
+type MemoryTransactionStore struct {
+	lock    sync.RWMutex
+	results map[string]TransactionResult
+	sizes   map[string]int64
+	// bySource indexes the results by their source account and sequence number
+	bySource map[sourceSequence]string
+	// queue keeps the results which aren't pending in timestamp order, so that the oldest
+	// ones are evicted and expired without scanning all the results
+	queue  evictionQueue
+	queued map[string]*queuedResult
+	size   int64
+	// maxBytes is the memory budget of the results, zero means unlimited
+	maxBytes int64
+	evicted  int64
+}
+
+// NewMemoryTransactionStore creates an empty MemoryTransactionStore
+func NewMemoryTransactionStore() *MemoryTransactionStore {
+	return NewMemoryTransactionStoreWithBudget(0)
+}
+
+// NewMemoryTransactionStoreWithBudget creates an empty MemoryTransactionStore whose results use
+// approximately up to maxBytes of memory. Once the budget is exceeded the oldest results which
+// aren't pending are evicted (down to 90% of the budget, so that evictions are batched), pending
+// results are never evicted since their submissions are still in progress.
+// A maxBytes of zero disables the budget.
+func NewMemoryTransactionStoreWithBudget(maxBytes int64) *MemoryTransactionStore {
+	return &MemoryTransactionStore{
+		results:  map[string]TransactionResult{},
+		sizes:    map[string]int64{},
+		bySource: map[sourceSequence]string{},
+		queued:   map[string]*queuedResult{},
+		maxBytes: maxBytes,
+	}
+}
+
+func (m *MemoryTransactionStore) Get(txHash string) (TransactionResult, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	result, ok := m.results[txHash]
+	return result, ok
+}
+
+func (m *MemoryTransactionStore) Put(txHash string, result TransactionResult) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.put(txHash, result)
+	m.enforceBudget()
+}
+
+func (m *MemoryTransactionStore) Delete(txHash string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.delete(txHash)
+}
+
+func (m *MemoryTransactionStore) DeleteExpired(cutoff time.Time) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for len(m.queue) > 0 && m.queue[0].timestamp.Before(cutoff) {
+		m.delete(m.queue[0].txHash)
+	}
+}
+
+func (m *MemoryTransactionStore) Find(sourceAccount string, sequence int64) (string, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	txHash, ok := m.bySource[sourceSequence{sourceAccount: sourceAccount, sequence: sequence}]
+	return txHash, ok
+}
+
+// Len returns the number of results in the store
+func (m *MemoryTransactionStore) Len() int {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return len(m.results)
+}
+
+// SizeBytes returns the approximate memory used by the results
+func (m *MemoryTransactionStore) SizeBytes() int64 {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.size
+}
+
+// MaxBytes returns the memory budget of the store, zero when it is unlimited
+func (m *MemoryTransactionStore) MaxBytes() int64 {
+	return m.maxBytes
+}
+
+// Evicted returns the number of results evicted to fit in the memory budget
+func (m *MemoryTransactionStore) Evicted() int64 {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.evicted
+}
+
+func (m *MemoryTransactionStore) put(txHash string, result TransactionResult) {
+	m.delete(txHash)
+	size := resultSize(txHash, result)
+	m.results[txHash] = result
+	m.sizes[txHash] = size
+	m.size += size
+	if result.SourceAccount != "" {
+		m.bySource[sourceSequence{sourceAccount: result.SourceAccount, sequence: result.Sequence}] = txHash
+	}
+	if !result.Pending {
+		entry := &queuedResult{txHash: txHash, timestamp: result.Timestamp}
+		heap.Push(&m.queue, entry)
+		m.queued[txHash] = entry
+	}
+}
+
+func (m *MemoryTransactionStore) delete(txHash string) {
+	result, ok := m.results[txHash]
+	if !ok {
+		return
+	}
+	key := sourceSequence{sourceAccount: result.SourceAccount, sequence: result.Sequence}
+	if m.bySource[key] == txHash {
+		delete(m.bySource, key)
+	}
+	m.size -= m.sizes[txHash]
+	delete(m.results, txHash)
+	delete(m.sizes, txHash)
+	if entry, ok := m.queued[txHash]; ok {
+		heap.Remove(&m.queue, entry.index)
+		delete(m.queued, txHash)
+	}
+}
+
+// enforceBudget evicts the oldest results which aren't pending until the store fits in its budget
+func (m *MemoryTransactionStore) enforceBudget() {
+	if m.maxBytes <= 0 || m.size <= m.maxBytes {
+		return
+	}
+	target := m.maxBytes - m.maxBytes/10
+	for m.size > target && len(m.queue) > 0 {
+		m.delete(m.queue[0].txHash)
+		m.evicted++
+	}
+}
+
+// resultSize approximates the memory used by a result, its errors can carry arbitrary data
+func resultSize(txHash string, result TransactionResult) int64 {
+	size := int64(len(txHash) + len(result.SourceAccount) + entryOverhead)
+	if result.Err != nil {
+		if encoded, err := json.Marshal(result.Err); err == nil {
+			size += int64(len(encoded))
+		}
+	}
+	return size
+}
+
+// SaveSnapshot writes the results to the given file, atomically replacing it
+func (m *MemoryTransactionStore) SaveSnapshot(path string) error {
+	m.lock.RLock()
+	encoded, err := json.Marshal(m.results)
+	m.lock.RUnlock()
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(encoded); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadSnapshot adds the results of a file written by SaveSnapshot, a missing file is ignored.
+// Pending results are skipped since their submissions didn't survive the snapshotting process,
+// their final status is obtained from Horizon.
+func (m *MemoryTransactionStore) LoadSnapshot(path string) error {
+	encoded, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var results map[string]TransactionResult
+	if err := json.Unmarshal(encoded, &results); err != nil {
+		return fmt.Errorf("could not parse transaction store snapshot %s: %v", path, err)
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for txHash, result := range results {
+		if !result.Pending {
+			m.put(txHash, result)
+		}
+	}
+	m.enforceBudget()
+	return nil
+}
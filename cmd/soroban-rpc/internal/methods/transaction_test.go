@@ -1,10 +1,28 @@
 package methods
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/support/render/problem"
+	"github.com/stellar/go/txnbuild"
 )
 
 func TestDeleteExpiredTransaction(t *testing.T) {
@@ -15,40 +33,405 @@ func TestDeleteExpiredTransaction(t *testing.T) {
 		10,
 		"",
 		ttl,
+		NewMemoryTransactionStore(),
+		nil,
+		SubmissionRetryPolicy{},
+		nil,
 	)
-	pending := transactionResult{
-		pending: true,
+	store := proxy.store.(*MemoryTransactionStore)
+	pending := TransactionResult{
+		Pending: true,
 	}
-	proxy.results["a"] = pending
-	proxy.results["b"] = pending
+	store.Put("a", pending)
+	store.Put("b", pending)
 	t.Run("ignores pending", func(t *testing.T) {
 		proxy.deleteExpiredEntries(time.Now())
-		assert.Len(t, proxy.results, 2)
+		assert.Len(t, store.results, 2)
 
-		assert.Equal(t, pending, proxy.results["a"])
-		assert.Equal(t, pending, proxy.results["b"])
+		assert.Equal(t, pending, store.results["a"])
+		assert.Equal(t, pending, store.results["b"])
 	})
 
-	proxy.results = map[string]transactionResult{}
-	proxy.results["a"] = transactionResult{
-		pending: false,
-	}
-	proxy.results["b"] = transactionResult{
-		pending:   false,
-		timestamp: time.Now().Add(-time.Hour),
-	}
-	notYetExpired := transactionResult{
-		pending:   false,
-		timestamp: time.Now().Add(-time.Second),
+	store.Delete("a")
+	store.Delete("b")
+	store.Put("a", TransactionResult{
+		Pending: false,
+	})
+	store.Put("b", TransactionResult{
+		Pending:   false,
+		Timestamp: time.Now().Add(-time.Hour),
+	})
+	notYetExpired := TransactionResult{
+		Pending:   false,
+		Timestamp: time.Now().Add(-time.Second),
 	}
-	proxy.results["c"] = notYetExpired
-	proxy.results["d"] = pending
+	store.Put("c", notYetExpired)
+	store.Put("d", pending)
 	t.Run("ignores pending", func(t *testing.T) {
 		proxy.deleteExpiredEntries(time.Now())
-		assert.Len(t, proxy.results, 2)
+		assert.Len(t, store.results, 2)
+
+		assert.Equal(t, notYetExpired, store.results["c"])
+		assert.Equal(t, pending, store.results["d"])
+	})
+
+}
+
+func TestIsRetryableSubmissionError(t *testing.T) {
+	for _, testCase := range []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"network error", errors.New("connection refused"), false},
+		{"service unavailable", &horizonclient.Error{Problem: problem.P{Status: http.StatusServiceUnavailable}}, true},
+		{"timeout", &horizonclient.Error{Problem: problem.P{Status: http.StatusGatewayTimeout}}, true},
+		{
+			"try again later",
+			&horizonclient.Error{Problem: problem.P{
+				Status: http.StatusBadRequest,
+				Extras: map[string]interface{}{
+					"result_codes": map[string]interface{}{"transaction": "tx_try_again_later"},
+				},
+			}},
+			true,
+		},
+		{
+			"bad sequence",
+			&horizonclient.Error{Problem: problem.P{
+				Status: http.StatusBadRequest,
+				Extras: map[string]interface{}{
+					"result_codes": map[string]interface{}{"transaction": "tx_bad_seq"},
+				},
+			}},
+			false,
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, isRetryableSubmissionError(testCase.err))
+		})
+	}
+}
+
+func TestSubmissionRetries(t *testing.T) {
+	// failures is the number of submissions rejected by the server before accepting one
+	var submissions, failures int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&submissions, 1) <= atomic.LoadInt32(&failures) {
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(problem.P{Status: http.StatusServiceUnavailable, Title: "Service Unavailable"})
+			return
+		}
+		json.NewEncoder(w).Encode(horizon.Transaction{Successful: true})
+	}))
+	defer server.Close()
+
+	proxy := NewTransactionProxy(
+		&horizonclient.Client{HorizonURL: server.URL + "/"},
+		1,
+		1,
+		"",
+		time.Minute,
+		NewMemoryTransactionStore(),
+		nil,
+		SubmissionRetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond},
+		nil,
+	)
+	atomic.StoreInt32(&failures, 2)
+	proxy.store.Put("a", TransactionResult{Pending: true})
+	proxy.submit(context.Background(), horizonRequest{txHash: "a", transactionXDR: "AAAA"})
+
+	assert.Equal(t, int32(3), atomic.LoadInt32(&submissions))
+	_, ok := proxy.store.Get("a")
+	assert.False(t, ok)
+
+	atomic.StoreInt32(&submissions, 0)
+	atomic.StoreInt32(&failures, 10)
+	proxy.store.Put("b", TransactionResult{Pending: true})
+	proxy.submit(context.Background(), horizonRequest{txHash: "b", transactionXDR: "AAAA"})
+
+	assert.Equal(t, int32(3), atomic.LoadInt32(&submissions))
+	result, ok := proxy.store.Get("b")
+	assert.True(t, ok)
+	assert.False(t, result.Pending)
+	assert.Equal(t, "tx_submission_failed", result.Err.Code)
+}
+
+func TestSubmissionBadSequence(t *testing.T) {
+	source := keypair.MustRandom().Address()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(problem.P{
+				Status: http.StatusBadRequest,
+				Title:  "Transaction Failed",
+				Extras: map[string]interface{}{
+					"result_codes": map[string]interface{}{"transaction": "tx_bad_seq"},
+				},
+			})
+		case r.URL.Path == "/transactions/included":
+			json.NewEncoder(w).Encode(horizon.Transaction{Hash: "included", Successful: true})
+		case r.URL.Path == "/accounts/"+source:
+			json.NewEncoder(w).Encode(horizon.Account{AccountID: source, Sequence: 41})
+		default:
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(problem.P{Status: http.StatusNotFound, Title: "Resource Missing"})
+		}
+	}))
+	defer server.Close()
 
-		assert.Equal(t, notYetExpired, proxy.results["c"])
-		assert.Equal(t, pending, proxy.results["d"])
+	proxy := NewTransactionProxy(
+		&horizonclient.Client{HorizonURL: server.URL + "/"},
+		1,
+		1,
+		"",
+		time.Minute,
+		NewMemoryTransactionStore(),
+		nil,
+		SubmissionRetryPolicy{},
+		nil,
+	)
+
+	proxy.store.Put("a", TransactionResult{Pending: true})
+	proxy.submit(context.Background(), horizonRequest{
+		txHash:         "a",
+		transactionXDR: "AAAA",
+		sourceAccount:  source,
+		sequence:       50,
+	})
+	result, ok := proxy.store.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "tx_bad_seq", result.Err.Code)
+	assert.Equal(t, source, result.Err.Data["sourceAccount"])
+	assert.Equal(t, "50", result.Err.Data["transactionSequence"])
+	assert.Equal(t, "41", result.Err.Data["accountSequence"])
+	assert.Equal(t, "42", result.Err.Data["expectedSequence"])
+
+	// transactions which were already included in a ledger are duplicates, not failures
+	proxy.store.Put("included", TransactionResult{Pending: true})
+	proxy.submit(context.Background(), horizonRequest{
+		txHash:         "included",
+		transactionXDR: "AAAA",
+		sourceAccount:  source,
+		sequence:       41,
+	})
+	_, ok = proxy.store.Get("included")
+	assert.False(t, ok)
+}
+
+func TestSendFeeBumpTransaction(t *testing.T) {
+	passphrase := network.TestNetworkPassphrase
+	source := keypair.MustRandom()
+	feeSource := keypair.MustRandom()
+	account := txnbuild.NewSimpleAccount(source.Address(), 1)
+	inner, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+		SourceAccount:        &account,
+		IncrementSequenceNum: true,
+		Operations:           []txnbuild.Operation{&txnbuild.BumpSequence{BumpTo: 10}},
+		BaseFee:              txnbuild.MinBaseFee,
+		Preconditions:        txnbuild.Preconditions{TimeBounds: txnbuild.NewInfiniteTimeout()},
+	})
+	assert.NoError(t, err)
+	inner, err = inner.Sign(passphrase, source)
+	assert.NoError(t, err)
+	feeBump, err := txnbuild.NewFeeBumpTransaction(txnbuild.FeeBumpTransactionParams{
+		Inner:      inner,
+		FeeAccount: feeSource.Address(),
+		BaseFee:    2 * txnbuild.MinBaseFee,
 	})
+	assert.NoError(t, err)
+	feeBump, err = feeBump.Sign(passphrase, feeSource)
+	assert.NoError(t, err)
+	envelope, err := feeBump.Base64()
+	assert.NoError(t, err)
 
+	proxy := NewTransactionProxy(nil, 1, 1, passphrase, time.Minute, NewMemoryTransactionStore(), nil, SubmissionRetryPolicy{}, nil)
+	response := proxy.SendTransaction(context.Background(), SendTransactionRequest{Transaction: envelope})
+
+	outerHash, err := feeBump.HashHex(passphrase)
+	assert.NoError(t, err)
+	innerHash, err := inner.HashHex(passphrase)
+	assert.NoError(t, err)
+	assert.Equal(t, TransactionPending, response.Status)
+	assert.Equal(t, outerHash, response.ID)
+	assert.Equal(t, &FeeBumpInfo{OuterHash: outerHash, InnerHash: innerHash}, response.FeeBump)
+	assert.False(t, response.Duplicate)
+
+	// submitting the transaction again returns its existing status
+	response = proxy.SendTransaction(context.Background(), SendTransactionRequest{Transaction: envelope})
+	assert.Equal(t, TransactionPending, response.Status)
+	assert.Equal(t, outerHash, response.ID)
+	assert.True(t, response.Duplicate)
+}
+
+func TestWaitForTransactionStatus(t *testing.T) {
+	var lookups sync.Map
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count, _ := lookups.LoadOrStore(r.URL.Path, new(int32))
+		atomic.AddInt32(count.(*int32), 1)
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(problem.P{Status: http.StatusNotFound, Title: "Resource Missing"})
+	}))
+	defer server.Close()
+
+	proxy := NewTransactionProxy(
+		&horizonclient.Client{HorizonURL: server.URL + "/"},
+		1,
+		1,
+		"",
+		time.Minute,
+		NewMemoryTransactionStore(),
+		nil,
+		SubmissionRetryPolicy{},
+		nil,
+	)
+	proxy.store.Put("a", TransactionResult{Pending: true})
+	proxy.store.Put("b", TransactionResult{Pending: true})
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		proxy.setTxResult("a", TransactionResult{Err: &TransactionResponseError{Code: "tx_submission_failed"}})
+	}()
+	start := time.Now()
+	response := proxy.WaitForTransactionStatus(context.Background(), GetTransactionStatusRequest{Hash: "a"}, time.Minute)
+	assert.Equal(t, TransactionError, response.Status)
+	assert.Equal(t, "tx_submission_failed", response.Error.Code)
+	assert.Less(t, time.Since(start), time.Minute)
+
+	// transactions still pending once the wait elapses are returned as such,
+	// the updates of other transactions don't cause additional lookups
+	go func() {
+		for i := 0; i < 5; i++ {
+			time.Sleep(10 * time.Millisecond)
+			proxy.setTxResult("a", TransactionResult{Pending: true})
+		}
+	}()
+	response = proxy.WaitForTransactionStatus(context.Background(), GetTransactionStatusRequest{Hash: "b"}, 100*time.Millisecond)
+	assert.Equal(t, TransactionPending, response.Status)
+	count, ok := lookups.Load("/transactions/b")
+	require.True(t, ok)
+	assert.Equal(t, int32(1), atomic.LoadInt32(count.(*int32)))
+	assert.Empty(t, proxy.waiters)
+}
+
+func TestMemoryTransactionStoreSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transactions.json")
+	store := NewMemoryTransactionStore()
+	// a missing snapshot is an empty store
+	assert.NoError(t, store.LoadSnapshot(path))
+
+	failed := TransactionResult{
+		Timestamp: time.Now().Add(-time.Minute).Round(0).UTC(),
+		Err: &TransactionResponseError{
+			Code:    "tx_submission_failed",
+			Message: "Transaction submission failed",
+			Data:    map[string]interface{}{"result_codes": "tx_bad_seq"},
+		},
+	}
+	store.Put("failed", failed)
+	store.Put("pending", TransactionResult{Pending: true, Attempts: 1})
+	assert.NoError(t, store.SaveSnapshot(path))
+
+	restored := NewMemoryTransactionStore()
+	assert.NoError(t, restored.LoadSnapshot(path))
+	result, ok := restored.Get("failed")
+	assert.True(t, ok)
+	assert.Equal(t, failed, result)
+	// pending submissions don't survive restarts
+	_, ok = restored.Get("pending")
+	assert.False(t, ok)
+
+	assert.NoError(t, os.WriteFile(path, []byte("garbage"), 0o600))
+	assert.Error(t, restored.LoadSnapshot(path))
+}
+
+func TestMemoryTransactionStoreBudget(t *testing.T) {
+	entrySize := resultSize("a", TransactionResult{})
+	store := NewMemoryTransactionStoreWithBudget(10 * entrySize)
+	now := time.Now()
+	store.Put("p", TransactionResult{Pending: true})
+	for i := 0; i < 9; i++ {
+		store.Put(strconv.Itoa(i), TransactionResult{Timestamp: now.Add(time.Duration(i) * time.Second)})
+	}
+	assert.Equal(t, 10, store.Len())
+	assert.Equal(t, 10*entrySize, store.SizeBytes())
+	assert.Zero(t, store.Evicted())
+
+	// exceeding the budget evicts the oldest results down to 90% of it
+	store.Put("9", TransactionResult{Timestamp: now.Add(9 * time.Second)})
+	assert.Equal(t, 9, store.Len())
+	assert.Equal(t, 9*entrySize, store.SizeBytes())
+	assert.Equal(t, int64(2), store.Evicted())
+	for _, txHash := range []string{"0", "1"} {
+		_, ok := store.Get(txHash)
+		assert.False(t, ok)
+	}
+	// pending results are never evicted
+	_, ok := store.Get("p")
+	assert.True(t, ok)
+
+	// replacing and deleting results releases their memory
+	store.Put("9", TransactionResult{Timestamp: now})
+	assert.Equal(t, 9*entrySize, store.SizeBytes())
+	store.Delete("9")
+	store.Delete("unknown")
+	assert.Equal(t, 8*entrySize, store.SizeBytes())
+
+	// results are evicted in timestamp order regardless of the order they were stored in,
+	// and completed results become evictable
+	store = NewMemoryTransactionStoreWithBudget(3 * entrySize)
+	store.Put("p", TransactionResult{Pending: true})
+	store.Put("b", TransactionResult{Timestamp: now.Add(2 * time.Second)})
+	store.Put("a", TransactionResult{Timestamp: now.Add(time.Second)})
+	store.Put("p", TransactionResult{Timestamp: now})
+	store.Put("c", TransactionResult{Timestamp: now.Add(3 * time.Second)})
+	assert.Equal(t, 2, store.Len())
+	for txHash, stored := range map[string]bool{"p": false, "a": false, "b": true, "c": true} {
+		_, ok := store.Get(txHash)
+		assert.Equal(t, stored, ok, txHash)
+	}
+}
+
+func TestMemoryTransactionStoreFind(t *testing.T) {
+	store := NewMemoryTransactionStore()
+	store.Put("a", TransactionResult{Pending: true, SourceAccount: "G1", Sequence: 1})
+	txHash, ok := store.Find("G1", 1)
+	assert.True(t, ok)
+	assert.Equal(t, "a", txHash)
+	_, ok = store.Find("G1", 2)
+	assert.False(t, ok)
+
+	// the latest submission of a sequence number is found
+	store.Put("b", TransactionResult{Pending: true, SourceAccount: "G1", Sequence: 1})
+	txHash, _ = store.Find("G1", 1)
+	assert.Equal(t, "b", txHash)
+	store.Delete("a")
+	txHash, ok = store.Find("G1", 1)
+	assert.True(t, ok)
+	assert.Equal(t, "b", txHash)
+	store.Delete("b")
+	_, ok = store.Find("G1", 1)
+	assert.False(t, ok)
+}
+
+func TestTransactionProxyCloseTwice(t *testing.T) {
+	proxy := NewTransactionProxy(
+		nil,
+		1,
+		1,
+		"",
+		time.Minute,
+		NewMemoryTransactionStore(),
+		nil,
+		SubmissionRetryPolicy{},
+		nil,
+	)
+	proxy.Start(context.Background())
+	proxy.Close()
+	assert.NotPanics(t, proxy.Close)
 }
@@ -0,0 +1,266 @@
+package methods
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/handler"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/xdr"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/rpcerror"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/tracing"
+)
+
+const (
+	// ValidationError is the severity of the issues which make the transaction fail
+	ValidationError = "error"
+	// ValidationWarning is the severity of the issues which may delay or fail the transaction
+	ValidationWarning = "warning"
+)
+
+type ValidateTransactionRequest struct {
+	Transaction string `json:"transaction"`
+}
+
+// ValidationIssue is a problem found in a transaction by validateTransaction
+type ValidationIssue struct {
+	Severity string `json:"severity"`
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+}
+
+type ValidateTransactionResponse struct {
+	// Hash is only set when the transaction could be decoded
+	Hash string `json:"hash,omitempty"`
+	// Valid is false when any of the issues is an error
+	Valid  bool              `json:"valid"`
+	Issues []ValidationIssue `json:"issues,omitempty"`
+}
+
+func (r *ValidateTransactionResponse) addIssue(severity, code, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, ValidationIssue{Severity: severity, Code: code, Message: fmt.Sprintf(format, args...)})
+	if severity == ValidationError {
+		r.Valid = false
+	}
+}
+
+// transactionValidator holds the state of a validateTransaction request
+type transactionValidator struct {
+	ctx           context.Context
+	horizonClient *horizonclient.Client
+	passphrase    string
+	response      ValidateTransactionResponse
+}
+
+// NewValidateTransactionHandler returns a json rpc handler checking transactions without submitting
+// or simulating them: their XDR, their signatures (against the signers of the source account and,
+// for fee bump transactions, of the fee account), their sequence number, time bounds and fee
+// (against the fee stats of Horizon), and the sanity of their soroban operations. Signatures by
+// pre-authorized transaction and hash signers aren't verified.
+func NewValidateTransactionHandler(logger *log.Entry, horizonClient *horizonclient.Client, networkPassphrase string) jrpc2.Handler {
+	return handler.New(func(ctx context.Context, request ValidateTransactionRequest) (ValidateTransactionResponse, error) {
+		v := transactionValidator{
+			ctx:           ctx,
+			horizonClient: horizonClient,
+			passphrase:    networkPassphrase,
+			response:      ValidateTransactionResponse{Valid: true},
+		}
+		if err := v.validate(request.Transaction); err != nil {
+			logger.WithError(err).Info("could not validate transaction")
+			return ValidateTransactionResponse{}, rpcerror.NewUpstreamUnavailable(rpcerror.UpstreamHorizon, err.Error())
+		}
+		return v.response, nil
+	})
+}
+
+// validate records the issues of the transaction in the response, it only fails when Horizon can't be reached
+func (v *transactionValidator) validate(transaction string) error {
+	var envelope xdr.TransactionEnvelope
+	if err := xdr.SafeUnmarshalBase64(transaction, &envelope); err != nil {
+		v.response.addIssue(ValidationError, "invalid_xdr", "cannot unmarshal transaction: %v", err)
+		return nil
+	}
+	hash, err := network.HashTransactionInEnvelope(envelope, v.passphrase)
+	if err != nil {
+		v.response.addIssue(ValidationError, "invalid_hash", "cannot hash transaction: %v", err)
+		return nil
+	}
+	v.response.Hash = hex.EncodeToString(hash[:])
+
+	v.checkTimeBounds(envelope.TimeBounds())
+	v.checkOperations(envelope.Operations())
+
+	source := envelope.SourceAccount().ToAccountId()
+	sourceAccount, found, err := v.account(source.Address())
+	if err != nil {
+		return err
+	}
+	if found {
+		v.checkSequence(sourceAccount, envelope.SeqNum(), envelope.MinSeqNum())
+	}
+
+	fee, operations := int64(envelope.Fee()), int64(len(envelope.Operations()))
+	if envelope.IsFeeBump() {
+		innerHash, err := network.HashTransaction(envelope.FeeBump.Tx.InnerTx.MustV1().Tx, v.passphrase)
+		if err != nil {
+			v.response.addIssue(ValidationError, "invalid_hash", "cannot hash inner transaction: %v", err)
+			return nil
+		}
+		if found {
+			v.checkSignatures("source", sourceAccount, innerHash, envelope.Signatures())
+		}
+		feeSource := envelope.FeeBumpAccount().ToAccountId()
+		feeAccount, found, err := v.account(feeSource.Address())
+		if err != nil {
+			return err
+		}
+		if found {
+			v.checkSignatures("fee source", feeAccount, hash, envelope.FeeBumpSignatures())
+		}
+		// the fee bump transaction pays for one more operation
+		fee, operations = envelope.FeeBumpFee(), operations+1
+	} else if found {
+		v.checkSignatures("source", sourceAccount, hash, envelope.Signatures())
+	}
+	return v.checkFee(fee, operations)
+}
+
+// account returns the account of the given address, found is false when it doesn't exist
+func (v *transactionValidator) account(address string) (account horizon.Account, found bool, err error) {
+	_, span := tracing.StartSpan(v.ctx, "horizon.account_detail")
+	account, err = v.horizonClient.AccountDetail(horizonclient.AccountRequest{AccountID: address})
+	tracing.EndSpan(span, err)
+	if herr, ok := err.(*horizonclient.Error); ok && herr.Problem.Status == http.StatusNotFound {
+		v.response.addIssue(ValidationError, "account_not_found", "account %s doesn't exist", address)
+		return account, false, nil
+	}
+	if err != nil {
+		return account, false, fmt.Errorf("could not obtain account %s from horizon", address)
+	}
+	return account, true, nil
+}
+
+func (v *transactionValidator) checkTimeBounds(timeBounds *xdr.TimeBounds) {
+	if timeBounds == nil {
+		return
+	}
+	now := time.Now().Unix()
+	if timeBounds.MaxTime != 0 && int64(timeBounds.MaxTime) < now {
+		v.response.addIssue(ValidationError, "tx_too_late", "the transaction expired at %d", timeBounds.MaxTime)
+	}
+	if int64(timeBounds.MinTime) > now {
+		v.response.addIssue(ValidationWarning, "tx_too_early", "the transaction is only valid from %d", timeBounds.MinTime)
+	}
+}
+
+// checkOperations checks the soroban operations, which must be alone in their transaction
+// and whose footprint is needed to be applied
+func (v *transactionValidator) checkOperations(operations []xdr.Operation) {
+	for i, op := range operations {
+		invoke, ok := op.Body.GetInvokeHostFunctionOp()
+		if !ok {
+			continue
+		}
+		if len(operations) > 1 {
+			v.response.addIssue(ValidationError, "invalid_soroban_operation", "operation %d invokes a host function, which must be the only operation of the transaction", i)
+		}
+		footprint := invoke.Footprint
+		if len(footprint.ReadOnly) == 0 && len(footprint.ReadWrite) == 0 {
+			v.response.addIssue(ValidationWarning, "empty_footprint", "the footprint of operation %d is empty, simulate the transaction to obtain it", i)
+		}
+		seen := map[string]bool{}
+		for _, key := range append(append([]xdr.LedgerKey{}, footprint.ReadOnly...), footprint.ReadWrite...) {
+			keyB64, err := xdr.MarshalBase64(key)
+			if err != nil {
+				v.response.addIssue(ValidationError, "invalid_footprint", "cannot marshal the footprint of operation %d: %v", i, err)
+				break
+			}
+			if seen[keyB64] {
+				v.response.addIssue(ValidationError, "invalid_footprint", "the footprint of operation %d contains the ledger key %s more than once", i, keyB64)
+			}
+			seen[keyB64] = true
+		}
+	}
+}
+
+func (v *transactionValidator) checkSequence(account horizon.Account, sequence int64, minSequence *int64) {
+	if minSequence != nil {
+		if account.Sequence < *minSequence || account.Sequence >= sequence {
+			v.response.addIssue(ValidationError, "tx_bad_seq", "the sequence number of the source account is %d, expected a sequence number between %d and %d", account.Sequence, *minSequence, sequence-1)
+		}
+		return
+	}
+	if sequence != account.Sequence+1 {
+		v.response.addIssue(ValidationError, "tx_bad_seq", "bad sequence number %d, expected sequence number %d", sequence, account.Sequence+1)
+	}
+}
+
+// checkSignatures checks that the signatures are valid and that their weight meets the low
+// threshold of the account, which is the one required for the transaction itself
+func (v *transactionValidator) checkSignatures(role string, account horizon.Account, hash [32]byte, signatures []xdr.DecoratedSignature) {
+	var weight int32
+	signed := map[string]bool{}
+	for i, signature := range signatures {
+		signer, ok := findSigner(account, hash, signature)
+		if !ok {
+			v.response.addIssue(ValidationWarning, "unknown_signature", "signature %d doesn't match any ed25519 signer of the %s account", i, role)
+			continue
+		}
+		if !signed[signer.Key] {
+			signed[signer.Key] = true
+			weight += signer.Weight
+		}
+	}
+	threshold := int32(account.Thresholds.LowThreshold)
+	if threshold == 0 {
+		threshold = 1
+	}
+	if weight < threshold {
+		v.response.addIssue(ValidationError, "tx_bad_auth", "the signatures of the %s account have a weight of %d, lower than its threshold of %d", role, weight, threshold)
+	}
+}
+
+func findSigner(account horizon.Account, hash [32]byte, signature xdr.DecoratedSignature) (horizon.Signer, bool) {
+	for _, signer := range account.Signers {
+		if signer.Type != "ed25519_public_key" {
+			continue
+		}
+		kp, err := keypair.ParseAddress(signer.Key)
+		if err != nil || kp.Hint() != signature.Hint {
+			continue
+		}
+		if kp.Verify(hash[:], signature.Signature) == nil {
+			return signer, true
+		}
+	}
+	return horizon.Signer{}, false
+}
+
+// checkFee compares the fee of the transaction with the base fee and the fees charged in the recent ledgers
+func (v *transactionValidator) checkFee(fee, operations int64) error {
+	_, span := tracing.StartSpan(v.ctx, "horizon.fee_stats")
+	feeStats, err := v.horizonClient.FeeStats()
+	tracing.EndSpan(span, err)
+	if err != nil {
+		return fmt.Errorf("could not obtain fee stats from horizon")
+	}
+	if operations == 0 {
+		v.response.addIssue(ValidationError, "tx_missing_operation", "the transaction has no operations")
+		return nil
+	}
+	if fee < feeStats.LastLedgerBaseFee*operations {
+		v.response.addIssue(ValidationError, "tx_insufficient_fee", "the fee (%d) is lower than the minimum fee of %d for %d operation(s)", fee, feeStats.LastLedgerBaseFee*operations, operations)
+	} else if fee/operations < feeStats.FeeCharged.P50 {
+		v.response.addIssue(ValidationWarning, "low_fee", "the fee per operation (%d) is lower than the median fee charged in the recent ledgers (%d), the transaction may not be included when ledgers are full", fee/operations, feeStats.FeeCharged.P50)
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package methods
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/stellar/go/support/log"
+)
+
+const webhookMaxAttempts = 3
+
+// TransactionWebhookPayload is the body POSTed to the callback URL of a transaction
+// once its submission completes.
+type TransactionWebhookPayload struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	// ResultXDR will be empty unless Status is equal to "success"
+	ResultXDR string `json:"resultXdr,omitempty"`
+	// Error will be nil unless Status is equal to "error"
+	Error *TransactionResponseError `json:"error,omitempty"`
+}
+
+// errPrivateDestination is returned when a webhook would be delivered to a non-public address
+var errPrivateDestination = errors.New("callback destination is not a public address")
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), which isn't publicly routable
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+type webhookNotification struct {
+	url     string
+	payload TransactionWebhookPayload
+}
+
+// WebhookNotifier delivers transaction status notifications to client provided callback URLs.
+type WebhookNotifier struct {
+	logger  *log.Entry
+	client  *http.Client
+	queue   chan webhookNotification
+	workers int
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	// AllowPrivateDestinations, when set, allows delivering notifications to loopback, private
+	// and link-local addresses. Otherwise, since callback URLs are provided by clients, they
+	// could be used to reach services which are only accessible from the host of soroban-rpc.
+	AllowPrivateDestinations bool
+}
+
+func NewWebhookNotifier(logger *log.Entry, workers, queueSize int, timeout time.Duration) *WebhookNotifier {
+	if workers > queueSize {
+		queueSize = workers
+	}
+	n := &WebhookNotifier{
+		logger:  logger,
+		queue:   make(chan webhookNotification, queueSize),
+		workers: workers,
+	}
+	// The destination is checked when connecting (rather than when resolving the callback host)
+	// so that it also applies to redirects and can't be bypassed through DNS rebinding.
+	// Proxies are not used, since they would hide the destination.
+	dialer := &net.Dialer{Timeout: timeout, Control: n.checkDestination}
+	n.client = &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConns:        workers,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+	return n
+}
+
+// checkDestination is the Control function of the dialer of the webhook client, refusing
+// to connect to non-public addresses unless AllowPrivateDestinations is set
+func (n *WebhookNotifier) checkDestination(network, address string, _ syscall.RawConn) error {
+	if n.AllowPrivateDestinations {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !isPublicAddress(ip) {
+		return errPrivateDestination
+	}
+	return nil
+}
+
+// isPublicAddress reports whether ip is a publicly routable unicast address
+func isPublicAddress(ip net.IP) bool {
+	return !ip.IsLoopback() &&
+		!ip.IsPrivate() &&
+		!ip.IsLinkLocalUnicast() &&
+		!ip.IsMulticast() &&
+		!ip.IsUnspecified() &&
+		!sharedAddressSpace.Contains(ip)
+}
+
+// validateCallbackURL checks that the callback URL is an absolute http(s) URL. Hosts which are
+// known to be non-public are refused early, the rest are checked when connecting.
+func (n *WebhookNotifier) validateCallbackURL(callbackURL string) error {
+	u, err := url.Parse(callbackURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	host := u.Hostname()
+	if host == "" {
+		return fmt.Errorf("missing host")
+	}
+	if n.AllowPrivateDestinations {
+		return nil
+	}
+	if ip := net.ParseIP(host); (ip != nil && !isPublicAddress(ip)) || strings.EqualFold(host, "localhost") {
+		return errPrivateDestination
+	}
+	return nil
+}
+
+func (n *WebhookNotifier) Start(ctx context.Context) {
+	ctx, n.cancel = context.WithCancel(ctx)
+	n.wg.Add(n.workers)
+	for i := 0; i < n.workers; i++ {
+		go n.startWorker(ctx)
+	}
+}
+
+func (n *WebhookNotifier) Close() {
+	// signal the worker go routines to abort (if they were started)
+	if n.cancel != nil {
+		n.cancel()
+	}
+	// wait until the worker go routines are done
+	n.wg.Wait()
+}
+
+// Notify enqueues a notification, dropping it if the queue is full
+func (n *WebhookNotifier) Notify(callbackURL string, payload TransactionWebhookPayload) {
+	select {
+	case n.queue <- webhookNotification{url: callbackURL, payload: payload}:
+	default:
+		n.logger.WithField("url", callbackURL).WithField("id", payload.ID).
+			Warn("webhook queue is full, dropping notification")
+	}
+}
+
+func (n *WebhookNotifier) startWorker(ctx context.Context) {
+	defer n.wg.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case notification := <-n.queue:
+			n.deliver(ctx, notification)
+		}
+	}
+}
+
+func (n *WebhookNotifier) deliver(ctx context.Context, notification webhookNotification) {
+	body, err := json.Marshal(notification.payload)
+	if err != nil {
+		n.logger.WithError(err).WithField("id", notification.payload.ID).
+			Warn("could not marshal webhook payload")
+		return
+	}
+	backoff := time.Second
+	for attempt := 1; ; attempt++ {
+		err = n.post(ctx, notification.url, body)
+		if err == nil {
+			return
+		}
+		if attempt == webhookMaxAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	n.logger.WithError(err).WithField("url", notification.url).
+		WithField("id", notification.payload.ID).
+		Warn("could not deliver webhook notification")
+}
+
+func (n *WebhookNotifier) post(ctx context.Context, callbackURL string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return nil
+}
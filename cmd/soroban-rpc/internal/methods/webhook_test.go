@@ -0,0 +1,85 @@
+package methods
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stellar/go/support/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCallbackURL(t *testing.T) {
+	notifier := NewWebhookNotifier(log.DefaultLogger, 1, 1, time.Second)
+	assert.NoError(t, notifier.validateCallbackURL("https://example.com/callback"))
+	assert.NoError(t, notifier.validateCallbackURL("http://8.8.8.8:8080"))
+	assert.Error(t, notifier.validateCallbackURL("ftp://example.com"))
+	assert.Error(t, notifier.validateCallbackURL("/callback"))
+	assert.Error(t, notifier.validateCallbackURL("http://"))
+	for _, callbackURL := range []string{
+		"http://localhost:8080",
+		"http://127.0.0.1/callback",
+		"http://10.0.0.1/callback",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]:8080",
+		"http://[fe80::1]/callback",
+		"http://100.64.0.1/callback",
+	} {
+		assert.ErrorIs(t, notifier.validateCallbackURL(callbackURL), errPrivateDestination, callbackURL)
+	}
+
+	notifier.AllowPrivateDestinations = true
+	assert.NoError(t, notifier.validateCallbackURL("http://localhost:8080"))
+	assert.NoError(t, notifier.validateCallbackURL("http://169.254.169.254/latest/meta-data"))
+}
+
+func TestWebhookNotifierRefusesPrivateDestinations(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	notifier := NewWebhookNotifier(log.DefaultLogger, 1, 1, time.Second)
+	// the loopback address is only detected when connecting, as if a public host name
+	// resolved to it
+	err := notifier.post(context.Background(), server.URL, []byte("{}"))
+	assert.ErrorIs(t, err, errPrivateDestination)
+	assert.Equal(t, 0, requests)
+
+	notifier.AllowPrivateDestinations = true
+	require.NoError(t, notifier.post(context.Background(), server.URL, []byte("{}")))
+	assert.Equal(t, 1, requests)
+}
+
+func TestWebhookNotifierDelivers(t *testing.T) {
+	received := make(chan TransactionWebhookPayload, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload TransactionWebhookPayload
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		received <- payload
+	}))
+	defer server.Close()
+
+	notifier := NewWebhookNotifier(log.DefaultLogger, 1, 1, time.Second)
+	notifier.AllowPrivateDestinations = true
+	notifier.Start(context.Background())
+	defer notifier.Close()
+
+	payload := TransactionWebhookPayload{
+		ID:        "abcd",
+		Status:    TransactionSuccess,
+		ResultXDR: "AAAA",
+	}
+	notifier.Notify(server.URL, payload)
+	select {
+	case got := <-received:
+		assert.Equal(t, payload, got)
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+}
@@ -0,0 +1,130 @@
+package methods
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/code"
+
+	"github.com/stellar/go/xdr"
+)
+
+const (
+	// FormatBase64 only returns the XDR structures encoded in base64 (default)
+	FormatBase64 = "base64"
+	// FormatJSON additionally returns the XDR structures decoded into JSON
+	FormatJSON = "json"
+)
+
+var (
+	stringerType     = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+	accountIDType    = reflect.TypeOf(xdr.AccountId{})
+	muxedAccountType = reflect.TypeOf(xdr.MuxedAccount{})
+)
+
+// validateFormat checks that format is one of the supported response formats
+func validateFormat(format string) error {
+	switch format {
+	case "", FormatBase64, FormatJSON:
+		return nil
+	default:
+		return &jrpc2.Error{
+			Code:    code.InvalidParams,
+			Message: fmt.Sprintf("invalid format %q, expected %q or %q", format, FormatBase64, FormatJSON),
+		}
+	}
+}
+
+// xdrToJSON converts an XDR structure into a value which can be encoded into readable JSON:
+//   - union arms which are not set are omitted
+//   - enums are encoded by name
+//   - fixed size opaque values (hashes, keys) are encoded in hex and variable size ones in base64
+//   - 64 bit integers are encoded as strings, to avoid losing precision
+//   - accounts are encoded as strkeys
+func xdrToJSON(value interface{}) (interface{}, error) {
+	return convertXDRValue(reflect.ValueOf(value))
+}
+
+func convertXDRValue(v reflect.Value) (interface{}, error) {
+	switch v.Type() {
+	case accountIDType:
+		accountID := v.Interface().(xdr.AccountId)
+		return accountID.GetAddress()
+	case muxedAccountType:
+		muxedAccount := v.Interface().(xdr.MuxedAccount)
+		return muxedAccount.GetAddress()
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return convertXDRValue(v.Elem())
+	case reflect.Struct:
+		result := make(map[string]interface{}, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			fieldValue := v.Field(i)
+			// unset union arms and optional values
+			if fieldValue.Kind() == reflect.Ptr && fieldValue.IsNil() {
+				continue
+			}
+			converted, err := convertXDRValue(fieldValue)
+			if err != nil {
+				return nil, err
+			}
+			result[lowerFirst(field.Name)] = converted
+		}
+		return result, nil
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			bytes := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(bytes), v)
+			return hex.EncodeToString(bytes), nil
+		}
+		return convertXDRSlice(v)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return base64.StdEncoding.EncodeToString(v.Bytes()), nil
+		}
+		return convertXDRSlice(v)
+	case reflect.Int32:
+		if v.Type().Implements(stringerType) {
+			return v.Interface().(fmt.Stringer).String(), nil
+		}
+		return v.Int(), nil
+	case reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	default:
+		return v.Interface(), nil
+	}
+}
+
+func convertXDRSlice(v reflect.Value) (interface{}, error) {
+	result := make([]interface{}, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		converted, err := convertXDRValue(v.Index(i))
+		if err != nil {
+			return nil, err
+		}
+		result[i] = converted
+	}
+	return result, nil
+}
+
+func lowerFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[size:]
+}
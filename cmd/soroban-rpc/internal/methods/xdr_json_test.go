@@ -0,0 +1,53 @@
+package methods
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestXDRToJSON(t *testing.T) {
+	sym := xdr.ScSymbol("counter")
+	u63 := xdr.Int64(1 << 62)
+	var contractID xdr.Hash
+	contractID[0] = 0xab
+	data := xdr.LedgerEntryData{
+		Type: xdr.LedgerEntryTypeContractData,
+		ContractData: &xdr.ContractDataEntry{
+			ContractId: contractID,
+			Key:        xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &sym},
+			Val:        xdr.ScVal{Type: xdr.ScValTypeScvU63, U63: &u63},
+		},
+	}
+
+	converted, err := xdrToJSON(data)
+	require.NoError(t, err)
+	encoded, err := json.Marshal(converted)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "LedgerEntryTypeContractData",
+		"contractData": {
+			"contractId": "ab00000000000000000000000000000000000000000000000000000000000000",
+			"key": {"type": "ScValTypeScvSymbol", "sym": "counter"},
+			"val": {"type": "ScValTypeScvU63", "u63": "4611686018427387904"}
+		}
+	}`, string(encoded))
+}
+
+func TestXDRToJSONAccount(t *testing.T) {
+	address := keypair.MustRandom().Address()
+	converted, err := xdrToJSON(xdr.MustAddress(address))
+	require.NoError(t, err)
+	assert.Equal(t, address, converted)
+}
+
+func TestValidateFormat(t *testing.T) {
+	assert.NoError(t, validateFormat(""))
+	assert.NoError(t, validateFormat(FormatBase64))
+	assert.NoError(t, validateFormat(FormatJSON))
+	assert.Error(t, validateFormat("yaml"))
+}
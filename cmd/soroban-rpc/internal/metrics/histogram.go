@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// nativeHistogramBucketFactor bounds the growth between consecutive native histogram buckets (10%)
+	nativeHistogramBucketFactor     = 1.1
+	nativeHistogramMaxBucketNumber  = 160
+	nativeHistogramMinResetDuration = time.Hour
+)
+
+// HistogramConfig configures the histograms of soroban-rpc
+type HistogramConfig struct {
+	// NativeHistograms enables native (exponential) histograms, which are only exposed to the
+	// scrapers negotiating the protobuf format
+	NativeHistograms bool
+	// DisableClassicBuckets drops the regular buckets of the histograms, it requires NativeHistograms
+	DisableClassicBuckets bool
+}
+
+// Validate checks that the configuration keeps some buckets
+func (c HistogramConfig) Validate() error {
+	if c.DisableClassicBuckets && !c.NativeHistograms {
+		return errors.New("the classic histogram buckets can only be disabled along with native histograms")
+	}
+	return nil
+}
+
+// Apply returns the histogram options configured according to c
+func (c HistogramConfig) Apply(opts prometheus.HistogramOpts) prometheus.HistogramOpts {
+	if c.NativeHistograms {
+		opts.NativeHistogramBucketFactor = nativeHistogramBucketFactor
+		opts.NativeHistogramMaxBucketNumber = nativeHistogramMaxBucketNumber
+		opts.NativeHistogramMinResetDuration = nativeHistogramMinResetDuration
+		if c.DisableClassicBuckets {
+			opts.Buckets = nil
+		}
+	}
+	return opts
+}
+
+// Observe records the value, along with the ID of the trace of ctx as an exemplar when it is
+// sampled, so that the observations can be linked to their traces
+func Observe(ctx context.Context, observer prometheus.Observer, value float64) {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok && spanContext.IsSampled() {
+		exemplarObserver.ObserveWithExemplar(value, prometheus.Labels{"trace_id": spanContext.TraceID().String()})
+		return
+	}
+	observer.Observe(value)
+}
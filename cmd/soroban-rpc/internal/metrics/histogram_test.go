@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestHistogramConfig(t *testing.T) {
+	assert.Error(t, HistogramConfig{DisableClassicBuckets: true}.Validate())
+	assert.NoError(t, HistogramConfig{NativeHistograms: true, DisableClassicBuckets: true}.Validate())
+
+	opts := prometheus.HistogramOpts{Name: "h", Buckets: []float64{1, 2}}
+	assert.Equal(t, opts, HistogramConfig{}.Apply(opts))
+	native := HistogramConfig{NativeHistograms: true}.Apply(opts)
+	assert.Equal(t, nativeHistogramBucketFactor, native.NativeHistogramBucketFactor)
+	assert.Equal(t, []float64{1, 2}, native.Buckets)
+	assert.Nil(t, HistogramConfig{NativeHistograms: true, DisableClassicBuckets: true}.Apply(opts).Buckets)
+}
+
+func TestObserveWithTraceExemplar(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "h", Buckets: []float64{1, 2}})
+	registry.MustRegister(histogram)
+
+	// untraced observations don't have exemplars
+	Observe(context.Background(), histogram, 0.5)
+	traceID := trace.TraceID{1, 2, 3}
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     trace.SpanID{4},
+		TraceFlags: trace.FlagsSampled,
+	}))
+	Observe(ctx, histogram, 1.5)
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	buckets := families[0].GetMetric()[0].GetHistogram().GetBucket()
+	assert.Nil(t, buckets[0].GetExemplar())
+	exemplar := buckets[1].GetExemplar()
+	require.NotNil(t, exemplar)
+	assert.Equal(t, 1.5, exemplar.GetValue())
+	assert.Equal(t, "trace_id", exemplar.GetLabel()[0].GetName())
+	assert.Equal(t, traceID.String(), exemplar.GetLabel()[0].GetValue())
+}
@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// LogMetricsHook is a logrus hook counting the log entries emitted at every level
+type LogMetricsHook struct {
+	counter *prometheus.CounterVec
+}
+
+// NewLogMetricsHook creates a LogMetricsHook and registers its counter in the registry
+func NewLogMetricsHook(registry *prometheus.Registry) *LogMetricsHook {
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: PrometheusNamespace,
+		Subsystem: "log",
+		Name:      "entries_total",
+		Help:      "number of log entries, by level",
+	}, []string{"level"})
+	registry.MustRegister(counter)
+	return &LogMetricsHook{counter: counter}
+}
+
+// Levels implements logrus.Hook
+func (h *LogMetricsHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook
+func (h *LogMetricsHook) Fire(entry *logrus.Entry) error {
+	h.counter.With(prometheus.Labels{"level": entry.Level.String()}).Inc()
+	return nil
+}
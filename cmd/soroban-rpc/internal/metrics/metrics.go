@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/version"
+)
+
+// PrometheusNamespace is the namespace of all the soroban-rpc metrics
+const PrometheusNamespace = "soroban_rpc"
+
+// NewRegistry creates a prometheus registry including the Go runtime and process collectors
+// and the build information of soroban-rpc (along with the passphrase of the network it serves)
+func NewRegistry(networkPassphrase string) *prometheus.Registry {
+	buildInfo := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: PrometheusNamespace,
+		Name:      "build_info",
+		Help:      "build information of soroban-rpc, the value is always 1",
+		ConstLabels: prometheus.Labels{
+			"version":            version.Version,
+			"commit":             version.CommitHash,
+			"timestamp":          version.BuildTimestamp,
+			"network_passphrase": networkPassphrase,
+		},
+	})
+	buildInfo.Set(1)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{Namespace: PrometheusNamespace}),
+		buildInfo,
+	)
+	return registry
+}
+
+// Handler returns an http.Handler exposing the metrics of the registry. The OpenMetrics format
+// is served to the scrapers requesting it, since it is the only text format including exemplars.
+func Handler(registry *prometheus.Registry) http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{EnableOpenMetrics: true})
+}
@@ -0,0 +1,253 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/creachadair/jrpc2"
+	"golang.org/x/time/rate"
+
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/rpcerror"
+)
+
+// APIKey describes a client allowed to use the server
+type APIKey struct {
+	// Name identifies the client in logs and metrics, the key itself is never exposed
+	Name string `json:"name"`
+	Key  string `json:"key"`
+	// RateLimit is the maximum number of requests per second, zero means no limit
+	RateLimit float64 `json:"rateLimit,omitempty"`
+	// Methods lists the methods the client can call, an empty list allows all the methods
+	Methods []string `json:"methods,omitempty"`
+}
+
+type apiKey struct {
+	name    string
+	limiter *rate.Limiter
+	methods map[string]bool
+}
+
+// APIKeyAuth authenticates JSON-RPC requests using API keys provided in the Authorization header
+// (either as "Bearer <key>" or as the bare key) and enforces the quotas and method allowlists of every key.
+type APIKeyAuth struct {
+	lock sync.Mutex
+	keys map[string]*apiKey
+	// OnRequest, when set, is invoked for every call accepted for the given API key name
+	OnRequest func(name, method string)
+	// OnRejected, when set, is invoked every time a call is rejected.
+	// reason is either "unauthorized", "method" or "rate_limit".
+	OnRejected func(name, method, reason string)
+}
+
+// LoadAPIKeys reads a JSON file containing an array of API keys
+func LoadAPIKeys(path string) ([]APIKey, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var keys []APIKey
+	if err := json.Unmarshal(contents, &keys); err != nil {
+		return nil, fmt.Errorf("could not parse api keys file %s: %v", path, err)
+	}
+	return keys, nil
+}
+
+// ParseAPIKeys parses a comma separated list of name:key pairs (e.g. "wallet:s3cr3t,explorer:0th3r"),
+// the resulting keys have no rate limit and are allowed to call all the methods.
+func ParseAPIKeys(s string) ([]APIKey, error) {
+	var keys []APIKey
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid api key %q, expected <name>:<key>", entry)
+		}
+		keys = append(keys, APIKey{Name: strings.TrimSpace(parts[0]), Key: strings.TrimSpace(parts[1])})
+	}
+	return keys, nil
+}
+
+// NewAPIKeyAuth creates an APIKeyAuth accepting the given keys
+func NewAPIKeyAuth(keys []APIKey) (*APIKeyAuth, error) {
+	a := &APIKeyAuth{}
+	if err := a.SetKeys(keys); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// SetKeys replaces the accepted API keys, it is safe to call it while requests are being served.
+func (a *APIKeyAuth) SetKeys(keys []APIKey) error {
+	result := make(map[string]*apiKey, len(keys))
+	names := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		switch {
+		case key.Name == "" || key.Key == "":
+			return fmt.Errorf("api keys must have a name and a key")
+		case names[key.Name]:
+			return fmt.Errorf("duplicate api key name %q", key.Name)
+		case result[key.Key] != nil:
+			return fmt.Errorf("api key %q uses the same key as %q", key.Name, result[key.Key].name)
+		case key.RateLimit < 0:
+			return fmt.Errorf("invalid rate limit of api key %q", key.Name)
+		}
+		names[key.Name] = true
+		entry := &apiKey{name: key.Name}
+		if key.RateLimit > 0 {
+			entry.limiter = rate.NewLimiter(rate.Limit(key.RateLimit), burst(key.RateLimit))
+		}
+		if len(key.Methods) > 0 {
+			entry.methods = make(map[string]bool, len(key.Methods))
+			for _, method := range key.Methods {
+				entry.methods[method] = true
+			}
+		}
+		result[key.Key] = entry
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.keys = result
+	return nil
+}
+
+func (a *APIKeyAuth) lookup(r *http.Request) (*apiKey, bool) {
+	key := strings.TrimSpace(r.Header.Get("Authorization"))
+	if len(key) > len("bearer ") && strings.EqualFold(key[:len("bearer ")], "bearer ") {
+		key = strings.TrimSpace(key[len("bearer "):])
+	}
+	if key == "" {
+		return nil, false
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	entry, ok := a.keys[key]
+	return entry, ok
+}
+
+// allow reports whether the given key can call method. If it can, it also returns the rate limit
+// reservation of the call (if any). If it can't, it returns the reason and, when the rate limit
+// is exceeded, how long the client should wait before retrying.
+func (a *APIKeyAuth) allow(now time.Time, key *apiKey, method string) (bool, *rate.Reservation, string, time.Duration) {
+	if key.methods != nil && !key.methods[method] {
+		return false, nil, "method", 0
+	}
+	if key.limiter != nil {
+		reservation, delay := reserve(now, key.limiter)
+		if reservation == nil {
+			return false, nil, "rate_limit", delay
+		}
+		return true, reservation, "", 0
+	}
+	return true, nil, "", 0
+}
+
+// Middleware returns an http.Handler which rejects JSON-RPC requests without a valid API key
+// (with an HTTP 401 status), calling methods the key is not allowed to call (with an HTTP 403 status)
+// or exceeding the rate limit of the key (with an HTTP 429 status) before they reach next.
+func (a *APIKeyAuth) Middleware(logger *log.Entry, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "could not read request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		requests, parseErr := jrpc2.ParseRequests(body)
+
+		key, ok := a.lookup(r)
+		if !ok {
+			logger.WithField("ip", ClientIP(r)).Debug("request without a valid api key")
+			batch := parseErr == nil && isBatch(body)
+			if parseErr != nil || len(requests) == 0 {
+				requests, batch = []*jrpc2.ParsedRequest{{ID: "null"}}, false
+			}
+			for _, req := range requests {
+				a.rejected("", req.Method, "unauthorized")
+			}
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeErrorResponses(logger, w, http.StatusUnauthorized, batch, requests, &jrpc2.Error{
+				Code:    rpcerror.Unauthorized,
+				Message: "missing or invalid api key",
+			})
+			return
+		}
+		if parseErr != nil {
+			// let the JSON-RPC bridge report the error
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		now := time.Now()
+		var rejectedMethod string
+		var retryAfter time.Duration
+		var granted []*rate.Reservation
+		for _, req := range requests {
+			ok, reservation, reason, delay := a.allow(now, key, req.Method)
+			if ok {
+				if reservation != nil {
+					granted = append(granted, reservation)
+				}
+				continue
+			}
+			a.rejected(key.name, req.Method, reason)
+			logger.WithField("method", req.Method).WithField("apiKey", key.name).
+				WithField("reason", reason).Debug("api key request rejected")
+			if reason == "method" && rejectedMethod == "" {
+				rejectedMethod = req.Method
+			}
+			if delay > retryAfter {
+				retryAfter = delay
+			}
+		}
+		if rejectedMethod != "" || retryAfter > 0 {
+			// the request is rejected as a whole, give back the tokens of the allowed calls
+			cancelReservations(now, granted)
+		}
+		switch {
+		case rejectedMethod != "":
+			writeErrorResponses(logger, w, http.StatusForbidden, isBatch(body), requests, &jrpc2.Error{
+				Code:    rpcerror.MethodNotAllowed,
+				Message: fmt.Sprintf("api key is not allowed to call %s", rejectedMethod),
+			})
+		case retryAfter > 0:
+			retryAfterSeconds := int64(math.Ceil(retryAfter.Seconds()))
+			rpcErr := (&jrpc2.Error{
+				Code:    rpcerror.RateLimitExceeded,
+				Message: "api key rate limit exceeded",
+			}).WithData(map[string]int64{"retryAfter": retryAfterSeconds})
+			w.Header().Set("Retry-After", strconv.FormatInt(retryAfterSeconds, 10))
+			writeErrorResponses(logger, w, http.StatusTooManyRequests, isBatch(body), requests, rpcErr)
+		default:
+			if a.OnRequest != nil {
+				for _, req := range requests {
+					a.OnRequest(key.name, req.Method)
+				}
+			}
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+func (a *APIKeyAuth) rejected(name, method, reason string) {
+	if a.OnRejected != nil {
+		a.OnRejected(name, method, reason)
+	}
+}
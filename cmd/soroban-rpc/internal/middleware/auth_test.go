@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stellar/go/support/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAPIKeys(t *testing.T) {
+	keys, err := ParseAPIKeys("wallet:abc, explorer:def,")
+	require.NoError(t, err)
+	assert.Equal(t, []APIKey{{Name: "wallet", Key: "abc"}, {Name: "explorer", Key: "def"}}, keys)
+
+	keys, err = ParseAPIKeys("")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	_, err = ParseAPIKeys("wallet")
+	assert.Error(t, err)
+}
+
+func TestLoadAPIKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[
+		{"name": "wallet", "key": "abc", "rateLimit": 10, "methods": ["getAccount"]}
+	]`), 0600))
+	keys, err := LoadAPIKeys(path)
+	require.NoError(t, err)
+	assert.Equal(t, []APIKey{{Name: "wallet", Key: "abc", RateLimit: 10, Methods: []string{"getAccount"}}}, keys)
+}
+
+func TestNewAPIKeyAuthValidation(t *testing.T) {
+	_, err := NewAPIKeyAuth([]APIKey{{Name: "wallet"}})
+	assert.Error(t, err)
+	_, err = NewAPIKeyAuth([]APIKey{{Name: "wallet", Key: "abc"}, {Name: "wallet", Key: "def"}})
+	assert.Error(t, err)
+	_, err = NewAPIKeyAuth([]APIKey{{Name: "wallet", Key: "abc"}, {Name: "explorer", Key: "abc"}})
+	assert.Error(t, err)
+}
+
+func TestAPIKeyAuthMiddleware(t *testing.T) {
+	auth, err := NewAPIKeyAuth([]APIKey{
+		{Name: "wallet", Key: "abc", RateLimit: 1},
+		{Name: "explorer", Key: "def", Methods: []string{"getHealth"}},
+	})
+	require.NoError(t, err)
+	var accepted, rejected []string
+	auth.OnRequest = func(name, method string) {
+		accepted = append(accepted, name+"/"+method)
+	}
+	auth.OnRejected = func(name, method, reason string) {
+		rejected = append(rejected, name+"/"+method+"/"+reason)
+	}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := auth.Middleware(log.DefaultLogger, next)
+	serve := func(key string, method string) *httptest.ResponseRecorder {
+		body := `{"jsonrpc":"2.0","id":7,"method":"` + method + `","params":{}}`
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		if key != "" {
+			r.Header.Set("Authorization", key)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve("", "getHealth")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.JSONEq(t,
+		`{"jsonrpc":"2.0","id":7,"error":{"code":-32032,"message":"missing or invalid api key"}}`,
+		w.Body.String(),
+	)
+	assert.Equal(t, http.StatusUnauthorized, serve("Bearer xyz", "getHealth").Code)
+
+	assert.Equal(t, http.StatusOK, serve("Bearer abc", "getAccount").Code)
+	w = serve("abc", "getAccount")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusOK, serve("Bearer def", "getHealth").Code)
+	w = serve("Bearer def", "getAccount")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.JSONEq(t,
+		`{"jsonrpc":"2.0","id":7,"error":{"code":-32033,"message":"api key is not allowed to call getAccount"}}`,
+		w.Body.String(),
+	)
+
+	assert.Equal(t, []string{"wallet/getAccount", "explorer/getHealth"}, accepted)
+	assert.Equal(t, []string{
+		"/getHealth/unauthorized",
+		"/getHealth/unauthorized",
+		"wallet/getAccount/rate_limit",
+		"explorer/getAccount/method",
+	}, rejected)
+}
+
+func TestAPIKeyAuthRejectedBatchKeepsQuota(t *testing.T) {
+	auth, err := NewAPIKeyAuth([]APIKey{
+		{Name: "wallet", Key: "abc", RateLimit: 1, Methods: []string{"getAccount"}},
+	})
+	require.NoError(t, err)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := auth.Middleware(log.DefaultLogger, next)
+	serve := func(body string) int {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		r.Header.Set("Authorization", "Bearer abc")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusForbidden, serve(`[
+		{"jsonrpc":"2.0","id":1,"method":"getAccount","params":{}},
+		{"jsonrpc":"2.0","id":2,"method":"getHealth","params":{}}
+	]`))
+	// the getAccount call of the rejected batch didn't consume the quota of the key
+	assert.Equal(t, http.StatusOK, serve(`{"jsonrpc":"2.0","id":3,"method":"getAccount","params":{}}`))
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/code"
+
+	"github.com/stellar/go/support/log"
+)
+
+// BatchSizeLimit returns an http.Handler which rejects JSON-RPC batches containing more
+// than maxBatchSize requests before they reach next.
+func BatchSizeLimit(logger *log.Entry, maxBatchSize int, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "could not read request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		requests, err := jrpc2.ParseRequests(body)
+		if err != nil || len(requests) <= maxBatchSize {
+			// let the JSON-RPC bridge serve the request (or report the parsing error)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		logger.WithField("size", len(requests)).Debug("batch size limit exceeded")
+		writeErrorResponses(logger, w, http.StatusBadRequest, isBatch(body), requests, &jrpc2.Error{
+			Code:    code.InvalidRequest,
+			Message: fmt.Sprintf("batch size exceeds the maximum of %d requests", maxBatchSize),
+		})
+	})
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stellar/go/support/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchSizeLimit(t *testing.T) {
+	var forwardedBody string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		forwardedBody = string(body)
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := BatchSizeLimit(log.DefaultLogger, 2, next)
+
+	body := `[{"jsonrpc":"2.0","id":1,"method":"getHealth"},{"jsonrpc":"2.0","id":2,"method":"getHealth"}]`
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, body, forwardedBody)
+
+	body = `[{"jsonrpc":"2.0","id":1,"method":"getHealth"},{"jsonrpc":"2.0","method":"getHealth"},{"jsonrpc":"2.0","id":3,"method":"getHealth"}]`
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t,
+		`[{"jsonrpc":"2.0","id":1,"error":{"code":-32600,"message":"batch size exceeds the maximum of 2 requests"}},`+
+			`{"jsonrpc":"2.0","id":3,"error":{"code":-32600,"message":"batch size exceeds the maximum of 2 requests"}}]`,
+		w.Body.String(),
+	)
+}
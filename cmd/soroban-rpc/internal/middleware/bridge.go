@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/server"
+)
+
+// Bridge is an http.Handler which forwards JSON RPC requests (sent in the body of POST requests)
+// to a JSON RPC server. It behaves like jhttp.Bridge, except that the in-flight requests of
+// HTTP clients which go away are canceled, so that their handlers stop doing unneeded work.
+type Bridge struct {
+	local server.Local
+}
+
+// NewBridge starts a JSON RPC server serving the methods of mux, which runs until the bridge is closed
+func NewBridge(mux jrpc2.Assigner, opts *jrpc2.ServerOptions) Bridge {
+	return Bridge{local: server.NewLocal(mux, &server.LocalOptions{Server: opts})}
+}
+
+func (b Bridge) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Accept-Post", "application/json")
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if r.Header.Get("Content-Type") != "application/json" {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	requests, err := jrpc2.ParseRequests(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// The server is shared by all the HTTP clients, so the request IDs are assigned
+	// by the bridge client and the responses are mapped back to the inbound IDs
+	var results []json.RawMessage
+	var inboundIDs []string
+	var specs []jrpc2.Spec
+	for _, req := range requests {
+		if req.Error != nil {
+			// invalid requests are answered right away
+			id := req.ID
+			if id == "" {
+				id = "null"
+			}
+			encoded, err := json.Marshal(errorResponse{JSONRPC: "2.0", ID: json.RawMessage(id), Error: req.Error})
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			results = append(results, encoded)
+			continue
+		}
+		specs = append(specs, jrpc2.Spec{Method: req.Method, Params: req.Params, Notify: req.ID == ""})
+		if req.ID != "" {
+			inboundIDs = append(inboundIDs, req.ID)
+		}
+	}
+	if len(specs) > 0 {
+		responses, err := b.local.Client.Batch(r.Context(), specs)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if r.Context().Err() != nil {
+			// The client is gone, the responses are canceled but their handlers may still be running
+			for _, response := range responses {
+				b.local.Server.CancelRequest(response.ID())
+			}
+			return
+		}
+		for i, response := range responses {
+			response.SetID(inboundIDs[i])
+			encoded, err := json.Marshal(response)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			results = append(results, encoded)
+		}
+	}
+
+	if len(results) == 0 {
+		// only notifications
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	// the responses of a batch are always sent as an array, even if there is a single one
+	encoded := []byte(results[0])
+	if isBatch(body) {
+		if encoded, err = json.Marshal(results); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(encoded)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(encoded)
+}
+
+// Close stops the JSON RPC server, waiting for it to exit
+func (b Bridge) Close() error {
+	return b.local.Close()
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creachadair/jrpc2/handler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge(t *testing.T) {
+	bridge := NewBridge(handler.Map{
+		"echo": handler.New(func(_ context.Context, params []string) (string, error) {
+			return params[0], nil
+		}),
+	}, nil)
+	defer bridge.Close()
+	post := func(body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		bridge.ServeHTTP(w, request)
+		return w
+	}
+
+	w := post(`{"jsonrpc":"2.0","id":"a","method":"echo","params":["hello"]}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"jsonrpc":"2.0","id":"a","result":"hello"}`, w.Body.String())
+
+	w = post(`[{"jsonrpc":"2.0","id":1,"method":"echo","params":["a"]},{"jsonrpc":"2.0","method":"echo","params":["b"]},{"jsonrpc":"2.0","id":2,"method":"unknown"}]`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t,
+		`[{"jsonrpc":"2.0","id":1,"result":"a"},{"jsonrpc":"2.0","id":2,"error":{"code":-32601,"message":"method not found","data":"unknown"}}]`,
+		w.Body.String(),
+	)
+
+	// a batch is answered with an array, even when it has a single call
+	w = post(`[{"jsonrpc":"2.0","id":3,"method":"echo","params":["c"]}]`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[{"jsonrpc":"2.0","id":3,"result":"c"}]`, w.Body.String())
+	w = post(`[{"jsonrpc":"2.0","id":4,"method":"echo","params":["d"]},{"jsonrpc":"2.0","method":"echo","params":["e"]}]`)
+	assert.JSONEq(t, `[{"jsonrpc":"2.0","id":4,"result":"d"}]`, w.Body.String())
+
+	w = post(`{"jsonrpc":"2.0","method":"echo","params":["notification"]}`)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	bridge.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`)))
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+}
+
+func TestBridgeCancelsRequestsOfGoneClients(t *testing.T) {
+	started := make(chan struct{})
+	canceled := make(chan struct{})
+	bridge := NewBridge(handler.Map{
+		"wait": handler.New(func(ctx context.Context) error {
+			close(started)
+			select {
+			case <-ctx.Done():
+				close(canceled)
+			case <-time.After(time.Minute):
+			}
+			return ctx.Err()
+		}),
+	}, nil)
+	defer bridge.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"wait"}`)).WithContext(ctx)
+	request.Header.Set("Content-Type", "application/json")
+	go func() {
+		<-started
+		cancel()
+	}()
+	bridge.ServeHTTP(httptest.NewRecorder(), request)
+
+	select {
+	case <-canceled:
+	case <-time.After(10 * time.Second):
+		require.Fail(t, "the handler was not canceled")
+	}
+}
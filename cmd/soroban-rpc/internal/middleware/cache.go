@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/handler"
+)
+
+// Cacheable can be implemented by method results which are only cacheable in some cases
+// (e.g. simulations which don't modify the ledger). Other results are always cacheable.
+type Cacheable interface {
+	Cacheable() bool
+}
+
+// CacheKeyFunc derives the cache key of a request, so that requests which only differ in ways not
+// affecting the result (e.g. the sequence number of a simulated transaction) share their results.
+// It returns false when the request can't be interpreted, in which case its raw params are used.
+type CacheKeyFunc func(req *jrpc2.Request) (string, bool)
+
+// LatestLedgerFunc returns the sequence of the latest closed ledger. It is called on every
+// lookup, so it must not wait for the upstream services (e.g. LedgerRangeTracker.Get).
+type LatestLedgerFunc func(ctx context.Context) (int64, error)
+
+type cacheKey struct {
+	method string
+	params string
+	ledger int64
+}
+
+type cacheEntry struct {
+	key     cacheKey
+	result  json.RawMessage
+	expires time.Time
+}
+
+// ResponseCache is an LRU cache of the successful results of idempotent methods.
+// Results are cached per latest ledger, so they are never served once a new ledger is closed,
+// and expire after a TTL.
+type ResponseCache struct {
+	lock         sync.Mutex
+	maxEntries   int
+	ttl          time.Duration
+	entries      map[cacheKey]*list.Element
+	lru          *list.List
+	latestLedger LatestLedgerFunc
+
+	// OnHit and OnMiss, when set, are invoked on every lookup of the given method
+	OnHit  func(method string)
+	OnMiss func(method string)
+}
+
+// NewResponseCache creates a ResponseCache holding up to maxEntries results for (at most) ttl
+func NewResponseCache(maxEntries int, ttl time.Duration, latestLedger LatestLedgerFunc) *ResponseCache {
+	return &ResponseCache{
+		maxEntries:   maxEntries,
+		ttl:          ttl,
+		entries:      make(map[cacheKey]*list.Element, maxEntries),
+		lru:          list.New(),
+		latestLedger: latestLedger,
+	}
+}
+
+// Wrap returns a handler serving the results of h from the cache. Errors are never cached
+// and requests are forwarded to h when the latest ledger can't be obtained.
+func (c *ResponseCache) Wrap(method string, h jrpc2.Handler) jrpc2.Handler {
+	return c.WrapWithKey(method, nil, h)
+}
+
+// WrapWithKey is like Wrap but derives the cache keys of the requests with keyFunc (when not nil)
+func (c *ResponseCache) WrapWithKey(method string, keyFunc CacheKeyFunc, h jrpc2.Handler) jrpc2.Handler {
+	return handler.Func(func(ctx context.Context, req *jrpc2.Request) (interface{}, error) {
+		ledger, err := c.latestLedger(ctx)
+		if err != nil {
+			return h.Handle(ctx, req)
+		}
+		key := cacheKey{method: method, params: "params:" + req.ParamString(), ledger: ledger}
+		if keyFunc != nil {
+			if derived, ok := keyFunc(req); ok {
+				key.params = "key:" + derived
+			}
+		}
+		if result, ok := c.get(time.Now(), key); ok {
+			if c.OnHit != nil {
+				c.OnHit(method)
+			}
+			return result, nil
+		}
+		if c.OnMiss != nil {
+			c.OnMiss(method)
+		}
+
+		result, err := h.Handle(ctx, req)
+		if err != nil {
+			return result, err
+		}
+		if cacheable, ok := result.(Cacheable); ok && !cacheable.Cacheable() {
+			return result, nil
+		}
+		encoded, err := json.Marshal(result)
+		if err != nil {
+			return nil, err
+		}
+		c.put(time.Now(), key, encoded)
+		// avoid encoding the result twice
+		return json.RawMessage(encoded), nil
+	})
+}
+
+func (c *ResponseCache) get(now time.Time, key cacheKey) (json.RawMessage, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*cacheEntry)
+	if now.After(entry.expires) {
+		c.lru.Remove(element)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.lru.MoveToFront(element)
+	return entry.result, true
+}
+
+func (c *ResponseCache) put(now time.Time, key cacheKey, result json.RawMessage) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*cacheEntry)
+		entry.result = result
+		entry.expires = now.Add(c.ttl)
+		c.lru.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, result: result, expires: now.Add(c.ttl)})
+	for c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// Len returns the number of cached results
+func (c *ResponseCache) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.Len()
+}
@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/channel"
+	"github.com/creachadair/jrpc2/handler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type echoResult struct {
+	Value     string `json:"value"`
+	Calls     int    `json:"calls"`
+	Transient bool   `json:"-"`
+}
+
+func (r echoResult) Cacheable() bool {
+	return !r.Transient
+}
+
+func TestResponseCache(t *testing.T) {
+	calls := 0
+	echo := handler.New(func(_ context.Context, params []string) (echoResult, error) {
+		calls++
+		switch params[0] {
+		case "error":
+			return echoResult{}, errors.New("failed")
+		case "transient":
+			return echoResult{Value: params[0], Calls: calls, Transient: true}, nil
+		}
+		return echoResult{Value: params[0], Calls: calls}, nil
+	})
+	ledger := int64(1)
+	cache := NewResponseCache(2, time.Hour, func(context.Context) (int64, error) {
+		return ledger, nil
+	})
+	var hits, misses int
+	cache.OnHit = func(string) { hits++ }
+	cache.OnMiss = func(string) { misses++ }
+
+	cch, sch := channel.Direct()
+	server := jrpc2.NewServer(handler.Map{"echo": cache.Wrap("echo", echo)}, nil).Start(sch)
+	client := jrpc2.NewClient(cch, nil)
+	defer func() {
+		client.Close()
+		server.Wait()
+	}()
+	call := func(param string) echoResult {
+		var result echoResult
+		require.NoError(t, client.CallResult(context.Background(), "echo", []string{param}, &result))
+		return result
+	}
+
+	assert.Equal(t, echoResult{Value: "a", Calls: 1}, call("a"))
+	assert.Equal(t, echoResult{Value: "a", Calls: 1}, call("a"))
+	assert.Equal(t, echoResult{Value: "b", Calls: 2}, call("b"))
+	assert.Equal(t, 1, hits)
+	assert.Equal(t, 2, misses)
+
+	// errors and non cacheable results are not cached
+	_, err := client.Call(context.Background(), "echo", []string{"error"})
+	assert.Error(t, err)
+	assert.Equal(t, 4, call("transient").Calls)
+	assert.Equal(t, 5, call("transient").Calls)
+	assert.Equal(t, 2, cache.Len())
+
+	// the least recently used entry is evicted
+	call("a")
+	assert.Equal(t, 6, call("c").Calls)
+	assert.Equal(t, 2, cache.Len())
+	assert.Equal(t, 1, call("a").Calls)
+	assert.Equal(t, 7, call("b").Calls)
+
+	// results are not reused once a new ledger is closed
+	ledger = 2
+	assert.Equal(t, 8, call("b").Calls)
+}
+
+func TestResponseCacheExpiration(t *testing.T) {
+	cache := NewResponseCache(10, time.Minute, nil)
+	now := time.Now()
+	key := cacheKey{method: "echo", params: "[]", ledger: 1}
+	cache.put(now, key, []byte(`"result"`))
+	result, ok := cache.get(now.Add(59*time.Second), key)
+	assert.True(t, ok)
+	assert.Equal(t, `"result"`, string(result))
+	_, ok = cache.get(now.Add(61*time.Second), key)
+	assert.False(t, ok)
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestResponseCacheWithoutLatestLedger(t *testing.T) {
+	calls := 0
+	echo := handler.New(func(context.Context) (int, error) {
+		calls++
+		return calls, nil
+	})
+	cache := NewResponseCache(10, time.Minute, func(context.Context) (int64, error) {
+		return 0, errors.New("core is unavailable")
+	})
+	cch, sch := channel.Direct()
+	server := jrpc2.NewServer(handler.Map{"count": cache.Wrap("count", echo)}, nil).Start(sch)
+	client := jrpc2.NewClient(cch, nil)
+	defer func() {
+		client.Close()
+		server.Wait()
+	}()
+	var result int
+	require.NoError(t, client.CallResult(context.Background(), "count", nil, &result))
+	require.NoError(t, client.CallResult(context.Background(), "count", nil, &result))
+	assert.Equal(t, 2, result)
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestResponseCacheWithKey(t *testing.T) {
+	echo := handler.New(func(_ context.Context, params []string) (echoResult, error) {
+		return echoResult{Value: params[0]}, nil
+	})
+	cache := NewResponseCache(10, time.Hour, func(context.Context) (int64, error) {
+		return 1, nil
+	})
+	// only the first param is part of the key
+	keyFunc := func(req *jrpc2.Request) (string, bool) {
+		var params []string
+		if err := req.UnmarshalParams(&params); err != nil || len(params) < 2 {
+			return "", false
+		}
+		return params[0], true
+	}
+	cch, sch := channel.Direct()
+	server := jrpc2.NewServer(handler.Map{"echo": cache.WrapWithKey("echo", keyFunc, echo)}, nil).Start(sch)
+	client := jrpc2.NewClient(cch, nil)
+	defer func() {
+		client.Close()
+		server.Wait()
+	}()
+	var misses int
+	cache.OnMiss = func(string) { misses++ }
+	call := func(params ...string) {
+		var result echoResult
+		require.NoError(t, client.CallResult(context.Background(), "echo", params, &result))
+	}
+
+	call("a", "1")
+	call("a", "2")
+	assert.Equal(t, 1, misses)
+	// requests without a derived key don't share the entries of derived keys
+	call("a")
+	assert.Equal(t, 2, misses)
+	call("b", "1")
+	assert.Equal(t, 3, misses)
+}
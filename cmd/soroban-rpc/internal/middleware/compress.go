@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/stellar/go/support/log"
+)
+
+const (
+	EncodingGzip = "gzip"
+	EncodingZstd = "zstd"
+)
+
+// ResponseCompressor compresses the HTTP responses with the encodings accepted by the clients
+// (see the Accept-Encoding header). The JSON RPC responses are dominated by base64 encoded XDR,
+// which compresses well.
+type ResponseCompressor struct {
+	// encodings are the supported encodings, in order of preference
+	encodings []string
+	minSize   int
+	gzipPool  sync.Pool
+	zstd      *zstd.Encoder
+	// OnCompressed, when set, is invoked after every response is compressed
+	OnCompressed func(encoding string, uncompressedBytes, compressedBytes int)
+}
+
+// NewResponseCompressor creates a ResponseCompressor using the given encodings (gzip or zstd),
+// in order of preference. Responses smaller than minSize bytes aren't compressed.
+func NewResponseCompressor(encodings []string, minSize int) (*ResponseCompressor, error) {
+	c := &ResponseCompressor{minSize: minSize}
+	for _, encoding := range encodings {
+		switch encoding {
+		case EncodingGzip:
+			c.gzipPool.New = func() interface{} {
+				return gzip.NewWriter(nil)
+			}
+		case EncodingZstd:
+			encoder, err := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+			if err != nil {
+				return nil, err
+			}
+			c.zstd = encoder
+		default:
+			return nil, fmt.Errorf("unsupported encoding %q", encoding)
+		}
+		c.encodings = append(c.encodings, encoding)
+	}
+	return c, nil
+}
+
+// negotiate returns the preferred encoding accepted by an Accept-Encoding header, or "" when
+// none of them is accepted
+func (c *ResponseCompressor) negotiate(acceptEncoding string) string {
+	accepted := map[string]bool{}
+	wildcard := false
+	for _, value := range strings.Split(acceptEncoding, ",") {
+		parts := strings.Split(value, ";")
+		encoding := strings.ToLower(strings.TrimSpace(parts[0]))
+		acceptable := true
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+				acceptable = err == nil && q > 0
+			}
+		}
+		if encoding == "*" {
+			wildcard = acceptable
+			continue
+		}
+		accepted[encoding] = acceptable
+	}
+	for _, encoding := range c.encodings {
+		if acceptable, ok := accepted[encoding]; acceptable || (!ok && wildcard) {
+			return encoding
+		}
+	}
+	return ""
+}
+
+func (c *ResponseCompressor) compress(encoding string, data []byte) ([]byte, error) {
+	if encoding == EncodingZstd {
+		return c.zstd.EncodeAll(data, make([]byte, 0, len(data)/2)), nil
+	}
+	var compressed bytes.Buffer
+	writer := c.gzipPool.Get().(*gzip.Writer)
+	defer c.gzipPool.Put(writer)
+	writer.Reset(&compressed)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return compressed.Bytes(), nil
+}
+
+// Middleware returns an http.Handler buffering the responses of next, in order to compress the
+// ones larger than the minimum size. The responses which don't shrink are sent uncompressed.
+func (c *ResponseCompressor) Middleware(logger *log.Entry, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		encoding := c.negotiate(r.Header.Get("Accept-Encoding"))
+		if encoding == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		response := &bufferedResponseWriter{header: w.Header(), status: http.StatusOK}
+		next.ServeHTTP(response, r)
+
+		body := response.body.Bytes()
+		if len(body) >= c.minSize && w.Header().Get("Content-Encoding") == "" {
+			compressed, err := c.compress(encoding, body)
+			if err != nil {
+				logger.WithError(err).WithField("encoding", encoding).Warn("could not compress response")
+			} else if len(compressed) < len(body) {
+				if c.OnCompressed != nil {
+					c.OnCompressed(encoding, len(body), len(compressed))
+				}
+				body = compressed
+				w.Header().Set("Content-Encoding", encoding)
+				w.Header().Del("Content-Length")
+			}
+		}
+		w.WriteHeader(response.status)
+		if _, err := w.Write(body); err != nil {
+			logger.WithError(err).Warn("could not write response")
+		}
+	})
+}
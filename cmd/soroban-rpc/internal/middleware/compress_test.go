@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stellar/go/support/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseCompressorNegotiate(t *testing.T) {
+	compressor, err := NewResponseCompressor([]string{EncodingZstd, EncodingGzip}, 0)
+	require.NoError(t, err)
+	for acceptEncoding, expected := range map[string]string{
+		"":                     "",
+		"identity":             "",
+		"gzip":                 EncodingGzip,
+		"gzip, deflate, br":    EncodingGzip,
+		"gzip, zstd":           EncodingZstd,
+		"gzip;q=0.5, zstd;q=0": EncodingGzip,
+		"*":                    EncodingZstd,
+		"*, zstd;q=0":          EncodingGzip,
+	} {
+		assert.Equal(t, expected, compressor.negotiate(acceptEncoding), acceptEncoding)
+	}
+
+	_, err = NewResponseCompressor([]string{"br"}, 0)
+	assert.EqualError(t, err, `unsupported encoding "br"`)
+}
+
+func TestResponseCompressor(t *testing.T) {
+	body := `{"jsonrpc":"2.0","id":1,"result":{"xdr":"` + strings.Repeat("AAAA", 100) + `"}}`
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, body)
+	})
+	compressor, err := NewResponseCompressor([]string{EncodingGzip, EncodingZstd}, 100)
+	require.NoError(t, err)
+	var saved int
+	compressor.OnCompressed = func(encoding string, uncompressedBytes, compressedBytes int) {
+		saved += uncompressedBytes - compressedBytes
+	}
+	handler := compressor.Middleware(log.DefaultLogger, next)
+
+	decoders := map[string]func(io.Reader) (io.Reader, error){
+		EncodingGzip: func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		EncodingZstd: func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) },
+	}
+	for encoding, decode := range decoders {
+		request := httptest.NewRequest(http.MethodPost, "/", nil)
+		request.Header.Set("Accept-Encoding", encoding)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, request)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, encoding, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+		assert.Less(t, w.Body.Len(), len(body))
+		reader, err := decode(w.Body)
+		require.NoError(t, err)
+		decompressed, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, body, string(decompressed))
+	}
+	assert.Greater(t, saved, 0)
+
+	// clients not accepting the encodings and small responses get uncompressed responses
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, body, w.Body.String())
+
+	body = `{"jsonrpc":"2.0","id":1,"result":{}}`
+	request := httptest.NewRequest(http.MethodPost, "/", nil)
+	request.Header.Set("Accept-Encoding", EncodingGzip)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, request)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, body, w.Body.String())
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/code"
+	"github.com/creachadair/jrpc2/handler"
+)
+
+// ParseMethodTimeouts parses a comma separated list of method=seconds pairs
+// (e.g. "simulateTransaction=10,getLedgerEntries=2.5")
+func ParseMethodTimeouts(s string) (map[string]time.Duration, error) {
+	result := map[string]time.Duration{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid method timeout %q, expected <method>=<seconds>", entry)
+		}
+		seconds, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil || seconds <= 0 {
+			return nil, fmt.Errorf("invalid timeout in method timeout %q", entry)
+		}
+		result[strings.TrimSpace(parts[0])] = time.Duration(seconds * float64(time.Second))
+	}
+	return result, nil
+}
+
+// Deadline decorates the handler of method so that its context (and therefore the downstream
+// calls to stellar-core and preflight executions) is canceled once timeout elapses.
+// Requests exceeding the deadline fail with a code.DeadlineExceeded error.
+func Deadline(method string, timeout time.Duration, h jrpc2.Handler) jrpc2.Handler {
+	return handler.Func(func(ctx context.Context, req *jrpc2.Request) (interface{}, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		result, err := h.Handle(ctx, req)
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, &jrpc2.Error{
+				Code:    code.DeadlineExceeded,
+				Message: fmt.Sprintf("%s exceeded its deadline of %v", method, timeout),
+			}
+		}
+		return result, err
+	})
+}
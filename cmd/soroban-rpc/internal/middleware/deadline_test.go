@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/channel"
+	"github.com/creachadair/jrpc2/handler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMethodTimeouts(t *testing.T) {
+	timeouts, err := ParseMethodTimeouts("simulateTransaction=10, getLedgerEntries=0.5,")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]time.Duration{
+		"simulateTransaction": 10 * time.Second,
+		"getLedgerEntries":    500 * time.Millisecond,
+	}, timeouts)
+
+	timeouts, err = ParseMethodTimeouts("")
+	require.NoError(t, err)
+	assert.Empty(t, timeouts)
+
+	_, err = ParseMethodTimeouts("simulateTransaction")
+	assert.Error(t, err)
+	_, err = ParseMethodTimeouts("simulateTransaction=0")
+	assert.Error(t, err)
+}
+
+func TestDeadline(t *testing.T) {
+	wait := handler.New(func(ctx context.Context, params []int) (string, error) {
+		select {
+		case <-time.After(time.Duration(params[0]) * time.Millisecond):
+			return "done", nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	})
+	cch, sch := channel.Direct()
+	server := jrpc2.NewServer(handler.Map{"wait": Deadline("wait", 50*time.Millisecond, wait)}, nil).Start(sch)
+	client := jrpc2.NewClient(cch, nil)
+	defer func() {
+		client.Close()
+		server.Wait()
+	}()
+
+	var result string
+	require.NoError(t, client.CallResult(context.Background(), "wait", []int{1}, &result))
+	assert.Equal(t, "done", result)
+
+	err := client.CallResult(context.Background(), "wait", []int{60000}, &result)
+	// the client translates the code.DeadlineExceeded error
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/code"
+
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/rpcerror"
+)
+
+// QueryParamsFunc converts the query parameters of an HTTP GET request into the params of a JSON RPC call
+type QueryParamsFunc func(query url.Values) (json.RawMessage, error)
+
+type getRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      int             `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type getResponse struct {
+	Error *jrpc2.Error `json:"error"`
+}
+
+// bufferedResponseWriter holds the response of a JSON RPC call, so that its HTTP status
+// can be set from the JSON RPC error (if any)
+type bufferedResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponseWriter) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponseWriter) Write(data []byte) (int, error) {
+	return b.body.Write(data)
+}
+
+func (b *bufferedResponseWriter) WriteHeader(status int) {
+	b.status = status
+}
+
+// HTTPGet returns an http.Handler which serves the methods in getMethods over HTTP GET (e.g. GET /getHealth),
+// translating the query parameters into a JSON RPC call which is handled by next.
+// This allows CDNs and monitoring probes to query and cache the results without crafting POST bodies.
+// Successful responses are cacheable for maxAge (they aren't cached when zero) and JSON RPC
+// errors are reflected in the HTTP status. When authenticated is set (i.e. next requires an API key),
+// responses can only be cached by the client, since shared caches would serve them without the key.
+func HTTPGet(logger *log.Entry, getMethods map[string]QueryParamsFunc, maxAge time.Duration, authenticated bool, next http.Handler) http.Handler {
+	cacheControl := "no-cache"
+	if maxAge > 0 {
+		visibility := "public"
+		if authenticated {
+			visibility = "private"
+		}
+		cacheControl = fmt.Sprintf("%s, max-age=%d", visibility, int(maxAge.Seconds()))
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method := strings.TrimPrefix(r.URL.Path, "/")
+		queryParams, ok := getMethods[method]
+		if r.Method != http.MethodGet || !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		params, err := queryParams(r.URL.Query())
+		if err != nil {
+			var rpcErr *jrpc2.Error
+			if !errors.As(err, &rpcErr) {
+				rpcErr = &jrpc2.Error{Code: code.InvalidParams, Message: err.Error()}
+			}
+			writeErrorResponses(logger, w, http.StatusBadRequest, false, []*jrpc2.ParsedRequest{{ID: "1"}}, rpcErr)
+			return
+		}
+		body, err := json.Marshal(getRequest{JSONRPC: "2.0", ID: 1, Method: method, Params: params})
+		if err != nil {
+			http.Error(w, "could not encode request", http.StatusInternalServerError)
+			return
+		}
+
+		rpcRequest := r.Clone(r.Context())
+		rpcRequest.Method = http.MethodPost
+		rpcRequest.URL.Path = "/"
+		rpcRequest.URL.RawQuery = ""
+		rpcRequest.Header.Set("Content-Type", "application/json")
+		rpcRequest.Body = io.NopCloser(bytes.NewReader(body))
+		rpcRequest.ContentLength = int64(len(body))
+
+		response := &bufferedResponseWriter{header: w.Header(), status: http.StatusOK}
+		next.ServeHTTP(response, rpcRequest)
+
+		status := response.status
+		if status == http.StatusOK {
+			var decoded getResponse
+			if err := json.Unmarshal(response.body.Bytes(), &decoded); err != nil {
+				logger.WithError(err).Warn("could not decode JSON RPC response")
+				status = http.StatusInternalServerError
+			} else if decoded.Error != nil {
+				status = errorStatus(decoded.Error.Code)
+			} else {
+				w.Header().Set("Cache-Control", cacheControl)
+				if authenticated {
+					w.Header().Add("Vary", "Authorization")
+				}
+			}
+		}
+		w.WriteHeader(status)
+		if _, err := w.Write(response.body.Bytes()); err != nil {
+			logger.WithError(err).Warn("could not write response")
+		}
+	})
+}
+
+// errorStatus maps JSON RPC error codes to the HTTP status of GET responses
+func errorStatus(errorCode code.Code) int {
+	switch errorCode {
+	case code.InvalidParams, code.InvalidRequest, code.ParseError:
+		return http.StatusBadRequest
+	case rpcerror.NotFound:
+		return http.StatusNotFound
+	case rpcerror.UpstreamUnavailable:
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/code"
+	"github.com/creachadair/jrpc2/handler"
+	"github.com/creachadair/jrpc2/jhttp"
+	"github.com/stellar/go/support/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/rpcerror"
+)
+
+func TestHTTPGet(t *testing.T) {
+	bridge := jhttp.NewBridge(handler.Map{
+		"echo": handler.New(func(_ context.Context, params map[string]string) (map[string]string, error) {
+			if params["value"] == "missing" {
+				return nil, &jrpc2.Error{Code: rpcerror.NotFound, Message: "not found"}
+			}
+			return params, nil
+		}),
+		"fail": handler.New(func(context.Context) (string, error) {
+			return "", rpcerror.NewUpstreamUnavailable(rpcerror.UpstreamHorizon, "unavailable")
+		}),
+	}, nil)
+	defer bridge.Close()
+	queryParams := func(query url.Values) (json.RawMessage, error) {
+		if query.Has("invalid") {
+			return nil, errors.New("invalid query")
+		}
+		params := map[string]string{}
+		for key := range query {
+			params[key] = query.Get(key)
+		}
+		return json.Marshal(params)
+	}
+	noParams := func(query url.Values) (json.RawMessage, error) {
+		return nil, nil
+	}
+	handler := HTTPGet(log.DefaultLogger, map[string]QueryParamsFunc{"echo": queryParams, "fail": noParams}, time.Minute, false, bridge)
+
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	w := get("/echo?value=a")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "public, max-age=60", w.Header().Get("Cache-Control"))
+	assert.JSONEq(t, `{"jsonrpc":"2.0","id":1,"result":{"value":"a"}}`, w.Body.String())
+
+	// responses requiring an api key can't be stored by shared caches
+	authenticated := HTTPGet(log.DefaultLogger, map[string]QueryParamsFunc{"echo": queryParams}, time.Minute, true, bridge)
+	w = httptest.NewRecorder()
+	authenticated.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/echo?value=a", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "private, max-age=60", w.Header().Get("Cache-Control"))
+	assert.Equal(t, "Authorization", w.Header().Get("Vary"))
+
+	w = get("/echo?value=missing")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, w.Header().Get("Cache-Control"))
+
+	w = get("/fail")
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+
+	w = get("/echo?invalid")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var response errorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, code.InvalidParams, response.Error.Code)
+	assert.Equal(t, "invalid query", response.Error.Message)
+
+	// other requests are forwarded untouched
+	var forwarded *http.Request
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = r
+	})
+	handler = HTTPGet(log.DefaultLogger, map[string]QueryParamsFunc{"echo": queryParams}, 0, false, next)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/other", nil))
+	assert.Equal(t, "/other", forwarded.URL.Path)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/echo", nil))
+	assert.Equal(t, http.MethodPost, forwarded.Method)
+	assert.Equal(t, "/echo", forwarded.URL.Path)
+
+	// non-200 responses (e.g. from the rate limiter) are kept
+	next = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	handler = HTTPGet(log.DefaultLogger, map[string]QueryParamsFunc{"echo": queryParams}, 0, false, next)
+	w = get("/echo")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
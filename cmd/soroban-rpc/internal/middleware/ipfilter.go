@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/creachadair/jrpc2"
+
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/rpcerror"
+)
+
+type clientIPKey struct{}
+
+// ParseCIDRs parses a comma separated list of CIDR ranges (e.g. "10.0.0.0/8,2001:db8::/32"),
+// plain IP addresses are accepted as single address ranges
+func ParseCIDRs(s string) ([]*net.IPNet, error) {
+	var result []*net.IPNet
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address %q", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			result = append(result, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR range %q", entry)
+		}
+		result = append(result, ipNet)
+	}
+	return result, nil
+}
+
+func containsIP(ranges []*net.IPNet, ip net.IP) bool {
+	for _, ipNet := range ranges {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// IPFilter resolves the IP of the clients, honoring the X-Forwarded-For header of the requests
+// sent by trusted proxies, and rejects the clients which aren't allowed by its allow and deny lists.
+type IPFilter struct {
+	allow          []*net.IPNet
+	deny           []*net.IPNet
+	trustedProxies []*net.IPNet
+	// OnRejected, when set, is invoked every time a request is rejected
+	OnRejected func()
+}
+
+// NewIPFilter creates an IPFilter. When allow isn't empty only the clients in its ranges are
+// allowed, the clients in deny are always rejected.
+func NewIPFilter(allow, deny, trustedProxies []*net.IPNet) *IPFilter {
+	return &IPFilter{allow: allow, deny: deny, trustedProxies: trustedProxies}
+}
+
+// resolve returns the IP of the client which sent the request. The X-Forwarded-For header is
+// walked from the closest hop as long as the hops are trusted proxies, so that clients can't
+// spoof their IP by sending the header themselves.
+func (f *IPFilter) resolve(r *http.Request) string {
+	ip := remoteIP(r)
+	parsed := net.ParseIP(ip)
+	if parsed == nil || !containsIP(f.trustedProxies, parsed) {
+		return ip
+	}
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			break
+		}
+		ip = hop.String()
+		if !containsIP(f.trustedProxies, hop) {
+			break
+		}
+	}
+	return ip
+}
+
+func (f *IPFilter) allowed(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		// e.g. requests received through a unix socket
+		return len(f.allow) == 0
+	}
+	if containsIP(f.deny, parsed) {
+		return false
+	}
+	return len(f.allow) == 0 || containsIP(f.allow, parsed)
+}
+
+// Middleware returns an http.Handler which resolves the client IP used by the other middlewares
+// (see ClientIP) and rejects the requests of clients which aren't allowed with an HTTP 403 status
+// before they reach next.
+func (f *IPFilter) Middleware(logger *log.Entry, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := f.resolve(r)
+		if f.allowed(ip) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientIPKey{}, ip)))
+			return
+		}
+		logger.WithField("ip", ip).Debug("request from a client which isn't allowed")
+		if f.OnRejected != nil {
+			f.OnRejected()
+		}
+		// The body of rejected clients isn't parsed (it can be as large as they like), so a single
+		// error is returned. Only its beginning is read to tell whether it is a batch.
+		batch := false
+		if r.Method == http.MethodPost {
+			prefix, _ := io.ReadAll(io.LimitReader(r.Body, 512))
+			batch = isBatch(prefix)
+		}
+		writeErrorResponses(logger, w, http.StatusForbidden, batch, []*jrpc2.ParsedRequest{{ID: "null"}}, &jrpc2.Error{
+			Code:    rpcerror.Forbidden,
+			Message: "client ip is not allowed",
+		})
+	})
+}
+
+// ClientIP returns the IP address of the client which sent the request, as resolved by the
+// IPFilter middleware when it is used
+func ClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPKey{}).(string); ok {
+		return ip
+	}
+	return remoteIP(r)
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stellar/go/support/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustParseCIDRs(t *testing.T, s string) []*net.IPNet {
+	ranges, err := ParseCIDRs(s)
+	require.NoError(t, err)
+	return ranges
+}
+
+func TestParseCIDRs(t *testing.T) {
+	ranges := mustParseCIDRs(t, "10.0.0.0/8, 192.168.1.1,2001:db8::/32,")
+	require.Len(t, ranges, 3)
+	assert.Equal(t, "10.0.0.0/8", ranges[0].String())
+	assert.Equal(t, "192.168.1.1/32", ranges[1].String())
+	assert.Equal(t, "2001:db8::/32", ranges[2].String())
+
+	_, err := ParseCIDRs("10.0.0.0/33")
+	assert.Error(t, err)
+	_, err = ParseCIDRs("localhost")
+	assert.Error(t, err)
+}
+
+func TestIPFilterResolve(t *testing.T) {
+	filter := NewIPFilter(nil, nil, mustParseCIDRs(t, "10.0.0.0/8"))
+	request := func(remoteAddr string, forwardedFor ...string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.RemoteAddr = remoteAddr
+		for _, header := range forwardedFor {
+			r.Header.Add("X-Forwarded-For", header)
+		}
+		return r
+	}
+
+	assert.Equal(t, "1.1.1.1", filter.resolve(request("1.1.1.1:1234")))
+	// the header of untrusted clients is ignored
+	assert.Equal(t, "1.1.1.1", filter.resolve(request("1.1.1.1:1234", "2.2.2.2")))
+	assert.Equal(t, "2.2.2.2", filter.resolve(request("10.0.0.1:1234", "2.2.2.2")))
+	// spoofed entries before the first untrusted hop are ignored
+	assert.Equal(t, "2.2.2.2", filter.resolve(request("10.0.0.1:1234", "3.3.3.3, 2.2.2.2", "10.0.0.2")))
+	assert.Equal(t, "10.0.0.2", filter.resolve(request("10.0.0.1:1234", "10.0.0.3, garbage, 10.0.0.2")))
+	assert.Equal(t, "10.0.0.1", filter.resolve(request("10.0.0.1:1234")))
+}
+
+func TestIPFilterMiddleware(t *testing.T) {
+	filter := NewIPFilter(mustParseCIDRs(t, "2.2.2.0/24"), mustParseCIDRs(t, "2.2.2.2"), mustParseCIDRs(t, "10.0.0.1"))
+	rejected := 0
+	filter.OnRejected = func() { rejected++ }
+	var clientIP string
+	handler := filter.Middleware(log.DefaultLogger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientIP = ClientIP(r)
+	}))
+	serve := func(remoteAddr, forwardedFor string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"jsonrpc": "2.0", "id": 1, "method": "getHealth"}`))
+		r.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			r.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, serve("2.2.2.1:1234", "").Code)
+	assert.Equal(t, "2.2.2.1", clientIP)
+	assert.Equal(t, http.StatusOK, serve("10.0.0.1:1234", "2.2.2.3").Code)
+	assert.Equal(t, "2.2.2.3", clientIP)
+
+	// the body of rejected requests isn't parsed
+	w := serve("2.2.2.2:1234", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.JSONEq(t, `{"jsonrpc":"2.0","id":null,"error":{"code":-32034,"message":"client ip is not allowed"}}`, w.Body.String())
+	assert.Equal(t, http.StatusForbidden, serve("3.3.3.3:1234", "").Code)
+	assert.Equal(t, http.StatusForbidden, serve("10.0.0.1:1234", "2.2.2.2").Code)
+	assert.Equal(t, 3, rejected)
+}
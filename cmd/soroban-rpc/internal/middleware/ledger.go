@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/handler"
+
+	"github.com/stellar/go/support/log"
+)
+
+// LedgerStampFunc returns the value whose fields are added to the results by StampLedger
+type LedgerStampFunc func(ctx context.Context) (interface{}, error)
+
+// StampLedger adds the fields of the value returned by stamp (e.g. the ledger range of the node)
+// to the results of h which don't contain them already. The stamp is obtained before calling h,
+// so the node had reached at least the stamped ledger when the result was computed. Results which
+// aren't JSON objects are returned unchanged, and so are all results when the stamp can't be
+// obtained, since failing the requests would be worse than omitting the stamp.
+func StampLedger(logger *log.Entry, method string, stamp LedgerStampFunc, h jrpc2.Handler) jrpc2.Handler {
+	return handler.Func(func(ctx context.Context, req *jrpc2.Request) (interface{}, error) {
+		value, stampErr := stamp(ctx)
+		result, err := h.Handle(ctx, req)
+		if err != nil {
+			return result, err
+		}
+		if stampErr != nil {
+			logger.WithError(stampErr).WithField("method", method).Debug("could not stamp the result with the ledger")
+			return result, nil
+		}
+		stamped, err := mergeObjects(result, value)
+		if err != nil {
+			return result, nil
+		}
+		return stamped, nil
+	})
+}
+
+// mergeObjects adds the fields of extra missing from the JSON object encoding of result
+func mergeObjects(result, extra interface{}) (json.RawMessage, error) {
+	var fields, extraFields map[string]json.RawMessage
+	if err := remarshal(result, &fields); err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		return nil, errors.New("the result is not a JSON object")
+	}
+	if err := remarshal(extra, &extraFields); err != nil {
+		return nil, err
+	}
+	for name, value := range extraFields {
+		if _, ok := fields[name]; !ok {
+			fields[name] = value
+		}
+	}
+	return json.Marshal(fields)
+}
+
+func remarshal(value interface{}, target *map[string]json.RawMessage) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(encoded, target)
+}
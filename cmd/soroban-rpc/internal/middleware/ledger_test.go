@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/channel"
+	"github.com/creachadair/jrpc2/handler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/support/log"
+)
+
+func TestStampLedger(t *testing.T) {
+	type ledgerStamp struct {
+		LatestLedger int64 `json:"latestLedger"`
+		OldestLedger int64 `json:"oldestLedger"`
+	}
+	var stampErr error
+	stamp := func(context.Context) (interface{}, error) {
+		return ledgerStamp{LatestLedger: 10, OldestLedger: 2}, stampErr
+	}
+	methods := handler.Map{
+		"object": handler.New(func(context.Context) (map[string]interface{}, error) {
+			return map[string]interface{}{"value": "a", "latestLedger": 9}, nil
+		}),
+		"string": handler.New(func(context.Context) (string, error) {
+			return "a", nil
+		}),
+		"error": handler.New(func(context.Context) (interface{}, error) {
+			return nil, errors.New("failed")
+		}),
+	}
+	for method, h := range methods {
+		methods[method] = StampLedger(log.DefaultLogger, method, stamp, h)
+	}
+	cch, sch := channel.Direct()
+	server := jrpc2.NewServer(methods, nil).Start(sch)
+	client := jrpc2.NewClient(cch, nil)
+	defer func() {
+		client.Close()
+		server.Wait()
+	}()
+
+	// the fields of the result take precedence over the stamp
+	var result map[string]interface{}
+	require.NoError(t, client.CallResult(context.Background(), "object", nil, &result))
+	assert.Equal(t, map[string]interface{}{"value": "a", "latestLedger": 9.0, "oldestLedger": 2.0}, result)
+
+	var s string
+	require.NoError(t, client.CallResult(context.Background(), "string", nil, &s))
+	assert.Equal(t, "a", s)
+
+	_, err := client.Call(context.Background(), "error", nil)
+	assert.Error(t, err)
+
+	stampErr = errors.New("unavailable")
+	result = nil
+	require.NoError(t, client.CallResult(context.Background(), "object", nil, &result))
+	assert.Equal(t, map[string]interface{}{"value": "a", "latestLedger": 9.0}, result)
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/code"
+	"github.com/creachadair/jrpc2/handler"
+
+	"github.com/stellar/go/support/log"
+)
+
+// redactedParams are the request parameters whose values are not logged,
+// since they can be large and carry full transaction envelopes
+var redactedParams = map[string]bool{
+	"transaction": true,
+}
+
+// RequestLogger logs the JSON-RPC requests served by the method handlers.
+type RequestLogger struct {
+	Logger *log.Entry
+	// SampleRatio is the fraction (between 0 and 1) of the successful requests which are logged.
+	// Failed and slow requests are always logged.
+	SampleRatio float64
+	// SlowRequestThreshold is the duration above which a request is logged as slow.
+	// Zero disables slow request logging.
+	SlowRequestThreshold time.Duration
+	random               func() float64
+}
+
+// NewRequestLogger creates a RequestLogger
+func NewRequestLogger(logger *log.Entry, sampleRatio float64, slowRequestThreshold time.Duration) *RequestLogger {
+	return &RequestLogger{
+		Logger:               logger,
+		SampleRatio:          sampleRatio,
+		SlowRequestThreshold: slowRequestThreshold,
+		random:               rand.Float64,
+	}
+}
+
+// Wrap decorates the handler of method so that its requests are logged.
+func (l *RequestLogger) Wrap(method string, h jrpc2.Handler) jrpc2.Handler {
+	return handler.Func(func(ctx context.Context, req *jrpc2.Request) (interface{}, error) {
+		startTime := time.Now()
+		result, err := h.Handle(ctx, req)
+		l.log(method, req, time.Since(startTime), err)
+		return result, err
+	})
+}
+
+func (l *RequestLogger) log(method string, req *jrpc2.Request, duration time.Duration, err error) {
+	slow := l.SlowRequestThreshold > 0 && duration > l.SlowRequestThreshold
+	if err == nil && !slow && (l.SampleRatio <= 0 || l.random() >= l.SampleRatio) {
+		return
+	}
+
+	entry := l.Logger.WithFields(log.F{
+		"method":   method,
+		"id":       req.ID(),
+		"duration": duration.Seconds(),
+		"params":   redactParams(req.ParamString()),
+	})
+	switch {
+	case err != nil:
+		entry.WithField("code", code.FromError(err)).WithError(err).Info("json rpc request failed")
+	case slow:
+		entry.Warn("slow json rpc request")
+	default:
+		entry.Info("json rpc request")
+	}
+}
+
+// redactParams replaces the values of the redacted parameters with a placeholder
+// indicating their size.
+func redactParams(params string) string {
+	if params == "" {
+		return ""
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(params), &object); err != nil {
+		// Positional parameters can't be told apart without knowing the parameters
+		// of the method, so they are redacted altogether
+		return fmt.Sprintf("redacted (%d bytes)", len(params))
+	}
+	redacted := false
+	for key, value := range object {
+		if redactedParams[key] {
+			object[key] = json.RawMessage(fmt.Sprintf(`"redacted (%d bytes)"`, len(value)))
+			redacted = true
+		}
+	}
+	if !redacted {
+		return params
+	}
+	result, err := json.Marshal(object)
+	if err != nil {
+		return "redacted"
+	}
+	return string(result)
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/channel"
+	"github.com/creachadair/jrpc2/handler"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/support/log"
+)
+
+func TestRedactParams(t *testing.T) {
+	assert.Equal(t, "", redactParams(""))
+	assert.Equal(t, "redacted (8 bytes)", redactParams(`["AAAA"]`))
+	assert.Equal(t, `{"hash":"abcd"}`, redactParams(`{"hash":"abcd"}`))
+	assert.Equal(t, `{"transaction":"redacted (6 bytes)"}`, redactParams(`{"transaction":"AAAA"}`))
+}
+
+func callLogged(t *testing.T, l *RequestLogger, h jrpc2.Handler) []logrus.Entry {
+	done := l.Logger.StartTest(logrus.DebugLevel)
+	cch, sch := channel.Direct()
+	server := jrpc2.NewServer(handler.Map{"test": l.Wrap("test", h)}, nil).Start(sch)
+	client := jrpc2.NewClient(cch, nil)
+	_, _ = client.Call(context.Background(), "test", map[string]string{"transaction": "AAAA"})
+	client.Close()
+	server.Wait()
+	return done()
+}
+
+func TestRequestLoggerSampling(t *testing.T) {
+	ok := handler.Func(func(context.Context, *jrpc2.Request) (interface{}, error) { return nil, nil })
+
+	l := NewRequestLogger(log.New(), 0.5, 0)
+	l.random = func() float64 { return 0.7 }
+	assert.Empty(t, callLogged(t, l, ok))
+
+	l.random = func() float64 { return 0.2 }
+	logs := callLogged(t, l, ok)
+	require.Len(t, logs, 1)
+	assert.Equal(t, "json rpc request", logs[0].Message)
+	assert.Equal(t, "test", logs[0].Data["method"])
+	assert.Equal(t, `{"transaction":"redacted (6 bytes)"}`, logs[0].Data["params"])
+}
+
+func TestRequestLoggerErrorsAndSlowRequests(t *testing.T) {
+	l := NewRequestLogger(log.New(), 0, time.Millisecond)
+
+	logs := callLogged(t, l, handler.Func(func(context.Context, *jrpc2.Request) (interface{}, error) {
+		return nil, errors.New("boom")
+	}))
+	require.Len(t, logs, 1)
+	assert.Equal(t, "json rpc request failed", logs[0].Message)
+
+	logs = callLogged(t, l, handler.Func(func(context.Context, *jrpc2.Request) (interface{}, error) {
+		time.Sleep(5 * time.Millisecond)
+		return nil, nil
+	}))
+	require.Len(t, logs, 1)
+	assert.Equal(t, logrus.WarnLevel, logs[0].Level)
+	assert.Equal(t, "slow json rpc request", logs[0].Message)
+}
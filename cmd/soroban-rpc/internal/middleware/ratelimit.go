@@ -0,0 +1,244 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/creachadair/jrpc2"
+	"golang.org/x/time/rate"
+
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/rpcerror"
+)
+
+// ipLimiterIdleTimeout is the time after which the limiter of an inactive client IP is discarded
+const ipLimiterIdleTimeout = 5 * time.Minute
+
+type ipLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// RateLimiter enforces request rate limits per JSON-RPC method and per client IP.
+type RateLimiter struct {
+	lock           sync.Mutex
+	methodLimiters map[string]*rate.Limiter
+	ipRate         float64
+	ipLimiters     map[string]*ipLimiter
+	lastSweep      time.Time
+	// OnLimitExceeded, when set, is invoked every time a request is rejected.
+	// limitType is either "method" or "ip".
+	OnLimitExceeded func(method, limitType string)
+}
+
+// NewRateLimiter creates a RateLimiter allowing methodRates[method] requests per second
+// for each listed method and ipRate requests per second for every client IP.
+// A rate of zero disables the corresponding limit.
+func NewRateLimiter(methodRates map[string]float64, ipRate float64) *RateLimiter {
+	l := &RateLimiter{}
+	l.SetLimits(methodRates, ipRate)
+	return l
+}
+
+// SetLimits replaces the limits of the RateLimiter, it is safe to call it while
+// requests are being served. The request history of the clients is discarded.
+func (l *RateLimiter) SetLimits(methodRates map[string]float64, ipRate float64) {
+	methodLimiters := map[string]*rate.Limiter{}
+	for method, r := range methodRates {
+		if r > 0 {
+			methodLimiters[method] = rate.NewLimiter(rate.Limit(r), burst(r))
+		}
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.methodLimiters = methodLimiters
+	l.ipRate = ipRate
+	l.ipLimiters = map[string]*ipLimiter{}
+}
+
+// ParseMethodRates parses a comma separated list of method=rate pairs
+// (e.g. "simulateTransaction=10,sendTransaction=5")
+func ParseMethodRates(s string) (map[string]float64, error) {
+	result := map[string]float64{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid method rate limit %q, expected <method>=<requests per second>", entry)
+		}
+		r, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil || r < 0 {
+			return nil, fmt.Errorf("invalid rate in method rate limit %q", entry)
+		}
+		result[strings.TrimSpace(parts[0])] = r
+	}
+	return result, nil
+}
+
+func burst(r float64) int {
+	if r < 1 {
+		return 1
+	}
+	return int(math.Ceil(r))
+}
+
+// limitRejection describes a call rejected by the RateLimiter
+type limitRejection struct {
+	method    string
+	limitType string
+	delay     time.Duration
+}
+
+// allow reports whether a request for the given method coming from the given ip is allowed.
+// If it isn't, it also returns how long the client should wait before retrying.
+func (l *RateLimiter) allow(now time.Time, ip string, method string) (bool, string, time.Duration) {
+	rejections := l.allowAll(now, ip, []string{method})
+	if len(rejections) > 0 {
+		return false, rejections[0].limitType, rejections[0].delay
+	}
+	return true, "", 0
+}
+
+// allowAll checks the calls of a request (for the given methods) coming from the given ip,
+// returning the rejected ones. Calls are allowed all together or not at all: when any of them
+// is rejected, the tokens reserved for the others are given back.
+func (l *RateLimiter) allowAll(now time.Time, ip string, methods []string) []limitRejection {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	var ipBucket *rate.Limiter
+	if l.ipRate > 0 {
+		l.sweep(now)
+		entry, ok := l.ipLimiters[ip]
+		if !ok {
+			entry = &ipLimiter{limiter: rate.NewLimiter(rate.Limit(l.ipRate), burst(l.ipRate))}
+			l.ipLimiters[ip] = entry
+		}
+		entry.lastSeen = now
+		ipBucket = entry.limiter
+	}
+
+	var granted []*rate.Reservation
+	var rejections []limitRejection
+	for _, method := range methods {
+		var reservations []*rate.Reservation
+		if ipBucket != nil {
+			reservation, delay := reserve(now, ipBucket)
+			if reservation == nil {
+				rejections = append(rejections, limitRejection{method: method, limitType: "ip", delay: delay})
+				continue
+			}
+			reservations = append(reservations, reservation)
+		}
+		if limiter, ok := l.methodLimiters[method]; ok {
+			reservation, delay := reserve(now, limiter)
+			if reservation == nil {
+				cancelReservations(now, reservations)
+				rejections = append(rejections, limitRejection{method: method, limitType: "method", delay: delay})
+				continue
+			}
+			reservations = append(reservations, reservation)
+		}
+		granted = append(granted, reservations...)
+	}
+	if len(rejections) > 0 {
+		cancelReservations(now, granted)
+	}
+	return rejections
+}
+
+// reserve takes a token from limiter, returning a nil reservation and the time to wait
+// for the next token if none is available.
+func reserve(now time.Time, limiter *rate.Limiter) (*rate.Reservation, time.Duration) {
+	reservation := limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return nil, delay
+	}
+	return reservation, 0
+}
+
+// cancelReservations gives back the tokens of reservations, most recent first
+func cancelReservations(now time.Time, reservations []*rate.Reservation) {
+	for i := len(reservations) - 1; i >= 0; i-- {
+		reservations[i].CancelAt(now)
+	}
+}
+
+// sweep should only be called while the lock is held
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < ipLimiterIdleTimeout {
+		return
+	}
+	l.lastSweep = now
+	for ip, entry := range l.ipLimiters {
+		if now.Sub(entry.lastSeen) > ipLimiterIdleTimeout {
+			delete(l.ipLimiters, ip)
+		}
+	}
+}
+
+// Middleware returns an http.Handler which rejects JSON-RPC requests exceeding the configured
+// limits before they reach next. Rejected requests are answered with an HTTP 429 status,
+// a Retry-After header and a JSON-RPC error for every call in the request.
+func (l *RateLimiter) Middleware(logger *log.Entry, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "could not read request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		requests, err := jrpc2.ParseRequests(body)
+		if err != nil {
+			// let the JSON-RPC bridge report the error
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ip := ClientIP(r)
+		methods := make([]string, len(requests))
+		for i, req := range requests {
+			methods[i] = req.Method
+		}
+		var retryAfter time.Duration
+		for _, rejection := range l.allowAll(time.Now(), ip, methods) {
+			if l.OnLimitExceeded != nil {
+				l.OnLimitExceeded(rejection.method, rejection.limitType)
+			}
+			logger.WithField("method", rejection.method).WithField("ip", ip).
+				WithField("limit", rejection.limitType).Debug("rate limit exceeded")
+			if rejection.delay > retryAfter {
+				retryAfter = rejection.delay
+			}
+		}
+		if retryAfter == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		retryAfterSeconds := int64(math.Ceil(retryAfter.Seconds()))
+		rpcErr := (&jrpc2.Error{
+			Code:    rpcerror.RateLimitExceeded,
+			Message: "rate limit exceeded",
+		}).WithData(map[string]int64{"retryAfter": retryAfterSeconds})
+		w.Header().Set("Retry-After", strconv.FormatInt(retryAfterSeconds, 10))
+		writeErrorResponses(logger, w, http.StatusTooManyRequests, isBatch(body), requests, rpcErr)
+	})
+}
@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stellar/go/support/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMethodRates(t *testing.T) {
+	rates, err := ParseMethodRates("simulateTransaction=10, sendTransaction=0.5,")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{
+		"simulateTransaction": 10,
+		"sendTransaction":     0.5,
+	}, rates)
+
+	rates, err = ParseMethodRates("")
+	require.NoError(t, err)
+	assert.Empty(t, rates)
+
+	_, err = ParseMethodRates("simulateTransaction")
+	assert.Error(t, err)
+	_, err = ParseMethodRates("simulateTransaction=fast")
+	assert.Error(t, err)
+}
+
+func TestRateLimiterAllow(t *testing.T) {
+	now := time.Now()
+	t.Run("per method", func(t *testing.T) {
+		limiter := NewRateLimiter(map[string]float64{"simulateTransaction": 1}, 0)
+		ok, _, _ := limiter.allow(now, "1.1.1.1", "simulateTransaction")
+		assert.True(t, ok)
+		ok, limitType, delay := limiter.allow(now, "2.2.2.2", "simulateTransaction")
+		assert.False(t, ok)
+		assert.Equal(t, "method", limitType)
+		assert.Greater(t, delay, time.Duration(0))
+		// other methods are not limited
+		ok, _, _ = limiter.allow(now, "2.2.2.2", "getHealth")
+		assert.True(t, ok)
+		// the bucket refills over time
+		ok, _, _ = limiter.allow(now.Add(time.Second), "2.2.2.2", "simulateTransaction")
+		assert.True(t, ok)
+	})
+
+	t.Run("per ip", func(t *testing.T) {
+		limiter := NewRateLimiter(nil, 1)
+		ok, _, _ := limiter.allow(now, "1.1.1.1", "getHealth")
+		assert.True(t, ok)
+		ok, limitType, _ := limiter.allow(now, "1.1.1.1", "getAccount")
+		assert.False(t, ok)
+		assert.Equal(t, "ip", limitType)
+		ok, _, _ = limiter.allow(now, "2.2.2.2", "getAccount")
+		assert.True(t, ok)
+	})
+
+	t.Run("set limits", func(t *testing.T) {
+		limiter := NewRateLimiter(nil, 0)
+		ok, _, _ := limiter.allow(now, "1.1.1.1", "sendTransaction")
+		assert.True(t, ok)
+		limiter.SetLimits(map[string]float64{"sendTransaction": 1}, 0)
+		ok, _, _ = limiter.allow(now, "1.1.1.1", "sendTransaction")
+		assert.True(t, ok)
+		ok, limitType, _ := limiter.allow(now, "1.1.1.1", "sendTransaction")
+		assert.False(t, ok)
+		assert.Equal(t, "method", limitType)
+		limiter.SetLimits(nil, 0)
+		ok, _, _ = limiter.allow(now, "1.1.1.1", "sendTransaction")
+		assert.True(t, ok)
+	})
+}
+
+func TestRateLimiterAllowAll(t *testing.T) {
+	now := time.Now()
+	t.Run("rejected batch gives tokens back", func(t *testing.T) {
+		limiter := NewRateLimiter(map[string]float64{"simulateTransaction": 1, "sendTransaction": 1}, 0)
+		ok, _, _ := limiter.allow(now, "1.1.1.1", "sendTransaction")
+		assert.True(t, ok)
+		rejections := limiter.allowAll(now, "1.1.1.1", []string{"simulateTransaction", "sendTransaction"})
+		require.Len(t, rejections, 1)
+		assert.Equal(t, "sendTransaction", rejections[0].method)
+		assert.Equal(t, "method", rejections[0].limitType)
+		// the call to simulateTransaction of the rejected batch didn't consume its token
+		ok, _, _ = limiter.allow(now, "1.1.1.1", "simulateTransaction")
+		assert.True(t, ok)
+	})
+
+	t.Run("method rejection gives ip token back", func(t *testing.T) {
+		limiter := NewRateLimiter(map[string]float64{"sendTransaction": 1}, 1)
+		ok, _, _ := limiter.allow(now, "1.1.1.1", "sendTransaction")
+		assert.True(t, ok)
+		ok, limitType, _ := limiter.allow(now, "2.2.2.2", "sendTransaction")
+		assert.False(t, ok)
+		assert.Equal(t, "method", limitType)
+		ok, _, _ = limiter.allow(now, "2.2.2.2", "getHealth")
+		assert.True(t, ok)
+	})
+
+	t.Run("allowed batch", func(t *testing.T) {
+		limiter := NewRateLimiter(nil, 2)
+		assert.Empty(t, limiter.allowAll(now, "1.1.1.1", []string{"getHealth", "getHealth"}))
+		rejections := limiter.allowAll(now, "1.1.1.1", []string{"getHealth"})
+		require.Len(t, rejections, 1)
+		assert.Equal(t, "ip", rejections[0].limitType)
+	})
+}
+
+func TestRateLimiterMiddleware(t *testing.T) {
+	var limitHits []string
+	limiter := NewRateLimiter(map[string]float64{"simulateTransaction": 1}, 0)
+	limiter.OnLimitExceeded = func(method, limitType string) {
+		limitHits = append(limitHits, method+"/"+limitType)
+	}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := limiter.Middleware(log.DefaultLogger, next)
+
+	body := `{"jsonrpc":"2.0","id":7,"method":"simulateTransaction","params":{}}`
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.JSONEq(t,
+		`{"jsonrpc":"2.0","id":7,"error":{"code":-32029,"message":"rate limit exceeded","data":{"retryAfter":1}}}`,
+		w.Body.String(),
+	)
+	assert.Equal(t, []string{"simulateTransaction/method"}, limitHits)
+
+	// a batch with a single call is answered with an array
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("["+body+"]")))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.JSONEq(t,
+		`[{"jsonrpc":"2.0","id":7,"error":{"code":-32029,"message":"rate limit exceeded","data":{"retryAfter":1}}}]`,
+		w.Body.String(),
+	)
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/creachadair/jrpc2"
+
+	"github.com/stellar/go/support/log"
+)
+
+type errorResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Error   *jrpc2.Error    `json:"error"`
+}
+
+// isBatch reports whether body is (the beginning of) a JSON-RPC batch
+func isBatch(body []byte) bool {
+	body = bytes.TrimLeft(body, " \t\r\n")
+	return len(body) > 0 && body[0] == '['
+}
+
+// writeErrorResponses answers every call (i.e. every request which isn't a notification)
+// in requests with rpcErr, using the given HTTP status. When batch is set the responses are
+// encoded as an array, even if there is a single one.
+func writeErrorResponses(logger *log.Entry, w http.ResponseWriter, status int, batch bool, requests []*jrpc2.ParsedRequest, rpcErr *jrpc2.Error) {
+	var responses []errorResponse
+	for _, req := range requests {
+		if req.ID == "" {
+			continue
+		}
+		responses = append(responses, errorResponse{
+			JSONRPC: "2.0",
+			ID:      json.RawMessage(req.ID),
+			Error:   rpcErr,
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	var err error
+	switch {
+	case len(responses) == 0:
+		// there is nothing to answer when all the requests are notifications
+	case batch:
+		err = json.NewEncoder(w).Encode(responses)
+	default:
+		err = json.NewEncoder(w).Encode(responses[0])
+	}
+	if err != nil {
+		logger.WithError(err).Warn("could not write error response")
+	}
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/handler"
+
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/rpcerror"
+)
+
+// RequestSizeLimit returns an http.Handler which rejects requests whose body is larger
+// than maxBytes before they reach next.
+func RequestSizeLimit(logger *log.Entry, maxBytes int64, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body []byte
+		if r.ContentLength <= maxBytes {
+			var err error
+			body, err = io.ReadAll(io.LimitReader(r.Body, maxBytes+1))
+			if err != nil {
+				http.Error(w, "could not read request body", http.StatusBadRequest)
+				return
+			}
+			if int64(len(body)) <= maxBytes {
+				r.Body = io.NopCloser(bytes.NewReader(body))
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		logger.WithField("contentLength", r.ContentLength).Debug("request size limit exceeded")
+		if body == nil {
+			// only the beginning of the body is needed to tell whether it is a batch
+			body, _ = io.ReadAll(io.LimitReader(r.Body, 512))
+		}
+		// The request can't be parsed to obtain its ids, so a single error is returned
+		// (within an array for batches)
+		writeErrorResponses(logger, w, http.StatusRequestEntityTooLarge, isBatch(body),
+			[]*jrpc2.ParsedRequest{{ID: "null"}},
+			&jrpc2.Error{
+				Code:    rpcerror.RequestTooLarge,
+				Message: fmt.Sprintf("request body exceeds the maximum size of %d bytes", maxBytes),
+			},
+		)
+	})
+}
+
+// ResponseSizeLimit decorates the handler of method so that results larger than
+// maxBytes (once encoded in JSON) are replaced by an error.
+func ResponseSizeLimit(method string, maxBytes int, h jrpc2.Handler) jrpc2.Handler {
+	return handler.Func(func(ctx context.Context, req *jrpc2.Request) (interface{}, error) {
+		result, err := h.Handle(ctx, req)
+		if err != nil {
+			return result, err
+		}
+		encoded, err := json.Marshal(result)
+		if err != nil {
+			return nil, err
+		}
+		if len(encoded) > maxBytes {
+			return nil, &jrpc2.Error{
+				Code:    rpcerror.ResponseTooLarge,
+				Message: fmt.Sprintf("%s response exceeds the maximum size of %d bytes", method, maxBytes),
+			}
+		}
+		// avoid encoding the result twice
+		return json.RawMessage(encoded), nil
+	})
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/channel"
+	"github.com/creachadair/jrpc2/handler"
+	"github.com/stellar/go/support/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/rpcerror"
+)
+
+func TestRequestSizeLimit(t *testing.T) {
+	var forwardedBody string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		forwardedBody = string(body)
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := RequestSizeLimit(log.DefaultLogger, 60, next)
+
+	body := `{"jsonrpc":"2.0","id":1,"method":"getHealth"}`
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, body, forwardedBody)
+
+	body = `{"jsonrpc":"2.0","id":1,"method":"getHealth","params":{"padding":"aaaaa"}}`
+	for _, contentLength := range []int64{int64(len(body)), -1} {
+		request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		// an unknown length must be detected while reading the body
+		request.ContentLength = contentLength
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, request)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.JSONEq(t,
+			`{"jsonrpc":"2.0","id":null,"error":{"code":-32030,"message":"request body exceeds the maximum size of 60 bytes"}}`,
+			w.Body.String(),
+		)
+	}
+
+	// batches are answered with an array
+	body = `[{"jsonrpc":"2.0","id":1,"method":"getHealth","params":{"padding":"aaaaa"}}]`
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.JSONEq(t,
+		`[{"jsonrpc":"2.0","id":null,"error":{"code":-32030,"message":"request body exceeds the maximum size of 60 bytes"}}]`,
+		w.Body.String(),
+	)
+}
+
+func TestResponseSizeLimit(t *testing.T) {
+	echo := handler.New(func(_ context.Context, params []string) ([]string, error) {
+		return params, nil
+	})
+	cch, sch := channel.Direct()
+	server := jrpc2.NewServer(handler.Map{"echo": ResponseSizeLimit("echo", 10, echo)}, nil).Start(sch)
+	client := jrpc2.NewClient(cch, nil)
+	defer func() {
+		client.Close()
+		server.Wait()
+	}()
+
+	var result []string
+	require.NoError(t, client.CallResult(context.Background(), "echo", []string{"abc"}, &result))
+	assert.Equal(t, []string{"abc"}, result)
+
+	_, err := client.Call(context.Background(), "echo", []string{"abcdefghijk"})
+	require.Error(t, err)
+	assert.Equal(t, rpcerror.ResponseTooLarge, err.(*jrpc2.Error).Code)
+}
@@ -0,0 +1,268 @@
+// Package mockbackend emulates the Horizon and Stellar Core endpoints used by soroban-rpc on top of
+// a deterministic in-memory ledger. It allows clients (e.g. SDKs) to run fast hermetic integration
+// tests against the full JSON RPC surface, without running stellar-core or Horizon:
+//
+//	backend := mockbackend.New(mockbackend.StandaloneNetworkPassphrase)
+//	defer backend.Close()
+//	backend.AddAccount(address, 1000*10_000_000)
+//	d, err := daemon.NewDaemon(backend.DaemonConfig())
+//
+// Every submitted transaction is applied in a ledger of its own. Signatures are not verified
+// and only the create account, native payment and invoke contract operations are supported.
+package mockbackend
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/toid"
+	"github.com/stellar/go/xdr"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/daemon"
+)
+
+const (
+	StandaloneNetworkPassphrase = "Standalone Network ; February 2017"
+	// ProtocolVersion is the protocol version of the emulated ledgers
+	ProtocolVersion = 20
+	// BaseFee is the fee (in stroops) charged for every operation
+	BaseFee = 100
+	// BaseReserve is the base reserve (in stroops) of the emulated ledgers
+	BaseReserve = 5_000_000
+	// LedgerCloseInterval is the time between the close times of two consecutive ledgers
+	LedgerCloseInterval = 5 * time.Second
+)
+
+// ContractFunc implements a contract function, it is invoked both when simulating and
+// when applying transactions. It must not call the Backend.
+type ContractFunc func(args []xdr.ScVal) (xdr.ScVal, error)
+
+// Backend is an in-memory ledger served through Horizon and Stellar Core compatible HTTP servers
+type Backend struct {
+	lock              sync.Mutex
+	networkPassphrase string
+	genesisTime       time.Time
+	ledgers           []horizon.Ledger
+	latestLedgerHash  xdr.Hash
+	// entries are indexed by their base64 encoded ledger key
+	entries      map[string]xdr.LedgerEntry
+	transactions map[string]horizon.Transaction
+	functions    map[contractFunctionKey]ContractFunc
+
+	horizonServer *httptest.Server
+	coreServer    *httptest.Server
+}
+
+type contractFunctionKey struct {
+	contractID xdr.Hash
+	function   string
+}
+
+// New creates a Backend with a single (genesis) ledger and starts its servers.
+// Ledger close times start at the current time, use NewWithGenesisTime to make them deterministic.
+func New(networkPassphrase string) *Backend {
+	return NewWithGenesisTime(networkPassphrase, time.Now().Truncate(time.Second))
+}
+
+// NewWithGenesisTime creates a Backend whose genesis ledger closed at the given time.
+func NewWithGenesisTime(networkPassphrase string, genesisTime time.Time) *Backend {
+	b := &Backend{
+		networkPassphrase: networkPassphrase,
+		genesisTime:       genesisTime.UTC(),
+		entries:           map[string]xdr.LedgerEntry{},
+		transactions:      map[string]horizon.Transaction{},
+		functions:         map[contractFunctionKey]ContractFunc{},
+	}
+	b.closeLedger(0)
+	b.horizonServer = httptest.NewServer(b.horizonHandler())
+	b.coreServer = httptest.NewServer(b.coreHandler())
+	return b
+}
+
+// Close stops the servers of the backend
+func (b *Backend) Close() {
+	b.horizonServer.Close()
+	b.coreServer.Close()
+}
+
+// HorizonURL returns the URL of the emulated Horizon server
+func (b *Backend) HorizonURL() string {
+	return b.horizonServer.URL
+}
+
+// CoreURL returns the URL of the emulated Stellar Core server
+func (b *Backend) CoreURL() string {
+	return b.coreServer.URL
+}
+
+// DaemonConfig returns a daemon configuration connected to the backend and listening on a free local port
+func (b *Backend) DaemonConfig() daemon.Config {
+	return daemon.Config{
+		Endpoint:             "localhost:0",
+		HorizonURL:           b.HorizonURL(),
+		StellarCoreURL:       b.CoreURL(),
+		NetworkPassphrase:    b.networkPassphrase,
+		TxConcurrency:        1,
+		TxQueueSize:          10,
+		PreflightConcurrency: 1,
+		PreflightQueueSize:   10,
+		PreflightTimeout:     10 * time.Second,
+	}
+}
+
+// LatestLedger returns the sequence of the latest closed ledger
+func (b *Backend) LatestLedger() uint32 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return uint32(b.latestLedger().Sequence)
+}
+
+// CloseLedger closes an empty ledger and returns its sequence
+func (b *Backend) CloseLedger() uint32 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return uint32(b.closeLedger(0).Sequence)
+}
+
+// AddAccount creates (or replaces) an account with the given balance (in stroops),
+// the account sequence number is derived from the current ledger as stellar-core does.
+func (b *Backend) AddAccount(address string, balance int64) error {
+	var accountID xdr.AccountId
+	if err := accountID.SetAddress(address); err != nil {
+		return err
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.setEntry(xdr.LedgerEntryData{
+		Type: xdr.LedgerEntryTypeAccount,
+		Account: &xdr.AccountEntry{
+			AccountId:  accountID,
+			Balance:    xdr.Int64(balance),
+			SeqNum:     xdr.SequenceNumber(int64(b.latestLedger().Sequence) << 32),
+			Thresholds: xdr.Thresholds{1, 0, 0, 0},
+		},
+	})
+	return nil
+}
+
+// AddRandomAccount creates an account with a random key pair and the given balance (in stroops)
+func (b *Backend) AddRandomAccount(balance int64) (*keypair.Full, error) {
+	kp, err := keypair.Random()
+	if err != nil {
+		return nil, err
+	}
+	return kp, b.AddAccount(kp.Address(), balance)
+}
+
+// SetContractData creates (or replaces) a contract data ledger entry
+func (b *Backend) SetContractData(contractID xdr.Hash, key, val xdr.ScVal) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.setEntry(xdr.LedgerEntryData{
+		Type: xdr.LedgerEntryTypeContractData,
+		ContractData: &xdr.ContractDataEntry{
+			ContractId: contractID,
+			Key:        key,
+			Val:        val,
+		},
+	})
+}
+
+// RemoveContractData removes a contract data ledger entry
+func (b *Backend) RemoveContractData(contractID xdr.Hash, key xdr.ScVal) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	delete(b.entries, mustMarshalKey(xdr.LedgerKey{
+		Type:         xdr.LedgerEntryTypeContractData,
+		ContractData: &xdr.LedgerKeyContractData{ContractId: contractID, Key: key},
+	}))
+}
+
+// RegisterContractFunction makes function callable on the given contract
+func (b *Backend) RegisterContractFunction(contractID xdr.Hash, function string, fn ContractFunc) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.functions[contractFunctionKey{contractID: contractID, function: function}] = fn
+}
+
+func (b *Backend) latestLedger() horizon.Ledger {
+	return b.ledgers[len(b.ledgers)-1]
+}
+
+// closeLedger appends a new ledger to the chain. Ledger hashes are derived from the
+// ledger headers, so the chain only depends on the genesis time.
+func (b *Backend) closeLedger(operationCount int32) horizon.Ledger {
+	sequence := int32(len(b.ledgers) + 1)
+	closeTime := b.genesisTime.Add(time.Duration(sequence-1) * LedgerCloseInterval)
+	header := xdr.LedgerHeader{
+		LedgerVersion: ProtocolVersion,
+		LedgerSeq:     xdr.Uint32(sequence),
+		BaseFee:       BaseFee,
+		BaseReserve:   BaseReserve,
+		MaxTxSetSize:  100,
+		ScpValue:      xdr.StellarValue{CloseTime: xdr.TimePoint(closeTime.Unix())},
+	}
+	var prevHash string
+	if len(b.ledgers) > 0 {
+		header.PreviousLedgerHash = b.latestLedgerHash
+		prevHash = hex.EncodeToString(b.latestLedgerHash[:])
+	}
+	headerBytes, err := header.MarshalBinary()
+	if err != nil {
+		panic(err)
+	}
+	b.latestLedgerHash = sha256.Sum256(headerBytes)
+	ledger := horizon.Ledger{
+		ID:              hex.EncodeToString(b.latestLedgerHash[:]),
+		Hash:            hex.EncodeToString(b.latestLedgerHash[:]),
+		PT:              fmt.Sprint(toid.New(sequence, 0, 0).ToInt64()),
+		PrevHash:        prevHash,
+		Sequence:        sequence,
+		OperationCount:  operationCount,
+		ClosedAt:        closeTime,
+		BaseFee:         BaseFee,
+		BaseReserve:     BaseReserve,
+		MaxTxSetSize:    100,
+		ProtocolVersion: ProtocolVersion,
+		HeaderXDR:       base64.StdEncoding.EncodeToString(headerBytes),
+	}
+	b.ledgers = append(b.ledgers, ledger)
+	return ledger
+}
+
+func (b *Backend) setEntry(data xdr.LedgerEntryData) {
+	entry := xdr.LedgerEntry{
+		LastModifiedLedgerSeq: xdr.Uint32(b.latestLedger().Sequence),
+		Data:                  data,
+	}
+	b.entries[mustMarshalKey(entry.LedgerKey())] = entry
+}
+
+func (b *Backend) account(accountID xdr.AccountId) (xdr.AccountEntry, bool) {
+	entry, ok := b.entries[mustMarshalKey(xdr.LedgerKey{
+		Type:    xdr.LedgerEntryTypeAccount,
+		Account: &xdr.LedgerKeyAccount{AccountId: accountID},
+	})]
+	if !ok {
+		return xdr.AccountEntry{}, false
+	}
+	return entry.Data.MustAccount(), true
+}
+
+func (b *Backend) setAccount(account xdr.AccountEntry) {
+	b.setEntry(xdr.LedgerEntryData{Type: xdr.LedgerEntryTypeAccount, Account: &account})
+}
+
+func mustMarshalKey(key xdr.LedgerKey) string {
+	encoded, err := key.MarshalBinaryBase64()
+	if err != nil {
+		panic(err)
+	}
+	return encoded
+}
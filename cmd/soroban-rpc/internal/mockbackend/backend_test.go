@@ -0,0 +1,458 @@
+package mockbackend
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/code"
+	"github.com/creachadair/jrpc2/jhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/clients/stellarcore"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/go/xdr"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/daemon"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/methods"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/rpcerror"
+)
+
+func startDaemon(t *testing.T, backend *Backend) *jrpc2.Client {
+	d, err := daemon.NewDaemon(backend.DaemonConfig())
+	require.NoError(t, err)
+	require.NoError(t, d.Start())
+	client := jrpc2.NewClient(jhttp.NewChannel("http://"+d.Addr().String(), nil), nil)
+	t.Cleanup(func() {
+		client.Close()
+		d.Close()
+	})
+	return client
+}
+
+func buildTransaction(t *testing.T, client *jrpc2.Client, source *keypair.Full, op txnbuild.Operation) string {
+	var account methods.AccountInfo
+	require.NoError(t, client.CallResult(context.Background(), "getAccount", methods.AccountRequest{Address: source.Address()}, &account))
+	tx, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+		SourceAccount:        &txnbuild.SimpleAccount{AccountID: account.ID, Sequence: account.Sequence},
+		IncrementSequenceNum: true,
+		Operations:           []txnbuild.Operation{op},
+		BaseFee:              txnbuild.MinBaseFee,
+		Preconditions:        txnbuild.Preconditions{TimeBounds: txnbuild.NewInfiniteTimeout()},
+	})
+	require.NoError(t, err)
+	tx, err = tx.Sign(StandaloneNetworkPassphrase, source)
+	require.NoError(t, err)
+	b64, err := tx.Base64()
+	require.NoError(t, err)
+	return b64
+}
+
+func sendTransaction(t *testing.T, client *jrpc2.Client, txXDR string) methods.TransactionStatusResponse {
+	var sendResponse methods.SendTransactionResponse
+	require.NoError(t, client.CallResult(context.Background(), "sendTransaction", methods.SendTransactionRequest{Transaction: txXDR}, &sendResponse))
+	var status methods.TransactionStatusResponse
+	require.Eventually(t, func() bool {
+		require.NoError(t, client.CallResult(context.Background(), "getTransactionStatus", methods.GetTransactionStatusRequest{Hash: sendResponse.ID}, &status))
+		return status.Status != methods.TransactionPending
+	}, 10*time.Second, 10*time.Millisecond)
+	return status
+}
+
+func TestBackendEmbeddedHandler(t *testing.T) {
+	backend := NewWithGenesisTime(StandaloneNetworkPassphrase, time.Unix(1_600_000_000, 0))
+	defer backend.Close()
+	d, err := daemon.NewDaemon(backend.DaemonConfig())
+	require.NoError(t, err)
+	defer d.Close()
+	require.NoError(t, d.StartBackground())
+	server := httptest.NewServer(d.Handler())
+	defer server.Close()
+	client := jrpc2.NewClient(jhttp.NewChannel(server.URL, nil), nil)
+	defer client.Close()
+
+	source, err := backend.AddRandomAccount(100_0000000)
+	require.NoError(t, err)
+	status := sendTransaction(t, client, buildTransaction(t, client, source, &txnbuild.CreateAccount{
+		Destination: keypair.MustRandom().Address(),
+		Amount:      "10",
+	}))
+	assert.Equal(t, methods.TransactionSuccess, status.Status)
+}
+
+func TestBackendPayments(t *testing.T) {
+	backend := NewWithGenesisTime(StandaloneNetworkPassphrase, time.Unix(1_600_000_000, 0))
+	defer backend.Close()
+	client := startDaemon(t, backend)
+
+	source, err := backend.AddRandomAccount(100_0000000)
+	require.NoError(t, err)
+	destination := keypair.MustRandom()
+
+	var result methods.HealthCheckResult
+	require.NoError(t, client.CallResult(context.Background(), "getHealth", nil, &result))
+	assert.Equal(t, methods.HealthStatusHealthy, result.Status)
+
+	status := sendTransaction(t, client, buildTransaction(t, client, source, &txnbuild.CreateAccount{
+		Destination: destination.Address(),
+		Amount:      "10",
+	}))
+	assert.Equal(t, methods.TransactionSuccess, status.Status)
+	assert.Equal(t, uint32(2), backend.LatestLedger())
+
+	// the destination doesn't have enough funds for a payment of 20 XLM
+	status = sendTransaction(t, client, buildTransaction(t, client, destination, &txnbuild.Payment{
+		Destination: source.Address(),
+		Amount:      "20",
+		Asset:       txnbuild.NativeAsset{},
+	}))
+	assert.Equal(t, methods.TransactionError, status.Status)
+	assert.Equal(t, "tx_failed", status.Error.Code)
+
+	var account methods.GetAccountInfoResponse
+	require.NoError(t, client.CallResult(context.Background(), "getAccountInfo", methods.GetAccountInfoRequest{Address: destination.Address()}, &account))
+	// the failed payment consumed a sequence number and its fee
+	assert.Equal(t, int64(2)<<32+1, account.Sequence)
+	assert.Equal(t, int64(10_0000000-BaseFee), account.Balance)
+	assert.Equal(t, int64(3), account.LastModifiedLedger)
+
+	var ledger methods.GetLatestLedgerResponse
+	require.NoError(t, client.CallResult(context.Background(), "getLatestLedger", nil, &ledger))
+	assert.Equal(t, uint32(3), ledger.Sequence)
+	assert.Equal(t, int64(1_600_000_010), ledger.CloseTime)
+}
+
+func TestBackendContracts(t *testing.T) {
+	backend := New(StandaloneNetworkPassphrase)
+	defer backend.Close()
+	client := startDaemon(t, backend)
+
+	contractID := xdr.Hash{0xca, 0xfe}
+	key := xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: ptr(xdr.ScSymbol("COUNTER"))}
+	backend.SetContractData(contractID, key, xdr.ScVal{Type: xdr.ScValTypeScvU32, U32: ptr(xdr.Uint32(7))})
+	backend.RegisterContractFunction(contractID, "add", func(args []xdr.ScVal) (xdr.ScVal, error) {
+		if len(args) != 2 {
+			return xdr.ScVal{}, errors.New("expected two arguments")
+		}
+		sum := *args[0].U32 + *args[1].U32
+		return xdr.ScVal{Type: xdr.ScValTypeScvU32, U32: &sum}, nil
+	})
+
+	keyXDR, err := xdr.MarshalBase64(key)
+	require.NoError(t, err)
+	var contractData methods.GetContractDataResponse
+	require.NoError(t, client.CallResult(context.Background(), "getContractData", methods.GetContractDataRequest{
+		ContractID: hex.EncodeToString(contractID[:]),
+		Key:        keyXDR,
+	}, &contractData))
+	var val xdr.ScVal
+	require.NoError(t, xdr.SafeUnmarshalBase64(contractData.XDR, &val))
+	assert.Equal(t, xdr.Uint32(7), *val.U32)
+
+	source, err := backend.AddRandomAccount(100_0000000)
+	require.NoError(t, err)
+	contractIDBytes := contractID[:]
+	contractIDObj := &xdr.ScObject{Type: xdr.ScObjectTypeScoBytes, Bin: &contractIDBytes}
+	invoke := &txnbuild.InvokeHostFunction{
+		Function: xdr.HostFunctionHostFnInvokeContract,
+		Parameters: xdr.ScVec{
+			{Type: xdr.ScValTypeScvObject, Obj: &contractIDObj},
+			{Type: xdr.ScValTypeScvSymbol, Sym: ptr(xdr.ScSymbol("add"))},
+			{Type: xdr.ScValTypeScvU32, U32: ptr(xdr.Uint32(2))},
+			{Type: xdr.ScValTypeScvU32, U32: ptr(xdr.Uint32(3))},
+		},
+	}
+	txXDR := buildTransaction(t, client, source, invoke)
+
+	var simulation methods.SimulateTransactionResponse
+	require.NoError(t, client.CallResult(context.Background(), "simulateTransaction", methods.SimulateTransactionRequest{Transaction: txXDR}, &simulation))
+	require.Empty(t, simulation.Error)
+	require.Len(t, simulation.Results, 1)
+	require.NoError(t, xdr.SafeUnmarshalBase64(simulation.Results[0].XDR, &val))
+	assert.Equal(t, xdr.Uint32(5), *val.U32)
+	assert.Equal(t, uint64(PreflightCPUInstructions), simulation.Cost.CPUInstructions)
+
+	status := sendTransaction(t, client, txXDR)
+	require.Equal(t, methods.TransactionSuccess, status.Status)
+	require.Len(t, status.Results, 1)
+	require.NoError(t, xdr.SafeUnmarshalBase64(status.Results[0].XDR, &val))
+	assert.Equal(t, xdr.Uint32(5), *val.U32)
+	assert.Empty(t, status.ResultMetaXDR)
+
+	require.NoError(t, client.CallResult(context.Background(), "getTransactionStatus", methods.GetTransactionStatusRequest{
+		Hash:              status.ID,
+		Format:            methods.FormatJSON,
+		IncludeResultMeta: true,
+	}, &status))
+	var meta xdr.TransactionMeta
+	require.NoError(t, xdr.SafeUnmarshalBase64(status.ResultMetaXDR, &meta))
+	assert.Len(t, meta.MustV2().Operations, 1)
+	assert.NotNil(t, status.ResultMetaJSON)
+}
+
+func TestBackendLedgerChainIsDeterministic(t *testing.T) {
+	genesis := time.Unix(1_600_000_000, 0)
+	first := NewWithGenesisTime(StandaloneNetworkPassphrase, genesis)
+	defer first.Close()
+	second := NewWithGenesisTime(StandaloneNetworkPassphrase, genesis)
+	defer second.Close()
+	first.CloseLedger()
+	second.CloseLedger()
+	assert.Equal(t, first.latestLedger(), second.latestLedger())
+	assert.Equal(t, first.ledgers[0].Hash, first.latestLedger().PrevHash)
+}
+
+func TestDaemonRejectsNetworkPassphraseMismatch(t *testing.T) {
+	backend := New(StandaloneNetworkPassphrase)
+	defer backend.Close()
+	cfg := backend.DaemonConfig()
+	cfg.NetworkPassphrase = network.TestNetworkPassphrase
+
+	d, err := daemon.NewDaemon(cfg)
+	require.NoError(t, err)
+	defer d.Close()
+	var mismatch *methods.PassphraseMismatchError
+	require.ErrorAs(t, d.Start(), &mismatch)
+	assert.Equal(t, "horizon", mismatch.Upstream)
+	assert.Equal(t, StandaloneNetworkPassphrase, mismatch.Passphrase)
+
+	checker := methods.HealthChecker{
+		Logger:            log.DefaultLogger,
+		HorizonClient:     &horizonclient.Client{HorizonURL: backend.HorizonURL()},
+		CoreClient:        &stellarcore.Client{URL: backend.CoreURL()},
+		NetworkPassphrase: network.TestNetworkPassphrase,
+	}
+	result := checker.Check(context.Background())
+	assert.Equal(t, methods.HealthStatusUnhealthy, result.Status)
+	assert.Equal(t, methods.HealthStatusUnhealthy, result.Horizon.Status)
+	assert.Equal(t, methods.HealthStatusUnhealthy, result.StellarCore.Status)
+	assert.Contains(t, result.StellarCore.Error, "network passphrase mismatch")
+}
+
+func TestBackendHealthLedgerLatency(t *testing.T) {
+	backend := NewWithGenesisTime(StandaloneNetworkPassphrase, time.Unix(1_600_000_000, 0))
+	defer backend.Close()
+
+	checker := methods.HealthChecker{
+		Logger:           log.DefaultLogger,
+		HorizonClient:    &horizonclient.Client{HorizonURL: backend.HorizonURL()},
+		CoreClient:       &stellarcore.Client{URL: backend.CoreURL()},
+		MaxLedgerLatency: 30 * time.Second,
+	}
+	result := checker.Check(context.Background())
+	assert.Equal(t, methods.HealthStatusUnhealthy, result.Status)
+	assert.Equal(t, methods.HealthStatusHealthy, result.Horizon.Status)
+	assert.Equal(t, methods.HealthStatusUnhealthy, result.StellarCore.Status)
+	assert.Contains(t, result.StellarCore.Error, "exceeding the maximum healthy ledger latency of 30s")
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestBackendTokenBalance(t *testing.T) {
+	backend := New(StandaloneNetworkPassphrase)
+	defer backend.Close()
+	client := startDaemon(t, backend)
+
+	holder, err := keypair.Random()
+	require.NoError(t, err)
+	contractID := xdr.Hash{0x70, 0x4e}
+	backend.RegisterContractFunction(contractID, "balance", func(args []xdr.ScVal) (xdr.ScVal, error) {
+		if len(args) != 1 {
+			return xdr.ScVal{}, errors.New("expected one argument")
+		}
+		identifier := *(*args[0].Obj).Vec
+		if *identifier[0].Sym != "Account" || (*identifier[1].Obj).AccountId.Address() != holder.Address() {
+			return xdr.ScVal{}, errors.New("unexpected identifier")
+		}
+		magnitude := big.NewInt(0).Lsh(big.NewInt(1), 70).Bytes()
+		obj := &xdr.ScObject{Type: xdr.ScObjectTypeScoBigInt, BigInt: &xdr.ScBigInt{Sign: xdr.ScNumSignPositive, Magnitude: &magnitude}}
+		return xdr.ScVal{Type: xdr.ScValTypeScvObject, Obj: &obj}, nil
+	})
+	backend.RegisterContractFunction(contractID, "decimals", func([]xdr.ScVal) (xdr.ScVal, error) {
+		return xdr.ScVal{Type: xdr.ScValTypeScvU32, U32: ptr(xdr.Uint32(7))}, nil
+	})
+
+	var balance methods.GetTokenBalanceResponse
+	require.NoError(t, client.CallResult(context.Background(), "getTokenBalance", methods.GetTokenBalanceRequest{
+		ContractID: hex.EncodeToString(contractID[:]),
+		Address:    holder.Address(),
+	}, &balance))
+	assert.Equal(t, "1180591620717411303424", balance.Balance)
+	require.NotNil(t, balance.Decimals)
+	assert.Equal(t, uint32(7), *balance.Decimals)
+	// the contract has no symbol function
+	assert.Empty(t, balance.Symbol)
+	assert.Equal(t, int64(backend.LatestLedger()), balance.LatestLedger)
+
+	err = client.CallResult(context.Background(), "getTokenBalance", methods.GetTokenBalanceRequest{
+		ContractID: hex.EncodeToString(contractID[:]),
+		Address:    "not an address",
+	}, &balance)
+	assert.Equal(t, code.InvalidParams, code.FromError(err))
+}
+
+func TestBackendReadOnly(t *testing.T) {
+	backend := New(StandaloneNetworkPassphrase)
+	defer backend.Close()
+	cfg := backend.DaemonConfig()
+	cfg.ReadOnly = true
+	cfg.DisabledMethods = []string{"getFeeStats"}
+	d, err := daemon.NewDaemon(cfg)
+	require.NoError(t, err)
+	require.NoError(t, d.Start())
+	client := jrpc2.NewClient(jhttp.NewChannel("http://"+d.Addr().String(), nil), nil)
+	defer func() {
+		client.Close()
+		d.Close()
+	}()
+
+	source, err := backend.AddRandomAccount(100_0000000)
+	require.NoError(t, err)
+	txXDR := buildTransaction(t, client, source, &txnbuild.BumpSequence{BumpTo: 1})
+	_, err = client.Call(context.Background(), "sendTransaction", methods.SendTransactionRequest{Transaction: txXDR})
+	var rpcErr *jrpc2.Error
+	require.ErrorAs(t, err, &rpcErr)
+	assert.Equal(t, rpcerror.MethodNotAllowed, rpcErr.Code)
+	_, err = client.Call(context.Background(), "getFeeStats", nil)
+	require.ErrorAs(t, err, &rpcErr)
+	assert.Equal(t, rpcerror.MethodNotAllowed, rpcErr.Code)
+	_, err = client.Call(context.Background(), "getNetwork", nil)
+	assert.NoError(t, err)
+
+	cfg.DisabledMethods = []string{"unknownMethod"}
+	_, err = daemon.NewDaemon(cfg)
+	assert.Error(t, err)
+}
+
+func TestBackendLedgerRange(t *testing.T) {
+	backend := New(StandaloneNetworkPassphrase)
+	defer backend.Close()
+	backend.CloseLedger()
+	client := startDaemon(t, backend)
+
+	var result methods.LedgerRange
+	require.NoError(t, client.CallResult(context.Background(), "getNetwork", nil, &result))
+	assert.Equal(t, int64(backend.LatestLedger()), result.LatestLedger)
+	assert.NotZero(t, result.LatestLedgerCloseTime)
+	assert.Equal(t, int64(1), result.OldestLedger)
+	assert.LessOrEqual(t, result.OldestLedgerCloseTime, result.LatestLedgerCloseTime)
+}
+
+func TestBackendValidateTransaction(t *testing.T) {
+	backend := New(StandaloneNetworkPassphrase)
+	defer backend.Close()
+	client := startDaemon(t, backend)
+
+	source, err := backend.AddRandomAccount(100_0000000)
+	require.NoError(t, err)
+	validate := func(txXDR string) methods.ValidateTransactionResponse {
+		var response methods.ValidateTransactionResponse
+		require.NoError(t, client.CallResult(context.Background(), "validateTransaction", methods.ValidateTransactionRequest{Transaction: txXDR}, &response))
+		return response
+	}
+	issueCodes := func(response methods.ValidateTransactionResponse) []string {
+		var codes []string
+		for _, issue := range response.Issues {
+			codes = append(codes, issue.Code)
+		}
+		return codes
+	}
+
+	response := validate(buildTransaction(t, client, source, &txnbuild.BumpSequence{BumpTo: 1}))
+	assert.True(t, response.Valid)
+	assert.Empty(t, response.Issues)
+	assert.Len(t, response.Hash, 64)
+
+	response = validate("AAAA")
+	assert.False(t, response.Valid)
+	assert.Equal(t, []string{"invalid_xdr"}, issueCodes(response))
+
+	// signed by another key, with a sequence number which was already used
+	other := keypair.MustRandom()
+	tx, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+		SourceAccount: &txnbuild.SimpleAccount{AccountID: source.Address(), Sequence: 0},
+		Operations:    []txnbuild.Operation{&txnbuild.BumpSequence{BumpTo: 1}},
+		BaseFee:       txnbuild.MinBaseFee,
+		Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewInfiniteTimeout()},
+	})
+	require.NoError(t, err)
+	tx, err = tx.Sign(StandaloneNetworkPassphrase, other)
+	require.NoError(t, err)
+	txXDR, err := tx.Base64()
+	require.NoError(t, err)
+	response = validate(txXDR)
+	assert.False(t, response.Valid)
+	assert.ElementsMatch(t, []string{"tx_bad_seq", "unknown_signature", "tx_bad_auth"}, issueCodes(response))
+}
+
+func TestBackendTransactionBySourceAndSequence(t *testing.T) {
+	backend := New(StandaloneNetworkPassphrase)
+	defer backend.Close()
+	client := startDaemon(t, backend)
+
+	source, err := backend.AddRandomAccount(100_0000000)
+	require.NoError(t, err)
+	var account methods.AccountInfo
+	require.NoError(t, client.CallResult(context.Background(), "getAccount", methods.AccountRequest{Address: source.Address()}, &account))
+	payment := &txnbuild.Payment{Destination: keypair.MustRandom().Address(), Amount: "1", Asset: txnbuild.NativeAsset{}}
+	// a payment to an account which doesn't exist fails, but it is still included in a ledger
+	first := sendTransaction(t, client, buildTransaction(t, client, source, payment))
+	second := sendTransaction(t, client, buildTransaction(t, client, source, &txnbuild.CreateAccount{
+		Destination: keypair.MustRandom().Address(),
+		Amount:      "10",
+	}))
+	assert.Equal(t, methods.TransactionSuccess, second.Status)
+
+	getStatus := func(sequence int64) methods.TransactionStatusResponse {
+		var status methods.TransactionStatusResponse
+		require.NoError(t, client.CallResult(context.Background(), "getTransactionStatus", methods.GetTransactionStatusRequest{
+			SourceAccount: source.Address(),
+			Sequence:      sequence,
+		}, &status))
+		return status
+	}
+	status := getStatus(account.Sequence + 1)
+	assert.Equal(t, first.ID, status.ID)
+	assert.Equal(t, "tx_failed", status.Error.Code)
+	status = getStatus(account.Sequence + 2)
+	assert.Equal(t, second.ID, status.ID)
+	assert.Equal(t, methods.TransactionSuccess, status.Status)
+	status = getStatus(account.Sequence + 3)
+	assert.Equal(t, methods.TransactionError, status.Status)
+	assert.Equal(t, "tx_not_found", status.Error.Code)
+
+	// submissions rejected before reaching a ledger are found in the transaction store
+	tx, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+		SourceAccount:        &txnbuild.SimpleAccount{AccountID: source.Address(), Sequence: account.Sequence + 9},
+		IncrementSequenceNum: true,
+		Operations:           []txnbuild.Operation{payment},
+		BaseFee:              txnbuild.MinBaseFee,
+		Preconditions:        txnbuild.Preconditions{TimeBounds: txnbuild.NewInfiniteTimeout()},
+	})
+	require.NoError(t, err)
+	tx, err = tx.Sign(StandaloneNetworkPassphrase, source)
+	require.NoError(t, err)
+	txXDR, err := tx.Base64()
+	require.NoError(t, err)
+	rejected := sendTransaction(t, client, txXDR)
+	assert.Equal(t, "tx_bad_seq", rejected.Error.Code)
+	status = getStatus(account.Sequence + 10)
+	assert.Equal(t, rejected.ID, status.ID)
+	assert.Equal(t, "tx_bad_seq", status.Error.Code)
+
+	err = client.CallResult(context.Background(), "getTransactionStatus", methods.GetTransactionStatusRequest{SourceAccount: source.Address()}, &status)
+	assert.Equal(t, code.InvalidParams, err.(*jrpc2.Error).Code)
+	err = client.CallResult(context.Background(), "getTransactionStatus", methods.GetTransactionStatusRequest{SourceAccount: "invalid", Sequence: 1}, &status)
+	assert.Equal(t, code.InvalidParams, err.(*jrpc2.Error).Code)
+}
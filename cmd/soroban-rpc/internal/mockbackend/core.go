@@ -0,0 +1,107 @@
+package mockbackend
+
+import (
+	"net/http"
+
+	proto "github.com/stellar/go/protocols/stellarcore"
+	"github.com/stellar/go/xdr"
+)
+
+const (
+	// PreflightCPUInstructions is the cpu cost reported for every simulated contract invocation
+	PreflightCPUInstructions = 1_000_000
+	// PreflightMemoryBytes is the memory cost reported for every simulated contract invocation
+	PreflightMemoryBytes = 100_000
+)
+
+func (b *Backend) coreHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/info", b.serveInfo)
+	mux.HandleFunc("/getledgerentry", b.serveGetLedgerEntry)
+	mux.HandleFunc("/preflight", b.servePreflight)
+	return mux
+}
+
+func (b *Backend) serveInfo(w http.ResponseWriter, r *http.Request) {
+	b.lock.Lock()
+	latest := b.latestLedger()
+	b.lock.Unlock()
+	var response proto.InfoResponse
+	response.Info.Build = "mockbackend"
+	response.Info.Network = b.networkPassphrase
+	response.Info.ProtocolVersion = ProtocolVersion
+	response.Info.State = "Synced!"
+	response.Info.Ledger = proto.LedgerInfo{
+		BaseFee:      BaseFee,
+		BaseReserve:  BaseReserve,
+		CloseTime:    int(latest.ClosedAt.Unix()),
+		Hash:         latest.Hash,
+		MaxTxSetSize: 100,
+		Num:          int(latest.Sequence),
+		Version:      ProtocolVersion,
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (b *Backend) serveGetLedgerEntry(w http.ResponseWriter, r *http.Request) {
+	var key xdr.LedgerKey
+	if err := xdr.SafeUnmarshalBase64(r.URL.Query().Get("key"), &key); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"exception": "could not decode ledger key"})
+		return
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	response := proto.GetLedgerEntryResponse{
+		State:  proto.DeadState,
+		Ledger: int64(b.latestLedger().Sequence),
+	}
+	if entry, ok := b.entries[mustMarshalKey(key)]; ok {
+		encoded, err := xdr.MarshalBase64(entry)
+		if err != nil {
+			panic(err)
+		}
+		response.State = proto.LiveState
+		response.Entry = encoded
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (b *Backend) servePreflight(w http.ResponseWriter, r *http.Request) {
+	var op xdr.InvokeHostFunctionOp
+	if err := xdr.SafeUnmarshalBase64(r.URL.Query().Get("blob"), &op); err != nil {
+		writeJSON(w, http.StatusOK, proto.PreflightResponse{
+			Status: proto.PreflightStatusError,
+			Detail: "could not decode invoke host function operation",
+		})
+		return
+	}
+	b.lock.Lock()
+	latest := b.latestLedger().Sequence
+	fn, args, err := b.contractFunction(op)
+	b.lock.Unlock()
+	if err != nil {
+		writeJSON(w, http.StatusOK, proto.PreflightResponse{Status: proto.PreflightStatusError, Detail: err.Error()})
+		return
+	}
+	val, err := fn(args)
+	if err != nil {
+		writeJSON(w, http.StatusOK, proto.PreflightResponse{Status: proto.PreflightStatusError, Detail: err.Error()})
+		return
+	}
+	result, err := xdr.MarshalBase64(val)
+	if err != nil {
+		panic(err)
+	}
+	footprint, err := xdr.MarshalBase64(xdr.LedgerFootprint{})
+	if err != nil {
+		panic(err)
+	}
+	writeJSON(w, http.StatusOK, proto.PreflightResponse{
+		Status:          proto.PreflightStatusOk,
+		Result:          result,
+		Footprint:       footprint,
+		CPUInstructions: PreflightCPUInstructions,
+		MemoryBytes:     PreflightMemoryBytes,
+		Ledger:          int64(latest),
+	})
+}
@@ -0,0 +1,272 @@
+package mockbackend
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/protocols/horizon/base"
+	"github.com/stellar/go/support/render/problem"
+	"github.com/stellar/go/xdr"
+)
+
+const (
+	defaultPageLimit = 10
+	maxPageLimit     = 200
+)
+
+var notFound = problem.P{
+	Type:   "not_found",
+	Title:  "Resource Missing",
+	Status: http.StatusNotFound,
+	Detail: "The resource at the url requested was not found.",
+}
+
+func (b *Backend) horizonHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			writeJSON(w, notFound.Status, notFound)
+			return
+		}
+		b.lock.Lock()
+		latest := b.latestLedger()
+		b.lock.Unlock()
+		writeJSON(w, http.StatusOK, horizon.Root{
+			HorizonVersion:               "mockbackend",
+			StellarCoreVersion:           "mockbackend",
+			IngestSequence:               uint32(latest.Sequence),
+			HorizonSequence:              latest.Sequence,
+			HorizonLatestClosedAt:        latest.ClosedAt,
+			HistoryElderSequence:         1,
+			CoreSequence:                 latest.Sequence,
+			NetworkPassphrase:            b.networkPassphrase,
+			CurrentProtocolVersion:       ProtocolVersion,
+			SupportedProtocolVersion:     ProtocolVersion,
+			CoreSupportedProtocolVersion: ProtocolVersion,
+		})
+	})
+	mux.HandleFunc("/accounts/", b.serveAccount)
+	mux.HandleFunc("/fee_stats", b.serveFeeStats)
+	mux.HandleFunc("/ledgers", b.serveLedgers)
+	mux.HandleFunc("/transactions", b.serveSubmitTransaction)
+	mux.HandleFunc("/transactions/", b.serveTransaction)
+	return mux
+}
+
+func (b *Backend) serveAccount(w http.ResponseWriter, r *http.Request) {
+	address, collection, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/accounts/"), "/")
+	var accountID xdr.AccountId
+	if err := accountID.SetAddress(address); err != nil || (collection != "" && collection != "transactions") {
+		writeJSON(w, notFound.Status, notFound)
+		return
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	entry, ok := b.entries[mustMarshalKey(xdr.LedgerKey{
+		Type:    xdr.LedgerEntryTypeAccount,
+		Account: &xdr.LedgerKeyAccount{AccountId: accountID},
+	})]
+	if !ok {
+		writeJSON(w, notFound.Status, notFound)
+		return
+	}
+	if collection == "transactions" {
+		b.serveAccountTransactions(w, r, address)
+		return
+	}
+	account := entry.Data.MustAccount()
+	signers := []horizon.Signer{{
+		Weight: int32(account.Thresholds.MasterKeyWeight()),
+		Key:    address,
+		Type:   "ed25519_public_key",
+	}}
+	for _, signer := range account.Signers {
+		signers = append(signers, horizon.Signer{
+			Weight: int32(signer.Weight),
+			Key:    signer.Key.Address(),
+			Type:   horizonSignerType(signer.Key.Type),
+		})
+	}
+	writeJSON(w, http.StatusOK, horizon.Account{
+		ID:                 address,
+		AccountID:          address,
+		Sequence:           int64(account.SeqNum),
+		SubentryCount:      int32(account.NumSubEntries),
+		LastModifiedLedger: uint32(entry.LastModifiedLedgerSeq),
+		Thresholds: horizon.AccountThresholds{
+			LowThreshold:  account.Thresholds.ThresholdLow(),
+			MedThreshold:  account.Thresholds.ThresholdMedium(),
+			HighThreshold: account.Thresholds.ThresholdHigh(),
+		},
+		Balances: []horizon.Balance{{
+			Balance: amount.String(account.Balance),
+			Asset:   base.Asset{Type: "native"},
+		}},
+		Signers: signers,
+		Data:    map[string]string{},
+		PT:      address,
+	})
+}
+
+func horizonSignerType(signerType xdr.SignerKeyType) string {
+	switch signerType {
+	case xdr.SignerKeyTypeSignerKeyTypePreAuthTx:
+		return "preauth_tx"
+	case xdr.SignerKeyTypeSignerKeyTypeHashX:
+		return "sha256_hash"
+	case xdr.SignerKeyTypeSignerKeyTypeEd25519SignedPayload:
+		return "ed25519_signed_payload"
+	default:
+		return "ed25519_public_key"
+	}
+}
+
+func (b *Backend) serveFeeStats(w http.ResponseWriter, r *http.Request) {
+	b.lock.Lock()
+	latest := b.latestLedger()
+	b.lock.Unlock()
+	fees := horizon.FeeDistribution{
+		Max: BaseFee, Min: BaseFee, Mode: BaseFee,
+		P10: BaseFee, P20: BaseFee, P30: BaseFee, P40: BaseFee, P50: BaseFee,
+		P60: BaseFee, P70: BaseFee, P80: BaseFee, P90: BaseFee, P95: BaseFee, P99: BaseFee,
+	}
+	writeJSON(w, http.StatusOK, horizon.FeeStats{
+		LastLedger:        uint32(latest.Sequence),
+		LastLedgerBaseFee: BaseFee,
+		FeeCharged:        fees,
+		MaxFee:            fees,
+	})
+}
+
+// pageQuery parses the paging parameters of a collection request, as Horizon does
+func pageQuery(r *http.Request) (limit int, cursor int64, descending bool, p *problem.P) {
+	query := r.URL.Query()
+	limit = defaultPageLimit
+	if s := query.Get("limit"); s != "" {
+		var err error
+		if limit, err = strconv.Atoi(s); err != nil || limit < 1 || limit > maxPageLimit {
+			return 0, 0, false, &problem.P{
+				Type:   "bad_request",
+				Title:  "Bad Request",
+				Status: http.StatusBadRequest,
+				Detail: "invalid limit",
+			}
+		}
+	}
+	if s := query.Get("cursor"); s != "" {
+		var err error
+		if cursor, err = strconv.ParseInt(s, 10, 64); err != nil {
+			return 0, 0, false, &problem.P{
+				Type:   "bad_request",
+				Title:  "Bad Request",
+				Status: http.StatusBadRequest,
+				Detail: "invalid cursor",
+			}
+		}
+	}
+	return limit, cursor, query.Get("order") == "desc", nil
+}
+
+// beforeCursor returns true if a record with the given paging token isn't part of a page following cursor
+func beforeCursor(pagingToken string, cursor int64, descending bool) bool {
+	token, _ := strconv.ParseInt(pagingToken, 10, 64)
+	return cursor != 0 && ((descending && token >= cursor) || (!descending && token <= cursor))
+}
+
+func (b *Backend) serveLedgers(w http.ResponseWriter, r *http.Request) {
+	limit, cursor, descending, p := pageQuery(r)
+	if p != nil {
+		writeJSON(w, p.Status, p)
+		return
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	var page horizon.LedgersPage
+	page.Embedded.Records = []horizon.Ledger{}
+	for i := range b.ledgers {
+		ledger := b.ledgers[i]
+		if descending {
+			ledger = b.ledgers[len(b.ledgers)-1-i]
+		}
+		if beforeCursor(ledger.PT, cursor, descending) {
+			continue
+		}
+		page.Embedded.Records = append(page.Embedded.Records, ledger)
+		if len(page.Embedded.Records) == limit {
+			break
+		}
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// serveAccountTransactions serves the transactions the account is the source or the fee source of,
+// it should only be called while the lock is held
+func (b *Backend) serveAccountTransactions(w http.ResponseWriter, r *http.Request, address string) {
+	limit, cursor, descending, p := pageQuery(r)
+	if p != nil {
+		writeJSON(w, p.Status, p)
+		return
+	}
+	var transactions []horizon.Transaction
+	for _, tx := range b.transactions {
+		if tx.Account == address || tx.FeeAccount == address {
+			transactions = append(transactions, tx)
+		}
+	}
+	sort.Slice(transactions, func(i, j int) bool {
+		ti, _ := strconv.ParseInt(transactions[i].PT, 10, 64)
+		tj, _ := strconv.ParseInt(transactions[j].PT, 10, 64)
+		if descending {
+			return ti > tj
+		}
+		return ti < tj
+	})
+	var page horizon.TransactionsPage
+	page.Embedded.Records = []horizon.Transaction{}
+	for _, tx := range transactions {
+		if beforeCursor(tx.PT, cursor, descending) {
+			continue
+		}
+		page.Embedded.Records = append(page.Embedded.Records, tx)
+		if len(page.Embedded.Records) == limit {
+			break
+		}
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+func (b *Backend) serveSubmitTransaction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, notFound.Status, notFound)
+		return
+	}
+	tx, p := b.submitTransaction(r.PostFormValue("tx"))
+	if p != nil {
+		writeJSON(w, p.Status, p)
+		return
+	}
+	writeJSON(w, http.StatusOK, tx)
+}
+
+func (b *Backend) serveTransaction(w http.ResponseWriter, r *http.Request) {
+	b.lock.Lock()
+	tx, ok := b.transactions[strings.TrimPrefix(r.URL.Path, "/transactions/")]
+	b.lock.Unlock()
+	if !ok {
+		writeJSON(w, notFound.Status, notFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, tx)
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
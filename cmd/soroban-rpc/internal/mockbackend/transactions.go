@@ -0,0 +1,362 @@
+package mockbackend
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/support/render/problem"
+	"github.com/stellar/go/toid"
+	"github.com/stellar/go/xdr"
+)
+
+// submitTransaction applies a transaction in a new ledger. Transactions which can't be applied
+// (e.g. with a bad sequence number) are rejected with the same problems Horizon responds with.
+func (b *Backend) submitTransaction(envelopeXDR string) (horizon.Transaction, *problem.P) {
+	var envelope xdr.TransactionEnvelope
+	if err := xdr.SafeUnmarshalBase64(envelopeXDR, &envelope); err != nil {
+		return horizon.Transaction{}, &problem.P{
+			Type:   "transaction_malformed",
+			Title:  "Transaction Malformed",
+			Status: http.StatusBadRequest,
+			Detail: fmt.Sprintf("could not decode transaction envelope: %v", err),
+		}
+	}
+	hash, err := network.HashTransactionInEnvelope(envelope, b.networkPassphrase)
+	if err != nil {
+		return horizon.Transaction{}, &problem.P{
+			Type:   "transaction_malformed",
+			Title:  "Transaction Malformed",
+			Status: http.StatusBadRequest,
+			Detail: fmt.Sprintf("could not hash transaction: %v", err),
+		}
+	}
+	txHash := hex.EncodeToString(hash[:])
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if tx, ok := b.transactions[txHash]; ok {
+		return tx, nil
+	}
+
+	sourceID := envelope.SourceAccount().ToAccountId()
+	source, ok := b.account(sourceID)
+	if !ok {
+		return horizon.Transaction{}, transactionFailed(envelopeXDR, "tx_no_source_account", nil)
+	}
+	if envelope.SeqNum() != int64(source.SeqNum)+1 {
+		return horizon.Transaction{}, transactionFailed(envelopeXDR, "tx_bad_seq", nil)
+	}
+	operations := envelope.Operations()
+	if len(operations) == 0 {
+		return horizon.Transaction{}, transactionFailed(envelopeXDR, "tx_missing_operation", nil)
+	}
+
+	feeSourceID := sourceID
+	maxFee := int64(envelope.Fee())
+	feeCharged := int64(BaseFee * len(operations))
+	if envelope.IsFeeBump() {
+		feeSourceID = envelope.FeeBumpAccount().ToAccountId()
+		maxFee = envelope.FeeBumpFee()
+		feeCharged += BaseFee
+	}
+	if maxFee < feeCharged {
+		return horizon.Transaction{}, transactionFailed(envelopeXDR, "tx_insufficient_fee", nil)
+	}
+	feeSource, ok := b.account(feeSourceID)
+	if !ok {
+		return horizon.Transaction{}, transactionFailed(envelopeXDR, "tx_no_source_account", nil)
+	}
+	if int64(feeSource.Balance) < feeCharged {
+		return horizon.Transaction{}, transactionFailed(envelopeXDR, "tx_insufficient_balance", nil)
+	}
+
+	ledger := b.closeLedger(int32(len(operations)))
+	// the fee and the sequence number are consumed even if the operations fail
+	feeSource.Balance -= xdr.Int64(feeCharged)
+	b.setAccount(feeSource)
+	source, _ = b.account(sourceID)
+	source.SeqNum++
+	b.setAccount(source)
+
+	snapshot := make(map[string]xdr.LedgerEntry, len(b.entries))
+	for key, entry := range b.entries {
+		snapshot[key] = entry
+	}
+	successful := true
+	opResults := make([]xdr.OperationResult, len(operations))
+	for i, op := range operations {
+		opSource := sourceID
+		if op.SourceAccount != nil {
+			opSource = op.SourceAccount.ToAccountId()
+		}
+		opResults[i] = b.applyOperation(opSource, op)
+		if resultCode(opResults[i]) != "op_success" {
+			successful = false
+		}
+	}
+	if !successful {
+		b.entries = snapshot
+	}
+
+	tx := horizon.Transaction{
+		ID:              txHash,
+		PT:              fmt.Sprint(toid.New(ledger.Sequence, 1, 0).ToInt64()),
+		Successful:      successful,
+		Hash:            txHash,
+		Ledger:          ledger.Sequence,
+		LedgerCloseTime: ledger.ClosedAt,
+		Account:         sourceID.Address(),
+		AccountSequence: envelope.SeqNum(),
+		FeeAccount:      feeSourceID.Address(),
+		FeeCharged:      feeCharged,
+		MaxFee:          maxFee,
+		OperationCount:  int32(len(operations)),
+		EnvelopeXdr:     envelopeXDR,
+		MemoType:        "none",
+		Signatures:      encodeSignatures(envelope.Signatures()),
+	}
+	txResult := xdr.TransactionResult{FeeCharged: xdr.Int64(feeCharged)}
+	code := xdr.TransactionResultCodeTxSuccess
+	if !successful {
+		code = xdr.TransactionResultCodeTxFailed
+	}
+	if envelope.IsFeeBump() {
+		innerHash, err := network.HashTransaction(envelope.FeeBump.Tx.InnerTx.MustV1().Tx, b.networkPassphrase)
+		if err != nil {
+			panic(err)
+		}
+		outerCode := xdr.TransactionResultCodeTxFeeBumpInnerSuccess
+		if !successful {
+			outerCode = xdr.TransactionResultCodeTxFeeBumpInnerFailed
+		}
+		txResult.Result = xdr.TransactionResultResult{
+			Code: outerCode,
+			InnerResultPair: &xdr.InnerTransactionResultPair{
+				TransactionHash: innerHash,
+				Result: xdr.InnerTransactionResult{
+					Result: xdr.InnerTransactionResultResult{Code: code, Results: &opResults},
+				},
+			},
+		}
+		tx.FeeBumpTransaction = &horizon.FeeBumpTransaction{
+			Hash:       txHash,
+			Signatures: encodeSignatures(envelope.FeeBumpSignatures()),
+		}
+		tx.InnerTransaction = &horizon.InnerTransaction{
+			Hash:       hex.EncodeToString(innerHash[:]),
+			Signatures: encodeSignatures(envelope.Signatures()),
+			MaxFee:     int64(envelope.Fee()),
+		}
+	} else {
+		txResult.Result = xdr.TransactionResultResult{Code: code, Results: &opResults}
+	}
+	if tx.ResultXdr, err = xdr.MarshalBase64(txResult); err != nil {
+		panic(err)
+	}
+	// ledger entry changes aren't tracked, the meta only describes the operations
+	meta := xdr.TransactionMeta{V: 2, V2: &xdr.TransactionMetaV2{Operations: make([]xdr.OperationMeta, len(operations))}}
+	if tx.ResultMetaXdr, err = xdr.MarshalBase64(meta); err != nil {
+		panic(err)
+	}
+
+	b.transactions[txHash] = tx
+	last := &b.ledgers[len(b.ledgers)-1]
+	if successful {
+		last.SuccessfulTransactionCount++
+		return tx, nil
+	}
+	failedCount := int32(1)
+	last.FailedTransactionCount = &failedCount
+	opCodes := make([]string, len(opResults))
+	for i, result := range opResults {
+		opCodes[i] = resultCode(result)
+	}
+	txCode := "tx_failed"
+	if envelope.IsFeeBump() {
+		txCode = "tx_fee_bump_inner_failed"
+	}
+	p := transactionFailed(envelopeXDR, txCode, opCodes)
+	p.Extras["result_xdr"] = tx.ResultXdr
+	return tx, p
+}
+
+func (b *Backend) applyOperation(sourceID xdr.AccountId, op xdr.Operation) xdr.OperationResult {
+	source, ok := b.account(sourceID)
+	if !ok {
+		return xdr.OperationResult{Code: xdr.OperationResultCodeOpNoAccount}
+	}
+	switch op.Body.Type {
+	case xdr.OperationTypeCreateAccount:
+		body := op.Body.MustCreateAccountOp()
+		code := xdr.CreateAccountResultCodeCreateAccountSuccess
+		if _, exists := b.account(body.Destination); exists {
+			code = xdr.CreateAccountResultCodeCreateAccountAlreadyExist
+		} else if body.StartingBalance < 0 {
+			code = xdr.CreateAccountResultCodeCreateAccountMalformed
+		} else if source.Balance < body.StartingBalance {
+			code = xdr.CreateAccountResultCodeCreateAccountUnderfunded
+		} else {
+			source.Balance -= body.StartingBalance
+			b.setAccount(source)
+			b.setAccount(xdr.AccountEntry{
+				AccountId:  body.Destination,
+				Balance:    body.StartingBalance,
+				SeqNum:     xdr.SequenceNumber(int64(b.latestLedger().Sequence) << 32),
+				Thresholds: xdr.Thresholds{1, 0, 0, 0},
+			})
+		}
+		return xdr.OperationResult{
+			Code: xdr.OperationResultCodeOpInner,
+			Tr: &xdr.OperationResultTr{
+				Type:                xdr.OperationTypeCreateAccount,
+				CreateAccountResult: &xdr.CreateAccountResult{Code: code},
+			},
+		}
+	case xdr.OperationTypePayment:
+		body := op.Body.MustPaymentOp()
+		if body.Asset.Type != xdr.AssetTypeAssetTypeNative {
+			return xdr.OperationResult{Code: xdr.OperationResultCodeOpNotSupported}
+		}
+		code := xdr.PaymentResultCodePaymentSuccess
+		destinationID := body.Destination.ToAccountId()
+		if body.Amount <= 0 {
+			code = xdr.PaymentResultCodePaymentMalformed
+		} else if _, exists := b.account(destinationID); !exists {
+			code = xdr.PaymentResultCodePaymentNoDestination
+		} else if source.Balance < body.Amount {
+			code = xdr.PaymentResultCodePaymentUnderfunded
+		} else {
+			source.Balance -= body.Amount
+			b.setAccount(source)
+			destination, _ := b.account(destinationID)
+			destination.Balance += body.Amount
+			b.setAccount(destination)
+		}
+		return xdr.OperationResult{
+			Code: xdr.OperationResultCodeOpInner,
+			Tr: &xdr.OperationResultTr{
+				Type:          xdr.OperationTypePayment,
+				PaymentResult: &xdr.PaymentResult{Code: code},
+			},
+		}
+	case xdr.OperationTypeInvokeHostFunction:
+		result := xdr.InvokeHostFunctionResult{Code: xdr.InvokeHostFunctionResultCodeInvokeHostFunctionSuccess}
+		if fn, args, err := b.contractFunction(op.Body.MustInvokeHostFunctionOp()); err != nil {
+			result.Code = xdr.InvokeHostFunctionResultCodeInvokeHostFunctionMalformed
+		} else if val, err := fn(args); err != nil {
+			result.Code = xdr.InvokeHostFunctionResultCodeInvokeHostFunctionTrapped
+		} else {
+			result.Success = &val
+		}
+		return xdr.OperationResult{
+			Code: xdr.OperationResultCodeOpInner,
+			Tr: &xdr.OperationResultTr{
+				Type:                     xdr.OperationTypeInvokeHostFunction,
+				InvokeHostFunctionResult: &result,
+			},
+		}
+	default:
+		return xdr.OperationResult{Code: xdr.OperationResultCodeOpNotSupported}
+	}
+}
+
+// contractFunction looks up the registered function called by an invoke contract operation,
+// whose parameters are the contract id, the function name and the function arguments.
+func (b *Backend) contractFunction(op xdr.InvokeHostFunctionOp) (ContractFunc, []xdr.ScVal, error) {
+	if op.Function != xdr.HostFunctionHostFnInvokeContract {
+		return nil, nil, fmt.Errorf("unsupported host function %s", op.Function)
+	}
+	if len(op.Parameters) < 2 {
+		return nil, nil, fmt.Errorf("missing contract id or function name")
+	}
+	obj, ok := op.Parameters[0].GetObj()
+	if !ok || obj == nil {
+		return nil, nil, fmt.Errorf("invalid contract id")
+	}
+	contractID, ok := obj.GetBin()
+	if !ok || len(contractID) != len(xdr.Hash{}) {
+		return nil, nil, fmt.Errorf("invalid contract id")
+	}
+	symbol, ok := op.Parameters[1].GetSym()
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid function name")
+	}
+	var key contractFunctionKey
+	copy(key.contractID[:], contractID)
+	key.function = string(symbol)
+	fn, ok := b.functions[key]
+	if !ok {
+		return nil, nil, fmt.Errorf("function %s is not registered on contract %x", key.function, key.contractID)
+	}
+	return fn, op.Parameters[2:], nil
+}
+
+func transactionFailed(envelopeXDR, txCode string, opCodes []string) *problem.P {
+	codes := map[string]interface{}{"transaction": txCode}
+	if opCodes != nil {
+		codes["operations"] = opCodes
+	}
+	return &problem.P{
+		Type:   "transaction_failed",
+		Title:  "Transaction Failed",
+		Status: http.StatusBadRequest,
+		Detail: "The transaction failed when submitted to the stellar network.",
+		Extras: map[string]interface{}{
+			"envelope_xdr": envelopeXDR,
+			"result_codes": codes,
+		},
+	}
+}
+
+// resultCode returns the Horizon result code of an operation result
+func resultCode(result xdr.OperationResult) string {
+	switch result.Code {
+	case xdr.OperationResultCodeOpInner:
+	case xdr.OperationResultCodeOpNoAccount:
+		return "op_no_source_account"
+	case xdr.OperationResultCodeOpNotSupported:
+		return "op_not_supported"
+	default:
+		return "op_failed"
+	}
+	switch result.Tr.Type {
+	case xdr.OperationTypeCreateAccount:
+		switch result.Tr.CreateAccountResult.Code {
+		case xdr.CreateAccountResultCodeCreateAccountSuccess:
+			return "op_success"
+		case xdr.CreateAccountResultCodeCreateAccountAlreadyExist:
+			return "op_already_exists"
+		case xdr.CreateAccountResultCodeCreateAccountUnderfunded:
+			return "op_underfunded"
+		}
+	case xdr.OperationTypePayment:
+		switch result.Tr.PaymentResult.Code {
+		case xdr.PaymentResultCodePaymentSuccess:
+			return "op_success"
+		case xdr.PaymentResultCodePaymentNoDestination:
+			return "op_no_destination"
+		case xdr.PaymentResultCodePaymentUnderfunded:
+			return "op_underfunded"
+		}
+	case xdr.OperationTypeInvokeHostFunction:
+		switch result.Tr.InvokeHostFunctionResult.Code {
+		case xdr.InvokeHostFunctionResultCodeInvokeHostFunctionSuccess:
+			return "op_success"
+		case xdr.InvokeHostFunctionResultCodeInvokeHostFunctionTrapped:
+			return "op_trapped"
+		}
+	}
+	return "op_malformed"
+}
+
+func encodeSignatures(signatures []xdr.DecoratedSignature) []string {
+	encoded := make([]string, len(signatures))
+	for i, signature := range signatures {
+		encoded[i] = base64.StdEncoding.EncodeToString(signature.Signature)
+	}
+	return encoded
+}
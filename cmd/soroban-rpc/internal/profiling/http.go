@@ -0,0 +1,86 @@
+package profiling
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stellar/go/support/log"
+)
+
+// CaptureRequest is the body of the requests to capture a profile
+type CaptureRequest struct {
+	Type string `json:"type"`
+	// DurationSeconds is the duration of CPU profiles
+	DurationSeconds int `json:"durationSeconds"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Handler returns an http.Handler serving the profile capture API, which is meant to be mounted under /profiles:
+//
+//	POST /profiles       captures a profile, described by a CaptureRequest body
+//	GET  /profiles       lists the stored profiles
+//	GET  /profiles/<id>  downloads a stored profile (in pprof format)
+func Handler(logger *log.Entry, capturer *Capturer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/profiles"), "/")
+		switch {
+		case id == "" && r.Method == http.MethodPost:
+			var request CaptureRequest
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				writeJSON(logger, w, http.StatusBadRequest, errorResponse{Error: "cannot parse request: " + err.Error()})
+				return
+			}
+			duration := time.Duration(request.DurationSeconds) * time.Second
+			profile, err := capturer.Capture(r.Context(), request.Type, duration)
+			if err != nil {
+				status := http.StatusInternalServerError
+				switch {
+				case errors.Is(err, ErrUnknownProfileType), errors.Is(err, ErrInvalidDuration):
+					status = http.StatusBadRequest
+				case errors.Is(err, ErrCPUProfileInProgress):
+					status = http.StatusConflict
+				}
+				logger.WithError(err).WithField("type", request.Type).Info("could not capture profile")
+				writeJSON(logger, w, status, errorResponse{Error: err.Error()})
+				return
+			}
+			writeJSON(logger, w, http.StatusOK, profile)
+		case id == "" && r.Method == http.MethodGet:
+			writeJSON(logger, w, http.StatusOK, capturer.Profiles())
+		case r.Method == http.MethodGet:
+			profileID, err := strconv.Atoi(id)
+			if err != nil {
+				writeJSON(logger, w, http.StatusBadRequest, errorResponse{Error: "invalid profile id"})
+				return
+			}
+			profile, data, ok := capturer.Get(profileID)
+			if !ok {
+				writeJSON(logger, w, http.StatusNotFound, errorResponse{Error: "profile not found"})
+				return
+			}
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%d.pprof"`, profile.Type, profile.ID))
+			if _, err := w.Write(data); err != nil {
+				logger.WithError(err).Warn("could not write profile")
+			}
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func writeJSON(logger *log.Entry, w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		logger.WithError(err).Warn("could not write response")
+	}
+}
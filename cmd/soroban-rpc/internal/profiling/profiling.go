@@ -0,0 +1,174 @@
+package profiling
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/metrics"
+)
+
+const (
+	ProfileTypeCPU       = "cpu"
+	ProfileTypeHeap      = "heap"
+	ProfileTypeGoroutine = "goroutine"
+)
+
+// MaxCPUProfileDuration is the maximum duration of a CPU profile capture
+const MaxCPUProfileDuration = 2 * time.Minute
+
+// maxStoredProfiles is the number of captured profiles kept in memory,
+// older profiles are discarded first
+const maxStoredProfiles = 10
+
+var (
+	ErrUnknownProfileType     = errors.New("unknown profile type")
+	ErrInvalidDuration        = fmt.Errorf("cpu profile duration must be positive and at most %v", MaxCPUProfileDuration)
+	ErrCPUProfileInProgress   = errors.New("a cpu profile is already being captured")
+	ErrProfileCaptureCanceled = errors.New("profile capture canceled")
+)
+
+// Profile describes a captured profile
+type Profile struct {
+	ID        int       `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Duration is the duration (in seconds) of the capture, it is only set for CPU profiles
+	Duration float64 `json:"durationSeconds,omitempty"`
+	Size     int     `json:"size"`
+	data     []byte
+}
+
+// Capturer captures profiles on demand and keeps the latest ones in memory
+// so that they can be downloaded later.
+type Capturer struct {
+	lock     sync.Mutex
+	profiles []Profile
+	nextID   int
+	// cpuProfiling is set while a CPU profile is being captured, since the runtime
+	// only supports one CPU profile at a time
+	cpuProfiling bool
+	captures     *prometheus.CounterVec
+}
+
+// NewCapturer creates a Capturer and registers its metrics in the registry
+func NewCapturer(registry *prometheus.Registry) *Capturer {
+	captures := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.PrometheusNamespace,
+		Subsystem: "profiling",
+		Name:      "captures_total",
+		Help:      "number of on-demand profile captures, by profile type and result",
+	}, []string{"type", "result"})
+	registry.MustRegister(captures)
+	return &Capturer{captures: captures, nextID: 1}
+}
+
+// Capture captures a profile of the given type. CPU profiles are captured for the given duration,
+// which is ignored for other profile types. The capture is stopped early if ctx is canceled.
+func (c *Capturer) Capture(ctx context.Context, profileType string, duration time.Duration) (Profile, error) {
+	profile, err := c.capture(ctx, profileType, duration)
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	// the type is requested by the clients, unknown types share a label to bound the number of series
+	typeLabel := profileType
+	if errors.Is(err, ErrUnknownProfileType) {
+		typeLabel = "unknown"
+	}
+	c.captures.With(prometheus.Labels{"type": typeLabel, "result": result}).Inc()
+	return profile, err
+}
+
+func (c *Capturer) capture(ctx context.Context, profileType string, duration time.Duration) (Profile, error) {
+	profile := Profile{Type: profileType, CreatedAt: time.Now()}
+	var buf bytes.Buffer
+	switch profileType {
+	case ProfileTypeCPU:
+		if duration <= 0 || duration > MaxCPUProfileDuration {
+			return Profile{}, ErrInvalidDuration
+		}
+		if err := c.captureCPU(ctx, &buf, duration); err != nil {
+			return Profile{}, err
+		}
+		profile.Duration = duration.Seconds()
+	case ProfileTypeHeap, ProfileTypeGoroutine:
+		if profileType == ProfileTypeHeap {
+			// Make sure the profile reflects the live objects
+			runtime.GC()
+		}
+		if err := pprof.Lookup(profileType).WriteTo(&buf, 0); err != nil {
+			return Profile{}, err
+		}
+	default:
+		return Profile{}, ErrUnknownProfileType
+	}
+	profile.data = buf.Bytes()
+	profile.Size = buf.Len()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	profile.ID = c.nextID
+	c.nextID++
+	c.profiles = append(c.profiles, profile)
+	if len(c.profiles) > maxStoredProfiles {
+		c.profiles = c.profiles[len(c.profiles)-maxStoredProfiles:]
+	}
+	return profile, nil
+}
+
+func (c *Capturer) captureCPU(ctx context.Context, buf *bytes.Buffer, duration time.Duration) error {
+	c.lock.Lock()
+	if c.cpuProfiling {
+		c.lock.Unlock()
+		return ErrCPUProfileInProgress
+	}
+	c.cpuProfiling = true
+	c.lock.Unlock()
+	defer func() {
+		c.lock.Lock()
+		c.cpuProfiling = false
+		c.lock.Unlock()
+	}()
+
+	if err := pprof.StartCPUProfile(buf); err != nil {
+		// a CPU profile started through the pprof endpoints may be in progress
+		return ErrCPUProfileInProgress
+	}
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		pprof.StopCPUProfile()
+		return nil
+	case <-ctx.Done():
+		pprof.StopCPUProfile()
+		return ErrProfileCaptureCanceled
+	}
+}
+
+// Profiles returns the stored profiles, oldest first
+func (c *Capturer) Profiles() []Profile {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]Profile(nil), c.profiles...)
+}
+
+// Get returns the stored profile with the given id and its pprof-encoded data
+func (c *Capturer) Get(id int) (Profile, []byte, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, profile := range c.profiles {
+		if profile.ID == id {
+			return profile, profile.data, true
+		}
+	}
+	return Profile{}, nil, false
+}
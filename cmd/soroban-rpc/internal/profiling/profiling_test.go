@@ -0,0 +1,86 @@
+package profiling
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stellar/go/support/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapture(t *testing.T) {
+	capturer := NewCapturer(prometheus.NewRegistry())
+
+	profile, err := capturer.Capture(context.Background(), ProfileTypeGoroutine, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, profile.ID)
+	assert.Greater(t, profile.Size, 0)
+
+	profile, err = capturer.Capture(context.Background(), ProfileTypeCPU, 10*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, 2, profile.ID)
+	assert.Equal(t, 0.01, profile.Duration)
+
+	_, err = capturer.Capture(context.Background(), ProfileTypeCPU, time.Hour)
+	assert.ErrorIs(t, err, ErrInvalidDuration)
+	_, err = capturer.Capture(context.Background(), "threadcreate", 0)
+	assert.ErrorIs(t, err, ErrUnknownProfileType)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = capturer.Capture(ctx, ProfileTypeCPU, time.Minute)
+	assert.ErrorIs(t, err, ErrProfileCaptureCanceled)
+
+	assert.Len(t, capturer.Profiles(), 2)
+	assert.Equal(t, 2.0, testutil.ToFloat64(capturer.captures.WithLabelValues(ProfileTypeCPU, "error")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(capturer.captures.WithLabelValues(ProfileTypeCPU, "success")))
+	// unknown profile types don't create their own series
+	assert.Equal(t, 1.0, testutil.ToFloat64(capturer.captures.WithLabelValues("unknown", "error")))
+	assert.Equal(t, 4, testutil.CollectAndCount(capturer.captures))
+}
+
+func TestCaptureKeepsLatestProfiles(t *testing.T) {
+	capturer := NewCapturer(prometheus.NewRegistry())
+	for i := 0; i < maxStoredProfiles+2; i++ {
+		_, err := capturer.Capture(context.Background(), ProfileTypeGoroutine, 0)
+		require.NoError(t, err)
+	}
+	profiles := capturer.Profiles()
+	require.Len(t, profiles, maxStoredProfiles)
+	assert.Equal(t, 3, profiles[0].ID)
+	_, _, ok := capturer.Get(1)
+	assert.False(t, ok)
+}
+
+func TestHandler(t *testing.T) {
+	handler := Handler(log.DefaultLogger, NewCapturer(prometheus.NewRegistry()))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/profiles", strings.NewReader(`{"type":"heap"}`)))
+	require.Equal(t, http.StatusOK, w.Code)
+	var profile Profile
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&profile))
+	assert.Equal(t, ProfileTypeHeap, profile.Type)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/profiles/1", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, profile.Size, w.Body.Len())
+	assert.Equal(t, `attachment; filename="heap-1.pprof"`, w.Header().Get("Content-Disposition"))
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/profiles/2", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/profiles", strings.NewReader(`{"type":"cpu","durationSeconds":0}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,50 @@
+// Package rpcerror defines the JSON-RPC error codes returned by soroban-rpc, on top of the
+// ones defined by the JSON-RPC specification (see github.com/creachadair/jrpc2/code).
+// Clients should branch on these codes and on the error data instead of parsing error messages.
+package rpcerror
+
+import (
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/code"
+)
+
+const (
+	// NotFound is returned when the requested ledger entry, account or transaction doesn't exist
+	NotFound code.Code = -32001
+	// SimulationFailed is returned when the simulation of a transaction fails
+	SimulationFailed code.Code = -32003
+	// UpstreamUnavailable is returned when Horizon or Stellar Core could not serve the request.
+	// The data of the error contains the name of the service under the "upstream" key.
+	UpstreamUnavailable code.Code = -32004
+	// PreflightRejected is returned when a simulation is rejected before or during its execution,
+	// because the preflight queue is full or the preflight budget is exceeded
+	PreflightRejected code.Code = -32005
+
+	// RateLimitExceeded is returned when a request is rejected by a rate limiter.
+	// The data of the error contains the number of seconds to wait under the "retryAfter" key.
+	RateLimitExceeded code.Code = -32029
+	// RequestTooLarge is returned when the request body exceeds the maximum size
+	RequestTooLarge code.Code = -32030
+	// ResponseTooLarge is returned when the result of a method exceeds the maximum size
+	ResponseTooLarge code.Code = -32031
+	// Unauthorized is returned when a request doesn't provide a valid API key
+	Unauthorized code.Code = -32032
+	// MethodNotAllowed is returned when the API key of a request isn't allowed to call the requested method,
+	// or when the method is disabled on the server
+	MethodNotAllowed code.Code = -32033
+	// Forbidden is returned when the IP of the client isn't allowed by the IP allow and deny lists
+	Forbidden code.Code = -32034
+)
+
+const (
+	UpstreamHorizon     = "horizon"
+	UpstreamStellarCore = "stellar_core"
+)
+
+// NewUpstreamUnavailable returns an UpstreamUnavailable error for the given upstream service
+func NewUpstreamUnavailable(upstream string, message string) *jrpc2.Error {
+	return (&jrpc2.Error{
+		Code:    UpstreamUnavailable,
+		Message: message,
+	}).WithData(map[string]string{"upstream": upstream})
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/methods"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/rpcerror"
 )
 
 func TestAccount(t *testing.T) {
@@ -39,3 +40,32 @@ func TestAccount(t *testing.T) {
 		string(err.Data),
 	)
 }
+
+func TestGetAccountInfo(t *testing.T) {
+	test := NewTest(t)
+
+	ch := jhttp.NewChannel(test.server.URL, nil)
+	client := jrpc2.NewClient(ch, nil)
+
+	request := methods.GetAccountInfoRequest{
+		Address: keypair.Master(StandaloneNetworkPassphrase).Address(),
+	}
+	var result methods.GetAccountInfoResponse
+	if err := client.CallResult(context.Background(), "getAccountInfo", request, &result); err != nil {
+		t.Fatalf("rpc call failed: %v", err)
+	}
+	assert.Equal(t, request.Address, result.ID)
+	assert.Equal(t, int64(0), result.Sequence)
+	assert.Greater(t, result.Balance, int64(0))
+	assert.Equal(t, uint8(1), result.Thresholds.MasterWeight)
+	assert.Empty(t, result.Signers)
+	assert.Greater(t, result.LatestLedger, int64(0))
+
+	request.Address = keypair.MustRandom().Address()
+	err := client.CallResult(context.Background(), "getAccountInfo", request, &result).(*jrpc2.Error)
+	assert.Equal(t, rpcerror.NotFound, err.Code)
+
+	request.Address = "invalid"
+	err = client.CallResult(context.Background(), "getAccountInfo", request, &result).(*jrpc2.Error)
+	assert.Equal(t, code.InvalidParams, err.Code)
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/stellar/go/txnbuild"
 	"github.com/stellar/go/xdr"
 	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/methods"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/rpcerror"
 )
 
 func TestGetContractDataNotFound(t *testing.T) {
@@ -37,7 +38,7 @@ func TestGetContractDataNotFound(t *testing.T) {
 	var result methods.GetContractDataResponse
 	jsonRPCErr := client.CallResult(context.Background(), "getContractData", request, &result).(*jrpc2.Error)
 	assert.Equal(t, "not found", jsonRPCErr.Message)
-	assert.Equal(t, code.InvalidRequest, jsonRPCErr.Code)
+	assert.Equal(t, rpcerror.NotFound, jsonRPCErr.Code)
 }
 
 func TestGetContractDataInvalidParams(t *testing.T) {
@@ -92,7 +93,8 @@ func TestGetContractDataDeadlineError(t *testing.T) {
 	var result methods.GetContractDataResponse
 	jsonRPCErr := client.CallResult(context.Background(), "getContractData", request, &result).(*jrpc2.Error)
 	assert.Equal(t, "could not submit request to core", jsonRPCErr.Message)
-	assert.Equal(t, code.InternalError, jsonRPCErr.Code)
+	assert.Equal(t, rpcerror.UpstreamUnavailable, jsonRPCErr.Code)
+	assert.JSONEq(t, `{"upstream":"stellar_core"}`, string(jsonRPCErr.Data))
 }
 
 func TestGetContractDataSucceeds(t *testing.T) {
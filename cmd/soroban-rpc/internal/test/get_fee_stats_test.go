@@ -0,0 +1,27 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/jhttp"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/methods"
+)
+
+func TestGetFeeStats(t *testing.T) {
+	test := NewTest(t)
+
+	ch := jhttp.NewChannel(test.server.URL, nil)
+	client := jrpc2.NewClient(ch, nil)
+
+	var result methods.GetFeeStatsResponse
+	if err := client.CallResult(context.Background(), "getFeeStats", nil, &result); err != nil {
+		t.Fatalf("rpc call failed: %v", err)
+	}
+	assert.Greater(t, result.LatestLedger, int64(0))
+	assert.Greater(t, result.LatestLedgerBaseFee, int64(0))
+	assert.LessOrEqual(t, result.InclusionFeeCharged.Min, result.InclusionFeeCharged.Max)
+}
@@ -0,0 +1,47 @@
+package test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/jhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/methods"
+)
+
+func TestGetLatestLedger(t *testing.T) {
+	test := NewTest(t)
+
+	ch := jhttp.NewChannel(test.server.URL, nil)
+	client := jrpc2.NewClient(ch, nil)
+
+	var result methods.GetLatestLedgerResponse
+	if err := client.CallResult(context.Background(), "getLatestLedger", nil, &result); err != nil {
+		t.Fatalf("rpc call failed: %v", err)
+	}
+	assert.Greater(t, result.Sequence, uint32(0))
+	assert.Greater(t, result.CloseTime, int64(0))
+	assert.Empty(t, result.RecentLedgers)
+
+	request := methods.GetLatestLedgerRequest{Window: 3}
+	if err := client.CallResult(context.Background(), "getLatestLedger", request, &result); err != nil {
+		t.Fatalf("rpc call failed: %v", err)
+	}
+	require.Len(t, result.RecentLedgers, 3)
+	for i, ledger := range result.RecentLedgers {
+		header, err := base64.StdEncoding.DecodeString(ledger.HeaderXDR)
+		require.NoError(t, err)
+		hash := sha256.Sum256(header)
+		assert.Equal(t, ledger.Hash, hex.EncodeToString(hash[:]))
+		if i > 0 {
+			assert.Equal(t, result.RecentLedgers[i-1].PreviousHash, ledger.Hash)
+			assert.Equal(t, result.RecentLedgers[i-1].Sequence-1, ledger.Sequence)
+		}
+	}
+}
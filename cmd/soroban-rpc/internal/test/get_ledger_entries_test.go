@@ -0,0 +1,73 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/code"
+	"github.com/creachadair/jrpc2/jhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/xdr"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/methods"
+)
+
+func TestGetLedgerEntriesPartialResults(t *testing.T) {
+	test := NewTest(t)
+
+	ch := jhttp.NewChannel(test.server.URL, nil)
+	client := jrpc2.NewClient(ch, nil)
+
+	sourceAccount := keypair.Root(StandaloneNetworkPassphrase).Address()
+	accountKeyB64, err := xdr.MarshalBase64(xdr.LedgerKey{
+		Type: xdr.LedgerEntryTypeAccount,
+		Account: &xdr.LedgerKeyAccount{
+			AccountId: xdr.MustAddress(sourceAccount),
+		},
+	})
+	require.NoError(t, err)
+	contractDataKeyB64, err := xdr.MarshalBase64(xdr.LedgerKey{
+		Type: xdr.LedgerEntryTypeContractData,
+		ContractData: &xdr.LedgerKeyContractData{
+			ContractId: getContractID(t, sourceAccount, testSalt),
+			Key:        getContractCodeLedgerKey(),
+		},
+	})
+	require.NoError(t, err)
+
+	request := methods.GetLedgerEntriesRequest{
+		Keys: []string{accountKeyB64, contractDataKeyB64, "@#$!@#!@#"},
+	}
+	var result methods.GetLedgerEntriesResponse
+	err = client.CallResult(context.Background(), "getLedgerEntries", request, &result)
+	require.NoError(t, err)
+	assert.Greater(t, result.LatestLedger, int64(0))
+	require.Len(t, result.Entries, 3)
+
+	account := result.Entries[0]
+	assert.Equal(t, accountKeyB64, account.Key)
+	assert.False(t, account.NotFound)
+	assert.Empty(t, account.Error)
+	var entryData xdr.LedgerEntryData
+	assert.NoError(t, xdr.SafeUnmarshalBase64(account.XDR, &entryData))
+	assert.Equal(t, xdr.LedgerEntryTypeAccount, entryData.Type)
+
+	assert.Equal(t, methods.LedgerEntryResult{Key: contractDataKeyB64, NotFound: true}, result.Entries[1])
+	assert.Equal(t, "cannot unmarshal ledger key", result.Entries[2].Error)
+}
+
+func TestGetLedgerEntriesInvalidParams(t *testing.T) {
+	test := NewTest(t)
+
+	ch := jhttp.NewChannel(test.server.URL, nil)
+	client := jrpc2.NewClient(ch, nil)
+
+	var result methods.GetLedgerEntriesResponse
+	request := methods.GetLedgerEntriesRequest{}
+	jsonRPCErr := client.CallResult(context.Background(), "getLedgerEntries", request, &result).(*jrpc2.Error)
+	assert.Equal(t, "no keys provided", jsonRPCErr.Message)
+	assert.Equal(t, code.InvalidParams, jsonRPCErr.Code)
+}
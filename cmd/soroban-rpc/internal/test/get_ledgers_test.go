@@ -0,0 +1,39 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/jhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/methods"
+)
+
+func TestGetLedgers(t *testing.T) {
+	test := NewTest(t)
+
+	ch := jhttp.NewChannel(test.server.URL, nil)
+	client := jrpc2.NewClient(ch, nil)
+
+	var result methods.GetLedgersResponse
+	request := methods.GetLedgersRequest{StartLedger: 2, Limit: 2}
+	if err := client.CallResult(context.Background(), "getLedgers", request, &result); err != nil {
+		t.Fatalf("rpc call failed: %v", err)
+	}
+	require.Len(t, result.Ledgers, 2)
+	assert.Equal(t, uint32(2), result.Ledgers[0].Sequence)
+	assert.Equal(t, uint32(3), result.Ledgers[1].Sequence)
+	assert.Equal(t, result.Ledgers[0].Hash, result.Ledgers[1].PreviousHash)
+	assert.NotEmpty(t, result.Cursor)
+
+	request = methods.GetLedgersRequest{Cursor: result.Cursor, Limit: 2, EndLedger: 4}
+	if err := client.CallResult(context.Background(), "getLedgers", request, &result); err != nil {
+		t.Fatalf("rpc call failed: %v", err)
+	}
+	require.Len(t, result.Ledgers, 1)
+	assert.Equal(t, uint32(4), result.Ledgers[0].Sequence)
+	assert.Empty(t, result.Cursor)
+}
@@ -0,0 +1,29 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/jhttp"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/methods"
+)
+
+func TestGetNetwork(t *testing.T) {
+	test := NewTest(t)
+
+	ch := jhttp.NewChannel(test.server.URL, nil)
+	client := jrpc2.NewClient(ch, nil)
+
+	var result methods.GetNetworkResponse
+	if err := client.CallResult(context.Background(), "getNetwork", nil, &result); err != nil {
+		t.Fatalf("rpc call failed: %v", err)
+	}
+	assert.Equal(t, StandaloneNetworkPassphrase, result.Passphrase)
+	assert.Equal(t, stellarCoreProtocolVersion, result.ProtocolVersion)
+	assert.Greater(t, result.BaseFee, int64(0))
+	assert.Greater(t, result.BaseReserve, int64(0))
+	assert.Greater(t, result.LatestLedger, int64(0))
+}
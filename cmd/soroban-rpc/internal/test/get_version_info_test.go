@@ -0,0 +1,29 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/jhttp"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/methods"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/version"
+)
+
+func TestGetVersionInfo(t *testing.T) {
+	test := NewTest(t)
+
+	ch := jhttp.NewChannel(test.server.URL, nil)
+	client := jrpc2.NewClient(ch, nil)
+
+	var result methods.GetVersionInfoResponse
+	if err := client.CallResult(context.Background(), "getVersionInfo", nil, &result); err != nil {
+		t.Fatalf("rpc call failed: %v", err)
+	}
+	assert.Equal(t, version.Version, result.Version)
+	assert.NotEmpty(t, result.CoreVersion)
+	assert.Equal(t, stellarCoreProtocolVersion, result.ProtocolVersion)
+	assert.GreaterOrEqual(t, result.CoreMaxProtocolVersion, result.ProtocolVersion)
+}
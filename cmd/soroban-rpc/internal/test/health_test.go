@@ -20,5 +20,9 @@ func TestHealth(t *testing.T) {
 	if err := client.CallResult(context.Background(), "getHealth", nil, &result); err != nil {
 		t.Fatalf("rpc call failed: %v", err)
 	}
-	assert.Equal(t, methods.HealthCheckResult{Status: "healthy"}, result)
+	assert.Equal(t, methods.HealthStatusHealthy, result.Status)
+	assert.True(t, result.CoreSynced)
+	assert.Equal(t, methods.HealthStatusHealthy, result.Horizon.Status)
+	assert.Equal(t, methods.HealthStatusHealthy, result.StellarCore.Status)
+	assert.Greater(t, result.LatestLedger, int64(0))
 }
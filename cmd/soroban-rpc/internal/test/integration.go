@@ -29,6 +29,7 @@ import (
 	"github.com/stellar/go/support/log"
 	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal"
 	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/methods"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/metrics"
 
 	"golang.org/x/mod/modfile"
 )
@@ -104,6 +105,10 @@ func (i *Test) configureJSONRPCServer() {
 		10,
 		StandaloneNetworkPassphrase,
 		2*time.Minute,
+		methods.NewMemoryTransactionStore(),
+		methods.NewWebhookNotifier(logger, 2, 10, 10*time.Second),
+		methods.SubmissionRetryPolicy{MaxAttempts: 3, Backoff: time.Second},
+		nil,
 	)
 
 	var err error
@@ -111,9 +116,13 @@ func (i *Test) configureJSONRPCServer() {
 		AccountStore: methods.AccountStore{
 			Client: i.horizonClient,
 		},
-		TransactionProxy: proxy,
-		CoreClient:       i.coreClient,
-		Logger:           logger,
+		TransactionProxy:  proxy,
+		PreflightQueue:    methods.NewPreflightQueue(10, 10, time.Minute),
+		MetricsRegistry:   metrics.NewRegistry(StandaloneNetworkPassphrase),
+		HorizonClient:     i.horizonClient,
+		CoreClient:        i.coreClient,
+		Logger:            logger,
+		NetworkPassphrase: StandaloneNetworkPassphrase,
 	})
 	if err != nil {
 		i.t.Fatalf("cannot create handler: %v", err)
@@ -0,0 +1,35 @@
+package version
+
+import (
+	"runtime/debug"
+)
+
+// The build information is set at link time, e.g.:
+//
+//	go build -ldflags "-X github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/version.Version=0.1.0 ..."
+//
+// When not set, CommitHash and BuildTimestamp default to the VCS information embedded by the Go toolchain.
+var (
+	Version        = "devel"
+	CommitHash     = ""
+	BuildTimestamp = ""
+)
+
+func init() {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if CommitHash == "" {
+				CommitHash = setting.Value
+			}
+		case "vcs.time":
+			if BuildTimestamp == "" {
+				BuildTimestamp = setting.Value
+			}
+		}
+	}
+}
@@ -3,38 +3,133 @@ package main
 import (
 	"fmt"
 	"go/types"
-	"net/http"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
-	"github.com/stellar/go/clients/horizonclient"
-	"github.com/stellar/go/clients/stellarcore"
 	"github.com/stellar/go/network"
 	"github.com/stellar/go/support/config"
-	supporthttp "github.com/stellar/go/support/http"
 	supportlog "github.com/stellar/go/support/log"
-	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/daemon"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/logging"
 	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/methods"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/metrics"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/middleware"
+	"github.com/stellar/soroban-tools/cmd/soroban-rpc/internal/tracing"
 )
 
 func main() {
-	var endpoint, horizonURL, stellarCoreURL, networkPassphrase string
-	var txConcurrency, txQueueSize int
+	var configPath string
+	var tlsCertFile, tlsKeyFile, corsAllowedOrigins string
+	var internalEndpoints string
+	var endpoint, adminEndpoint, horizonURL, stellarCoreURL, networkPassphrase string
+	var txConcurrency, txQueueSize, txSubmissionMaxAttempts int
+	var txSubmissionBackoff time.Duration
+	var txSubmissionHorizonURLs string
+	var txSubmissionProbeInterval time.Duration
+	var txWebhooksEnabled bool
+	var txWebhookTimeout time.Duration
+	var txWebhookAllowPrivate bool
+	var txStatusMaxWait time.Duration
+	var txStoreSnapshotFile string
+	var txStoreSnapshotInterval time.Duration
+	var txStoreMemoryLimit uint
+	var preflightConcurrency, preflightQueueSize int
+	var preflightTimeout, preflightExecutionTimeout time.Duration
+	var preflightCPUInstructionsLimit, preflightMemoryLimit uint
+	var maxHealthyLedgerLatency time.Duration
+	var shutdownGracePeriod time.Duration
+	var maxBatchSize, maxRequestConcurrency int
+	var maxRequestSize, maxResponseSize int
+	var methodRateLimits, methodTimeouts string
+	var enabledMethods, disabledMethods string
+	var readOnly bool
+	var ipRateLimit float64
+	var apiKeysFile, apiKeys string
+	var ipAllowList, ipDenyList, trustedProxies string
+	var requestLogSampleRatio float64
+	var slowRequestThreshold time.Duration
+	var responseCacheSize int
+	var responseCacheTTL time.Duration
+	var contractStatsSize, contractStatsMetricsTop int
+	var httpGetMethods string
+	var responseCompression string
+	var ledgerFreshnessInterval time.Duration
+	var ledgerFreshnessSLOs string
+	var responseCompressionMinSize int
+	var httpGetMaxAge time.Duration
+	var tracingConfig tracing.Config
+	var histogramConfig metrics.HistogramConfig
+	var classicBuckets bool
 	var logLevel logrus.Level
+	var loggingConfig logging.Config
+	var logFileMaxSize int
 	logger := supportlog.New()
 
 	configOpts := config.ConfigOptions{
+		{
+			Name:        "config-path",
+			Usage:       "path to a TOML file with the configuration (e.g. log-level = \"debug\"). command line flags and environment variables take precedence over the file. The dynamic settings of the file (log-level, method-rate-limits and ip-rate-limit) are reloaded on SIGHUP",
+			OptType:     types.String,
+			ConfigKey:   &configPath,
+			FlagDefault: "",
+			Required:    false,
+		},
 		{
 			Name:        "endpoint",
-			Usage:       "Endpoint to listen and serve on",
+			Usage:       "Comma separated list of endpoints to listen and serve on (e.g. 0.0.0.0:8000,[::]:8000): TCP addresses, Unix domain sockets (unix:<path>) or sockets passed by systemd socket activation (systemd:<FileDescriptorName or index>)",
 			OptType:     types.String,
 			ConfigKey:   &endpoint,
 			FlagDefault: "localhost:8000",
 			Required:    false,
 		},
+		{
+			Name:        "internal-endpoints",
+			Usage:       "Comma separated list of endpoints (with the same format as --endpoint) serving the JSON RPC requests without rate limits, API keys nor IP lists. WARNING: they should only be accessible to trusted clients",
+			OptType:     types.String,
+			ConfigKey:   &internalEndpoints,
+			FlagDefault: "",
+			Required:    false,
+		},
+		{
+			Name:        "admin-endpoint",
+			Usage:       "Admin endpoint to listen and serve on, with the same format as --endpoint. WARNING: this should not be accessible from the Internet and does not use TLS. \"\" (default) disables the admin server",
+			OptType:     types.String,
+			ConfigKey:   &adminEndpoint,
+			FlagDefault: "",
+			Required:    false,
+		},
+		{
+			Name:        "tls-cert-file",
+			Usage:       "path to the TLS certificate file used to serve the endpoint over HTTPS (requires --tls-key-file)",
+			OptType:     types.String,
+			ConfigKey:   &tlsCertFile,
+			FlagDefault: "",
+			Required:    false,
+		},
+		{
+			Name:        "tls-key-file",
+			Usage:       "path to the TLS private key file used to serve the endpoint over HTTPS (requires --tls-cert-file)",
+			OptType:     types.String,
+			ConfigKey:   &tlsKeyFile,
+			FlagDefault: "",
+			Required:    false,
+		},
+		{
+			Name:        "cors-allowed-origins",
+			Usage:       "comma separated list of origins browsers are allowed to send requests from (e.g. https://example.com). \"*\" allows all origins",
+			OptType:     types.String,
+			ConfigKey:   &corsAllowedOrigins,
+			FlagDefault: "*",
+			Required:    false,
+		},
 		&config.ConfigOption{
 			Name:        "horizon-url",
 			ConfigKey:   &horizonURL,
@@ -66,6 +161,46 @@ func main() {
 			},
 			Usage: "minimum log severity (debug, info, warn, error) to log",
 		},
+		{
+			Name:        "log-format",
+			Usage:       "format of the logs, text or json",
+			OptType:     types.String,
+			ConfigKey:   &loggingConfig.Format,
+			FlagDefault: logging.FormatText,
+			Required:    false,
+		},
+		{
+			Name:        "log-file",
+			Usage:       "file the logs are written to instead of stderr",
+			OptType:     types.String,
+			ConfigKey:   &loggingConfig.File,
+			FlagDefault: "",
+			Required:    false,
+		},
+		{
+			Name:        "log-file-max-size",
+			Usage:       "size (in megabytes) after which the log file is rotated (0 disables rotation)",
+			OptType:     types.Int,
+			ConfigKey:   &logFileMaxSize,
+			FlagDefault: 100,
+			Required:    false,
+		},
+		{
+			Name:        "log-file-max-backups",
+			Usage:       "number of rotated log files which are kept",
+			OptType:     types.Int,
+			ConfigKey:   &loggingConfig.FileMaxBackups,
+			FlagDefault: 5,
+			Required:    false,
+		},
+		{
+			Name:        "log-syslog-address",
+			Usage:       "also send the logs to syslog: \"local\" for the local syslog daemon, or a udp://<host:port> or tcp://<host:port> address",
+			OptType:     types.String,
+			ConfigKey:   &loggingConfig.SyslogAddress,
+			FlagDefault: "",
+			Required:    false,
+		},
 		{
 			Name:        "network-passphrase",
 			Usage:       "Network passphrase of the Stellar network transactions should be signed for",
@@ -90,60 +225,669 @@ func main() {
 			FlagDefault: 10,
 			Required:    false,
 		},
+		{
+			Name:        "tx-submission-max-attempts",
+			Usage:       "Maximum number of submissions of a transaction rejected because Stellar Core is overloaded or asks to try again later (1 disables retries)",
+			OptType:     types.Int,
+			ConfigKey:   &txSubmissionMaxAttempts,
+			FlagDefault: 1,
+			Required:    false,
+		},
+		{
+			Name:           "tx-submission-backoff",
+			Usage:          "Delay (in seconds) before retrying a transaction submission, doubled after every attempt",
+			OptType:        types.Int,
+			ConfigKey:      &txSubmissionBackoff,
+			FlagDefault:    1,
+			CustomSetValue: config.SetDuration,
+			Required:       false,
+		},
+		{
+			Name:        "tx-submission-horizon-urls",
+			Usage:       "comma separated list of additional Horizon instances to submit transactions to. Every submission goes to the healthiest of them and horizon-url, failing over to the others when it can't be processed",
+			OptType:     types.String,
+			ConfigKey:   &txSubmissionHorizonURLs,
+			FlagDefault: "",
+			Required:    false,
+		},
+		{
+			Name:           "tx-submission-probe-interval",
+			Usage:          "Interval (in seconds) between the health probes of the Horizon instances transactions are submitted to, see tx-submission-horizon-urls",
+			OptType:        types.Int,
+			ConfigKey:      &txSubmissionProbeInterval,
+			FlagDefault:    10,
+			CustomSetValue: config.SetDuration,
+			Required:       false,
+		},
+		{
+			Name:        "tx-webhooks",
+			Usage:       "Allow sendTransaction requests to provide a callbackUrl which is notified of the final transaction status",
+			OptType:     types.Bool,
+			ConfigKey:   &txWebhooksEnabled,
+			FlagDefault: false,
+			Required:    false,
+		},
+		{
+			Name:           "tx-webhook-timeout",
+			Usage:          "Timeout (in seconds) of every transaction status webhook delivery attempt",
+			OptType:        types.Int,
+			ConfigKey:      &txWebhookTimeout,
+			FlagDefault:    10,
+			CustomSetValue: config.SetDuration,
+			Required:       false,
+		},
+		{
+			Name:        "tx-webhook-allow-private",
+			Usage:       "Allow transaction status webhooks to be delivered to loopback, private and link-local addresses (only enable it when clients are trusted)",
+			OptType:     types.Bool,
+			ConfigKey:   &txWebhookAllowPrivate,
+			FlagDefault: false,
+			Required:    false,
+		},
+		{
+			Name:           "tx-status-max-wait",
+			Usage:          "Maximum time (in seconds) getTransactionStatus requests can wait for pending transactions to complete (see the waitSeconds parameter), 0 disables waiting",
+			OptType:        types.Int,
+			ConfigKey:      &txStatusMaxWait,
+			FlagDefault:    30,
+			CustomSetValue: config.SetDuration,
+			Required:       false,
+		},
+		{
+			Name:        "tx-store-snapshot-file",
+			Usage:       "file the submitted transactions are saved to (periodically and on shutdown) and restored from on startup, so that their submission errors survive restarts. \"\" (default) keeps them in memory only",
+			OptType:     types.String,
+			ConfigKey:   &txStoreSnapshotFile,
+			FlagDefault: "",
+			Required:    false,
+		},
+		{
+			Name:           "tx-store-snapshot-interval",
+			Usage:          "Interval (in seconds) between the snapshots of the submitted transactions, see tx-store-snapshot-file",
+			OptType:        types.Int,
+			ConfigKey:      &txStoreSnapshotInterval,
+			FlagDefault:    30,
+			CustomSetValue: config.SetDuration,
+			Required:       false,
+		},
+		{
+			Name:        "tx-store-memory-limit",
+			Usage:       "Approximate maximum memory (in bytes) used by the submitted transactions kept in memory, the oldest ones which aren't pending are evicted once it is exceeded (0 for no limit)",
+			OptType:     types.Uint,
+			ConfigKey:   &txStoreMemoryLimit,
+			FlagDefault: uint(0),
+			Required:    false,
+		},
+		{
+			Name:        "preflight-concurrency",
+			Usage:       "Maximum number of concurrent simulateTransaction preflight requests",
+			OptType:     types.Int,
+			ConfigKey:   &preflightConcurrency,
+			FlagDefault: 10,
+			Required:    false,
+		},
+		{
+			Name:        "preflight-queue",
+			Usage:       "Maximum number of simulateTransaction requests waiting for a preflight worker",
+			OptType:     types.Int,
+			ConfigKey:   &preflightQueueSize,
+			FlagDefault: 100,
+			Required:    false,
+		},
+		{
+			Name:           "preflight-timeout",
+			Usage:          "Timeout (in seconds) for queueing and executing a simulateTransaction preflight request",
+			OptType:        types.Int,
+			ConfigKey:      &preflightTimeout,
+			FlagDefault:    10,
+			CustomSetValue: config.SetDuration,
+			Required:       false,
+		},
+		{
+			Name:           "preflight-execution-timeout",
+			Usage:          "Timeout (in seconds) for executing a simulateTransaction preflight request, excluding the time spent in the queue (0 for no limit)",
+			OptType:        types.Int,
+			ConfigKey:      &preflightExecutionTimeout,
+			FlagDefault:    0,
+			CustomSetValue: config.SetDuration,
+			Required:       false,
+		},
+		{
+			Name:        "preflight-cpu-insns-limit",
+			Usage:       "Maximum number of CPU instructions of a simulateTransaction preflight request, results exceeding it are discarded since it can't be enforced while Stellar Core executes the request (0 for no limit)",
+			OptType:     types.Uint,
+			ConfigKey:   &preflightCPUInstructionsLimit,
+			FlagDefault: uint(0),
+			Required:    false,
+		},
+		{
+			Name:        "preflight-memory-limit",
+			Usage:       "Maximum memory (in bytes) used by a simulateTransaction preflight request, results exceeding it are discarded since it can't be enforced while Stellar Core executes the request (0 for no limit)",
+			OptType:     types.Uint,
+			ConfigKey:   &preflightMemoryLimit,
+			FlagDefault: uint(0),
+			Required:    false,
+		},
+		{
+			Name:           "max-healthy-ledger-latency",
+			Usage:          "maximum age (in seconds) of the latest closed ledger before the service is reported as unhealthy",
+			OptType:        types.Int,
+			ConfigKey:      &maxHealthyLedgerLatency,
+			FlagDefault:    30,
+			CustomSetValue: config.SetDuration,
+			Required:       false,
+		},
+		{
+			Name:           "shutdown-grace-period",
+			Usage:          "time (in seconds) given to in-flight requests to complete after receiving a termination signal",
+			OptType:        types.Int,
+			ConfigKey:      &shutdownGracePeriod,
+			FlagDefault:    10,
+			CustomSetValue: config.SetDuration,
+			Required:       false,
+		},
+		{
+			Name:        "max-batch-size",
+			Usage:       "maximum number of requests in a JSON RPC batch (0 disables the limit)",
+			OptType:     types.Int,
+			ConfigKey:   &maxBatchSize,
+			FlagDefault: 100,
+			Required:    false,
+		},
+		{
+			Name:        "max-request-concurrency",
+			Usage:       "maximum number of JSON RPC requests, including the requests of a batch, handled concurrently (0 defaults to the number of CPUs)",
+			OptType:     types.Int,
+			ConfigKey:   &maxRequestConcurrency,
+			FlagDefault: 0,
+			Required:    false,
+		},
+		{
+			Name:        "max-request-size",
+			Usage:       "maximum size (in bytes) of the HTTP request bodies (0 disables the limit)",
+			OptType:     types.Int,
+			ConfigKey:   &maxRequestSize,
+			FlagDefault: 1024 * 1024,
+			Required:    false,
+		},
+		{
+			Name:        "max-response-size",
+			Usage:       "maximum size (in bytes) of the result of a JSON RPC method (0 disables the limit)",
+			OptType:     types.Int,
+			ConfigKey:   &maxResponseSize,
+			FlagDefault: 16 * 1024 * 1024,
+			Required:    false,
+		},
+		{
+			Name:        "method-timeouts",
+			Usage:       "comma separated list of per-method deadlines in seconds (e.g. simulateTransaction=10,getLedgerEntries=5), the handling of requests (including their calls to stellar-core) is canceled once they elapse",
+			OptType:     types.String,
+			ConfigKey:   &methodTimeouts,
+			FlagDefault: "",
+			Required:    false,
+		},
+		{
+			Name:        "method-rate-limits",
+			Usage:       "comma separated list of per-method request rate limits in requests per second (e.g. simulateTransaction=10,sendTransaction=5)",
+			OptType:     types.String,
+			ConfigKey:   &methodRateLimits,
+			FlagDefault: "",
+			Required:    false,
+		},
+		{
+			Name:        "ip-rate-limit",
+			Usage:       "maximum number of requests per second accepted from a single client IP (0 disables the limit)",
+			OptType:     types.Float64,
+			ConfigKey:   &ipRateLimit,
+			FlagDefault: float64(0),
+			Required:    false,
+		},
+		{
+			Name:        "api-keys-file",
+			Usage:       "path to a JSON file with the API keys required to use the server, as an array of objects with name, key and optional rateLimit (requests per second) and methods (allowlist) fields",
+			OptType:     types.String,
+			ConfigKey:   &apiKeysFile,
+			FlagDefault: "",
+			Required:    false,
+		},
+		{
+			Name:        "api-keys",
+			Usage:       "comma separated list of name:key API keys required to use the server, without rate limits or method restrictions. They are added to the keys of api-keys-file",
+			OptType:     types.String,
+			ConfigKey:   &apiKeys,
+			FlagDefault: "",
+			Required:    false,
+		},
+		{
+			Name:        "ip-allowlist",
+			Usage:       "comma separated list of CIDR ranges (or IP addresses) of the clients allowed to use the server. All clients are allowed when empty",
+			OptType:     types.String,
+			ConfigKey:   &ipAllowList,
+			FlagDefault: "",
+			Required:    false,
+		},
+		{
+			Name:        "ip-denylist",
+			Usage:       "comma separated list of CIDR ranges (or IP addresses) of the clients which are rejected, it takes precedence over ip-allowlist",
+			OptType:     types.String,
+			ConfigKey:   &ipDenyList,
+			FlagDefault: "",
+			Required:    false,
+		},
+		{
+			Name:        "trusted-proxies",
+			Usage:       "comma separated list of CIDR ranges (or IP addresses) of the reverse proxies whose X-Forwarded-For header is used to resolve the client IP, for the IP lists, the IP rate limit and the logs",
+			OptType:     types.String,
+			ConfigKey:   &trustedProxies,
+			FlagDefault: "",
+			Required:    false,
+		},
+		{
+			Name:        "request-log-sample-ratio",
+			Usage:       "fraction (between 0 and 1) of the successful JSON RPC requests which are logged. Failed and slow requests are always logged",
+			OptType:     types.Float64,
+			ConfigKey:   &requestLogSampleRatio,
+			FlagDefault: float64(0),
+			Required:    false,
+		},
+		{
+			Name:           "slow-request-threshold",
+			Usage:          "duration (in seconds) above which JSON RPC requests are logged as slow (0 disables slow request logging)",
+			OptType:        types.Int,
+			ConfigKey:      &slowRequestThreshold,
+			FlagDefault:    5,
+			CustomSetValue: config.SetDuration,
+			Required:       false,
+		},
+		{
+			Name:        "response-cache-size",
+			Usage:       "maximum number of getLedgerEntries, getNetwork, getTokenBalance and read-only simulateTransaction results cached until the next ledger is closed (0 disables the cache)",
+			OptType:     types.Int,
+			ConfigKey:   &responseCacheSize,
+			FlagDefault: 0,
+			Required:    false,
+		},
+		{
+			Name:           "response-cache-ttl",
+			Usage:          "maximum duration (in seconds) results are kept in the response cache",
+			OptType:        types.Int,
+			ConfigKey:      &responseCacheTTL,
+			FlagDefault:    30,
+			CustomSetValue: config.SetDuration,
+			Required:       false,
+		},
+		{
+			Name:        "contract-stats-size",
+			Usage:       "maximum number of contracts whose simulated and submitted invocations are tracked and reported by getContractStats, the least invoked contracts are evicted first (0 disables the tracking)",
+			OptType:     types.Int,
+			ConfigKey:   &contractStatsSize,
+			FlagDefault: 1000,
+			Required:    false,
+		},
+		{
+			Name:        "contract-stats-metrics-top",
+			Usage:       "number of most invoked contracts whose usage is exported in the metrics",
+			OptType:     types.Int,
+			ConfigKey:   &contractStatsMetricsTop,
+			FlagDefault: 10,
+			Required:    false,
+		},
+		{
+			Name:        "enabled-methods",
+			Usage:       "comma separated list of the only JSON RPC methods which can be called (all methods can be called by default)",
+			OptType:     types.String,
+			ConfigKey:   &enabledMethods,
+			FlagDefault: "",
+			Required:    false,
+		},
+		{
+			Name:        "disabled-methods",
+			Usage:       "comma separated list of JSON RPC methods which can't be called (e.g. sendTransaction,simulateTransaction)",
+			OptType:     types.String,
+			ConfigKey:   &disabledMethods,
+			FlagDefault: "",
+			Required:    false,
+		},
+		{
+			Name:        "read-only",
+			Usage:       "disable the methods which change the state of the network (sendTransaction), for public data endpoints",
+			OptType:     types.Bool,
+			ConfigKey:   &readOnly,
+			FlagDefault: false,
+			Required:    false,
+		},
+		{
+			Name:        "http-get-methods",
+			Usage:       "comma separated list of read-only methods also served over HTTP GET with query parameters (e.g. getHealth,getLatestLedger serves GET /getLatestLedger?window=10)",
+			OptType:     types.String,
+			ConfigKey:   &httpGetMethods,
+			FlagDefault: "",
+			Required:    false,
+		},
+		{
+			Name:           "http-get-max-age",
+			Usage:          "duration (in seconds) CDNs and other HTTP caches may reuse successful HTTP GET responses (0 disables caching)",
+			OptType:        types.Int,
+			ConfigKey:      &httpGetMaxAge,
+			FlagDefault:    0,
+			CustomSetValue: config.SetDuration,
+			Required:       false,
+		},
+		{
+			Name:        "response-compression",
+			Usage:       "comma separated list of the encodings (gzip, zstd) responses are compressed with when the client accepts them, in order of preference (responses aren't compressed when empty)",
+			OptType:     types.String,
+			ConfigKey:   &responseCompression,
+			FlagDefault: "",
+			Required:    false,
+		},
+		{
+			Name:        "response-compression-min-size",
+			Usage:       "minimum size (in bytes) of the compressed responses, smaller responses are sent uncompressed",
+			OptType:     types.Int,
+			ConfigKey:   &responseCompressionMinSize,
+			FlagDefault: 1024,
+			Required:    false,
+		},
+		{
+			Name:           "ledger-freshness-interval",
+			Usage:          "how often (in seconds) the close time of the latest ledger ingested by horizon is compared with the current time, to export the ledger_freshness metrics (0 disables them)",
+			OptType:        types.Int,
+			ConfigKey:      &ledgerFreshnessInterval,
+			FlagDefault:    2,
+			CustomSetValue: config.SetDuration,
+			Required:       false,
+		},
+		{
+			Name:        "ledger-freshness-slos",
+			Usage:       "comma separated list of freshness thresholds (in seconds), the ledgers ingested later than each threshold after being closed are counted as SLO violations",
+			OptType:     types.String,
+			ConfigKey:   &ledgerFreshnessSLOs,
+			FlagDefault: "10,30",
+			Required:    false,
+		},
+		{
+			Name:        "otlp-endpoint",
+			Usage:       "host:port of the OTLP/HTTP collector traces are exported to (tracing is disabled when empty)",
+			OptType:     types.String,
+			ConfigKey:   &tracingConfig.OTLPEndpoint,
+			FlagDefault: "",
+			Required:    false,
+		},
+		{
+			Name:        "otlp-insecure",
+			Usage:       "disable TLS when exporting traces to the OTLP collector",
+			OptType:     types.Bool,
+			ConfigKey:   &tracingConfig.Insecure,
+			FlagDefault: false,
+			Required:    false,
+		},
+		{
+			Name:        "tracing-sample-ratio",
+			Usage:       "fraction (between 0 and 1) of the requests which are traced",
+			OptType:     types.Float64,
+			ConfigKey:   &tracingConfig.SampleRatio,
+			FlagDefault: float64(1),
+			Required:    false,
+		},
+		{
+			Name:        "metrics-native-histograms",
+			Usage:       "also export the histograms as native (exponential) histograms, which are only scraped with the protobuf format (e.g. by Prometheus with the native-histograms feature)",
+			OptType:     types.Bool,
+			ConfigKey:   &histogramConfig.NativeHistograms,
+			FlagDefault: false,
+			Required:    false,
+		},
+		{
+			Name:        "metrics-classic-buckets",
+			Usage:       "export the classic buckets of the histograms, they can only be disabled along with metrics-native-histograms",
+			OptType:     types.Bool,
+			ConfigKey:   &classicBuckets,
+			FlagDefault: true,
+			Required:    false,
+		},
 	}
 	cmd := &cobra.Command{
 		Use:   "soroban-rpc",
 		Short: "Run the remote soroban-rpc server",
 		Run: func(_ *cobra.Command, _ []string) {
+			// The options must be bound to viper before looking up the config path
+			for _, option := range configOpts {
+				option.Bind()
+			}
+			if path := viper.GetString("config-path"); path != "" {
+				viper.SetConfigFile(path)
+				if err := viper.ReadInConfig(); err != nil {
+					logger.WithError(err).Fatal("could not read config file")
+				}
+			}
 			configOpts.Require()
 			configOpts.SetValues()
 			logger.SetLevel(logLevel)
 
-			hc := &horizonclient.Client{
-				HorizonURL: horizonURL,
-				HTTP: &http.Client{
-					Timeout: horizonclient.HorizonTimeout,
-				},
-				AppName: "Soroban RPC",
+			methodRates, err := middleware.ParseMethodRates(methodRateLimits)
+			if err != nil {
+				logger.Fatalf("could not parse method rate limits: %v", err)
 			}
-			hc.SetHorizonTimeout(horizonclient.HorizonTimeout)
-
-			transactionProxy := methods.NewTransactionProxy(
-				hc,
-				txConcurrency,
-				txQueueSize,
-				networkPassphrase,
-				5*time.Minute,
-			)
-
-			handler, err := internal.NewJSONRPCHandler(internal.HandlerParams{
-				AccountStore:     methods.AccountStore{Client: hc},
-				Logger:           logger,
-				TransactionProxy: transactionProxy,
-				CoreClient:       &stellarcore.Client{URL: stellarCoreURL},
-			})
+			timeouts, err := middleware.ParseMethodTimeouts(methodTimeouts)
 			if err != nil {
-				logger.Fatalf("could not create handler: %v", err)
-			}
-			supporthttp.Run(supporthttp.Config{
-				ListenAddr: endpoint,
-				Handler:    handler,
-				OnStarting: func() {
-					logger.Infof("Starting Soroban JSON RPC server on %v", endpoint)
-					handler.Start()
-				},
-				OnStopping: func() {
-					handler.Close()
+				logger.Fatalf("could not parse method timeouts: %v", err)
+			}
+			freshnessSLOs, err := methods.ParseFreshnessSLOs(ledgerFreshnessSLOs)
+			if err != nil {
+				logger.Fatalf("could not parse ledger freshness slos: %v", err)
+			}
+			var keys []middleware.APIKey
+			if apiKeysFile != "" {
+				if keys, err = middleware.LoadAPIKeys(apiKeysFile); err != nil {
+					logger.Fatalf("could not load api keys: %v", err)
+				}
+			}
+			inlineKeys, err := middleware.ParseAPIKeys(apiKeys)
+			if err != nil {
+				logger.Fatalf("could not parse api keys: %v", err)
+			}
+			keys = append(keys, inlineKeys...)
+			ipRanges := map[string][]*net.IPNet{}
+			for name, value := range map[string]string{
+				"ip-allowlist":    ipAllowList,
+				"ip-denylist":     ipDenyList,
+				"trusted-proxies": trustedProxies,
+			} {
+				if ipRanges[name], err = middleware.ParseCIDRs(value); err != nil {
+					logger.Fatalf("could not parse %s: %v", name, err)
+				}
+			}
+			endpoints := strings.Split(endpoint, ",")
+			var internal []string
+			if internalEndpoints != "" {
+				internal = strings.Split(internalEndpoints, ",")
+			}
+			var submissionURLs, getMethods, compression, enabled, disabled []string
+			if txSubmissionHorizonURLs != "" {
+				submissionURLs = strings.Split(txSubmissionHorizonURLs, ",")
+			}
+			if httpGetMethods != "" {
+				getMethods = strings.Split(httpGetMethods, ",")
+			}
+			if responseCompression != "" {
+				compression = strings.Split(responseCompression, ",")
+			}
+			if enabledMethods != "" {
+				enabled = strings.Split(enabledMethods, ",")
+			}
+			if disabledMethods != "" {
+				disabled = strings.Split(disabledMethods, ",")
+			}
+
+			loggingConfig.FileMaxSize = int64(logFileMaxSize) * 1024 * 1024
+			histogramConfig.DisableClassicBuckets = !classicBuckets
+			d, err := daemon.NewDaemon(daemon.Config{
+				Logger:                    logger,
+				LogLevel:                  logLevel,
+				Endpoint:                  endpoints[0],
+				AdditionalEndpoints:       endpoints[1:],
+				InternalEndpoints:         internal,
+				AdminEndpoint:             adminEndpoint,
+				TLSCertFile:               tlsCertFile,
+				TLSKeyFile:                tlsKeyFile,
+				HorizonURL:                horizonURL,
+				StellarCoreURL:            stellarCoreURL,
+				NetworkPassphrase:         networkPassphrase,
+				TxConcurrency:             txConcurrency,
+				TxQueueSize:               txQueueSize,
+				TxSubmissionMaxAttempts:   txSubmissionMaxAttempts,
+				TxSubmissionBackoff:       txSubmissionBackoff,
+				TxSubmissionHorizonURLs:   submissionURLs,
+				TxSubmissionProbeInterval: txSubmissionProbeInterval,
+				TxWebhooksEnabled:         txWebhooksEnabled,
+				TxWebhookTimeout:          txWebhookTimeout,
+				TxWebhookAllowPrivate:     txWebhookAllowPrivate,
+				TxStatusMaxWait:           txStatusMaxWait,
+				TxStoreSnapshotFile:       txStoreSnapshotFile,
+				TxStoreSnapshotInterval:   txStoreSnapshotInterval,
+				TxStoreMemoryLimit:        int64(txStoreMemoryLimit),
+				PreflightConcurrency:      preflightConcurrency,
+				PreflightQueueSize:        preflightQueueSize,
+				PreflightTimeout:          preflightTimeout,
+				PreflightBudget: methods.PreflightBudget{
+					CPUInstructions: uint64(preflightCPUInstructionsLimit),
+					MemoryBytes:     uint64(preflightMemoryLimit),
+					Timeout:         preflightExecutionTimeout,
 				},
+				MaxHealthyLedgerLatency: maxHealthyLedgerLatency,
+				ShutdownGracePeriod:     shutdownGracePeriod,
+				CORSAllowedOrigins:      strings.Split(corsAllowedOrigins, ","),
+				MaxBatchSize:            maxBatchSize,
+				MaxRequestConcurrency:   maxRequestConcurrency,
+				MaxRequestSize:          int64(maxRequestSize),
+				MaxResponseSize:         maxResponseSize,
+				MethodTimeouts:          timeouts,
+				EnabledMethods:          enabled,
+				DisabledMethods:         disabled,
+				ReadOnly:                readOnly,
+				MethodRateLimits:        methodRates,
+				IPRateLimit:             ipRateLimit,
+				// The rate limiter is always needed when using a config file, since the limits can be reloaded
+				DynamicRateLimits:          configPath != "",
+				APIKeys:                    keys,
+				IPAllowList:                ipRanges["ip-allowlist"],
+				IPDenyList:                 ipRanges["ip-denylist"],
+				TrustedProxies:             ipRanges["trusted-proxies"],
+				RequestLogSampleRatio:      requestLogSampleRatio,
+				SlowRequestThreshold:       slowRequestThreshold,
+				ResponseCacheSize:          responseCacheSize,
+				ResponseCacheTTL:           responseCacheTTL,
+				GetMethods:                 getMethods,
+				GetCacheMaxAge:             httpGetMaxAge,
+				ResponseCompression:        compression,
+				ResponseCompressionMinSize: responseCompressionMinSize,
+				ContractStatsSize:          contractStatsSize,
+				ContractStatsMetricsTop:    contractStatsMetricsTop,
+				Tracing:                    tracingConfig,
+				MetricsHistograms:          histogramConfig,
+				LedgerFreshnessInterval:    ledgerFreshnessInterval,
+				LedgerFreshnessSLOs:        freshnessSLOs,
+				Logging:                    loggingConfig,
 			})
+			if err != nil {
+				logger.Fatalf("could not create daemon: %v", err)
+			}
+			if configPath != "" {
+				go reloadOnSIGHUP(logger, configOpts, d)
+			}
+			if err := d.Start(); err != nil {
+				logger.Fatalf("could not start daemon: %v", err)
+			}
+
+			serveErr := make(chan error, 1)
+			go func() {
+				serveErr <- d.Wait()
+			}()
+			signals := make(chan os.Signal, 1)
+			signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+			exitCode := 0
+			select {
+			case <-signals:
+			case err := <-serveErr:
+				logger.WithError(err).Error("server stopped unexpectedly")
+				exitCode = 1
+			}
+			if err := d.Close(); err != nil {
+				logger.WithError(err).Error("could not shut down cleanly")
+				os.Exit(1)
+			}
+			if exitCode != 0 {
+				os.Exit(exitCode)
+			}
 		},
 	}
 
 	if err := configOpts.Init(cmd); err != nil {
 		logger.WithError(err).Fatal("could not parse config options")
 	}
+	cmd.AddCommand(newBenchCommand())
+	cmd.AddCommand(newConformanceCommand())
 
 	if err := cmd.Execute(); err != nil {
 		logger.WithError(err).Fatal("could not run")
 	}
 }
+
+// dynamicOptionNames are the options which are reloaded from the config file on SIGHUP
+var dynamicOptionNames = map[string]bool{
+	"log-level":          true,
+	"method-rate-limits": true,
+	"ip-rate-limit":      true,
+}
+
+// reloadOnSIGHUP re-reads the config file and applies its dynamic settings
+// every time the process receives a SIGHUP.
+func reloadOnSIGHUP(logger *supportlog.Entry, configOpts config.ConfigOptions, d *daemon.Daemon) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	for range signals {
+		if err := reloadDynamicConfig(logger, configOpts, d); err != nil {
+			logger.WithError(err).Error("could not reload config file, keeping the current settings")
+			continue
+		}
+		logger.Info("Reloaded config file")
+	}
+}
+
+func reloadDynamicConfig(logger *supportlog.Entry, configOpts config.ConfigOptions, d *daemon.Daemon) error {
+	if err := viper.ReadInConfig(); err != nil {
+		return err
+	}
+	var logLevel logrus.Level
+	var methodRateLimits string
+	var ipRateLimit float64
+	for _, option := range configOpts {
+		if !dynamicOptionNames[option.Name] {
+			continue
+		}
+		// Set the option values into local variables, so that nothing is
+		// applied unless all of them are valid
+		reloaded := *option
+		switch option.Name {
+		case "log-level":
+			reloaded.ConfigKey = &logLevel
+		case "method-rate-limits":
+			reloaded.ConfigKey = &methodRateLimits
+		case "ip-rate-limit":
+			reloaded.ConfigKey = &ipRateLimit
+		}
+		if err := reloaded.SetValue(); err != nil {
+			return err
+		}
+	}
+	methodRates, err := middleware.ParseMethodRates(methodRateLimits)
+	if err != nil {
+		return err
+	}
+
+	logger.SetLevel(logLevel)
+	d.SetLogLevel(logLevel)
+	d.RateLimiter().SetLimits(methodRates, ipRateLimit)
+	return nil
+}
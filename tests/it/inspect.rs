@@ -0,0 +1,43 @@
+use crate::util::{test_wasm, Sandbox, SorobanCommand};
+
+#[test]
+fn inspect_hello_world() {
+    let output = Sandbox::new_cmd()
+        .arg("inspect")
+        .arg("--wasm")
+        .arg(test_wasm("test_hello_world"))
+        .assert()
+        .success()
+        .get_output()
+        .stdout
+        .clone();
+    let output = String::from_utf8(output).unwrap();
+    assert!(
+        output.contains(" • Function: hello(s: symbol) -> (vec<symbol>)\n"),
+        "{output}"
+    );
+}
+
+#[test]
+fn inspect_hello_world_json() {
+    let output = Sandbox::new_cmd()
+        .arg("inspect")
+        .arg("--wasm")
+        .arg(test_wasm("test_hello_world"))
+        .arg("--output=json")
+        .assert()
+        .success()
+        .get_output()
+        .stdout
+        .clone();
+    let contract: serde_json::Value = serde_json::from_slice(&output).unwrap();
+    assert_eq!(
+        contract["functions"],
+        serde_json::json!([{
+            "name": "hello",
+            "inputs": [{"name": "s", "type": "symbol"}],
+            "outputs": ["vec<symbol>"],
+        }])
+    );
+    assert!(contract["interfaceVersion"].is_u64());
+}
@@ -39,3 +39,69 @@ fn source_account_exists() {
         .success()
         .stdout("true\n");
 }
+
+#[test]
+fn snapshot_export_import() {
+    let ledger = temp_ledger_file();
+    Sandbox::new_cmd()
+        .arg("token")
+        .arg("create")
+        .arg("--ledger-file")
+        .arg(&ledger)
+        .arg("--name=tok")
+        .arg("--symbol=tok")
+        .assert()
+        .success();
+
+    let snapshot = temp_ledger_file();
+    Sandbox::new_cmd()
+        .arg("lab")
+        .arg("snapshot")
+        .arg("export")
+        .arg("--ledger-file")
+        .arg(&ledger)
+        .arg("--out")
+        .arg(&snapshot)
+        .assert()
+        .success();
+
+    let imported_ledger = temp_ledger_file();
+    Sandbox::new_cmd()
+        .arg("lab")
+        .arg("snapshot")
+        .arg("import")
+        .arg("--ledger-file")
+        .arg(&imported_ledger)
+        .arg("--file")
+        .arg(&snapshot)
+        .assert()
+        .success();
+
+    Sandbox::new_cmd()
+        .arg("invoke")
+        .arg("--ledger-file")
+        .arg(imported_ledger)
+        .arg("--id=d55b5a3a5793539545f957f7da783f7b19159369ccdb19c53dbd117ebfc08842")
+        .arg("--fn=decimals")
+        .assert()
+        .success()
+        .stdout("7\n");
+}
+
+#[test]
+fn invoke_dry_run_does_not_commit() {
+    let ledger = temp_ledger_file();
+    Sandbox::new_cmd()
+        .arg("invoke")
+        .arg("--ledger-file")
+        .arg(&ledger)
+        .arg("--id=1")
+        .arg("--wasm")
+        .arg(test_wasm("test_invoker_account_exists"))
+        .arg("--fn=invkexists")
+        .arg("--dry-run")
+        .assert()
+        .success()
+        .stdout("true\n");
+    assert!(!std::path::Path::new(&ledger).exists());
+}
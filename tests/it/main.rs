@@ -1,3 +1,4 @@
 mod e2e_rpc_server;
+mod inspect;
 mod invoke_sandbox;
 mod util;